@@ -49,6 +49,11 @@ const (
 	iexportVersionGenerics = iexportVersionPosCol
 
 	iexportVersionCurrent = iexportVersionGenerics
+
+	// iexportFlagInstances is set in the version of shallow export data
+	// written by go/types tools with a list of type instances following
+	// the index. The importer ignores the list.
+	iexportFlagInstances = 1 << 6
 )
 
 type ident struct {
@@ -98,7 +103,7 @@ func ImportData(imports map[string]*types2.Package, data, path string) (pkg *typ
 	r := &intReader{strings.NewReader(data), path}
 
 	version = int64(r.uint64())
-	switch version {
+	switch version &^ iexportFlagInstances {
 	case /* iexportVersionGenerics, */ iexportVersionPosCol, iexportVersionGo1_11:
 	default:
 		if version > iexportVersionGenerics {
@@ -107,6 +112,7 @@ func ImportData(imports map[string]*types2.Package, data, path string) (pkg *typ
 			errorf("unknown iexport format version %d", version)
 		}
 	}
+	version &^= iexportFlagInstances
 
 	sLen := int64(r.uint64())
 	dLen := int64(r.uint64())
@@ -233,6 +233,12 @@ const (
 	iexportVersionGenerics = iexportVersionPosCol
 
 	iexportVersionCurrent = iexportVersionGenerics
+
+	// iexportFlagInstances is set by go/internal/gcimporter in the version
+	// of shallow export data with a list of type instances following the
+	// index. The compiler neither writes nor reads such data, but versions
+	// must not use this bit.
+	iexportFlagInstances = 1 << 6
 )
 
 // predeclReserved is the number of type offsets reserved for types
@@ -0,0 +1,291 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the conversion from types2 to go/types.
+
+package typesconv
+
+import (
+	"cmd/compile/internal/types2"
+	"fmt"
+	"go/types"
+)
+
+// Package returns the go/types package corresponding to pkg, converting
+// it and the objects of its package scope if necessary.
+func (c *Converter) Package(pkg *types2.Package) *types.Package {
+	if pkg == nil {
+		return nil
+	}
+	if p := c.pkgs[pkg]; p != nil {
+		return p
+	}
+	p := types.NewPackage(pkg.Path(), pkg.Name())
+	c.pkgs[pkg] = p
+	c.pkgs2[p] = pkg
+
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		c.Object(scope.Lookup(name))
+	}
+	var imports []*types.Package
+	for _, imp := range pkg.Imports() {
+		imports = append(imports, c.Package(imp))
+	}
+	p.SetImports(imports)
+	if pkg.Complete() {
+		p.MarkComplete()
+	}
+	return p
+}
+
+// Object returns the go/types object corresponding to obj,
+// converting it if necessary.
+func (c *Converter) Object(obj types2.Object) types.Object {
+	if obj == nil {
+		return nil
+	}
+	if o := c.objs[obj]; o != nil {
+		return o
+	}
+	if obj.Pkg() == nil || obj.Pkg() == types2.Unsafe {
+		// predeclared object
+		scope := types.Universe
+		if obj.Pkg() != nil {
+			scope = types.Unsafe.Scope()
+		}
+		o := scope.Lookup(obj.Name())
+		if o == nil {
+			panic(fmt.Sprintf("typesconv: unknown predeclared object %s", obj.Name()))
+		}
+		c.record(o, obj)
+		return o
+	}
+
+	o := c.object(obj)
+	if o == nil {
+		return c.objs[obj] // converted while converting its type
+	}
+	c.record(o, obj)
+	if obj.Parent() == obj.Pkg().Scope() {
+		c.Package(obj.Pkg()).Scope().Insert(o)
+	}
+	return o
+}
+
+// object returns a new go/types object corresponding to obj, or nil if
+// obj was converted while converting its type. Objects that its type
+// may refer to are recorded by object itself.
+func (c *Converter) object(obj types2.Object) types.Object {
+	pos, pkg, name := c.pos(obj.Pos()), c.Package(obj.Pkg()), obj.Name()
+	switch obj := obj.(type) {
+	case *types2.Const:
+		return types.NewConst(pos, pkg, name, c.Type(obj.Type()), obj.Val())
+
+	case *types2.Var:
+		if obj.IsField() {
+			return types.NewField(pos, pkg, name, c.Type(obj.Type()), obj.Embedded())
+		}
+		return types.NewVar(pos, pkg, name, c.Type(obj.Type()))
+
+	case *types2.Func:
+		sig := c.Type(obj.Type()).(*types.Signature)
+		if o := c.objs[obj]; o != nil {
+			return nil
+		}
+		return types.NewFunc(pos, pkg, name, sig)
+
+	case *types2.TypeName:
+		switch typ := obj.Type().(type) {
+		case *types2.Named:
+			if typ.Obj() == obj {
+				tname := types.NewTypeName(pos, pkg, name, nil)
+				c.record(tname, obj)
+				c.named(tname, typ)
+				return tname
+			}
+		case *types2.TypeParam:
+			c.Type(typ) // records the type name
+			return nil
+		}
+		// alias
+		typ := c.Type(obj.Type())
+		if o := c.objs[obj]; o != nil {
+			return nil
+		}
+		return types.NewTypeName(pos, pkg, name, typ)
+
+	case *types2.Label:
+		return types.NewLabel(pos, pkg, name)
+
+	case *types2.PkgName:
+		return types.NewPkgName(pos, pkg, name, c.Package(obj.Imported()))
+	}
+	panic(fmt.Sprintf("typesconv: unexpected object %v", obj))
+}
+
+// named converts the defined type typ of the type name tname,
+// which has already been converted.
+func (c *Converter) named(tname *types.TypeName, typ *types2.Named) {
+	named := types.NewNamed(tname, nil, nil)
+	c.recordType(named, typ)
+	named.SetTypeParams(c.typeParams(typ.TypeParams()))
+	named.SetUnderlying(c.Type(typ.Underlying()))
+	for i := 0; i < typ.NumMethods(); i++ {
+		named.AddMethod(c.Object(typ.Method(i)).(*types.Func))
+	}
+}
+
+// typeParams converts the type parameters in list. The new type parameters
+// are recorded before their constraints are converted, which may refer to
+// them.
+func (c *Converter) typeParams(list *types2.TypeParamList) []*types.TypeParam {
+	if list.Len() == 0 {
+		return nil
+	}
+	tparams := make([]*types.TypeParam, list.Len())
+	for i := range tparams {
+		tpar := list.At(i)
+		tname := types.NewTypeName(c.pos(tpar.Obj().Pos()), c.Package(tpar.Obj().Pkg()), tpar.Obj().Name(), nil)
+		tparams[i] = types.NewTypeParam(tname, nil)
+		c.record(tname, tpar.Obj())
+		c.recordType(tparams[i], tpar)
+	}
+	for i, tpar := range tparams {
+		tpar.SetConstraint(c.Type(list.At(i).Constraint()))
+	}
+	return tparams
+}
+
+// Type returns the go/types type corresponding to typ,
+// converting it if necessary.
+func (c *Converter) Type(typ types2.Type) types.Type {
+	if typ == nil {
+		return nil
+	}
+	if t := c.typs[typ]; t != nil {
+		return t
+	}
+	t := c.typ(typ)
+	if t2 := c.typs[typ]; t2 != nil {
+		return t2 // converted while converting its components
+	}
+	c.recordType(t, typ)
+	return t
+}
+
+func (c *Converter) typ(typ types2.Type) types.Type {
+	switch t := typ.(type) {
+	case *types2.Basic:
+		if b := types.Typ[t.Kind()]; b.Name() == t.Name() {
+			return b
+		}
+		return types.Universe.Lookup(t.Name()).Type() // byte, rune
+
+	case *types2.Array:
+		return types.NewArray(c.Type(t.Elem()), t.Len())
+
+	case *types2.Slice:
+		return types.NewSlice(c.Type(t.Elem()))
+
+	case *types2.Struct:
+		fields := make([]*types.Var, t.NumFields())
+		tags := make([]string, t.NumFields())
+		for i := range fields {
+			fields[i] = c.Object(t.Field(i)).(*types.Var)
+			tags[i] = t.Tag(i)
+		}
+		return types.NewStruct(fields, tags)
+
+	case *types2.Pointer:
+		return types.NewPointer(c.Type(t.Elem()))
+
+	case *types2.Tuple:
+		return c.tuple(t)
+
+	case *types2.Signature:
+		return c.signature(t, true)
+
+	case *types2.Union:
+		terms := make([]*types.Term, t.Len())
+		for i := range terms {
+			term := t.Term(i)
+			terms[i] = types.NewTerm(term.Tilde(), c.Type(term.Type()))
+		}
+		return types.NewUnion(terms)
+
+	case *types2.Interface:
+		// The receivers of the methods are set by NewInterfaceType.
+		methods := make([]*types.Func, t.NumExplicitMethods())
+		for i := range methods {
+			m := t.ExplicitMethod(i)
+			f := types.NewFunc(c.pos(m.Pos()), c.Package(m.Pkg()), m.Name(), c.signature(m.Type().(*types2.Signature), false))
+			c.record(f, m)
+			methods[i] = f
+		}
+		embeddeds := make([]types.Type, t.NumEmbeddeds())
+		for i := range embeddeds {
+			embeddeds[i] = c.Type(t.EmbeddedType(i))
+		}
+		return types.NewInterfaceType(methods, embeddeds)
+
+	case *types2.Map:
+		return types.NewMap(c.Type(t.Key()), c.Type(t.Elem()))
+
+	case *types2.Chan:
+		return types.NewChan(types.ChanDir(t.Dir()), c.Type(t.Elem()))
+
+	case *types2.Named:
+		if t.Obj().Pkg() == nil {
+			return types.Universe.Lookup(t.Obj().Name()).Type() // error, comparable
+		}
+		if targs := t.TypeArgs(); targs.Len() > 0 {
+			list := make([]types.Type, targs.Len())
+			for i := range list {
+				list[i] = c.Type(targs.At(i))
+			}
+			inst, err := types.Instantiate(c.env, c.Type(t.Orig()), list, false)
+			if err != nil {
+				panic(fmt.Sprintf("typesconv: instantiating %s: %v", t, err))
+			}
+			return inst
+		}
+		return c.Object(t.Obj()).Type()
+
+	case *types2.TypeParam:
+		// Type parameters are converted with the declaration of their
+		// generic type or function.
+		panic(fmt.Sprintf("typesconv: type parameter %s converted outside its declaration", t))
+	}
+	panic(fmt.Sprintf("typesconv: unexpected type %T", typ))
+}
+
+// signature converts sig, and its receiver if recv is set.
+func (c *Converter) signature(sig *types2.Signature, recv bool) *types.Signature {
+	if s := c.typs[sig]; s != nil {
+		return s.(*types.Signature)
+	}
+	tparams := c.typeParams(sig.TypeParams())
+	rparams := c.typeParams(sig.RecvTypeParams())
+	var r *types.Var
+	if recv && sig.Recv() != nil {
+		r = c.Object(sig.Recv()).(*types.Var)
+	}
+	s := types.NewSignature(r, c.tuple(sig.Params()), c.tuple(sig.Results()), sig.Variadic())
+	s.SetTypeParams(tparams)
+	s.SetRecvTypeParams(rparams)
+	c.recordType(s, sig)
+	return s
+}
+
+func (c *Converter) tuple(tup *types2.Tuple) *types.Tuple {
+	if tup.Len() == 0 {
+		return nil
+	}
+	vars := make([]*types.Var, tup.Len())
+	for i := range vars {
+		vars[i] = c.Object(tup.At(i)).(*types.Var)
+	}
+	return types.NewTuple(vars...)
+}
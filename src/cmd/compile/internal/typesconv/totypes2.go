@@ -0,0 +1,294 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the conversion from go/types to types2.
+
+package typesconv
+
+import (
+	"cmd/compile/internal/types2"
+	"fmt"
+	"go/types"
+)
+
+// Package2 returns the types2 package corresponding to pkg, converting
+// it and the objects of its package scope if necessary.
+func (c *Converter) Package2(pkg *types.Package) *types2.Package {
+	if pkg == nil {
+		return nil
+	}
+	if p := c.pkgs2[pkg]; p != nil {
+		return p
+	}
+	p := types2.NewPackage(pkg.Path(), pkg.Name())
+	c.pkgs2[pkg] = p
+	c.pkgs[p] = pkg
+
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		c.Object2(scope.Lookup(name))
+	}
+	var imports []*types2.Package
+	for _, imp := range pkg.Imports() {
+		imports = append(imports, c.Package2(imp))
+	}
+	p.SetImports(imports)
+	if pkg.Complete() {
+		p.MarkComplete()
+	}
+	return p
+}
+
+// Object2 returns the types2 object corresponding to obj,
+// converting it if necessary.
+func (c *Converter) Object2(obj types.Object) types2.Object {
+	if obj == nil {
+		return nil
+	}
+	if o := c.objs2[obj]; o != nil {
+		return o
+	}
+	if obj.Pkg() == nil || obj.Pkg() == types.Unsafe {
+		// predeclared object
+		scope := types2.Universe
+		if obj.Pkg() != nil {
+			scope = types2.Unsafe.Scope()
+		}
+		o := scope.Lookup(obj.Name())
+		if o == nil {
+			panic(fmt.Sprintf("typesconv: unknown predeclared object %s", obj.Name()))
+		}
+		c.record(obj, o)
+		return o
+	}
+
+	o := c.object2(obj)
+	if o == nil {
+		return c.objs2[obj] // converted while converting its type
+	}
+	c.record(obj, o)
+	if obj.Parent() == obj.Pkg().Scope() {
+		c.Package2(obj.Pkg()).Scope().Insert(o)
+	}
+	return o
+}
+
+// object2 returns a new types2 object corresponding to obj, or nil if
+// obj was converted while converting its type. Objects that its type
+// may refer to are recorded by object2 itself.
+func (c *Converter) object2(obj types.Object) types2.Object {
+	pos, pkg, name := c.pos2(obj.Pos()), c.Package2(obj.Pkg()), obj.Name()
+	switch obj := obj.(type) {
+	case *types.Const:
+		return types2.NewConst(pos, pkg, name, c.Type2(obj.Type()), obj.Val())
+
+	case *types.Var:
+		if obj.IsField() {
+			return types2.NewField(pos, pkg, name, c.Type2(obj.Type()), obj.Embedded())
+		}
+		return types2.NewVar(pos, pkg, name, c.Type2(obj.Type()))
+
+	case *types.Func:
+		sig := c.Type2(obj.Type()).(*types2.Signature)
+		if o := c.objs2[obj]; o != nil {
+			return nil
+		}
+		return types2.NewFunc(pos, pkg, name, sig)
+
+	case *types.TypeName:
+		switch typ := obj.Type().(type) {
+		case *types.Named:
+			if typ.Obj() == obj {
+				tname := types2.NewTypeName(pos, pkg, name, nil)
+				c.record(obj, tname)
+				c.named2(tname, typ)
+				return tname
+			}
+		case *types.TypeParam:
+			c.Type2(typ) // records the type name
+			return nil
+		}
+		// alias
+		typ := c.Type2(obj.Type())
+		if o := c.objs2[obj]; o != nil {
+			return nil
+		}
+		return types2.NewTypeName(pos, pkg, name, typ)
+
+	case *types.Label:
+		return types2.NewLabel(pos, pkg, name)
+
+	case *types.PkgName:
+		return types2.NewPkgName(pos, pkg, name, c.Package2(obj.Imported()))
+	}
+	panic(fmt.Sprintf("typesconv: unexpected object %v", obj))
+}
+
+// named2 converts the defined type typ of the type name tname,
+// which has already been converted.
+func (c *Converter) named2(tname *types2.TypeName, typ *types.Named) {
+	named := types2.NewNamed(tname, nil, nil)
+	c.recordType(typ, named)
+	named.SetTypeParams(c.typeParams2(typ.TypeParams()))
+	named.SetUnderlying(c.Type2(typ.Underlying()))
+	for i := 0; i < typ.NumMethods(); i++ {
+		named.AddMethod(c.Object2(typ.Method(i)).(*types2.Func))
+	}
+}
+
+// typeParams2 converts the type parameters in list. The new type parameters
+// are recorded before their constraints are converted, which may refer to
+// them.
+func (c *Converter) typeParams2(list *types.TypeParamList) []*types2.TypeParam {
+	if list.Len() == 0 {
+		return nil
+	}
+	tparams := make([]*types2.TypeParam, list.Len())
+	for i := range tparams {
+		tpar := list.At(i)
+		tname := types2.NewTypeName(c.pos2(tpar.Obj().Pos()), c.Package2(tpar.Obj().Pkg()), tpar.Obj().Name(), nil)
+		tparams[i] = types2.NewTypeParam(tname, nil)
+		c.record(tpar.Obj(), tname)
+		c.recordType(tpar, tparams[i])
+	}
+	for i, tpar := range tparams {
+		tpar.SetConstraint(c.Type2(list.At(i).Constraint()))
+	}
+	return tparams
+}
+
+// Type2 returns the types2 type corresponding to typ,
+// converting it if necessary.
+func (c *Converter) Type2(typ types.Type) types2.Type {
+	if typ == nil {
+		return nil
+	}
+	if t := c.typs2[typ]; t != nil {
+		return t
+	}
+	t := c.typ2(typ)
+	if t2 := c.typs2[typ]; t2 != nil {
+		return t2 // converted while converting its components
+	}
+	c.recordType(typ, t)
+	return t
+}
+
+func (c *Converter) typ2(typ types.Type) types2.Type {
+	switch t := typ.(type) {
+	case *types.Basic:
+		if b := types2.Typ[t.Kind()]; b.Name() == t.Name() {
+			return b
+		}
+		return types2.Universe.Lookup(t.Name()).Type() // byte, rune
+
+	case *types.Array:
+		return types2.NewArray(c.Type2(t.Elem()), t.Len())
+
+	case *types.Slice:
+		return types2.NewSlice(c.Type2(t.Elem()))
+
+	case *types.Struct:
+		fields := make([]*types2.Var, t.NumFields())
+		tags := make([]string, t.NumFields())
+		for i := range fields {
+			fields[i] = c.Object2(t.Field(i)).(*types2.Var)
+			tags[i] = t.Tag(i)
+		}
+		return types2.NewStruct(fields, tags)
+
+	case *types.Pointer:
+		return types2.NewPointer(c.Type2(t.Elem()))
+
+	case *types.Tuple:
+		return c.tuple2(t)
+
+	case *types.Signature:
+		return c.signature2(t, true)
+
+	case *types.Union:
+		terms := make([]*types2.Term, t.Len())
+		for i := range terms {
+			term := t.Term(i)
+			terms[i] = types2.NewTerm(term.Tilde(), c.Type2(term.Type()))
+		}
+		return types2.NewUnion(terms)
+
+	case *types.Interface:
+		// The receivers of the methods are set by NewInterfaceType.
+		methods := make([]*types2.Func, t.NumExplicitMethods())
+		for i := range methods {
+			m := t.ExplicitMethod(i)
+			f := types2.NewFunc(c.pos2(m.Pos()), c.Package2(m.Pkg()), m.Name(), c.signature2(m.Type().(*types.Signature), false))
+			c.record(m, f)
+			methods[i] = f
+		}
+		embeddeds := make([]types2.Type, t.NumEmbeddeds())
+		for i := range embeddeds {
+			embeddeds[i] = c.Type2(t.EmbeddedType(i))
+		}
+		return types2.NewInterfaceType(methods, embeddeds)
+
+	case *types.Map:
+		return types2.NewMap(c.Type2(t.Key()), c.Type2(t.Elem()))
+
+	case *types.Chan:
+		return types2.NewChan(types2.ChanDir(t.Dir()), c.Type2(t.Elem()))
+
+	case *types.Named:
+		if t.Obj().Pkg() == nil {
+			return types2.Universe.Lookup(t.Obj().Name()).Type() // error, comparable
+		}
+		if targs := t.TypeArgs(); targs.Len() > 0 {
+			list := make([]types2.Type, targs.Len())
+			for i := range list {
+				list[i] = c.Type2(targs.At(i))
+			}
+			inst, err := types2.Instantiate(c.env2, c.Type2(t.Obj().Type()), list, false)
+			if err != nil {
+				panic(fmt.Sprintf("typesconv: instantiating %s: %v", t, err))
+			}
+			return inst
+		}
+		return c.Object2(t.Obj()).Type()
+
+	case *types.Alias:
+		return c.Type2(types.Unalias(t))
+
+	case *types.TypeParam:
+		// Type parameters are converted with the declaration of their
+		// generic type or function.
+		panic(fmt.Sprintf("typesconv: type parameter %s converted outside its declaration", t))
+	}
+	panic(fmt.Sprintf("typesconv: unexpected type %T", typ))
+}
+
+// signature2 converts sig, and its receiver if recv is set.
+func (c *Converter) signature2(sig *types.Signature, recv bool) *types2.Signature {
+	if s := c.typs2[sig]; s != nil {
+		return s.(*types2.Signature)
+	}
+	tparams := c.typeParams2(sig.TypeParams())
+	rparams := c.typeParams2(sig.RecvTypeParams())
+	var r *types2.Var
+	if recv && sig.Recv() != nil {
+		r = c.Object2(sig.Recv()).(*types2.Var)
+	}
+	s := types2.NewSignature(r, c.tuple2(sig.Params()), c.tuple2(sig.Results()), sig.Variadic())
+	s.SetTypeParams(tparams)
+	s.SetRecvTypeParams(rparams)
+	c.recordType(sig, s)
+	return s
+}
+
+func (c *Converter) tuple2(tup *types.Tuple) *types2.Tuple {
+	if tup.Len() == 0 {
+		return nil
+	}
+	vars := make([]*types2.Var, tup.Len())
+	for i := range vars {
+		vars[i] = c.Object2(tup.At(i)).(*types2.Var)
+	}
+	return types2.NewTuple(vars...)
+}
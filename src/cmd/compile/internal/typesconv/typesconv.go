@@ -0,0 +1,124 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package typesconv converts packages, objects, and types between
+// go/types and cmd/compile/internal/types2, so that tools integrated
+// with the compiler can use libraries written for go/types, and vice
+// versa.
+//
+// A Converter converts in both directions and remembers the objects and
+// types it converted: converting an object or type back yields the
+// original one, and converting it again yields the same result. Packages
+// are converted with all objects of their package scope; other scopes,
+// and information recorded while type-checking, are not converted.
+package typesconv
+
+import (
+	"cmd/compile/internal/syntax"
+	"cmd/compile/internal/types2"
+	"go/token"
+	"go/types"
+	"sync"
+)
+
+// A Converter converts between go/types and types2. Its methods
+// are not safe for concurrent use.
+type Converter struct {
+	fset *token.FileSet
+	env  *types.Environment
+	env2 *types2.Environment
+
+	files map[string]*token.File     // files of positions converted to go/types
+	bases map[string]*syntax.PosBase // bases of positions converted to types2
+
+	pkgs  map[*types2.Package]*types.Package
+	pkgs2 map[*types.Package]*types2.Package
+	objs  map[types2.Object]types.Object
+	objs2 map[types.Object]types2.Object
+	typs  map[types2.Type]types.Type
+	typs2 map[types.Type]types2.Type
+}
+
+// New returns a new Converter. Positions converted to go/types are
+// recorded in fset; those of go/types objects converted to types2 are
+// looked up in it. If env or env2 is non-nil, the instances of generic
+// types created by the Converter are shared through it.
+func New(fset *token.FileSet, env *types.Environment, env2 *types2.Environment) *Converter {
+	c := &Converter{
+		fset:  fset,
+		env:   env,
+		env2:  env2,
+		files: make(map[string]*token.File),
+		bases: make(map[string]*syntax.PosBase),
+		pkgs:  map[*types2.Package]*types.Package{types2.Unsafe: types.Unsafe},
+		pkgs2: map[*types.Package]*types2.Package{types.Unsafe: types2.Unsafe},
+		objs:  make(map[types2.Object]types.Object),
+		objs2: make(map[types.Object]types2.Object),
+		typs:  make(map[types2.Type]types.Type),
+		typs2: make(map[types.Type]types2.Type),
+	}
+	return c
+}
+
+// record records that obj and obj2 correspond to each other.
+func (c *Converter) record(obj types.Object, obj2 types2.Object) {
+	c.objs[obj2] = obj
+	c.objs2[obj] = obj2
+}
+
+// recordType records that typ and typ2 correspond to each other.
+func (c *Converter) recordType(typ types.Type, typ2 types2.Type) {
+	c.typs[typ2] = typ
+	c.typs2[typ] = typ2
+}
+
+// Since the columns and the sizes of files are unknown, positions
+// converted to go/types are recorded as if files consisted of up to
+// maxLines empty lines, as by the gc importer: only the file name and
+// line are preserved.
+const maxLines = 64 * 1024
+
+var (
+	fakeLines     []int
+	fakeLinesOnce sync.Once
+)
+
+// pos converts the types2 position pos.
+func (c *Converter) pos(pos syntax.Pos) token.Pos {
+	if !pos.IsKnown() {
+		return token.NoPos
+	}
+	filename := pos.RelFilename()
+	f := c.files[filename]
+	if f == nil {
+		f = c.fset.AddFile(filename, -1, maxLines)
+		c.files[filename] = f
+		fakeLinesOnce.Do(func() {
+			fakeLines = make([]int, maxLines)
+			for i := range fakeLines {
+				fakeLines[i] = i
+			}
+		})
+		f.SetLines(fakeLines)
+	}
+	line := int(pos.RelLine())
+	if line > maxLines {
+		line = 1
+	}
+	return f.Pos(line - 1)
+}
+
+// pos2 converts the go/types position pos.
+func (c *Converter) pos2(pos token.Pos) syntax.Pos {
+	if !pos.IsValid() {
+		return syntax.Pos{}
+	}
+	p := c.fset.Position(pos)
+	base := c.bases[p.Filename]
+	if base == nil {
+		base = syntax.NewFileBase(p.Filename)
+		c.bases[p.Filename] = base
+	}
+	return syntax.MakePos(base, uint(p.Line), uint(p.Column))
+}
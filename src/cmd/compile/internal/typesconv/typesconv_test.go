@@ -0,0 +1,209 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typesconv
+
+import (
+	"cmd/compile/internal/syntax"
+	"cmd/compile/internal/types2"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+const src = `package p
+
+import "unsafe"
+
+type T struct {
+	X int ` + "`json:\"x\"`" + `
+	next *T
+	I
+}
+
+func (T) M(...byte) (rune, error) { return 0, nil }
+func (t *T) N() unsafe.Pointer    { return unsafe.Pointer(t) }
+
+type I interface {
+	M(...byte) (rune, error)
+	comparable2
+}
+
+type comparable2 interface{ Equal(I) bool }
+
+type G[P interface{ ~int | ~string }] struct {
+	list []P
+	next *G[P]
+}
+
+func (g *G[Q]) Push(x Q) *G[Q] { return &G[Q]{append(g.list, x), g} }
+
+func F[P any, S interface{ ~[]P }](s S) (P, bool) { var p P; return p, len(s) > 0 }
+
+type A = map[string]G[int]
+
+const C = 1 << 70
+const D = len("abc")
+
+var V <-chan func(A, chan<- struct{}) []I
+var W = unsafe.Sizeof(V)
+`
+
+// objects returns the descriptions of the objects of a
+// package scope and their methods.
+func objects(scope interface {
+	Names() []string
+}, lookup func(name string) (obj string, methods []string)) []string {
+	var list []string
+	for _, name := range scope.Names() {
+		obj, methods := lookup(name)
+		list = append(list, obj)
+		list = append(list, methods...)
+	}
+	return list
+}
+
+// normalize removes the type parameter subscripts and instance markers
+// from s.
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '#' || '₀' <= r && r <= '₉' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func describe(pkg *types.Package) []string {
+	return objects(pkg.Scope(), func(name string) (string, []string) {
+		obj := pkg.Scope().Lookup(name)
+		var methods []string
+		if named, _ := obj.Type().(*types.Named); named != nil && named.Obj() == obj {
+			for i := 0; i < named.NumMethods(); i++ {
+				methods = append(methods, normalize(types.ObjectString(named.Method(i), types.RelativeTo(pkg))))
+			}
+		}
+		return normalize(types.ObjectString(obj, types.RelativeTo(pkg))), methods
+	})
+}
+
+func describe2(pkg *types2.Package) []string {
+	return objects(pkg.Scope(), func(name string) (string, []string) {
+		obj := pkg.Scope().Lookup(name)
+		var methods []string
+		if named, _ := obj.Type().(*types2.Named); named != nil && named.Obj() == obj {
+			for i := 0; i < named.NumMethods(); i++ {
+				methods = append(methods, normalize(types2.ObjectString(named.Method(i), types2.RelativeTo(pkg))))
+			}
+		}
+		return normalize(types2.ObjectString(obj, types2.RelativeTo(pkg))), methods
+	})
+}
+
+func check2(t *testing.T) *types2.Package {
+	f, err := syntax.Parse(syntax.NewFileBase("p.go"), strings.NewReader(src), nil, nil, syntax.AllowGenerics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types2.Config{Importer: importerFunc2(func(string) (*types2.Package, error) { return types2.Unsafe, nil })}
+	pkg, err := conf.Check("p", []*syntax.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+func check(t *testing.T, fset *token.FileSet) *types.Package {
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importerFunc(func(string) (*types.Package, error) { return types.Unsafe, nil })}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+type importerFunc2 func(path string) (*types2.Package, error)
+
+func (f importerFunc2) Import(path string) (*types2.Package, error) { return f(path) }
+
+func compare(t *testing.T, got, want []string) {
+	t.Helper()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n\t%s\nwant:\n\t%s", strings.Join(got, "\n\t"), strings.Join(want, "\n\t"))
+	}
+}
+
+func TestToTypes(t *testing.T) {
+	pkg2 := check2(t)
+	fset := token.NewFileSet()
+	c := New(fset, nil, nil)
+	pkg := c.Package(pkg2)
+	compare(t, describe(pkg), describe2(pkg2))
+
+	// The converted package behaves like a checked one.
+	T := pkg.Scope().Lookup("T").Type()
+	I := pkg.Scope().Lookup("I").Type().Underlying().(*types.Interface)
+	if !types.Implements(T, I) {
+		t.Errorf("converted %s does not implement %s", T, I)
+	}
+	if got := fset.Position(pkg.Scope().Lookup("V").Pos()).String(); got != "p.go:35:1" {
+		t.Errorf("V has position %s, want p.go:35:1", got)
+	}
+
+	// Converting back yields the original objects.
+	if c.Package2(pkg) != pkg2 {
+		t.Errorf("package not converted back to the original")
+	}
+	for _, name := range pkg2.Scope().Names() {
+		obj := pkg2.Scope().Lookup(name)
+		if c.Object2(c.Object(obj)) != obj {
+			t.Errorf("%s not converted back to the original", obj)
+		}
+		if c.Type2(c.Type(obj.Type())) != obj.Type() {
+			t.Errorf("type of %s not converted back to the original", obj)
+		}
+	}
+}
+
+func TestToTypes2(t *testing.T) {
+	fset := token.NewFileSet()
+	pkg := check(t, fset)
+	c := New(fset, types.NewEnvironment(), types2.NewEnvironment())
+	pkg2 := c.Package2(pkg)
+	compare(t, describe2(pkg2), describe(pkg))
+
+	// The result is shared with the conversion back to go/types.
+	if c.Package(pkg2) != pkg {
+		t.Errorf("package not converted back to the original")
+	}
+	F := pkg2.Scope().Lookup("F")
+	if got, want := F.Pos().String(), "p.go:28:6"; got != want {
+		t.Errorf("F has position %s, want %s", got, want)
+	}
+	T := pkg2.Scope().Lookup("T").Type()
+	I := pkg2.Scope().Lookup("I").Type().Underlying().(*types2.Interface)
+	if !types2.Implements(T, I) {
+		t.Errorf("converted %s does not implement %s", T, I)
+	}
+
+	// The receiver type parameters of methods of generic types
+	// are those of their receivers.
+	G := pkg2.Scope().Lookup("G").Type().(*types2.Named)
+	sig := G.Method(0).Type().(*types2.Signature)
+	recv := sig.Recv().Type().(*types2.Pointer).Elem().(*types2.Named)
+	if sig.RecvTypeParams().Len() != 1 || recv.TypeArgs().At(0) != sig.RecvTypeParams().At(0) {
+		t.Errorf("receiver %s of G.Push does not use its receiver type parameters", recv)
+	}
+}
@@ -296,7 +296,7 @@ var depsRules = `
 	math/big, go/token
 	< go/constant;
 
-	container/heap, go/constant, go/parser, regexp
+	container/heap, go/constant, go/parser, hash/fnv, regexp, runtime/metrics
 	< go/types;
 
 	FMT, internal/goexperiment
@@ -305,10 +305,24 @@ var depsRules = `
 	go/build/constraint, go/doc, go/parser, internal/buildcfg, internal/goroot, internal/goversion
 	< go/build;
 
-	DEBUG, go/build, go/types, text/scanner
-	< go/internal/gcimporter, go/internal/gccgoimporter, go/internal/srcimporter
+	DEBUG, encoding/gob, go/build, go/types, text/scanner
+	< go/internal/gcimporter, go/internal/gccgoimporter, go/internal/srcimporter;
+
+	archive/zip, go/internal/srcimporter
+	< go/internal/zipimporter;
+
+	go/internal/gcimporter, go/internal/gccgoimporter, go/internal/zipimporter
 	< go/importer;
 
+	encoding/binary, go/types
+	< go/types/symbols;
+
+	go/types
+	< go/types/typedast;
+
+	encoding/json, go/types, net/url
+	< go/types/sarif;
+
 	# databases
 	FMT
 	< database/sql/internal
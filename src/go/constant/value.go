@@ -13,6 +13,7 @@
 package constant
 
 import (
+	"encoding/binary"
 	"fmt"
 	"go/token"
 	"math"
@@ -836,6 +837,490 @@ func Imag(x Value) Value {
 	}
 }
 
+// ----------------------------------------------------------------------------
+// Serialization
+
+// Tags of the encoded value representations.
+const (
+	tagUnknown byte = iota
+	tagBool
+	tagString
+	tagInt64
+	tagInt
+	tagRat
+	tagFloat
+	tagComplex
+)
+
+// Encode returns a binary encoding of x. The encoding preserves the exact
+// value as well as the internal representation of x (such as the precision
+// of large floating-point values), so that Decode(Encode(x)) produces a
+// value indistinguishable from x.
+func Encode(x Value) []byte {
+	return appendValue(nil, x)
+}
+
+func appendValue(buf []byte, x Value) []byte {
+	switch x := x.(type) {
+	case unknownVal:
+		return append(buf, tagUnknown)
+	case boolVal:
+		if x {
+			return append(buf, tagBool, 1)
+		}
+		return append(buf, tagBool, 0)
+	case *stringVal:
+		buf = append(buf, tagString)
+		return appendBytes(buf, []byte(x.string()))
+	case int64Val:
+		var b [binary.MaxVarintLen64]byte
+		n := binary.PutVarint(b[:], int64(x))
+		return append(append(buf, tagInt64), b[:n]...)
+	case intVal:
+		return appendInt(append(buf, tagInt), x.val)
+	case ratVal:
+		buf = appendInt(append(buf, tagRat), x.val.Num())
+		return appendInt(buf, x.val.Denom())
+	case floatVal:
+		b, err := x.val.GobEncode()
+		if err != nil {
+			panic(err) // cannot happen for finite values
+		}
+		return appendBytes(append(buf, tagFloat), b)
+	case complexVal:
+		return appendValue(appendValue(append(buf, tagComplex), x.re), x.im)
+	default:
+		panic(fmt.Sprintf("%v not a Value", x))
+	}
+}
+
+// appendInt appends the encoding of x: a sign byte followed by the bytes
+// of the absolute value of x.
+func appendInt(buf []byte, x *big.Int) []byte {
+	sign := byte(0)
+	if x.Sign() < 0 {
+		sign = 1
+	}
+	return appendBytes(append(buf, sign), x.Bytes())
+}
+
+func appendBytes(buf, b []byte) []byte {
+	var n [binary.MaxVarintLen64]byte
+	k := binary.PutUvarint(n[:], uint64(len(b)))
+	return append(append(buf, n[:k]...), b...)
+}
+
+// Decode returns the value encoded by Encode in data.
+// It returns an error if data is not a valid encoding.
+func Decode(data []byte) (Value, error) {
+	d := decoder{data: data}
+	x := d.value()
+	if d.err == nil && len(d.data) != 0 {
+		d.err = fmt.Errorf("go/constant: %d bytes of trailing data", len(d.data))
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return x, nil
+}
+
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) fail(msg string) {
+	if d.err == nil {
+		d.err = fmt.Errorf("go/constant: invalid encoding: %s", msg)
+	}
+	d.data = nil
+}
+
+func (d *decoder) byte() byte {
+	if len(d.data) == 0 {
+		d.fail("unexpected end of data")
+		return 0
+	}
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b
+}
+
+func (d *decoder) bytes() []byte {
+	n, k := binary.Uvarint(d.data)
+	if k <= 0 || n > uint64(len(d.data)-k) {
+		d.fail("invalid length")
+		return nil
+	}
+	b := d.data[k : k+int(n)]
+	d.data = d.data[k+int(n):]
+	return b
+}
+
+func (d *decoder) int() *big.Int {
+	sign := d.byte()
+	x := newInt().SetBytes(d.bytes())
+	if sign == 1 {
+		x.Neg(x)
+	} else if sign != 0 {
+		d.fail("invalid sign")
+	}
+	return x
+}
+
+func (d *decoder) value() Value {
+	switch tag := d.byte(); tag {
+	case tagUnknown:
+		return unknownVal{}
+	case tagBool:
+		switch d.byte() {
+		case 0:
+			return boolVal(false)
+		case 1:
+			return boolVal(true)
+		}
+		d.fail("invalid bool")
+	case tagString:
+		return &stringVal{s: string(d.bytes())}
+	case tagInt64:
+		x, k := binary.Varint(d.data)
+		if k <= 0 {
+			d.fail("invalid int64")
+			break
+		}
+		d.data = d.data[k:]
+		return int64Val(x)
+	case tagInt:
+		return makeInt(d.int())
+	case tagRat:
+		num := d.int()
+		den := d.int()
+		if d.err == nil && den.Sign() <= 0 {
+			d.fail("invalid denominator")
+			break
+		}
+		return ratVal{newRat().SetFrac(num, den)}
+	case tagFloat:
+		x := new(big.Float)
+		if err := x.GobDecode(d.bytes()); err != nil && d.err == nil {
+			d.fail(err.Error())
+			break
+		}
+		if x.IsInf() {
+			d.fail("infinite float")
+			break
+		}
+		return makeFloat(x)
+	case tagComplex:
+		re := d.complexPart()
+		im := d.complexPart()
+		if d.err != nil {
+			break
+		}
+		return makeComplex(re, im)
+	default:
+		d.fail(fmt.Sprintf("invalid tag %d", tag))
+	}
+	return unknownVal{}
+}
+
+// complexPart decodes the real or imaginary part of a complex value, which
+// must be an Int or Float value.
+func (d *decoder) complexPart() Value {
+	if len(d.data) > 0 && d.data[0] == tagComplex {
+		d.fail("nested complex value") // don't recurse
+		return unknownVal{}
+	}
+	x := d.value()
+	if k := x.Kind(); k != Int && k != Float {
+		d.fail("invalid complex part")
+	}
+	return x
+}
+
+// ----------------------------------------------------------------------------
+// Formatting
+
+// Text returns a decimal string representation of the Int or Float value x,
+// rounded from the exact value of x (to nearest, ties to even), and reports
+// whether the result represents x exactly. The format is one of
+//
+//	'f'  -ddd.dddd, with prec digits after the decimal point
+//	'e'  -d.dddde±dd, with prec digits after the decimal point ('E' for E)
+//	'g'  'e' for large or small exponents, 'f' otherwise, with prec
+//	     significant digits and without trailing zeros ('G' for E)
+//	'r'  -num/den, the exact fraction (prec is ignored); integers are
+//	     formatted without denominator
+//
+// like the respective formats of strconv.FormatFloat. A negative prec selects
+// the smallest number of digits necessary to represent x exactly; in that case
+// 'g' uses exponent notation for decimal exponents < -4 or >= 21. If x has no
+// finite decimal representation (as is the case for 1/3) and prec is negative,
+// the result is ("", false). If x is Unknown, the result is ("unknown", false).
+func Text(x Value, format byte, prec int) (string, bool) {
+	var r *big.Rat
+	switch x := x.(type) {
+	case unknownVal:
+		return x.String(), false
+	case int64Val:
+		r = newRat().SetInt64(int64(x))
+	case intVal:
+		r = newRat().SetInt(x.val)
+	case ratVal:
+		r = x.val
+	case floatVal:
+		if x.val.IsInf() {
+			return x.val.String(), false
+		}
+		r, _ = x.val.Rat(nil)
+	default:
+		panic(fmt.Sprintf("%v not an Int or Float", x))
+	}
+
+	if format == 'r' {
+		if r.IsInt() {
+			return r.Num().String(), true
+		}
+		return r.String(), true
+	}
+
+	neg := r.Sign() < 0
+	num := newInt().Abs(r.Num())
+	den := r.Denom()
+
+	if prec < 0 {
+		k, ok := decimalPlaces(den)
+		if !ok {
+			return "", false
+		}
+		switch format {
+		case 'f':
+			prec = k
+		case 'e', 'E', 'g', 'G':
+			// the exact digits, without trailing zeros
+			m, _ := roundScaled(num, den, k)
+			digits := len(strings.TrimRight(m.String(), "0"))
+			if digits == 0 {
+				digits = 1 // m == 0
+			}
+			if format == 'e' || format == 'E' {
+				prec = digits - 1
+				break
+			}
+			if exp := decimalExp(num, den); exp < -4 || exp >= 21 {
+				s, _ := formatE(neg, num, den, digits-1, format-'g'+'e')
+				return s, true
+			}
+			s, _ := formatF(neg, num, den, k)
+			return trimZeros(s, 0), true
+		default:
+			panic(fmt.Sprintf("invalid format %q", format))
+		}
+	}
+
+	var s string
+	var exact bool
+	switch format {
+	case 'f':
+		s, exact = formatF(neg, num, den, prec)
+	case 'e', 'E':
+		s, exact = formatE(neg, num, den, prec, format)
+	case 'g', 'G':
+		if prec == 0 {
+			prec = 1
+		}
+		// determine the exponent after rounding to prec significant digits
+		_, exp, _ := roundSignificant(num, den, prec)
+		if exp < -4 || exp >= prec {
+			s, exact = formatE(neg, num, den, prec-1, format-'g'+'e')
+			s = trimZeros(s, format-'g'+'e')
+		} else {
+			s, exact = formatF(neg, num, den, prec-1-exp)
+			s = trimZeros(s, 0)
+		}
+	default:
+		panic(fmt.Sprintf("invalid format %q", format))
+	}
+	return s, exact
+}
+
+// Shortest returns the shortest decimal string representation of the
+// floating-point value of size bitSize (32 or 64) nearest to the Int or
+// Float value x, as strconv.FormatFloat(f, 'g', -1, bitSize). It reports
+// whether x is in the range of that floating-point type.
+func Shortest(x Value, bitSize int) (string, bool) {
+	var f float64
+	switch bitSize {
+	case 32:
+		f32, _ := Float32Val(x)
+		f = float64(f32)
+	case 64:
+		f, _ = Float64Val(x)
+	default:
+		panic(fmt.Sprintf("invalid bit size %d", bitSize))
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize), !math.IsInf(f, 0)
+}
+
+// decimalPlaces returns the number of decimal places needed to represent
+// fractions with the (positive) denominator den exactly, and whether such
+// a finite representation exists.
+func decimalPlaces(den *big.Int) (int, bool) {
+	d := newInt().Set(den)
+	var twos, fives int
+	for d.Bit(0) == 0 {
+		d.Rsh(d, 1)
+		twos++
+	}
+	five := big.NewInt(5)
+	for {
+		q, m := newInt().QuoRem(d, five, newInt())
+		if m.Sign() != 0 {
+			break
+		}
+		d = q
+		fives++
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return 0, false
+	}
+	if twos > fives {
+		return twos, true
+	}
+	return fives, true
+}
+
+// pow10 returns 10**n for n >= 0.
+func pow10(n int) *big.Int {
+	return newInt().Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundScaled returns num/den * 10**k rounded to the nearest integer (ties to
+// even), and whether no rounding was needed. k may be negative.
+func roundScaled(num, den *big.Int, k int) (*big.Int, bool) {
+	n, d := num, den
+	if k >= 0 {
+		n = newInt().Mul(num, pow10(k))
+	} else {
+		d = newInt().Mul(den, pow10(-k))
+	}
+	q, m := newInt().QuoRem(n, d, newInt())
+	if m.Sign() == 0 {
+		return q, true
+	}
+	switch m.Lsh(m, 1).Cmp(d) {
+	case 1:
+		q.Add(q, big.NewInt(1))
+	case 0:
+		if q.Bit(0) == 1 {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q, false
+}
+
+// decimalExp returns the decimal exponent e of num/den > 0 such that
+// 10**e <= num/den < 10**(e+1); the result is 0 if num is 0.
+func decimalExp(num, den *big.Int) int {
+	if num.Sign() == 0 {
+		return 0
+	}
+	e := len(num.String()) - len(den.String())
+	// adjust e such that 10**e <= num/den, then such that num/den < 10**(e+1)
+	for cmpPow10(num, den, e) < 0 {
+		e--
+	}
+	for cmpPow10(num, den, e+1) >= 0 {
+		e++
+	}
+	return e
+}
+
+// cmpPow10 compares num/den with 10**e.
+func cmpPow10(num, den *big.Int, e int) int {
+	if e >= 0 {
+		return num.Cmp(newInt().Mul(den, pow10(e)))
+	}
+	return newInt().Mul(num, pow10(-e)).Cmp(den)
+}
+
+// roundSignificant rounds num/den to n > 0 significant digits and returns
+// the digits m (with exactly n digits, unless num is 0), the decimal
+// exponent of the rounded value, and whether no rounding was needed.
+func roundSignificant(num, den *big.Int, n int) (m *big.Int, exp int, exact bool) {
+	exp = decimalExp(num, den)
+	m, exact = roundScaled(num, den, n-1-exp)
+	if m.Cmp(pow10(n)) >= 0 {
+		// rounding produced an extra digit (as in 9.99 -> 10.0)
+		m.Quo(m, big.NewInt(10))
+		exp++
+	}
+	return
+}
+
+func formatF(neg bool, num, den *big.Int, prec int) (string, bool) {
+	m, exact := roundScaled(num, den, prec)
+	digits := m.String()
+	if len(digits) <= prec {
+		digits = strings.Repeat("0", prec-len(digits)+1) + digits
+	}
+	var buf strings.Builder
+	if neg {
+		buf.WriteByte('-')
+	}
+	buf.WriteString(digits[:len(digits)-prec])
+	if prec > 0 {
+		buf.WriteByte('.')
+		buf.WriteString(digits[len(digits)-prec:])
+	}
+	return buf.String(), exact
+}
+
+func formatE(neg bool, num, den *big.Int, prec int, format byte) (string, bool) {
+	m, exp, exact := roundSignificant(num, den, prec+1)
+	digits := m.String()
+	if m.Sign() == 0 {
+		digits = strings.Repeat("0", prec+1)
+	}
+	var buf strings.Builder
+	if neg {
+		buf.WriteByte('-')
+	}
+	buf.WriteByte(digits[0])
+	if prec > 0 {
+		buf.WriteByte('.')
+		buf.WriteString(digits[1:])
+	}
+	buf.WriteByte(format)
+	if exp < 0 {
+		buf.WriteByte('-')
+		exp = -exp
+	} else {
+		buf.WriteByte('+')
+	}
+	if exp < 10 {
+		buf.WriteByte('0')
+	}
+	buf.WriteString(strconv.Itoa(exp))
+	return buf.String(), exact
+}
+
+// trimZeros removes trailing zeros (and a trailing decimal point) from the
+// mantissa of the formatted number s, which has an exponent introduced by
+// the exponent character e, if e != 0.
+func trimZeros(s string, e byte) string {
+	mant, exp := s, ""
+	if e != 0 {
+		i := strings.IndexByte(s, e)
+		mant, exp = s[:i], s[i:]
+	}
+	if strings.IndexByte(mant, '.') >= 0 {
+		mant = strings.TrimRight(mant, "0")
+		mant = strings.TrimSuffix(mant, ".")
+	}
+	return mant + exp
+}
+
 // ----------------------------------------------------------------------------
 // Numeric conversions
 
@@ -437,6 +437,59 @@ func TestString(t *testing.T) {
 	}
 }
 
+func TestEncode(t *testing.T) {
+	values := []Value{
+		MakeUnknown(),
+		MakeBool(false),
+		MakeInt64(math.MinInt64),
+		MakeUint64(math.MaxUint64),
+		MakeFloat64(-0.1),
+		BinaryOp(MakeString("foo"), token.ADD, MakeString("bar")),
+		Shift(MakeInt64(1), token.SHL, 300),
+		MakeFromLiteral("1e1000", token.FLOAT, 0),
+		MakeFromLiteral("-1.5e-1000", token.FLOAT, 0),
+		BinaryOp(MakeFloat64(2.5), token.QUO, MakeFromLiteral("1e-5i", token.IMAG, 0)),
+	}
+	for _, test := range stringTests {
+		values = append(values, val(test.input))
+	}
+	for _, test := range fracTests {
+		values = append(values, val(test))
+	}
+
+	for _, x := range values {
+		got, err := Decode(Encode(x))
+		if err != nil {
+			t.Errorf("%s: %v", x.ExactString(), err)
+			continue
+		}
+		if fmt.Sprintf("%T", got) != fmt.Sprintf("%T", x) || got.ExactString() != x.ExactString() {
+			t.Errorf("%s: got %T %s, want %T %s", x.ExactString(), got, got.ExactString(), x, x.ExactString())
+		}
+		if f, ok := x.(floatVal); ok && got.(floatVal).val.Prec() != f.val.Prec() {
+			t.Errorf("%s: got precision %d, want %d", x.ExactString(), got.(floatVal).val.Prec(), f.val.Prec())
+		}
+	}
+
+	for _, data := range [][]byte{
+		nil,
+		{tagBool, 2},
+		{tagString, 5, 'a'},
+		{tagInt64},
+		{tagRat, 0, 1, 1, 0, 0},
+		{tagComplex, tagInt64, 0},
+		{tagComplex, tagString, 1, 'a', tagBool, 1},
+		{tagComplex, tagUnknown, tagInt64, 2},
+		{tagComplex, tagComplex, tagInt64, 2, tagInt64, 2, tagInt64, 2},
+		{tagUnknown, 0},
+		{42},
+	} {
+		if x, err := Decode(data); err == nil {
+			t.Errorf("Decode(%v) = %v; want error", data, x)
+		}
+	}
+}
+
 // ----------------------------------------------------------------------------
 // Support functions
 
@@ -727,3 +780,76 @@ func TestBitLen(t *testing.T) {
 		}
 	}
 }
+
+var textTests = []struct {
+	val    string
+	format byte
+	prec   int
+	want   string
+	exact  bool
+}{
+	{"0", 'f', 2, "0.00", true},
+	{"0", 'e', -1, "0e+00", true},
+	{"0", 'g', -1, "0", true},
+	{"42", 'f', -1, "42", true},
+	{"-1/8", 'f', -1, "-0.125", true},
+	{"-1/8", 'f', 2, "-0.12", false},
+	{"3/8", 'f', 2, "0.38", false},
+	{"-1/1000", 'f', 1, "-0.0", false},
+	{"1/3", 'f', -1, "", false},
+	{"1/3", 'f', 5, "0.33333", false},
+	{"2/3", 'e', 3, "6.667e-01", false},
+	{"2/3", 'E', 0, "7E-01", false},
+	{"9.995", 'e', 2, "1.00e+01", false},
+	{"12345", 'e', -1, "1.2345e+04", true},
+	{"1e100", 'g', 3, "1e+100", true},
+	{"1e100", 'g', -1, "1e+100", true},
+	{"1234567890123456789012", 'g', -1, "1.234567890123456789012e+21", true},
+	{"12345678901234567890", 'g', -1, "12345678901234567890", true},
+	{"0.0001", 'g', -1, "0.0001", true},
+	{"0.00001", 'g', -1, "1e-05", true},
+	{"1.5", 'g', 5, "1.5", true},
+	{"100", 'G', 2, "1E+02", true},
+	{"0.1", 'g', 20, "0.1", true},
+	{"1/3", 'g', 4, "0.3333", false},
+	{"1e-1000", 'e', -1, "1e-1000", true},
+	{"6/4", 'r', 0, "3/2", true},
+	{"-12", 'r', 0, "-12", true},
+	{"?", 'f', 2, "unknown", false},
+}
+
+func TestText(t *testing.T) {
+	for _, test := range textTests {
+		x := val(test.val)
+		got, exact := Text(x, test.format, test.prec)
+		if got != test.want || exact != test.exact {
+			t.Errorf("Text(%s, %q, %d) = %q, %v; want %q, %v", test.val, test.format, test.prec, got, exact, test.want, test.exact)
+		}
+	}
+
+	// A float constant is formatted exactly, not like its 512-bit approximation.
+	x := MakeFromLiteral("0x1p-20", token.FLOAT, 0)
+	if got, _ := Text(x, 'f', -1); got != "0.00000095367431640625" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestShortest(t *testing.T) {
+	for _, test := range []struct {
+		val     string
+		bitSize int
+		want    string
+		ok      bool
+	}{
+		{"0.1", 64, "0.1", true},
+		{"1/3", 64, "0.3333333333333333", true},
+		{"1/3", 32, "0.33333334", true},
+		{"1e39", 32, "+Inf", false},
+		{"1e39", 64, "1e+39", true},
+	} {
+		got, ok := Shortest(val(test.val), test.bitSize)
+		if got != test.want || ok != test.ok {
+			t.Errorf("Shortest(%s, %d) = %q, %v; want %q, %v", test.val, test.bitSize, got, ok, test.want, test.ok)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"go/internal/gccgoimporter"
 	"go/internal/gcimporter"
 	"go/internal/srcimporter"
+	"go/internal/zipimporter"
 	"go/token"
 	"go/types"
 	"io"
@@ -37,6 +38,10 @@ type Lookup func(path string) (io.ReadCloser, error)
 // A lookup function must be provided for correct module-aware operation.
 // Deprecated: If lookup is nil, for backwards-compatibility, the importer
 // will attempt to resolve imports in the $GOPATH workspace.
+//
+// The "source" importer resolves import paths relative to the current
+// directory of the running process; use ForModule to import from the
+// source of another module.
 func ForCompiler(fset *token.FileSet, compiler string, lookup Lookup) types.Importer {
 	switch compiler {
 	case "gc":
@@ -69,6 +74,67 @@ func ForCompiler(fset *token.FileSet, compiler string, lookup Lookup) types.Impo
 	return nil
 }
 
+// ForModule returns an Importer for importing directly from the source,
+// like ForCompiler(fset, "source", nil), that resolves import paths the
+// way the go command does when run in directory dir: in module mode, the
+// main module is the one containing dir, and import paths are resolved
+// through its go.mod file, honoring its requirements and replace
+// directives and locating dependencies in the module cache. Relative
+// directories passed to the importer's ImportFrom method, as well as the
+// directory implied by Import, are interpreted relative to dir.
+//
+// Resolving packages outside the standard library requires the go
+// command. If module mode is disabled (GO111MODULE=off), import paths
+// are resolved in the $GOPATH workspace.
+func ForModule(fset *token.FileSet, dir string) types.Importer {
+	return ForSource(fset, &SourceConfig{Dir: dir})
+}
+
+// A SourceConfig configures an Importer returned by ForSource.
+type SourceConfig struct {
+	// Dir is the directory in which import paths are resolved,
+	// as for ForModule. If Dir is empty, the current directory of
+	// the running process is used.
+	Dir string
+
+	// Overlay maps file names to the contents to use instead of the
+	// contents of the file on disk, such as the unsaved buffers of an
+	// editor or generated files that are only present in memory. Files
+	// that do not exist on disk are added to their directory. Relative
+	// file names are relative to Dir. The map must not be modified
+	// while the importer is in use.
+	Overlay map[string][]byte
+}
+
+// ForSource returns an Importer for importing directly from the source,
+// configured by conf; a nil conf is the same as the zero SourceConfig.
+// With the zero SourceConfig, the result behaves like
+// ForCompiler(fset, "source", nil).
+func ForSource(fset *token.FileSet, conf *SourceConfig) types.Importer {
+	if conf == nil {
+		conf = new(SourceConfig)
+	}
+	ctxt := build.Default
+	ctxt.Dir = conf.Dir
+	imp := srcimporter.New(&ctxt, fset, make(map[string]*types.Package))
+	imp.SetOverlay(conf.Overlay)
+	return imp
+}
+
+// ForModuleZips returns an Importer for importing directly from the source
+// of the modules of a build list, which maps module paths to versions. The
+// source of each module version is read from its zip file in the download
+// cache of the module cache directory modCache (typically $GOMODCACHE)
+// without extracting it, so the go command is not needed. Opened zip files
+// are cached per module version. Packages of the standard library are
+// imported from the source in GOROOT.
+//
+// The result implements types.ImporterFrom and io.Closer; Close closes
+// the zip files opened by the importer.
+func ForModuleZips(fset *token.FileSet, modCache string, buildList map[string]string) types.Importer {
+	return zipimporter.New(&build.Default, fset, modCache, buildList, make(map[string]*types.Package))
+}
+
 // For calls ForCompiler with a new FileSet.
 //
 // Deprecated: Use ForCompiler, which populates a FileSet
@@ -83,6 +149,71 @@ func Default() types.Importer {
 	return For(runtime.Compiler, nil)
 }
 
+// A VersionError is returned by the gc importer for export data of a
+// format version it cannot read, such as export data written by a newer
+// toolchain. It reports the version found in the export data.
+type VersionError = gcimporter.VersionError
+
+// ExportDataVersions returns the versions of the (indexed) gc export data
+// format that the gc importer can read, in increasing order, and the
+// version written by ExportShallow.
+func ExportDataVersions() (readable []int64, written int64) {
+	return gcimporter.ReadableVersions(), gcimporter.WrittenVersion()
+}
+
+// ExportShallow returns "shallow" export data for the type-checked
+// package pkg, whose positions are recorded in fset. Shallow export data
+// only encodes the declarations of pkg itself: the objects of other
+// packages are referred to by package path and name instead of being
+// encoded again. Use ImportShallow to read it.
+func ExportShallow(fset *token.FileSet, pkg *types.Package) ([]byte, error) {
+	return gcimporter.IExportShallow(fset, pkg)
+}
+
+// ImportShallow returns the package with the given import path from the
+// shallow export data written by ExportShallow, adding the positions of
+// its objects to fset. The function getPackage is called for each other
+// package referred to by the data. It must return that package, which
+// must hold the objects referred to; typically, it imports the package
+// from its own shallow export data.
+func ImportShallow(fset *token.FileSet, data []byte, path string, getPackage func(path string) (*types.Package, error)) (*types.Package, error) {
+	return gcimporter.IImportShallow(fset, data, path, getPackage)
+}
+
+// ExportShallowInstances is like ExportShallow but also records the given
+// instances of generic types, typically the instances created while
+// type-checking pkg (as found in Info.Types). ImportShallowEnv registers
+// them in the Environment of the importing client, so that type-checking
+// with that Environment reuses them instead of creating identical
+// instances again. Types that are not instances, and instances depending
+// on type parameters, are ignored.
+func ExportShallowInstances(fset *token.FileSet, pkg *types.Package, instances []types.Type) ([]byte, error) {
+	return gcimporter.IExportShallowInstances(fset, pkg, instances)
+}
+
+// ImportShallowEnv is like ImportShallow but creates the instances of
+// generic types referred to by the data, including the instances recorded
+// by ExportShallowInstances, in env.
+func ImportShallowEnv(fset *token.FileSet, env *types.Environment, data []byte, path string, getPackage func(path string) (*types.Package, error)) (*types.Package, error) {
+	return gcimporter.IImportShallowEnv(fset, env, data, path, getPackage)
+}
+
+// ExportFacts returns an encoding of the facts attached to the package pkg
+// and its package-level objects and methods, to be stored alongside the
+// export data of pkg. The types of the facts must be registered with
+// encoding/gob. Use ImportFacts to attach the facts to the package
+// imported from the export data.
+func ExportFacts(pkg *types.Package) ([]byte, error) {
+	return gcimporter.EncodeFacts(pkg)
+}
+
+// ImportFacts attaches the facts encoded in data by ExportFacts to the
+// package pkg and its objects, typically after importing pkg. Facts of
+// objects not present in pkg are dropped.
+func ImportFacts(pkg *types.Package, data []byte) error {
+	return gcimporter.DecodeFacts(pkg, data)
+}
+
 // gc importer
 
 type gcimports struct {
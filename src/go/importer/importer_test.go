@@ -5,11 +5,15 @@
 package importer
 
 import (
+	"go/ast"
+	"go/parser"
 	"go/token"
+	"go/types"
 	"internal/testenv"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
@@ -85,3 +89,108 @@ func TestForCompiler(t *testing.T) {
 		}
 	})
 }
+
+func TestShallow(t *testing.T) {
+	const src = `package p
+
+import "fmt"
+
+type T struct{ fmt.Stringer }
+
+func F(format string, args ...interface{}) error { return fmt.Errorf(format, args...) }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp := Default()
+	conf := types.Config{Importer: imp}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ExportShallow(fset, pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	pkg2, err := ImportShallow(token.NewFileSet(), data, "p", func(path string) (*types.Package, error) {
+		paths = append(paths, path)
+		return imp.Import(path)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(paths, " "); got != "fmt" {
+		t.Errorf("got lookups for %s, want fmt", got)
+	}
+	for _, name := range []string{"T", "F"} {
+		if got, want := pkg2.Scope().Lookup(name).Type().Underlying(), pkg.Scope().Lookup(name).Type().Underlying(); !types.Identical(got, want) {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestForModule(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+
+	// The main module m requires dep, which is replaced by a local directory.
+	tmp := t.TempDir()
+	for name, src := range map[string]string{
+		"m/go.mod":     "module example.com/m\n\ngo 1.16\n\nrequire example.com/dep v1.0.0\n\nreplace example.com/dep => ../dep\n",
+		"m/p/p.go":     "package p\n\nimport \"example.com/dep\"\n\nvar V = dep.F()\n",
+		"dep/go.mod":   "module example.com/dep\n\ngo 1.16\n",
+		"dep/dep.go":   "package dep\n\nimport \"strings\"\n\nfunc F() *strings.Builder { return nil }\n",
+		"other/go.mod": "module example.com/other\n\ngo 1.16\n",
+	} {
+		filename := filepath.Join(tmp, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", filepath.Dir(testenv.GoToolPath(t))+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("GO111MODULE", "on")
+	t.Setenv("GOFLAGS", "-mod=mod")
+	t.Setenv("GOPROXY", "off")
+
+	fset := token.NewFileSet()
+	imp := ForModule(fset, filepath.Join(tmp, "m"))
+	pkg, err := imp.Import("example.com/m/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pkg.Scope().Lookup("V").Type().String(), "*strings.Builder"; got != want {
+		t.Errorf("p.V has type %s, want %s", got, want)
+	}
+	posn := fset.Position(pkg.Imports()[0].Scope().Lookup("F").Pos())
+	if want := filepath.Join(tmp, "dep", "dep.go"); posn.Filename != want {
+		t.Errorf("dep.F declared in %s, want %s", posn.Filename, want)
+	}
+
+	// Packages are resolved in the context of the main module only.
+	if _, err := ForModule(fset, filepath.Join(tmp, "other")).Import("example.com/m/p"); err == nil {
+		t.Errorf("importing example.com/m/p from another module succeeded unexpectedly")
+	}
+
+	// Overlaid files take the place of, or are added to, the files on disk.
+	imp = ForSource(fset, &SourceConfig{
+		Dir: filepath.Join(tmp, "m"),
+		Overlay: map[string][]byte{
+			filepath.Join("p", "p.go"):        []byte("package p\n\nimport \"example.com/dep\"\n\nvar V = dep.G()\n"),
+			filepath.Join(tmp, "dep", "g.go"): []byte("package dep\n\nfunc G() int { return 0 }\n"),
+		},
+	})
+	pkg, err = imp.Import("example.com/m/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pkg.Scope().Lookup("V").Type().String(), "int"; got != want {
+		t.Errorf("p.V has type %s with overlay, want %s", got, want)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the encoding of the facts attached to packages.
+
+package gcimporter
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// A gobFact is the encoding of a fact attached to a package
+// or one of its objects.
+type gobFact struct {
+	Object string // "" for the package, "Name" for package-level objects, "T.M" for methods
+	Fact   types.Fact
+}
+
+// EncodeFacts returns the gob encoding of the facts attached to pkg and
+// its package-level objects and the methods of its package-level types.
+// Facts attached to other objects are not encoded. The types of the
+// facts must be registered with gob.Register.
+func EncodeFacts(pkg *types.Package) ([]byte, error) {
+	var list []gobFact
+	for _, f := range pkg.Facts() {
+		name, ok := objectName(pkg, f.Object)
+		if ok {
+			list = append(list, gobFact{name, f.Fact})
+		}
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(list); err != nil {
+		return nil, fmt.Errorf("encoding facts of package %s: %v", pkg.Path(), err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeFacts attaches the facts encoded in data by EncodeFacts to pkg and
+// its objects, typically after importing pkg from its export data. Facts of
+// objects that are not present in pkg, such as unexported objects missing
+// from export data, are dropped.
+func DecodeFacts(pkg *types.Package, data []byte) error {
+	var list []gobFact
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&list); err != nil {
+		return fmt.Errorf("decoding facts of package %s: %v", pkg.Path(), err)
+	}
+	for _, f := range list {
+		if obj, ok := lookupObject(pkg, f.Object); ok {
+			pkg.SetFact(obj, f.Fact)
+		}
+	}
+	return nil
+}
+
+// objectName returns the name identifying obj in the encoding of the facts
+// of pkg; obj is nil for package facts. The result is false if obj is not a
+// package-level object or a method of a package-level type.
+func objectName(pkg *types.Package, obj types.Object) (string, bool) {
+	if obj == nil {
+		return "", true
+	}
+	if pkg.Scope().Lookup(obj.Name()) == obj {
+		return obj.Name(), true
+	}
+	if fn, _ := obj.(*types.Func); fn != nil {
+		if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+			if named := receiverBase(recv.Type()); named != nil && pkg.Scope().Lookup(named.Obj().Name()) == named.Obj() {
+				return named.Obj().Name() + "." + fn.Name(), true
+			}
+		}
+	}
+	return "", false
+}
+
+// lookupObject returns the object of pkg with the given name,
+// as returned by objectName.
+func lookupObject(pkg *types.Package, name string) (types.Object, bool) {
+	if name == "" {
+		return nil, true
+	}
+	tname, mname := name, ""
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		tname, mname = name[:i], name[i+1:]
+	}
+	obj := pkg.Scope().Lookup(tname)
+	if obj == nil {
+		return nil, false
+	}
+	if mname == "" {
+		return obj, true
+	}
+	if named, _ := obj.Type().(*types.Named); named != nil {
+		for i := 0; i < named.NumMethods(); i++ {
+			if m := named.Method(i); m.Name() == mname {
+				return m, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// receiverBase returns the defined type of the receiver type typ,
+// which may be a pointer; the result is nil if there is none.
+func receiverBase(typ types.Type) *types.Named {
+	if ptr, _ := typ.(*types.Pointer); ptr != nil {
+		typ = ptr.Elem()
+	}
+	named, _ := typ.(*types.Named)
+	return named
+}
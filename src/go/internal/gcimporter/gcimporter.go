@@ -127,7 +127,7 @@ func Import(fset *token.FileSet, packages map[string]*types.Package, path, srcDi
 		defer func() {
 			if err != nil {
 				// add file name to error
-				err = fmt.Errorf("%s: %v", filename, err)
+				err = fmt.Errorf("%s: %w", filename, err)
 			}
 		}()
 		rc = f
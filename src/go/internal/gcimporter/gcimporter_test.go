@@ -6,6 +6,7 @@ package gcimporter_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"internal/goexperiment"
 	"internal/testenv"
@@ -321,6 +322,10 @@ func TestVersionHandling(t *testing.T) {
 			if strings.Contains(err.Error(), "newer version") {
 				switch name {
 				case "test_go1.11_999i.a":
+					var verr *VersionError
+					if !errors.As(err, &verr) || verr.Version != 999 || !verr.Newer() {
+						t.Errorf("import %q: got error %v, want VersionError for version 999", pkgpath, err)
+					}
 					continue
 				}
 				// fall through
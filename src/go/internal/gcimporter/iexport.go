@@ -0,0 +1,891 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Indexed package export.
+// This is a go/types-based implementation of the writer for the
+// export data format read by iimport.go.
+// See cmd/compile/internal/typecheck/iexport.go for the format.
+
+package gcimporter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// Current export format version. Must match the version accepted by iimport.go.
+const iexportVersion = iexportVersionCurrent
+
+// IExportData writes indexed export data for pkg to out. The export data
+// includes the declarations of all objects of other packages referred to
+// by the declarations of pkg, so that it can be imported without access to
+// the export data of its dependencies.
+func IExportData(out io.Writer, fset *token.FileSet, pkg *types.Package) error {
+	return iexportCommon(out, fset, false, pkg, nil)
+}
+
+// IExportShallow returns "shallow" indexed export data for pkg. Unlike
+// the data written by IExportData, it only encodes the declarations of pkg
+// itself; objects of other packages are referred to by package path and
+// name only and must be provided by the importer (see IImportShallow).
+func IExportShallow(fset *token.FileSet, pkg *types.Package) ([]byte, error) {
+	return IExportShallowInstances(fset, pkg, nil)
+}
+
+// IExportShallowInstances is like IExportShallow but also records the
+// given instances of generic types, such as the instances created while
+// type-checking pkg, so that they may be registered in an Environment when
+// the data is imported (see IImportShallowEnv). Types that are not
+// instances, and instances depending on type parameters, are ignored. If
+// any instances are recorded, the flag iexportFlagInstances is set in the
+// format version of the data.
+func IExportShallowInstances(fset *token.FileSet, pkg *types.Package, instances []types.Type) ([]byte, error) {
+	var out bytes.Buffer
+	if err := iexportCommon(&out, fset, true, pkg, instances); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// internalError is the panic value used to report failures of the exporter.
+type internalError string
+
+func (e internalError) Error() string { return "gcimporter: " + string(e) }
+
+func internalErrorf(format string, args ...interface{}) error {
+	return internalError(fmt.Sprintf(format, args...))
+}
+
+func iexportCommon(out io.Writer, fset *token.FileSet, shallow bool, pkg *types.Package, instances []types.Type) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			if ierr, ok := e.(internalError); ok {
+				err = ierr
+				return
+			}
+			// Not an internal error; panic again.
+			panic(e)
+		}
+	}()
+
+	p := iexporter{
+		fset:        fset,
+		localpkg:    pkg,
+		shallow:     shallow,
+		allPkgs:     map[*types.Package]bool{},
+		stringIndex: map[string]uint64{},
+		declIndex:   map[types.Object]uint64{},
+		tparamNames: map[types.Object]string{},
+		typIndex:    map[types.Type]uint64{},
+	}
+
+	for i, pt := range predeclared {
+		p.typIndex[pt] = uint64(i)
+	}
+	if len(p.typIndex) > predeclReserved {
+		panic(internalErrorf("too many predeclared types: %d > %d", len(p.typIndex), predeclReserved))
+	}
+
+	// Initialize work queue with exported declarations.
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if token.IsExported(name) {
+			p.pushDecl(scope.Lookup(name))
+		}
+	}
+
+	// Write the recorded instances, which may add to the work queue.
+	var instOffs []uint64
+	seen := make(map[uint64]bool)
+	for _, t := range instances {
+		if named, _ := types.Unalias(t).(*types.Named); named == nil || named.TypeArgs().Len() == 0 || hasTypeParams(named) {
+			continue
+		}
+		if off := p.typOff(t, pkg); !seen[off] {
+			seen[off] = true
+			instOffs = append(instOffs, off)
+		}
+	}
+
+	// Loop until no more work.
+	for len(p.declTodo) > 0 {
+		obj := p.declTodo[0]
+		p.declTodo = p.declTodo[1:]
+		p.doDecl(obj)
+	}
+
+	// Append indices to data0 section.
+	dataLen := uint64(p.data0.Len())
+	w := p.newWriter()
+	w.writeIndex(p.declIndex)
+	version := uint64(iexportVersion)
+	if len(instOffs) > 0 {
+		// The list of instances follows the index.
+		version |= iexportFlagInstances
+		w.uint64(uint64(len(instOffs)))
+		for _, off := range instOffs {
+			w.uint64(off)
+		}
+	}
+	w.flush()
+
+	// Assemble header.
+	var hdr intWriter
+	hdr.WriteByte('i')
+	hdr.uint64(version)
+	hdr.uint64(uint64(p.strings.Len()))
+	hdr.uint64(dataLen)
+
+	// Flush output.
+	for _, buf := range []*intWriter{&hdr, &p.strings, &p.data0} {
+		if _, err := io.Copy(out, buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type iexporter struct {
+	fset     *token.FileSet
+	localpkg *types.Package
+	shallow  bool // export only the declarations of localpkg
+
+	// allPkgs tracks all packages that have been referenced by
+	// the export data, so we can ensure to include them in the
+	// main index.
+	allPkgs map[*types.Package]bool
+
+	declTodo []types.Object // work queue of objects to declare
+
+	strings     intWriter
+	stringIndex map[string]uint64
+
+	data0       intWriter
+	declIndex   map[types.Object]uint64
+	tparamNames map[types.Object]string // unique export names of type parameters
+	typIndex    map[types.Type]uint64
+}
+
+// stringOff returns the offset of s within the string section.
+// If not already present, it's added to the end.
+func (p *iexporter) stringOff(s string) uint64 {
+	off, ok := p.stringIndex[s]
+	if !ok {
+		off = uint64(p.strings.Len())
+		p.stringIndex[s] = off
+
+		p.strings.uint64(uint64(len(s)))
+		p.strings.WriteString(s)
+	}
+	return off
+}
+
+// pushDecl adds obj to the declaration work queue, if not already present.
+// In shallow mode, objects of other packages are not declared.
+func (p *iexporter) pushDecl(obj types.Object) {
+	// Package unsafe is known to the compiler and predeclared.
+	// Caller should not ask us to do export it.
+	if obj.Pkg() == types.Unsafe {
+		panic(internalErrorf("cannot export package unsafe"))
+	}
+	if p.shallow && obj.Pkg() != p.localpkg {
+		if _, isTypeParam := obj.Type().(*types.TypeParam); !isTypeParam {
+			return
+		}
+	}
+
+	if _, ok := p.declIndex[obj]; ok {
+		return
+	}
+
+	p.declIndex[obj] = ^uint64(0) // mark obj present in work queue
+	p.declTodo = append(p.declTodo, obj)
+}
+
+// exportName returns the name of obj in the index. Type parameters
+// are given a unique subscript as expected by the importer.
+func (p *iexporter) exportName(obj types.Object) string {
+	if _, ok := obj.Type().(*types.TypeParam); !ok {
+		return obj.Name()
+	}
+	name, ok := p.tparamNames[obj]
+	if !ok {
+		var buf []byte
+		for _, d := range strconv.Itoa(len(p.tparamNames) + 1) {
+			buf = append(buf, string('₀'+d-'0')...)
+		}
+		name = obj.Name() + string(buf)
+		p.tparamNames[obj] = name
+	}
+	return name
+}
+
+// exportPath returns the path of pkg as it appears in the export data;
+// the local package has the empty path.
+func (p *iexporter) exportPath(pkg *types.Package) string {
+	if pkg == p.localpkg {
+		return ""
+	}
+	return pkg.Path()
+}
+
+func (p *iexporter) doDecl(obj types.Object) {
+	w := p.newWriter()
+	w.pkg = obj.Pkg()
+
+	switch obj := obj.(type) {
+	case *types.Var:
+		w.tag('V')
+		w.pos(obj.Pos())
+		w.typ(obj.Type())
+
+	case *types.Func:
+		sig, _ := obj.Type().(*types.Signature)
+		if sig.Recv() != nil {
+			panic(internalErrorf("unexpected method: %v", sig))
+		}
+		tparams := sig.TypeParams()
+		if tparams.Len() == 0 {
+			w.tag('F')
+		} else {
+			w.tag('G')
+		}
+		w.pos(obj.Pos())
+		if tparams.Len() > 0 {
+			w.tparamList(tparams)
+		}
+		w.signature(sig)
+
+	case *types.Const:
+		w.tag('C')
+		w.pos(obj.Pos())
+		w.value(obj.Type(), obj.Val())
+
+	case *types.TypeName:
+		t := obj.Type()
+
+		if tparam, ok := t.(*types.TypeParam); ok {
+			w.tag('P')
+			w.pos(obj.Pos())
+			w.typ(tparam.Constraint())
+			break
+		}
+
+		if obj.IsAlias() {
+			w.tag('A')
+			w.pos(obj.Pos())
+			w.typ(t)
+			break
+		}
+
+		// Defined type.
+		named, ok := t.(*types.Named)
+		if !ok {
+			panic(internalErrorf("%s is not a defined type", t))
+		}
+		tparams := named.TypeParams()
+		if tparams.Len() == 0 {
+			w.tag('T')
+		} else {
+			w.tag('U')
+		}
+		w.pos(obj.Pos())
+		if tparams.Len() > 0 {
+			w.tparamList(tparams)
+		}
+
+		underlying := t.Underlying()
+		w.typ(underlying)
+
+		if types.IsInterface(t) {
+			break
+		}
+
+		n := named.NumMethods()
+		w.uint64(uint64(n))
+		for i := 0; i < n; i++ {
+			m := named.Method(i)
+			w.pos(m.Pos())
+			w.string(m.Name())
+			sig, _ := m.Type().(*types.Signature)
+			w.param(sig.Recv())
+			w.signature(sig)
+		}
+
+	default:
+		panic(internalErrorf("unexpected object: %v", obj))
+	}
+
+	p.declIndex[obj] = w.flush()
+}
+
+func (w *exportWriter) tag(tag byte) {
+	w.data.WriteByte(tag)
+}
+
+func (w *exportWriter) pos(pos token.Pos) {
+	p := w.p.fset.Position(pos)
+	file := p.Filename
+	line := int64(p.Line)
+	column := int64(p.Column)
+
+	// Encode position relative to the last position: column
+	// delta, then line delta, then file name. We reserve the
+	// bottom bit of the column and line deltas to encode whether
+	// the remaining fields are present.
+	//
+	// Note: Because data objects may be read out of order (or not
+	// at all), we can only apply delta encoding within a single
+	// object. This is handled implicitly by tracking prevFile,
+	// prevLine, and prevColumn as fields of exportWriter.
+
+	deltaColumn := (column - w.prevColumn) << 1
+	deltaLine := (line - w.prevLine) << 1
+
+	if file != w.prevFile {
+		deltaLine |= 1
+	}
+	if deltaLine != 0 {
+		deltaColumn |= 1
+	}
+
+	w.int64(deltaColumn)
+	if deltaColumn&1 != 0 {
+		w.int64(deltaLine)
+		if deltaLine&1 != 0 {
+			w.string(file)
+		}
+	}
+
+	w.prevFile = file
+	w.prevLine = line
+	w.prevColumn = column
+}
+
+func (w *exportWriter) writeIndex(index map[types.Object]uint64) {
+	type pkgObj struct {
+		obj  types.Object
+		name string // qualified name; differs from obj.Name for type params
+	}
+	// Build a map from packages to objects from that package.
+	pkgObjs := map[*types.Package][]pkgObj{}
+
+	// For the main index, make sure to include every package that
+	// we reference, even if we're not exporting (or reexporting)
+	// any symbols from it.
+	pkgObjs[w.p.localpkg] = nil
+	for pkg := range w.p.allPkgs {
+		pkgObjs[pkg] = nil
+	}
+
+	for obj := range index {
+		name := w.p.exportName(obj)
+		pkgObjs[obj.Pkg()] = append(pkgObjs[obj.Pkg()], pkgObj{obj, name})
+	}
+
+	var pkgs []*types.Package
+	for pkg, objs := range pkgObjs {
+		pkgs = append(pkgs, pkg)
+
+		sort.Slice(objs, func(i, j int) bool {
+			return objs[i].name < objs[j].name
+		})
+	}
+
+	// The local package has the empty export path and sorts first,
+	// as required by the importer.
+	sort.Slice(pkgs, func(i, j int) bool {
+		return w.p.exportPath(pkgs[i]) < w.p.exportPath(pkgs[j])
+	})
+
+	w.uint64(uint64(len(pkgs)))
+	for _, pkg := range pkgs {
+		w.string(w.p.exportPath(pkg))
+		w.string(pkg.Name())
+		w.uint64(uint64(0)) // package height is not needed for go/types
+
+		objs := pkgObjs[pkg]
+		w.uint64(uint64(len(objs)))
+		for _, obj := range objs {
+			w.string(obj.name)
+			w.uint64(index[obj.obj])
+		}
+	}
+}
+
+type exportWriter struct {
+	p *iexporter
+
+	data       intWriter
+	pkg        *types.Package // package of the object or type being written
+	prevFile   string
+	prevLine   int64
+	prevColumn int64
+}
+
+func (p *iexporter) newWriter() *exportWriter {
+	return &exportWriter{p: p}
+}
+
+// flush appends the data of w to the data section and returns its offset.
+func (w *exportWriter) flush() uint64 {
+	off := uint64(w.p.data0.Len())
+	io.Copy(&w.p.data0, &w.data)
+	return off
+}
+
+func (w *exportWriter) setPkg(pkg *types.Package) {
+	w.pkg = pkg
+	w.writePkg(pkg)
+}
+
+func (w *exportWriter) writePkg(pkg *types.Package) {
+	// Ensure any referenced packages are declared in the main index.
+	w.p.allPkgs[pkg] = true
+
+	w.string(w.p.exportPath(pkg))
+}
+
+func (w *exportWriter) qualifiedIdent(obj types.Object) {
+	// Ensure any referenced declarations are written out too.
+	w.p.pushDecl(obj)
+
+	w.string(w.p.exportName(obj))
+	w.writePkg(obj.Pkg())
+}
+
+func (w *exportWriter) typ(t types.Type) {
+	w.data.uint64(w.p.typOff(t, w.pkg))
+}
+
+// typOff returns the offset of the encoding of t in the data section,
+// writing it if necessary. Struct and function types are written
+// relative to pkg.
+func (p *iexporter) typOff(t types.Type, pkg *types.Package) uint64 {
+	t = types.Unalias(t)
+	off, ok := p.typIndex[t]
+	if !ok {
+		w := p.newWriter()
+		w.pkg = pkg
+		w.doTyp(t)
+		off = predeclReserved + w.flush()
+		p.typIndex[t] = off
+	}
+	return off
+}
+
+// hasTypeParams reports whether t depends on type parameters.
+func hasTypeParams(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Named:
+		targs := t.TypeArgs()
+		for i := 0; i < targs.Len(); i++ {
+			if hasTypeParams(targs.At(i)) {
+				return true
+			}
+		}
+	case *types.Pointer:
+		return hasTypeParams(t.Elem())
+	case *types.Slice:
+		return hasTypeParams(t.Elem())
+	case *types.Array:
+		return hasTypeParams(t.Elem())
+	case *types.Chan:
+		return hasTypeParams(t.Elem())
+	case *types.Map:
+		return hasTypeParams(t.Key()) || hasTypeParams(t.Elem())
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			if hasTypeParams(t.At(i).Type()) {
+				return true
+			}
+		}
+	case *types.Signature:
+		return hasTypeParams(t.Params()) || hasTypeParams(t.Results())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if hasTypeParams(t.Field(i).Type()) {
+				return true
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < t.NumMethods(); i++ {
+			if hasTypeParams(t.Method(i).Type()) {
+				return true
+			}
+		}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			if hasTypeParams(t.EmbeddedType(i)) {
+				return true
+			}
+		}
+	case *types.Union:
+		for i := 0; i < t.Len(); i++ {
+			if hasTypeParams(t.Term(i).Type()) {
+				return true
+			}
+		}
+	case *types.Alias:
+		return hasTypeParams(types.Unalias(t))
+	}
+	return false
+}
+
+func (w *exportWriter) startType(k itag) {
+	w.data.uint64(uint64(k))
+}
+
+func (w *exportWriter) doTyp(t types.Type) {
+	switch t := t.(type) {
+	case *types.Named:
+		if targs := t.TypeArgs(); targs.Len() > 0 {
+			w.startType(instType)
+			// The position is not used by the importer: instances
+			// are positioned on their original types.
+			w.pos(t.Obj().Pos())
+			w.typeList(targs)
+			w.typ(t.Obj().Type()) // original generic type
+			return
+		}
+		w.startType(definedType)
+		w.qualifiedIdent(t.Obj())
+
+	case *types.TypeParam:
+		w.startType(typeParamType)
+		w.qualifiedIdent(t.Obj())
+
+	case *types.Pointer:
+		w.startType(pointerType)
+		w.typ(t.Elem())
+
+	case *types.Slice:
+		w.startType(sliceType)
+		w.typ(t.Elem())
+
+	case *types.Array:
+		w.startType(arrayType)
+		w.uint64(uint64(t.Len()))
+		w.typ(t.Elem())
+
+	case *types.Chan:
+		w.startType(chanType)
+		// 1 RecvOnly; 2 SendOnly; 3 SendRecv
+		var dir uint64
+		switch t.Dir() {
+		case types.RecvOnly:
+			dir = 1
+		case types.SendOnly:
+			dir = 2
+		case types.SendRecv:
+			dir = 3
+		}
+		w.uint64(dir)
+		w.typ(t.Elem())
+
+	case *types.Map:
+		w.startType(mapType)
+		w.typ(t.Key())
+		w.typ(t.Elem())
+
+	case *types.Signature:
+		w.startType(signatureType)
+		w.setPkg(w.pkg)
+		w.signature(t)
+
+	case *types.Struct:
+		w.startType(structType)
+		n := t.NumFields()
+		pkg := w.pkg
+		if n > 0 {
+			pkg = t.Field(0).Pkg()
+		}
+		w.setPkg(pkg)
+		w.uint64(uint64(n))
+		for i := 0; i < n; i++ {
+			f := t.Field(i)
+			w.pos(f.Pos())
+			w.string(f.Name())
+			w.typ(f.Type())
+			w.bool(f.Anonymous())
+			w.string(t.Tag(i))
+		}
+
+	case *types.Interface:
+		w.startType(interfaceType)
+		pkg := w.pkg
+		if t.NumExplicitMethods() > 0 {
+			pkg = t.ExplicitMethod(0).Pkg()
+		}
+		w.setPkg(pkg)
+
+		n := t.NumEmbeddeds()
+		w.uint64(uint64(n))
+		for i := 0; i < n; i++ {
+			ft := t.EmbeddedType(i)
+			if named, _ := ft.(*types.Named); named != nil {
+				w.pos(named.Obj().Pos())
+			} else {
+				w.pos(token.NoPos)
+			}
+			w.typ(ft)
+		}
+
+		n = t.NumExplicitMethods()
+		w.uint64(uint64(n))
+		for i := 0; i < n; i++ {
+			m := t.ExplicitMethod(i)
+			w.pos(m.Pos())
+			w.string(m.Name())
+			sig, _ := m.Type().(*types.Signature)
+			w.signature(sig)
+		}
+
+	case *types.Union:
+		w.startType(unionType)
+		n := t.Len()
+		w.uint64(uint64(n))
+		for i := 0; i < n; i++ {
+			term := t.Term(i)
+			w.bool(term.Tilde())
+			w.typ(term.Type())
+		}
+
+	default:
+		panic(internalErrorf("unexpected type: %v, %v", t, fmt.Sprintf("%T", t)))
+	}
+}
+
+func (w *exportWriter) typeList(ts *types.TypeList) {
+	w.uint64(uint64(ts.Len()))
+	for i := 0; i < ts.Len(); i++ {
+		w.typ(ts.At(i))
+	}
+}
+
+func (w *exportWriter) tparamList(list *types.TypeParamList) {
+	w.uint64(uint64(list.Len()))
+	for i := 0; i < list.Len(); i++ {
+		w.typ(list.At(i))
+	}
+}
+
+func (w *exportWriter) signature(sig *types.Signature) {
+	w.paramList(sig.Params())
+	w.paramList(sig.Results())
+	if sig.Params().Len() > 0 {
+		w.bool(sig.Variadic())
+	}
+}
+
+func (w *exportWriter) paramList(tup *types.Tuple) {
+	n := tup.Len()
+	w.uint64(uint64(n))
+	for i := 0; i < n; i++ {
+		w.param(tup.At(i))
+	}
+}
+
+func (w *exportWriter) param(obj types.Object) {
+	w.pos(obj.Pos())
+	w.string(obj.Name())
+	w.typ(obj.Type())
+}
+
+func (w *exportWriter) value(typ types.Type, v constant.Value) {
+	w.typ(typ)
+
+	switch b := typ.Underlying().(*types.Basic); b.Info() & types.IsConstType {
+	case types.IsBoolean:
+		w.bool(constant.BoolVal(v))
+	case types.IsInteger:
+		w.mpint(constantToInt(v), b)
+	case types.IsFloat:
+		w.mpfloat(constantToFloat(v), b)
+	case types.IsComplex:
+		w.mpfloat(constantToFloat(constant.Real(v)), b)
+		w.mpfloat(constantToFloat(constant.Imag(v)), b)
+	case types.IsString:
+		w.string(constant.StringVal(v))
+	default:
+		panic(internalErrorf("unexpected type %v (%v)", typ, typ.Underlying()))
+	}
+}
+
+// constantToInt returns the integer value of x as a *big.Int.
+func constantToInt(x constant.Value) *big.Int {
+	var i big.Int
+	switch v := constant.Val(constant.ToInt(x)).(type) {
+	case int64:
+		i.SetInt64(v)
+	case *big.Int:
+		i.Set(v)
+	default:
+		panic(internalErrorf("%s is not an integer", x))
+	}
+	return &i
+}
+
+// constantToFloat returns the floating-point value of x as a *big.Float,
+// rounded to the precision used by cmd/compile.
+func constantToFloat(x constant.Value) *big.Float {
+	// Use the same floating-point precision (512) as cmd/compile
+	// (see Mpprec in cmd/compile/internal/gc/mpfloat.go).
+	const mpprec = 512
+	var f big.Float
+	f.SetPrec(mpprec)
+	switch v := constant.Val(constant.ToFloat(x)).(type) {
+	case int64:
+		f.SetInt64(v)
+	case *big.Int:
+		f.SetInt(v)
+	case *big.Rat:
+		f.SetRat(v)
+	case *big.Float:
+		f.Set(v)
+	default:
+		panic(internalErrorf("%s is not a floating-point value", x))
+	}
+	return &f
+}
+
+// mpint exports a multi-precision integer.
+//
+// For unsigned types, small values are written out as a single
+// byte. Larger values are written out as a length-prefixed big-endian
+// byte string, where the length prefix is encoded as its complement.
+// For example, bytes 0, 1, and 2 directly represent the integer
+// values 0, 1, and 2; while bytes 255, 254, and 253 indicate a 1-,
+// 2-, and 3-byte big-endian string follow.
+//
+// Encoding for signed types use the same general approach as for
+// unsigned types, except small values use zig-zag encoding and the
+// bottom bit of length prefix byte for large values is reserved as a
+// sign bit.
+//
+// The exact boundary between small and large encodings varies
+// according to the maximum number of bytes needed to encode a value
+// of type typ. As a special case, 8-bit types are always encoded as a
+// single byte.
+func (w *exportWriter) mpint(x *big.Int, typ *types.Basic) {
+	signed, maxBytes := intSize(typ)
+
+	negative := x.Sign() < 0
+	if !signed && negative {
+		panic(internalErrorf("negative unsigned integer; type %v, value %v", typ, x))
+	}
+
+	b := x.Bytes()
+	if uint(len(b)) > maxBytes {
+		panic(internalErrorf("bad mpint length: %d > %d (type %v, value %v)", len(b), maxBytes, typ, x))
+	}
+
+	maxSmall := 256 - maxBytes
+	if signed {
+		maxSmall = 256 - 2*maxBytes
+	}
+	if maxBytes == 1 {
+		maxSmall = 256
+	}
+
+	// Check if x can use small value encoding.
+	if len(b) <= 1 {
+		var ux uint
+		if len(b) == 1 {
+			ux = uint(b[0])
+		}
+		if signed {
+			ux <<= 1
+			if negative {
+				ux--
+			}
+		}
+		if ux < maxSmall {
+			w.data.WriteByte(byte(ux))
+			return
+		}
+	}
+
+	n := 256 - uint(len(b))
+	if signed {
+		n = 256 - 2*uint(len(b))
+		if negative {
+			n |= 1
+		}
+	}
+	if n < maxSmall || n >= 256 {
+		panic(internalErrorf("encoding mistake: %d, %v, %v => %d", len(b), signed, negative, n))
+	}
+
+	w.data.WriteByte(byte(n))
+	w.data.Write(b)
+}
+
+// mpfloat exports a multi-precision floating point number.
+//
+// The number's value is decomposed into mantissa × 2**exponent, where
+// mantissa is an integer. The value is written out as mantissa (as a
+// multi-precision integer) and then the exponent, except exponent is
+// omitted if mantissa is zero.
+func (w *exportWriter) mpfloat(f *big.Float, typ *types.Basic) {
+	if f.IsInf() {
+		panic(internalErrorf("infinite constant"))
+	}
+
+	// Break into f = mant × 2**exp, with 0.5 <= mant < 1.
+	var mant big.Float
+	exp := int64(f.MantExp(&mant))
+
+	// Scale so that mant is an integer.
+	prec := mant.MinPrec()
+	mant.SetMantExp(&mant, int(prec))
+	exp -= int64(prec)
+
+	manti, acc := mant.Int(nil)
+	if acc != big.Exact {
+		panic(internalErrorf("mantissa scaling failed for %f (%s)", f, acc))
+	}
+	w.mpint(manti, typ)
+	if manti.Sign() != 0 {
+		w.int64(exp)
+	}
+}
+
+func (w *exportWriter) bool(b bool) bool {
+	var x uint64
+	if b {
+		x = 1
+	}
+	w.uint64(x)
+	return b
+}
+
+func (w *exportWriter) int64(x int64)   { w.data.int64(x) }
+func (w *exportWriter) uint64(x uint64) { w.data.uint64(x) }
+func (w *exportWriter) string(s string) { w.uint64(w.p.stringOff(s)) }
+
+type intWriter struct {
+	bytes.Buffer
+}
+
+func (w *intWriter) int64(x int64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], x)
+	w.Write(buf[:n])
+}
+
+func (w *intWriter) uint64(x uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], x)
+	w.Write(buf[:n])
+}
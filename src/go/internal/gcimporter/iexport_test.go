@@ -0,0 +1,418 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gcimporter_test
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "go/internal/gcimporter"
+)
+
+const exportSrcA = `package a
+
+import "fmt"
+
+type T struct {
+	X int ` + "`json:\"x\"`" + `
+	y string
+}
+
+func (T) M() int { return 0 }
+func (*T) N(...int) {}
+
+type I interface {
+	M() int
+	fmt.Stringer
+}
+
+type (
+	A = T
+	L []L
+	G[P any] struct{ f P }
+	H[P interface{ ~int | ~string }] map[P]*G[P]
+)
+
+func (g *G[Q]) Get() Q { return g.f }
+
+func Id[P interface{ ~int | ~string }](x P) P { return x }
+
+const (
+	C = 1 << 70
+	F = 1.5
+	R = 1.0 / 3
+	Z = 1 + 2i
+	S = "s"
+	N int8 = -128
+)
+
+var V map[string][]*T
+var Ch <-chan func(x, y int) (G[int], error)
+
+func unexported() {}
+`
+
+const exportSrcB = `package b
+
+import "a"
+
+type U struct{ a.T }
+
+func F(x a.I, y chan<- a.G[int]) (a.A, error) { return a.A{}, nil }
+
+var W = a.Id[string]
+
+const K = a.C >> 60
+`
+
+// checkExportSrc type-checks src, importing the packages in imports.
+func checkExportSrc(t *testing.T, fset *token.FileSet, imports map[string]*types.Package, filename, src string) *types.Package {
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importerFunc(func(path string) (*types.Package, error) {
+		if pkg := imports[path]; pkg != nil {
+			return pkg, nil
+		}
+		return nil, fmt.Errorf("package %q not found", path)
+	})}
+	pkg, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+// checkExportSrcs type-checks the packages fmt (from export data), a, and b.
+func checkExportSrcs(t *testing.T, fset *token.FileSet) (fmtPkg, a, b *types.Package) {
+	fmtPkg = importPkg(t, "fmt", ".")
+	imports := map[string]*types.Package{"fmt": fmtPkg}
+	a = checkExportSrc(t, fset, imports, "a.go", exportSrcA)
+	imports["a"] = a
+	b = checkExportSrc(t, fset, imports, "b.go", exportSrcB)
+	return
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// exportedObjects returns the descriptions of the exported objects of pkg
+// and their methods.
+func exportedObjects(pkg *types.Package) []string {
+	var list []string
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if !token.IsExported(name) {
+			continue
+		}
+		obj := scope.Lookup(name)
+		str := types.ObjectString(obj, types.RelativeTo(pkg))
+		if c, _ := obj.(*types.Const); c != nil {
+			str += " = " + c.Val().String()
+		}
+		list = append(list, normalize(str))
+		if named, _ := obj.Type().(*types.Named); named != nil && isTypeName(obj) {
+			for i := 0; i < named.NumMethods(); i++ {
+				list = append(list, normalize(types.ObjectString(named.Method(i), types.RelativeTo(pkg))))
+			}
+		}
+	}
+	return list
+}
+
+// isTypeName reports whether obj is a type name that is not an alias.
+func isTypeName(obj types.Object) bool {
+	tname, _ := obj.(*types.TypeName)
+	return tname != nil && !tname.IsAlias()
+}
+
+// normalize removes the type parameter subscripts and instance markers,
+// which depend on the order in which types were created, from s.
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '#' || '₀' <= r && r <= '₉' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func compareExported(t *testing.T, got, want *types.Package) {
+	g, w := exportedObjects(got), exportedObjects(want)
+	if strings.Join(g, "\n") != strings.Join(w, "\n") {
+		t.Errorf("package %s:\ngot:\n\t%s\nwant:\n\t%s", want.Path(), strings.Join(g, "\n\t"), strings.Join(w, "\n\t"))
+	}
+}
+
+func TestIExportData(t *testing.T) {
+	fset := token.NewFileSet()
+	_, _, b := checkExportSrcs(t, fset)
+
+	var buf strings.Builder
+	if err := IExportData(&buf, fset, b); err != nil {
+		t.Fatal(err)
+	}
+	imports := make(map[string]*types.Package)
+	b2, err := IImportData(token.NewFileSet(), imports, []byte(buf.String()), "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareExported(t, b2, b)
+
+	// The declarations of a referred to by b were exported as well.
+	a2 := imports["a"]
+	if a2 == nil {
+		t.Fatal("package a not imported")
+	}
+	for _, name := range []string{"T", "I", "G"} {
+		if a2.Scope().Lookup(name) == nil {
+			t.Errorf("a.%s not imported", name)
+		}
+	}
+	if obj := a2.Scope().Lookup("V"); obj != nil {
+		t.Errorf("unreferenced object %s imported", obj)
+	}
+}
+
+func TestIExportShallow(t *testing.T) {
+	fset := token.NewFileSet()
+	fmtPkg, a, b := checkExportSrcs(t, fset)
+
+	// Export and import each package separately, resolving
+	// dependencies among the imported packages.
+	imported := map[string]*types.Package{"fmt": fmtPkg}
+	lookup := func(path string) (*types.Package, error) {
+		if pkg := imported[path]; pkg != nil {
+			return pkg, nil
+		}
+		return nil, fmt.Errorf("package %q not found", path)
+	}
+	var deep strings.Builder
+	if err := IExportData(&deep, fset, b); err != nil {
+		t.Fatal(err)
+	}
+	ifset := token.NewFileSet()
+	for _, pkg := range []*types.Package{a, b} {
+		data, err := IExportShallow(fset, pkg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pkg == b && len(data) >= deep.Len() {
+			t.Errorf("shallow export data of b is not smaller than deep export data (%d >= %d bytes)", len(data), deep.Len())
+		}
+		pkg2, err := IImportShallow(ifset, data, pkg.Path(), lookup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareExported(t, pkg2, pkg)
+		imported[pkg.Path()] = pkg2
+	}
+
+	// Objects of dependencies are shared, not copied.
+	u := imported["b"].Scope().Lookup("U").Type().Underlying().(*types.Struct)
+	if got, want := u.Field(0).Type().(*types.Named).Obj(), imported["a"].Scope().Lookup("T"); got != want {
+		t.Errorf("embedded field of b.U has type %s, want imported %s", got, want)
+	}
+	if obj := imported["a"].Scope().Lookup("unexported"); obj != nil {
+		t.Errorf("unexported object %s imported", obj)
+	}
+
+	// Unresolved dependencies are reported.
+	data, err := IExportShallow(fset, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delete(imported, "a")
+	if _, err := IImportShallow(token.NewFileSet(), data, "b", lookup); err == nil || !strings.Contains(err.Error(), `package "a" not found`) {
+		t.Errorf("got error %v, want package \"a\" not found", err)
+	}
+}
+
+func TestExportVersion(t *testing.T) {
+	fset := token.NewFileSet()
+	pkg := checkExportSrc(t, fset, nil, "p.go", "package p; const C = 1")
+	data, err := IExportShallow(fset, pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readable := ReadableVersions()
+	if v := WrittenVersion(); data[1] != byte(v) || v != readable[len(readable)-1] {
+		t.Errorf("export data has version %d, want %d (readable: %v)", data[1], v, readable)
+	}
+
+	// All versions beyond the readable ones are newer.
+	// Bit 6 flags the list of instances (see TestIExportInstances).
+	for _, v := range []byte{byte(readable[len(readable)-1] + 1), 1<<6 - 1} {
+		data[1] = v // single-byte uvarint
+		_, err := IImportShallow(token.NewFileSet(), data, "p", nil)
+		var verr *VersionError
+		if !errors.As(err, &verr) || verr.Version != int64(v) || !verr.Newer() {
+			t.Errorf("version %d: got error %v, want VersionError for newer version", v, err)
+		}
+	}
+}
+
+const instSrcA = `package a
+
+type G[P any] struct{ f P }
+
+func (G[P]) M() P { var p P; return p }
+`
+
+const instSrcB = `package b
+
+import "a"
+
+var V a.G[int]
+
+func F[Q any]() {
+	var _ a.G[Q] // depends on a type parameter
+}
+
+func f() {
+	var x a.G[string]
+	_ = x.M()
+}
+`
+
+func TestIExportInstances(t *testing.T) {
+	fset := token.NewFileSet()
+	a := checkExportSrc(t, fset, nil, "a.go", instSrcA)
+
+	// Record the instances created while checking b.
+	f, err := parser.ParseFile(fset, "b.go", instSrcB, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	conf := types.Config{Importer: importerFunc(func(string) (*types.Package, error) { return a, nil })}
+	b, err := conf.Check("b", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var instances []types.Type
+	for _, tv := range info.Types {
+		instances = append(instances, tv.Type)
+	}
+
+	data, err := IExportShallowInstances(fset, b, instances)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := byte(WrittenVersion() | 1<<6); data[1] != want { // iexportFlagInstances
+		t.Errorf("export data has version %d, want %d", data[1], want)
+	}
+
+	// Instances are registered in the environment passed to the importer.
+	lookup := func(string) (*types.Package, error) { return a, nil }
+	env := types.NewEnvironment()
+	instCount := func() int { return reflect.ValueOf(env).Elem().FieldByName("typeMap").Len() }
+	b2, err := IImportShallowEnv(token.NewFileSet(), env, data, "b", lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareExported(t, b2, b)
+	if got := instCount(); got != 2 {
+		t.Errorf("got %d instances in environment, want 2 (a.G[int] and a.G[string])", got)
+	}
+
+	// Type-checking with the environment shares the imported instances.
+	G := a.Scope().Lookup("G").Type()
+	inst, err := types.Instantiate(env, G, []types.Type{types.Typ[types.Int]}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b2.Scope().Lookup("V").Type(); got != inst {
+		t.Errorf("instance a.G[int] is not shared")
+	}
+	if _, err := types.Instantiate(env, G, []types.Type{types.Typ[types.String]}, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := instCount(); got != 2 {
+		t.Errorf("got %d instances in environment after instantiation, want 2", got)
+	}
+
+	// Without instances, the data is unchanged.
+	data, err = IExportShallowInstances(fset, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[1] != byte(WrittenVersion()) {
+		t.Errorf("export data without instances has version %d, want %d", data[1], WrittenVersion())
+	}
+}
+
+type purityFact struct{ Pure bool }
+
+func (*purityFact) AFact() {}
+
+func init() {
+	gob.Register(new(purityFact))
+}
+
+func TestFacts(t *testing.T) {
+	fset := token.NewFileSet()
+	_, a, _ := checkExportSrcs(t, fset)
+
+	a.SetFact(nil, &purityFact{true})
+	a.SetFact(a.Scope().Lookup("Id"), &purityFact{true})
+	T := a.Scope().Lookup("T").Type().(*types.Named)
+	for i := 0; i < T.NumMethods(); i++ {
+		a.SetFact(T.Method(i), &purityFact{T.Method(i).Name() == "M"})
+	}
+	a.SetFact(a.Scope().Lookup("unexported"), &purityFact{true}) // not in export data
+	param := a.Scope().Lookup("Id").Type().(*types.Signature).Params().At(0)
+	a.SetFact(param, &purityFact{true}) // not encoded
+
+	facts, err := EncodeFacts(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := IExportShallow(fset, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmtPkg := importPkg(t, "fmt", ".")
+	a2, err := IImportShallow(token.NewFileSet(), data, "a", func(string) (*types.Package, error) { return fmtPkg, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DecodeFacts(a2, facts); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range a2.Facts() {
+		name := "<package>"
+		if f.Object != nil {
+			name = normalize(types.ObjectString(f.Object, types.RelativeTo(a2)))
+		}
+		got = append(got, fmt.Sprintf("%s: %v", name, f.Fact.(*purityFact).Pure))
+	}
+	want := []string{
+		"<package>: true",
+		"func (T).M() int: true",
+		"func (*T).N(...int): false",
+		"func Id[P interface{~int|~string}](x P) P: true",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got facts\n\t%s\nwant\n\t%s", strings.Join(got, "\n\t"), strings.Join(want, "\n\t"))
+	}
+
+	if err := DecodeFacts(a2, []byte("junk")); err == nil {
+		t.Errorf("decoding junk succeeded")
+	}
+}
@@ -49,8 +49,60 @@ const (
 	iexportVersionGenerics = iexportVersionPosCol
 
 	iexportVersionCurrent = iexportVersionGenerics
+
+	// iexportFlagInstances is set in the version of shallow export data
+	// with a list of type instances following the index (see
+	// IExportShallowInstances). It is a flag rather than a version so
+	// that it does not collide with the versions used by the compiler.
+	iexportFlagInstances = 1 << 6
 )
 
+// readableVersions lists the export format versions the importer can
+// read, in increasing order.
+var readableVersions = [...]int64{iexportVersionGo1_11, iexportVersionPosCol}
+
+// ReadableVersions returns the indexed export format versions the
+// importer can read, in increasing order.
+func ReadableVersions() []int64 {
+	return append([]int64(nil), readableVersions[:]...)
+}
+
+// WrittenVersion returns the indexed export format version written by
+// IExportData and IExportShallow. Data recording type instances written by
+// IExportShallowInstances has the same version, with a flag set.
+func WrittenVersion() int64 {
+	return iexportVersion
+}
+
+func canRead(version int64) bool {
+	for _, v := range readableVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// A VersionError is returned when importing export data of a format
+// version the importer cannot read.
+type VersionError struct {
+	Path    string // import path of the package
+	Version int64  // format version of the export data
+}
+
+func (e *VersionError) Error() string {
+	if e.Newer() {
+		return fmt.Sprintf("cannot import %q: export data is newer version (format version %d, supported versions %v) - update tool", e.Path, e.Version, readableVersions)
+	}
+	return fmt.Sprintf("cannot import %q: unknown export data format version %d (supported versions %v)", e.Path, e.Version, readableVersions)
+}
+
+// Newer reports whether the export data is newer than any version the
+// importer can read, typically because it was written by a newer toolchain.
+func (e *VersionError) Newer() bool {
+	return e.Version > readableVersions[len(readableVersions)-1]
+}
+
 type ident struct {
 	pkg  string
 	name string
@@ -81,6 +133,66 @@ const (
 // If the export data version is not recognized or the format is otherwise
 // compromised, an error is returned.
 func iImportData(fset *token.FileSet, imports map[string]*types.Package, dataReader *bufio.Reader, path string) (pkg *types.Package, err error) {
+	return iimportCommon(fset, nil, dataReader, path, func(pkgPath, pkgName string) *types.Package {
+		pkg := imports[pkgPath]
+		if pkg == nil {
+			pkg = types.NewPackage(pkgPath, pkgName)
+			imports[pkgPath] = pkg
+		} else if pkg.Name() != pkgName {
+			errorf("conflicting names %s and %s for package %q", pkg.Name(), pkgName, path)
+		}
+		return pkg
+	})
+}
+
+// IImportData imports the package with the given import path from the
+// export data written by IExportData, adding the packages it refers to to
+// imports.
+func IImportData(fset *token.FileSet, imports map[string]*types.Package, data []byte, path string) (*types.Package, error) {
+	if len(data) == 0 || data[0] != 'i' {
+		return nil, fmt.Errorf("import %q: not indexed export data", path)
+	}
+	return iImportData(fset, imports, bufio.NewReader(bytes.NewReader(data[1:])), path)
+}
+
+// IImportShallow imports the package with the given import path from the
+// shallow export data written by IExportShallow. The lookup function is
+// called for every other package referred to by the data; it must return
+// that package, holding (at least) the objects referred to.
+func IImportShallow(fset *token.FileSet, data []byte, path string, lookup func(path string) (*types.Package, error)) (*types.Package, error) {
+	return IImportShallowEnv(fset, nil, data, path, lookup)
+}
+
+// IImportShallowEnv is like IImportShallow but creates the instances of
+// generic types in the environment env, if env is not nil. This includes
+// the instances recorded by IExportShallowInstances, so that type-checking
+// with env shares them rather than creating identical instances again.
+func IImportShallowEnv(fset *token.FileSet, env *types.Environment, data []byte, path string, lookup func(path string) (*types.Package, error)) (*types.Package, error) {
+	if len(data) == 0 || data[0] != 'i' {
+		return nil, fmt.Errorf("import %q: not indexed export data", path)
+	}
+	dataReader := bufio.NewReader(bytes.NewReader(data[1:]))
+	return iimportCommon(fset, env, dataReader, path, func(pkgPath, pkgName string) *types.Package {
+		if pkgPath == path {
+			return types.NewPackage(pkgPath, pkgName)
+		}
+		pkg, err := lookup(pkgPath)
+		if err != nil {
+			errorf("cannot resolve package %q: %v", pkgPath, err)
+		}
+		if pkg.Name() != pkgName {
+			errorf("conflicting names %s and %s for package %q", pkg.Name(), pkgName, pkgPath)
+		}
+		return pkg
+	})
+}
+
+// iimportCommon imports a package from the serialized package data.
+// The package function is called exactly once for each package referred
+// to by the data, including the imported package itself, and provides
+// the package (with its objects, if they are not part of the data).
+// Instances of generic types are created in env, which may be nil.
+func iimportCommon(fset *token.FileSet, env *types.Environment, dataReader *bufio.Reader, path string, packageFor func(path, name string) *types.Package) (pkg *types.Package, err error) {
 	const currentVersion = iexportVersionCurrent
 	version := int64(-1)
 	defer func() {
@@ -96,15 +208,11 @@ func iImportData(fset *token.FileSet, imports map[string]*types.Package, dataRea
 	r := &intReader{dataReader, path}
 
 	version = int64(r.uint64())
-	switch version {
-	case /* iexportVersionGenerics, */ iexportVersionPosCol, iexportVersionGo1_11:
-	default:
-		if version > iexportVersionGenerics {
-			errorf("unstable iexport format version %d, just rebuild compiler and std library", version)
-		} else {
-			errorf("unknown iexport format version %d", version)
-		}
+	instances := version&iexportFlagInstances != 0
+	if !canRead(version &^ iexportFlagInstances) {
+		return nil, &VersionError{Path: path, Version: version}
 	}
+	version &^= iexportFlagInstances
 
 	sLen := int64(r.uint64())
 	dLen := int64(r.uint64())
@@ -120,6 +228,7 @@ func iImportData(fset *token.FileSet, imports map[string]*types.Package, dataRea
 		exportVersion: version,
 		ipath:         path,
 		version:       int(version),
+		env:           env,
 
 		stringData:  stringData,
 		stringCache: make(map[uint64]string),
@@ -152,13 +261,7 @@ func iImportData(fset *token.FileSet, imports map[string]*types.Package, dataRea
 		if pkgPath == "" {
 			pkgPath = path
 		}
-		pkg := imports[pkgPath]
-		if pkg == nil {
-			pkg = types.NewPackage(pkgPath, pkgName)
-			imports[pkgPath] = pkg
-		} else if pkg.Name() != pkgName {
-			errorf("conflicting names %s and %s for package %q", pkg.Name(), pkgName, path)
-		}
+		pkg := packageFor(pkgPath, pkgName)
 
 		p.pkgCache[pkgPathOff] = pkg
 
@@ -183,6 +286,13 @@ func iImportData(fset *token.FileSet, imports map[string]*types.Package, dataRea
 		p.doDecl(localpkg, name)
 	}
 
+	// import the recorded instances, registering them in p.env
+	if instances {
+		for n := r.uint64(); n > 0; n-- {
+			p.typAt(r.uint64(), nil)
+		}
+	}
+
 	for _, typ := range p.interfaceList {
 		typ.Complete()
 	}
@@ -201,6 +311,7 @@ type iimporter struct {
 	exportVersion int64
 	ipath         string
 	version       int
+	env           *types.Environment // for instances, or nil
 
 	stringData  []byte
 	stringCache map[uint64]string
@@ -653,7 +764,7 @@ func (r *importReader) doType(base *types.Named) types.Type {
 		// The imported instantiated type doesn't include any methods, so
 		// we must always use the methods of the base (orig) type.
 		// TODO provide a non-nil *Checker
-		t, _ := types.Instantiate(nil, baseType, targs, false)
+		t, _ := types.Instantiate(r.p.env, baseType, targs, false)
 		return t
 
 	case unionType:
@@ -0,0 +1,171 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements file overlays.
+
+package srcimporter
+
+import (
+	"bytes"
+	"go/build"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SetOverlay sets the overlay of the importer, which maps file names to
+// the contents to use instead of the contents of the file on disk, such
+// as the unsaved buffers of an editor. Files that do not exist on disk are
+// added to their directory, so that packages may consist of, or include,
+// files that are only present in memory. Relative file names are relative
+// to the context's Dir, or to the current directory of the running process
+// if Dir is not set.
+//
+// The overlay applies to the selection, parsing, and type-checking of
+// package files; cgo files are not processed if any of them is overlaid.
+// SetOverlay must be called before any package is imported, and the map
+// must not be modified afterwards.
+func (p *Importer) SetOverlay(overlay map[string][]byte) {
+	if len(overlay) == 0 {
+		p.overlay = nil
+		p.octxt = nil
+		return
+	}
+	p.overlay = make(map[string][]byte, len(overlay))
+	for name, data := range overlay {
+		if abs, err := p.absPath(name); err == nil {
+			name = abs
+		}
+		p.overlay[filepath.Clean(name)] = data
+	}
+
+	c := *p.ctxt
+	c.IsDir = p.isDir
+	c.ReadDir = p.readDir
+	c.OpenFile = p.openFile
+	p.octxt = &c
+}
+
+// importBuild resolves the import path relative to srcDir and returns the
+// package found there, seeing the overlay if there is one.
+func (p *Importer) importBuild(path, srcDir string) (*build.Package, error) {
+	if p.overlay == nil {
+		return p.ctxt.Import(path, srcDir, 0)
+	}
+
+	// Resolve the import path with the regular context, which may use
+	// the go command (it cannot when the file system is replaced), and
+	// fall back to the overlay for directories that only exist in memory.
+	// Then select the package files, seeing the overlay.
+	dir, err := p.ctxt.Import(path, srcDir, build.FindOnly)
+	if err != nil {
+		var err2 error
+		if dir, err2 = p.octxt.Import(path, srcDir, build.FindOnly); err2 != nil {
+			return dir, err
+		}
+	}
+	bp, err := p.octxt.ImportDir(dir.Dir, 0)
+	if bp != nil {
+		bp.ImportPath = dir.ImportPath
+		bp.Root = dir.Root
+		bp.Goroot = dir.Goroot
+	}
+	return bp, err
+}
+
+// overlaid reports whether any of the named files in dir is overlaid.
+func (p *Importer) overlaid(dir string, filenames []string) bool {
+	for _, name := range filenames {
+		if _, ok := p.overlay[filepath.Clean(p.joinPath(dir, name))]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// openFile opens the named file, using the overlay if it contains the file,
+// or the context's OpenFile if there is one.
+func (p *Importer) openFile(name string) (io.ReadCloser, error) {
+	if data, ok := p.overlay[filepath.Clean(name)]; ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	if f := p.ctxt.OpenFile; f != nil {
+		return f(name)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err // nil interface
+	}
+	return f, nil
+}
+
+// isDir reports whether dir is a directory, which is the case if the
+// overlay contains a file in it or one of its subdirectories.
+func (p *Importer) isDir(dir string) bool {
+	prefix := filepath.Clean(dir) + string(filepath.Separator)
+	for name := range p.overlay {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	if f := p.ctxt.IsDir; f != nil {
+		return f(dir)
+	}
+	fi, err := os.Stat(dir)
+	return err == nil && fi.IsDir()
+}
+
+// readDir returns the files in dir, including the overlaid files.
+func (p *Importer) readDir(dir string) ([]fs.FileInfo, error) {
+	var list []fs.FileInfo
+	var err error
+	if f := p.ctxt.ReadDir; f != nil {
+		list, err = f(dir)
+	} else {
+		list, err = ioutil.ReadDir(dir)
+	}
+
+	dir = filepath.Clean(dir)
+	files := make(map[string]fs.FileInfo)
+	for name, data := range p.overlay {
+		if filepath.Dir(name) == dir {
+			base := filepath.Base(name)
+			files[base] = overlayFileInfo{base, int64(len(data))}
+		}
+	}
+	if len(files) == 0 {
+		return list, err // nothing to add
+	}
+
+	// err is ignored: dir may only exist in the overlay
+	for _, fi := range list {
+		if files[fi.Name()] == nil {
+			files[fi.Name()] = fi
+		}
+	}
+	list = list[:0]
+	for _, fi := range files {
+		list = append(list, fi)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list, nil
+}
+
+// An overlayFileInfo describes an overlaid file.
+type overlayFileInfo struct {
+	name string
+	size int64
+}
+
+func (fi overlayFileInfo) Name() string       { return fi.name }
+func (fi overlayFileInfo) Size() int64        { return fi.size }
+func (fi overlayFileInfo) Mode() fs.FileMode  { return 0444 }
+func (fi overlayFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi overlayFileInfo) IsDir() bool        { return false }
+func (fi overlayFileInfo) Sys() interface{}   { return nil }
@@ -7,6 +7,7 @@
 package srcimporter // import "go/internal/srcimporter"
 
 import (
+	"context"
 	"fmt"
 	"go/ast"
 	"go/build"
@@ -14,7 +15,6 @@ import (
 	"go/token"
 	"go/types"
 	exec "internal/execabs"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -28,6 +28,10 @@ type Importer struct {
 	fset     *token.FileSet
 	sizes    types.Sizes
 	packages map[string]*types.Package
+
+	stack   []string          // import paths of the packages being imported
+	overlay map[string][]byte // file contents by absolute file name, or nil
+	octxt   *build.Context    // ctxt seeing the overlay; valid if overlay != nil
 }
 
 // New returns a new Importer for the given context, file set, and map
@@ -50,6 +54,7 @@ func New(ctxt *build.Context, fset *token.FileSet, packages map[string]*types.Pa
 var importing types.Package
 
 // Import(path) is a shortcut for ImportFrom(path, ".", 0).
+// If the context's Dir is set, "." denotes that directory.
 func (p *Importer) Import(path string) (*types.Package, error) {
 	return p.ImportFrom(path, ".", 0) // use "." rather than "" (see issue #24441)
 }
@@ -61,14 +66,24 @@ func (p *Importer) Import(path string) (*types.Package, error) {
 // Packages that are not comprised entirely of pure Go files may fail to import because the
 // type checker may not be able to determine all exported entities (e.g. due to cgo dependencies).
 func (p *Importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*types.Package, error) {
+	return p.ImportFromContext(context.Background(), path, srcDir, mode)
+}
+
+// ImportFromContext is like ImportFrom but imports the package in the given
+// context, which is also used for importing its dependencies. The import
+// fails with ctx.Err() if ctx is cancelled before the package is type-checked.
+func (p *Importer) ImportFromContext(ctx context.Context, path, srcDir string, mode types.ImportMode) (*types.Package, error) {
 	if mode != 0 {
 		panic("non-zero import mode")
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	if abs, err := p.absPath(srcDir); err == nil { // see issue #14282
 		srcDir = abs
 	}
-	bp, err := p.ctxt.Import(path, srcDir, 0)
+	bp, err := p.importBuild(path, srcDir)
 	if err != nil {
 		return nil, err // err may be *build.NoGoError - return as is
 	}
@@ -82,7 +97,7 @@ func (p *Importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*type
 	pkg := p.packages[bp.ImportPath]
 	if pkg != nil {
 		if pkg == &importing {
-			return nil, fmt.Errorf("import cycle through package %q", bp.ImportPath)
+			return nil, p.cycleError(bp.ImportPath)
 		}
 		if !pkg.Complete() {
 			// Package exists but is not complete - we cannot handle this
@@ -95,7 +110,9 @@ func (p *Importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*type
 	}
 
 	p.packages[bp.ImportPath] = &importing
+	p.stack = append(p.stack, bp.ImportPath)
 	defer func() {
+		p.stack = p.stack[:len(p.stack)-1]
 		// clean up in case of error
 		// TODO(gri) Eventually we may want to leave a (possibly empty)
 		// package in the map in all cases (and use that package to
@@ -128,9 +145,9 @@ func (p *Importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*type
 		Sizes:    p.sizes,
 	}
 	if len(bp.CgoFiles) > 0 {
-		if p.ctxt.OpenFile != nil {
+		if p.ctxt.OpenFile != nil || p.overlaid(bp.Dir, bp.CgoFiles) {
 			// cgo, gcc, pkg-config, etc. do not support
-			// build.Context's VFS.
+			// build.Context's VFS or overlays.
 			conf.FakeImportC = true
 		} else {
 			setUsesCgo(&conf)
@@ -142,7 +159,10 @@ func (p *Importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*type
 		}
 	}
 
-	pkg, err = conf.Check(bp.ImportPath, p.fset, files, nil)
+	pkg, err = conf.CheckContext(ctx, bp.ImportPath, p.fset, files, nil)
+	if ctx.Err() != nil {
+		return nil, ctx.Err() // the package may be incomplete
+	}
 	if err != nil {
 		// If there was a hard error it is possibly unsafe
 		// to use the package as it may not be fully populated.
@@ -151,7 +171,7 @@ func (p *Importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*type
 			pkg = nil
 			err = firstHardErr // give preference to first hard error over any soft error
 		}
-		return pkg, fmt.Errorf("type-checking package %q failed (%v)", bp.ImportPath, err)
+		return pkg, fmt.Errorf("type-checking package %q failed (%w)", bp.ImportPath, err)
 	}
 	if firstHardErr != nil {
 		// this can only happen if we have a bug in go/types
@@ -162,13 +182,20 @@ func (p *Importer) ImportFrom(path, srcDir string, mode types.ImportMode) (*type
 	return pkg, nil
 }
 
-func (p *Importer) parseFiles(dir string, filenames []string) ([]*ast.File, error) {
-	// use build.Context's OpenFile if there is one
-	open := p.ctxt.OpenFile
-	if open == nil {
-		open = func(name string) (io.ReadCloser, error) { return os.Open(name) }
+// cycleError returns the error for an import of the package with the given
+// import path, which is being imported.
+func (p *Importer) cycleError(path string) error {
+	for i, stacked := range p.stack {
+		if stacked == path {
+			cycle := append(p.stack[i:len(p.stack):len(p.stack)], path) // don't modify p.stack
+			return &types.ImportCycleError{Cycle: cycle}
+		}
 	}
+	// the package is being imported by another importer sharing p.packages
+	return fmt.Errorf("import cycle through package %q", path)
+}
 
+func (p *Importer) parseFiles(dir string, filenames []string) ([]*ast.File, error) {
 	files := make([]*ast.File, len(filenames))
 	errors := make([]error, len(filenames))
 
@@ -177,7 +204,7 @@ func (p *Importer) parseFiles(dir string, filenames []string) ([]*ast.File, erro
 	for i, filename := range filenames {
 		go func(i int, filepath string) {
 			defer wg.Done()
-			src, err := open(filepath)
+			src, err := p.openFile(filepath)
 			if err != nil {
 				errors[i] = err // open provides operation and filename in error
 				return
@@ -244,6 +271,11 @@ func (p *Importer) cgo(bp *build.Package) (*ast.File, error) {
 func (p *Importer) absPath(path string) (string, error) {
 	// TODO(gri) This should be using p.ctxt.AbsPath which doesn't
 	// exist but probably should. See also issue #14282.
+	if p.ctxt.Dir != "" && !filepath.IsAbs(path) {
+		// path is relative to the context's directory, not the
+		// process's working directory
+		path = filepath.Join(p.ctxt.Dir, path)
+	}
 	return filepath.Abs(path)
 }
 
@@ -5,6 +5,8 @@
 package srcimporter
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"go/build"
 	"go/token"
@@ -242,6 +244,82 @@ func TestIssue24392(t *testing.T) {
 	testImportPath(t, "go/internal/srcimporter/testdata/issue24392")
 }
 
+func TestImportContext(t *testing.T) {
+	if !testenv.HasSrc() {
+		t.Skip("no source code available")
+	}
+
+	importer := New(&build.Default, token.NewFileSet(), make(map[string]*types.Package))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := importer.ImportFromContext(ctx, "math/bits", ".", 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+
+	// A cancelled import does not affect later imports.
+	pkg, err := importer.ImportFrom("math/bits", ".", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pkg.Complete() {
+		t.Errorf("package %s is not complete", pkg.Path())
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+
+	// Package p exists on disk; package q only exists in the overlay.
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "p"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "p", "p.go"), []byte("package p\n\nconst C = 0\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	importer := New(&build.Default, token.NewFileSet(), make(map[string]*types.Package))
+	importer.SetOverlay(map[string][]byte{
+		filepath.Join(dir, "p", "p.go"):      []byte("package p\n\nimport \"../q\"\n\nconst C = q.S\n"),
+		filepath.Join(dir, "p", "extra.go"):  []byte("package p\n\nfunc F() {}\n"),
+		filepath.Join(dir, "p", "other.go"):  []byte("//go:build ignore\n\npackage other\n"),
+		filepath.Join(dir, "q", "q.go"):      []byte("package q\n\nconst S = \"overlay\"\n"),
+		filepath.Join(dir, "q", "q_test.go"): []byte("package q\n\nconst S = 0\n"),
+	})
+	pkg, err := importer.ImportFrom("./p", dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pkg.Scope().Lookup("C").(*types.Const).Val().String(), `"overlay"`; got != want {
+		t.Errorf("p.C = %s, want %s", got, want)
+	}
+	if pkg.Scope().Lookup("F") == nil {
+		t.Errorf("p.F not found")
+	}
+}
+
+func TestImportCycle(t *testing.T) {
+	t.Setenv("GO111MODULE", "off")
+	ctxt := build.Default
+	ctxt.GOPATH = t.TempDir()
+	importer := New(&ctxt, token.NewFileSet(), make(map[string]*types.Package))
+	importer.SetOverlay(map[string][]byte{
+		filepath.Join(ctxt.GOPATH, "src", "a", "a.go"): []byte("package a\n\nimport _ \"b\"\n"),
+		filepath.Join(ctxt.GOPATH, "src", "b", "b.go"): []byte("package b\n\nimport _ \"c\"\n"),
+		filepath.Join(ctxt.GOPATH, "src", "c", "c.go"): []byte("package c\n\nimport _ \"a\"\n"),
+	})
+
+	// The cycle is reported as seen from the imported package.
+	_, err := importer.ImportFrom("a", ctxt.GOPATH, 0)
+	var e types.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("got error %v, want types.Error", err)
+	}
+	if got, want := strings.Join(e.ImportCycle, " "), "a b c a"; got != want {
+		t.Errorf("got import cycle %s, want %s (error: %v)", got, want, err)
+	}
+}
+
 func TestCgo(t *testing.T) {
 	testenv.MustHaveGoBuild(t)
 	testenv.MustHaveCGO(t)
@@ -0,0 +1,277 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package zipimporter implements importing directly from the source
+// files in the module zip files of a module cache, without extracting
+// them.
+package zipimporter // import "go/internal/zipimporter"
+
+import (
+	"archive/zip"
+	"go/build"
+	"go/internal/srcimporter"
+	"go/token"
+	"go/types"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// An Importer imports packages from the source files in module zip files.
+// Packages of the standard library are imported from the source in GOROOT.
+type Importer struct {
+	*srcimporter.Importer
+	fs *zipFS
+}
+
+// New returns a new Importer for the given context, file set, module cache
+// directory, build list, and map of packages. The build list maps the path
+// of each module to the version providing its packages; the source of a
+// module version is read from the zip file in the download cache of
+// modCache, typically $GOMODCACHE. Import paths are resolved to the module
+// with the longest matching path in the build list. Opened zip files are
+// cached per module version until Close is called.
+//
+// The context's file system functions are replaced by functions reading
+// from the zip files, or from the local file system for other paths.
+func New(ctxt *build.Context, fset *token.FileSet, modCache string, buildList map[string]string, packages map[string]*types.Package) *Importer {
+	if abs, err := filepath.Abs(modCache); err == nil {
+		modCache = abs
+	}
+	z := &zipFS{
+		// The source of module packages is presented as the
+		// GOPATH workspace root, with a package directory
+		// root/src/importpath for each package.
+		root:     filepath.Join(modCache, "@zip"),
+		modCache: modCache,
+		zips:     make(map[module]*moduleZip),
+	}
+	for path, version := range buildList {
+		z.mods = append(z.mods, module{path, version})
+	}
+
+	c := *ctxt
+	c.GOPATH = z.root
+	c.IsDir = z.isDir
+	c.ReadDir = z.readDir
+	c.OpenFile = z.openFile
+	c.IsAbsPath = nil
+	c.JoinPath = nil
+	c.SplitPathList = nil
+	c.HasSubdir = nil
+	return &Importer{srcimporter.New(&c, fset, packages), z}
+}
+
+// Close closes the zip files opened by the importer.
+func (p *Importer) Close() error {
+	return p.fs.close()
+}
+
+// A module is a module version.
+type module struct {
+	path, version string
+}
+
+// A moduleZip is an opened module zip file.
+type moduleZip struct {
+	r    *zip.ReadCloser
+	err  error                  // error opening the zip file
+	dirs map[string][]*zip.File // sorted files by slash-separated directory in the module; "" is the module root
+}
+
+// A zipFS implements the file system functions of a build.Context
+// for the package directories of the modules of a build list.
+type zipFS struct {
+	root     string
+	modCache string
+	mods     []module
+
+	mu   sync.Mutex
+	zips map[module]*moduleZip // cache of opened zip files
+}
+
+// lookup returns the opened zip file of the module providing the
+// directory dir of the workspace root, and the directory in the module.
+// If dir is not a package directory of a module in the build list,
+// lookup returns ok == false.
+func (z *zipFS) lookup(dir string) (m *moduleZip, rel string, ok bool) {
+	src := filepath.Join(z.root, "src")
+	if dir != src && !strings.HasPrefix(dir, src+string(filepath.Separator)) {
+		return nil, "", false
+	}
+	importPath := filepath.ToSlash(strings.TrimPrefix(dir[len(src):], string(filepath.Separator)))
+
+	var mod module
+	for _, m := range z.mods {
+		if len(m.path) > len(mod.path) && (importPath == m.path || strings.HasPrefix(importPath, m.path+"/")) {
+			mod = m
+		}
+	}
+	if mod.path == "" {
+		return nil, "", false
+	}
+	return z.open(mod), strings.TrimPrefix(importPath[len(mod.path):], "/"), true
+}
+
+// open returns the opened zip file of the module version m.
+func (z *zipFS) open(m module) *moduleZip {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	if mz := z.zips[m]; mz != nil {
+		return mz
+	}
+
+	mz := new(moduleZip)
+	z.zips[m] = mz
+	filename, err := z.zipFile(m)
+	if err == nil {
+		mz.r, err = zip.OpenReader(filename)
+	}
+	if err != nil {
+		mz.err = err
+		return mz
+	}
+
+	// Files of the module version are stored as
+	// "path@version/name" (see golang.org/x/mod/zip).
+	prefix := m.path + "@" + m.version + "/"
+	mz.dirs = map[string][]*zip.File{"": nil}
+	for _, f := range mz.r.File {
+		if !strings.HasPrefix(f.Name, prefix) || strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		dir, _ := path.Split(f.Name[len(prefix):])
+		dir = strings.TrimSuffix(dir, "/")
+		mz.dirs[dir] = append(mz.dirs[dir], f)
+		// record the parent directories as well
+		for dir != "" {
+			dir = path.Dir(dir)
+			if dir == "." {
+				dir = ""
+			}
+			if _, ok := mz.dirs[dir]; ok {
+				break
+			}
+			mz.dirs[dir] = nil
+		}
+	}
+	for _, files := range mz.dirs {
+		sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	}
+	return mz
+}
+
+// zipFile returns the name of the zip file of the module version m
+// in the download cache.
+func (z *zipFS) zipFile(m module) (string, error) {
+	escPath, err := escape(m.path)
+	if err != nil {
+		return "", err
+	}
+	escVersion, err := escape(m.version)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(z.modCache, "cache", "download", filepath.FromSlash(escPath), "@v", escVersion+".zip"), nil
+}
+
+// escape returns the case-encoded form of a module path or version used
+// in the module cache: each upper-case letter is replaced by an
+// exclamation mark followed by the lower-case letter.
+func escape(s string) (string, error) {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '!' || r >= utf8.RuneSelf:
+			return "", &fs.PathError{Op: "escape", Path: s, Err: fs.ErrInvalid}
+		case 'A' <= r && r <= 'Z':
+			b.WriteByte('!')
+			b.WriteRune(r + 'a' - 'A')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), nil
+}
+
+func (z *zipFS) isDir(name string) bool {
+	m, rel, ok := z.lookup(name)
+	if !ok {
+		fi, err := os.Stat(name)
+		return err == nil && fi.IsDir()
+	}
+	if m.err != nil {
+		// Report the directory to exist so that readDir
+		// reports why the zip file cannot be read.
+		return rel == ""
+	}
+	_, ok = m.dirs[rel]
+	return ok
+}
+
+// readDir returns the files in the directory name. The
+// subdirectories of directories in zip files are not listed.
+func (z *zipFS) readDir(name string) ([]fs.FileInfo, error) {
+	m, rel, ok := z.lookup(name)
+	if !ok {
+		return ioutil.ReadDir(name)
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	files, ok := m.dirs[rel]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	list := make([]fs.FileInfo, len(files))
+	for i, f := range files {
+		list[i] = f.FileInfo()
+	}
+	return list, nil
+}
+
+func (z *zipFS) openFile(name string) (io.ReadCloser, error) {
+	dir, base := filepath.Split(name)
+	m, rel, ok := z.lookup(filepath.Clean(dir))
+	if !ok {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err // nil interface
+		}
+		return f, nil
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, f := range m.dirs[rel] {
+		if path.Base(f.Name) == base {
+			return f.Open()
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (z *zipFS) close() error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	var firstErr error
+	for m, mz := range z.zips {
+		if mz.r != nil {
+			if err := mz.r.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		delete(z.zips, m)
+	}
+	return firstErr
+}
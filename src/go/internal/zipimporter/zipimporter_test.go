@@ -0,0 +1,128 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zipimporter
+
+import (
+	"archive/zip"
+	"go/build"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeZip writes the zip file of the module version path@version with
+// the given files to the download cache of modCache.
+func writeZip(t *testing.T, modCache, path, version string, files map[string]string) {
+	filename, err := (&zipFS{modCache: modCache}).zipFile(module{path, version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, src := range files {
+		fw, err := w.Create(path + "@" + version + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(src)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	modCache := t.TempDir()
+	writeZip(t, modCache, "example.com/M", "v1.0.0-RC", map[string]string{
+		"go.mod":      "module example.com/M\n",
+		"p/p.go":      "package p\n\nimport (\n\t\"example.com/dep\"\n\t\"example.com/dep/sub\"\n)\n\nvar V = dep.F(sub.C)\n",
+		"p/p_test.go": "package p\n\nimport \"missing\"\n",
+	})
+	writeZip(t, modCache, "example.com/dep", "v1.2.3", map[string]string{
+		"go.mod":     "module example.com/dep\n",
+		"dep.go":     "package dep\n\nimport \"strings\"\n\nfunc F(string) *strings.Builder { return nil }\n",
+		"sub/sub.go": "package sub\n\nconst C = 0\n", // not part of the module version in use
+	})
+	writeZip(t, modCache, "example.com/dep/sub", "v0.1.0", map[string]string{
+		"go.mod": "module example.com/dep/sub\n",
+		"sub.go": "package sub\n\nconst C = \"sub\"\n",
+	})
+
+	fset := token.NewFileSet()
+	imp := New(&build.Default, fset, modCache, map[string]string{
+		"example.com/M":       "v1.0.0-RC",
+		"example.com/dep":     "v1.2.3",
+		"example.com/dep/sub": "v0.1.0",
+		"example.com/absent":  "v1.0.0",
+	}, make(map[string]*types.Package))
+	defer imp.Close()
+
+	pkg, err := imp.Import("example.com/M/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pkg.Scope().Lookup("V").Type().String(), "*strings.Builder"; got != want {
+		t.Errorf("p.V has type %s, want %s", got, want)
+	}
+	for _, dep := range pkg.Imports() {
+		posn := fset.Position(dep.Scope().Lookup(dep.Scope().Names()[0]).Pos())
+		if want := filepath.Join(modCache, "@zip", "src", filepath.FromSlash(dep.Path())); filepath.Dir(posn.Filename) != want {
+			t.Errorf("package %s has position %s, want position in %s", dep.Path(), posn, want)
+		}
+	}
+
+	// Opened zip files are cached.
+	if n := len(imp.fs.zips); n != 3 {
+		t.Errorf("got %d opened zip files, want 3", n)
+	}
+
+	// Missing zip files are reported.
+	if _, err := imp.Import("example.com/absent"); err == nil || !strings.Contains(err.Error(), filepath.Join("example.com", "absent", "@v", "v1.0.0.zip")) {
+		t.Errorf("got error %v, want error for missing zip file", err)
+	}
+	// Packages outside the build list are not found.
+	if _, err := imp.Import("example.com/other"); err == nil {
+		t.Errorf("importing example.com/other succeeded unexpectedly")
+	}
+	if _, err := imp.Import("example.com/M/q"); err == nil {
+		t.Errorf("importing example.com/M/q succeeded unexpectedly")
+	}
+
+	if err := imp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(imp.fs.zips); n != 0 {
+		t.Errorf("got %d opened zip files after Close, want 0", n)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	for _, test := range []struct {
+		s, want string
+	}{
+		{"example.com/m", "example.com/m"},
+		{"github.com/Azure/SDK", "github.com/!azure/!s!d!k"},
+		{"v1.0.0-RC1", "v1.0.0-!r!c1"},
+		{"bad!", ""},
+		{"ünicode", ""},
+	} {
+		got, err := escape(test.s)
+		if (err != nil) != (test.want == "") || got != test.want {
+			t.Errorf("escape(%q) = %q, %v; want %q", test.s, got, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types
+
+// An Alias represents an alias type declared by an alias declaration
+// such as "type A = T". Alias types are only created by the type checker
+// if Config.EnableAlias is set; otherwise an alias name denotes the
+// aliased type directly.
+//
+// An Alias is identical to the type it denotes (its actual type) in
+// every respect, but it retains the alias name; for instance, it is
+// printed using that name. Use Unalias to obtain the actual type.
+type Alias struct {
+	obj     *TypeName // corresponding declared alias object
+	fromRHS Type      // type on the RHS of the alias declaration; may be an *Alias
+	actual  Type      // actual (aliased) type; never an *Alias
+}
+
+// NewAlias returns a new alias type for the given type name and
+// right-hand side type rhs, which must not be nil. If obj doesn't have a
+// type yet, its type is set to the returned alias.
+func NewAlias(obj *TypeName, rhs Type) *Alias {
+	assert(rhs != nil)
+	a := &Alias{obj: obj, fromRHS: rhs, actual: Unalias(rhs)}
+	if obj.typ == nil {
+		obj.typ = a
+	}
+	return a
+}
+
+// Obj returns the type name for the declaration defining the alias a.
+func (a *Alias) Obj() *TypeName { return a.obj }
+
+// Underlying returns the underlying type of the actual type of a.
+func (a *Alias) Underlying() Type { return a.actual.Underlying() }
+
+func (a *Alias) String() string { return TypeString(a, nil) }
+
+// Unalias returns t if it is not an alias type; otherwise it follows
+// t's chain of aliases and returns the actual type denoted by t.
+func Unalias(t Type) Type {
+	if a, _ := t.(*Alias); a != nil {
+		return a.actual
+	}
+	return t
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements batch allocation of short-lived checker data.
+
+package types
+
+import "go/token"
+
+// Operands, operand lists, and position lists that escape the function
+// creating them (for instance the arguments of a call) are allocated in
+// batches from per-Checker slabs rather than individually. Slab memory is
+// never reused while the Checker is active, so it is always safe to retain
+// the returned values; the slabs are simply dropped once checking of a set
+// of files completes.
+const slabSize = 64
+
+// A slabs holds the current allocation slabs of a Checker.
+type slabs struct {
+	operands []operand
+	lists    []*operand
+	posLists []token.Pos
+}
+
+// newOperand returns a new, zero operand.
+func (check *Checker) newOperand() *operand {
+	if len(check.slabs.operands) == 0 {
+		check.slabs.operands = make([]operand, slabSize)
+	}
+	x := &check.slabs.operands[0]
+	check.slabs.operands = check.slabs.operands[1:]
+	return x
+}
+
+// newOperandList returns a new operand list of length n.
+// Appending to the result never overwrites other lists.
+func (check *Checker) newOperandList(n int) []*operand {
+	if n > slabSize/4 {
+		return make([]*operand, n) // not worth batching
+	}
+	if len(check.slabs.lists) < n {
+		check.slabs.lists = make([]*operand, slabSize)
+	}
+	list := check.slabs.lists[:n:n]
+	check.slabs.lists = check.slabs.lists[n:]
+	return list
+}
+
+// newPosList returns a new position list of length n.
+// Appending to the result never overwrites other lists.
+func (check *Checker) newPosList(n int) []token.Pos {
+	if n > slabSize/4 {
+		return make([]token.Pos, n) // not worth batching
+	}
+	if len(check.slabs.posLists) < n {
+		check.slabs.posLists = make([]token.Pos, slabSize)
+	}
+	list := check.slabs.posLists[:n:n]
+	check.slabs.posLists = check.slabs.posLists[n:]
+	return list
+}
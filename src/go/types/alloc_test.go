@@ -0,0 +1,111 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestNewOperand(t *testing.T) {
+	var check Checker
+	var list []*operand
+	for i := 0; i < 2*slabSize+1; i++ {
+		x := check.newOperand()
+		if x.mode != invalid || x.typ != nil || x.expr != nil {
+			t.Fatalf("operand %d is not zero: %v", i, x)
+		}
+		x.mode = value
+		x.typ = Typ[Int]
+		list = append(list, x)
+	}
+	seen := make(map[*operand]bool)
+	for i, x := range list {
+		if seen[x] {
+			t.Errorf("operand %d allocated twice", i)
+		}
+		seen[x] = true
+		if x.mode != value || x.typ != Typ[Int] {
+			t.Errorf("operand %d was modified: %v", i, x)
+		}
+	}
+}
+
+func TestNewOperandList(t *testing.T) {
+	var check Checker
+	var lists [][]*operand
+	for n := 0; n <= slabSize/2; n++ {
+		list := check.newOperandList(n)
+		if len(list) != n {
+			t.Fatalf("newOperandList(%d) has length %d", n, len(list))
+		}
+		for i := range list {
+			if list[i] != nil {
+				t.Fatalf("newOperandList(%d)[%d] is not nil", n, i)
+			}
+			list[i] = &operand{id: builtinId(n)}
+		}
+		lists = append(lists, list)
+	}
+	// Appending to a list must not overwrite another list.
+	for n := range lists {
+		lists[n] = append(lists[n], nil)
+	}
+	for n, list := range lists {
+		for i, x := range list[:n] {
+			if x == nil || x.id != builtinId(n) {
+				t.Errorf("newOperandList(%d)[%d] was overwritten by appending to the lists", n, i)
+			}
+		}
+	}
+}
+
+func TestNewPosList(t *testing.T) {
+	var check Checker
+	var lists [][]token.Pos
+	for n := 0; n <= slabSize/2; n++ {
+		list := check.newPosList(n)
+		if len(list) != n {
+			t.Fatalf("newPosList(%d) has length %d", n, len(list))
+		}
+		for i := range list {
+			if list[i] != token.NoPos {
+				t.Fatalf("newPosList(%d)[%d] is not NoPos", n, i)
+			}
+			list[i] = token.Pos(n)
+		}
+		lists = append(lists, list)
+	}
+	for n := range lists {
+		lists[n] = append(lists[n], -1)
+	}
+	for n, list := range lists {
+		for i, pos := range list[:n] {
+			if pos != token.Pos(n) {
+				t.Errorf("newPosList(%d)[%d] = %d after appending to the lists, want %d", n, i, pos, n)
+			}
+		}
+	}
+}
+
+// The slabs are released once a set of files is checked.
+func TestSlabsReleased(t *testing.T) {
+	const src = `package p; func f(...int) int; var _ = f(1, 2, 3) + f(f(4), 5)`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := NewPackage("p", "p")
+	check := NewChecker(&Config{IgnoreFuncBodies: true}, fset, pkg, nil)
+	if err := check.Files([]*ast.File{f}); err != nil {
+		t.Fatal(err)
+	}
+	if check.slabs.operands != nil || check.slabs.lists != nil || check.slabs.posLists != nil {
+		t.Errorf("slabs not released after checking")
+	}
+}
@@ -28,10 +28,13 @@ package types
 
 import (
 	"bytes"
+	gocontext "context" // package types declares context
 	"fmt"
 	"go/ast"
 	"go/constant"
 	"go/token"
+	"strings"
+	"time"
 )
 
 const allowTypeLists = false
@@ -40,17 +43,50 @@ const allowTypeLists = false
 // A "soft" error is an error that still permits a valid interpretation of a
 // package (such as "unused variable"); "hard" errors may lead to unpredictable
 // behavior if ignored.
+//
+// Positions, including those mentioned in error messages, are reported as
+// by Fset.Position and hence relative to //line directives, if any: errors
+// in generated code refer to the original source. Use Fset.PositionFor with
+// adjusted set to false for positions in the checked files themselves.
 type Error struct {
 	Fset *token.FileSet // file set for interpretation of Pos
 	Pos  token.Pos      // error position
 	Msg  string         // error message
 	Soft bool           // if set, error is "soft"
 
+	// Severity is the severity of the error. Diagnostics of severity
+	// SeverityWarning report findings that don't make the package invalid
+	// as a matter of course, such as uses of features that require a newer
+	// language version than the one configured.
+	Severity Severity
+
+	// Instantiations describes the instantiations within which the error
+	// was found, innermost first; it is nil if the error is not specific
+	// to an instantiation. For instance, a failing constraint check for
+	// F[int] reports the instantiation F[int].
+	Instantiations []Instantiation
+
+	// Suggestion, if not empty, is a suggested replacement for the
+	// (presumably misspelled) identifier at Pos, as in "did you mean
+	// Suggestion?". Tools may offer it as a fix.
+	Suggestion string
+
+	// RequiresVersion, if not empty, is the Go language version (such as
+	// "go1.18") required by the language feature whose use is reported;
+	// see Config.ForwardCompatible.
+	RequiresVersion string
+
+	// ImportCycle, if not nil, lists the import paths of the packages of
+	// an import cycle that caused an import to fail, in import order and
+	// beginning and ending with the same package, as in [a b c a]. If the
+	// checked package is part of the cycle, the list begins with it. See
+	// also ImportCycleError.
+	ImportCycle []string
+
 	// go116code is a future API, unexported as the set of error codes is large
 	// and likely to change significantly during experimentation. Tools wishing
-	// to preview this feature may read go116code using reflection (see
-	// errorcodes_test.go), but beware that there is no guarantee of future
-	// compatibility.
+	// to preview this feature may use the Code method, but beware that there
+	// is no guarantee of future compatibility.
 	go116code  errorCode
 	go116start token.Pos
 	go116end   token.Pos
@@ -62,6 +98,47 @@ func (err Error) Error() string {
 	return fmt.Sprintf("%s: %s", err.Fset.Position(err.Pos), err.Msg)
 }
 
+// Code returns the error code of err, and the name of the code, such as
+// "DuplicateDecl"; the code is 0 and the name empty if err has no code.
+// Codes identify the kind of an error independently of its message, but
+// the set of codes is not stable: codes may be added, split or retired
+// in future versions.
+func (err Error) Code() (code int, name string) {
+	if err.go116code == 0 {
+		return 0, ""
+	}
+	return int(err.go116code), err.go116code.String()
+}
+
+// Span returns the source range of the expression or statement err is
+// about, if known: start and end are the positions of its first character
+// and immediately after its last character. The range usually, but not
+// always, contains err.Pos. If the range is not known, start and end are
+// token.NoPos.
+func (err Error) Span() (start, end token.Pos) {
+	return err.go116start, err.go116end
+}
+
+// An Instantiation describes the instantiation of a generic type or function
+// that provides the context for an Error.
+type Instantiation struct {
+	Orig     Type      // generic *Named or *Signature type
+	TypeArgs *TypeList // type arguments
+	Pos      token.Pos // position of the instantiation
+}
+
+// A Rounding describes a constant whose exact value was rounded, either by
+// constant arithmetic exceeding the precision of exact (rational) constant
+// arithmetic, or by a (possibly implicit) conversion to a floating-point or
+// complex type in which the constant is not exactly representable.
+type Rounding struct {
+	Pos        token.Pos      // position of the constant operation or converted operand
+	Conversion bool           // if set, the constant was rounded by a conversion to Type
+	Type       Type           // type of the rounded constant
+	Value      constant.Value // value before the conversion; nil for arithmetic
+	Rounded    constant.Value // rounded value
+}
+
 // An ArgumentError holds an error associated with an argument index.
 type ArgumentError struct {
 	index int
@@ -112,6 +189,36 @@ type ImporterFrom interface {
 	ImportFrom(path, dir string, mode ImportMode) (*Package, error)
 }
 
+// An ImporterFromContext is an ImporterFrom that accepts a context.
+// If the installed importer implements ImporterFromContext, the type
+// checker calls ImportFromContext with the context passed to
+// Config.CheckContext or Checker.FilesContext instead of ImportFrom.
+type ImporterFromContext interface {
+	ImporterFrom
+
+	// ImportFromContext is like ImportFrom but is called with the
+	// context of the surrounding type check. It should return ctx.Err()
+	// if ctx is cancelled before the import is complete. The context
+	// may also carry request-scoped values, such as tracing spans, to
+	// be propagated to the import.
+	ImportFromContext(ctx gocontext.Context, path, dir string, mode ImportMode) (*Package, error)
+}
+
+// An ImportCycleError may be returned by an importer if importing a package
+// leads to an import cycle. The type checker reports the cycle with the
+// Error for the failed import; importers type-checking dependencies from
+// source may also return such an Error, wrapped or not, to report a cycle
+// found by the type checker.
+type ImportCycleError struct {
+	// Cycle lists the import paths of the packages of the cycle,
+	// in import order and beginning and ending with the same package.
+	Cycle []string
+}
+
+func (e *ImportCycleError) Error() string {
+	return "import cycle not allowed: " + strings.Join(e.Cycle, " -> ")
+}
+
 // A Config specifies the configuration for type checking.
 // The zero value for Config is a ready-to-use default configuration.
 type Config struct {
@@ -127,10 +234,29 @@ type Config struct {
 	// panic.
 	GoVersion string
 
+	// If ForwardCompatible is set, uses of language features that require
+	// a newer language version than GoVersion are each reported once, as a
+	// warning with Error.RequiresVersion set, and are otherwise checked as
+	// if GoVersion permitted them. This avoids follow-on errors and yields
+	// complete type information when checking code written for a newer
+	// version, as editors may do. Syntax errors reported by the parser
+	// cannot be avoided this way.
+	ForwardCompatible bool
+
 	// If IgnoreFuncBodies is set, function bodies are not
 	// type-checked.
 	IgnoreFuncBodies bool
 
+	// If ConstantsOnly is set, only package-level constant declarations
+	// and the declarations they depend on are type-checked, and function
+	// bodies are not. This is sufficient to obtain the values of the
+	// constants of a package, at a fraction of the cost of checking all
+	// of it. The other package-level objects are declared in the package
+	// scope, but they have no type (their Type method returns nil), errors
+	// in their declarations are not reported, and the package is not
+	// marked as complete.
+	ConstantsOnly bool
+
 	// If FakeImportC is set, `import "C"` (for packages requiring Cgo)
 	// declares an empty "C" package and errors are omitted for qualified
 	// identifiers referring to package C (which won't find an object).
@@ -158,6 +284,18 @@ type Config struct {
 	// error found.
 	Error func(err error)
 
+	// If Warning != nil, it is called with each diagnostic of severity
+	// SeverityWarning instead of Error; err has dynamic type Error.
+	// Diagnostics reported to Warning don't stop type-checking and are
+	// not returned as the error result of Check. If Warning == nil,
+	// such diagnostics are reported like any other error.
+	Warning func(err error)
+
+	// If Rounding != nil, it is called for each constant whose
+	// exact value is rounded (see Rounding). Constant arithmetic
+	// is only checked for exactness if Rounding is set.
+	Rounding func(r Rounding)
+
 	// An importer is used to import packages referred to from
 	// import declarations.
 	// If the installed importer implements ImporterFrom, the type
@@ -166,15 +304,241 @@ type Config struct {
 	// but none was installed.
 	Importer Importer
 
+	// If ConcurrentImports is set, the type checker imports all packages
+	// imported by the checked files concurrently, before resolving any
+	// declarations. The Importer must then be safe for concurrent use.
+	// Imports are still reported (and errors are still issued) in source
+	// order.
+	ConcurrentImports bool
+
+	// If RecordDependencies is set, the checked package retains the
+	// Importer and the imported objects the package refers to, for use by
+	// Package.WalkDependencies. By default, they are not retained.
+	RecordDependencies bool
+
+	// Concurrency limits the number of goroutines the type checker runs
+	// at a time for work it may do in parallel, such as the imports of
+	// ConcurrentImports and the variants of CheckVariants. If Concurrency
+	// is 1, all such work is done sequentially by the calling goroutine.
+	// If Concurrency <= 0, the limit is runtime.GOMAXPROCS(0).
+	Concurrency int
+
 	// If Sizes != nil, it provides the sizing functions for package unsafe.
 	// Otherwise SizesFor("gc", "amd64") is used instead.
 	Sizes Sizes
 
+	// Predeclared lists objects that are predeclared in addition to those
+	// of Universe, such as the types, constants, and functions of a Go
+	// dialect. They are declared in a universe scope private to the checked
+	// package, whose parent is Universe: they may shadow objects of Universe,
+	// and may be shadowed by package-level declarations. The objects must
+	// have no package and distinct names; they are not modified, so they may
+	// be shared among packages checked concurrently. Predeclared functions
+	// are called like ordinary functions; built-in functions created with
+	// NewBuiltin are checked by their BuiltinFunc.
+	Predeclared []Object
+
+	// If MemoryBudget > 0, it limits the number of bytes by which the heap
+	// may grow while checking a package. Once the limit is exceeded, the
+	// checker stops and reports a *MemoryBudgetError, which it returns
+	// unless an error was reported before; the package is incomplete, and
+	// the results recorded in Info so far are retained. The heap is
+	// measured for the whole process and checked periodically, so the
+	// limit is approximate: memory used by concurrent goroutines counts
+	// toward it, and memory freed by the garbage collector does not.
+	MemoryBudget uint64
+
+	// If CheckStructTags is set, the tags of struct fields are checked
+	// with ParseStructTag: malformed tags, and tags whose keys are not
+	// unique, are reported with severity SeverityWarning, at the offending
+	// position within the tag. The tags of fields with well-formed tags
+	// are then validated with ValidateStructTag, if set.
+	CheckStructTags bool
+
+	// If ValidateStructTag != nil and CheckStructTags is set, it is called
+	// for each struct field with a well-formed tag, once the type of the
+	// field is set up, and may impose additional
+	// conventions, such as for the values of particular keys. An error
+	// it returns is reported with severity SeverityWarning; if it is a
+	// *StructTagError, it is reported at its offset within the tag.
+	ValidateStructTag func(field *Var, tag StructTag) error
+
+	// If ReportEmptyTypeSets is set, type parameters whose constraints
+	// have an empty type set (see Interface.IsEmptyTypeSet), and which
+	// therefore can never be instantiated, are reported with severity
+	// SeverityWarning.
+	ReportEmptyTypeSets bool
+
+	// If ReportImpossibleAssertions is set, type assertions x.(T) and
+	// type switch cases T where T is an interface type that has a method
+	// with the same name as a method of the type of x, but a different
+	// signature, are reported with severity SeverityWarning: no type can
+	// implement both interfaces, so the assertion never succeeds. (If T
+	// is not an interface type, such assertions are errors.)
+	ReportImpossibleAssertions bool
+
+	// If ReportShadowing is set, declarations of local constants, types,
+	// and variables (including the parameters of function literals) that
+	// shadow a local declaration of an enclosing block are reported with
+	// severity SeverityWarning. Declarations of the form x := x, which
+	// deliberately copy the shadowed variable, declarations of case
+	// clause variables in type switches, and declarations with a name in
+	// ShadowingExempt are not reported.
+	ReportShadowing bool
+
+	// ShadowingExempt lists the names of declarations that are not
+	// reported by ReportShadowing, typically names that are idiomatically
+	// shadowed, such as err and ctx. It has no effect if ReportShadowing
+	// is not set.
+	ShadowingExempt []string
+
 	// If DisableUnusedImportCheck is set, packages are not checked
 	// for unused imports.
 	DisableUnusedImportCheck bool
+
+	// UnusedVars and UnusedImports control how variables that are
+	// declared but not used and packages that are imported but not used
+	// are reported. By default, they are reported as errors, as required
+	// by the spec. Demoting them to warnings or ignoring them may be useful
+	// when checking incomplete code, for instance in an editor.
+	// DisableUnusedImportCheck takes precedence over UnusedImports.
+	UnusedVars    UnusedCheck
+	UnusedImports UnusedCheck
+
+	// By default, once an error has been reported, the type checker
+	// suppresses errors that merely follow from an earlier error, such
+	// as errors involving operands, types, or objects that are already
+	// invalid. If ReportFollowOnErrors is set, those errors are reported
+	// as well.
+	ReportFollowOnErrors bool
+
+	// ErrorVerbosity controls the level of detail of error messages.
+	// The default produces compact messages in the style of the compiler.
+	ErrorVerbosity ErrorVerbosity
+
+	// If EnableAlias is set, the type checker represents the type denoted
+	// by an alias name declared in the checked package with an *Alias,
+	// which retains the alias name. By default, for compatibility with
+	// clients that don't expect *Alias types, an alias name denotes the
+	// aliased type directly (as if the alias had been resolved eagerly).
+	EnableAlias bool
+
+	// Experiments enables experimental language features. Programs using
+	// them are not valid Go; the features may change or go away at any time.
+	Experiments Experiments
+
+	// If MessageRenderer != nil, it is called to produce the text of each
+	// error message from its structured description m. If it returns the
+	// empty string, the default text m.Text is used. MessageRenderer may
+	// be used to localize or rephrase messages.
+	MessageRenderer func(m Message) string
+}
+
+// Experiments describes the experimental language features enabled for
+// type checking.
+type Experiments struct {
+	// If GenericMethods is set, methods may declare their own type
+	// parameters, in method declarations as well as in interfaces.
+	// Type arguments for method type parameters may be inferred at call
+	// sites like for generic functions. A type implements an interface
+	// method with type parameters if its method has an identical (generic)
+	// signature. Constraints of method type parameters must not refer to
+	// the type parameters of the receiver type.
+	GenericMethods bool
+
+	// If MethodUnions is set, the terms of a union may be interfaces
+	// with methods (but not comparable), as in interface{ fmt.Stringer | int }.
+	// A type is in the type set of such a union if it is in the type set
+	// of one of its terms; the methods of the union are the methods
+	// present in all terms.
+	MethodUnions bool
+
+	// If VariadicTypeParams is set, the last type parameter of a generic
+	// function may be variadic, as in func F[Ts ...any](args ...Ts). It
+	// stands for zero or more type arguments, and it may only be used as
+	// the type of a final ... parameter. When the function is instantiated,
+	// the type argument for the variadic type parameter is a *Tuple holding
+	// the respective (trailing) type arguments, and a parameter args ...Ts
+	// is replaced by one parameter for each of them. If not provided
+	// explicitly, these type arguments are the (default) types of the
+	// arguments passed for args.
+	VariadicTypeParams bool
+
+	// If EmbeddedTypeParams is set, a struct may embed a type parameter P
+	// or a pointer *P, as in struct{ P }. As for other embedded fields,
+	// the field name is the (unqualified) name of P. The methods of the
+	// constraint of P are promoted to the struct like the methods of an
+	// embedded interface; fields are not promoted since the fields of a
+	// type argument are unknown. In an instance of the struct type, the
+	// field has the respective type argument as type and promotes its
+	// fields and methods like any other embedded field; the restrictions
+	// on embedded field types are not checked for type arguments.
+	EmbeddedTypeParams bool
+}
+
+// A Message is the structured description of an error message, as passed
+// to Config.MessageRenderer.
+type Message struct {
+	Pos token.Pos // error position
+
+	// Code identifies the kind of error. The set of codes is not part of
+	// the API and likely to change (see also the go116code field of Error).
+	Code int
+
+	// Format and Args describe the message in the style of fmt.Sprintf.
+	// Arguments are typically Types, Objects, ast.Exprs, token.Pos values,
+	// operands (of type MessageOperand), strings, and numbers.
+	Format string
+	Args   []interface{}
+
+	// Text is the message as rendered by default.
+	Text string
+}
+
+// A MessageOperand describes an operand argument of a Message.
+type MessageOperand struct {
+	Expr ast.Expr // operand expression; or nil
+	TypeAndValue
 }
 
+// An ErrorVerbosity specifies the level of detail of error messages.
+type ErrorVerbosity int
+
+const (
+	// CompactErrors selects short, compiler-style error messages.
+	CompactErrors ErrorVerbosity = iota
+
+	// VerboseErrors selects error messages which additionally spell out
+	// the underlying types of defined types, the type sets of constraints
+	// that are not satisfied, and inferred type arguments.
+	VerboseErrors
+)
+
+// A Severity classifies a diagnostic reported by the type checker.
+type Severity int
+
+const (
+	// SeverityError indicates an error that must be fixed.
+	SeverityError Severity = iota
+
+	// SeverityWarning indicates an informational finding.
+	SeverityWarning
+)
+
+// An UnusedCheck specifies how unused variables or imports are reported.
+type UnusedCheck int
+
+const (
+	// UnusedAsError reports unused entities as (soft) errors.
+	UnusedAsError UnusedCheck = iota
+
+	// UnusedAsWarning reports unused entities with severity SeverityWarning.
+	UnusedAsWarning
+
+	// UnusedIgnored doesn't report unused entities.
+	UnusedIgnored
+)
+
 func srcimporter_setUsesCgo(conf *Config) {
 	conf.go115UsesCgo = true
 }
@@ -183,6 +547,11 @@ func srcimporter_setUsesCgo(conf *Config) {
 // Only the information for which a map is provided is collected.
 // If the package has type errors, the collected information may
 // be incomplete.
+//
+// The positions of the recorded syntax nodes and objects are positions
+// in the checked files. As for errors, Fset.Position reports them relative
+// to //line directives, if any, and Fset.PositionFor with adjusted set to
+// false reports them in the files themselves.
 type Info struct {
 	// Types maps expressions to their types, and for constant
 	// expressions, also their values. Invalid expressions are
@@ -209,6 +578,11 @@ type Info struct {
 	// an *ast.CallExpr (as in f(x)), or an *ast.IndexExpr (s in f[T]).
 	Inferred map[ast.Expr]Inferred
 
+	// Untyped maps expressions that are untyped when they are evaluated
+	// to their original untyped type and the type they assume in their
+	// context (which is also the type recorded in Types).
+	Untyped map[ast.Expr]Untyped
+
 	// Defs maps identifiers to the objects they define (including
 	// package names, dots "." of dot-imports, and blank "_" identifiers).
 	// For identifiers that do not denote objects (e.g., the package name
@@ -242,6 +616,52 @@ type Info struct {
 	// to their corresponding selections.
 	Selections map[*ast.SelectorExpr]*Selection
 
+	// Callees maps call expressions to the statically known functions,
+	// methods, or built-ins they call. Calls of function values (such as
+	// variables, struct fields, or function literals), calls of interface
+	// methods, and conversions have no entry.
+	Callees map[*ast.CallExpr]Callee
+
+	// LitElements maps the elements of composite literals, including
+	// unkeyed ones, to the struct fields, array or slice indices, or map
+	// key types they resolve to. Keyed elements are represented by their
+	// *ast.KeyValueExpr, unkeyed elements by their value expression.
+	// Invalid elements, such as those for unknown fields or invalid map
+	// keys, and duplicate fields, indices, and constant map keys have no
+	// entry.
+	LitElements map[ast.Expr]LitElement
+
+	// Satisfies maps methods of concrete types to the interface methods
+	// they are used to satisfy in the checked package, in order of first
+	// use. A method is used to satisfy the methods of an interface T with
+	// the same name if a value of its receiver type is assigned to T
+	// (also implicitly, as in calls and return statements, or compared
+	// with a value of type T) or converted to T, if a value of type T is
+	// asserted to have the receiver type (also in type switches), or if
+	// its receiver type satisfies a constraint with the methods of T.
+	Satisfies map[*Func][]*Func
+
+	// DeclTimes maps package-level objects, including methods, to the time
+	// spent checking their declarations: their types, initialization
+	// expressions, and function bodies, including the function literals
+	// and instantiations therein. The time spent checking a declaration
+	// on which another one depends is charged to the former only, so that
+	// the times add up to (nearly) the total time spent checking. Use
+	// SlowestDecls to find the declarations that are most expensive to
+	// check.
+	DeclTimes map[Object]time.Duration
+
+	// Skipped lists the parts of the package that were not (fully)
+	// checked, in source order: function bodies ignored because of
+	// Config.IgnoreFuncBodies, declarations skipped because of
+	// Config.ConstantsOnly, unresolved qualified identifiers of fake
+	// packages (see Config.FakeImportC), and the declarations and function
+	// bodies not checked because checking stopped early. The information
+	// recorded in the other fields of Info is incomplete for these parts.
+	// Skipped is only recorded if it is not nil, for instance if it is
+	// set to make([]Skipped, 0); skipped parts are appended to it.
+	Skipped []Skipped
+
 	// Scopes maps ast.Nodes to the scopes they define. Package scopes are not
 	// associated with a specific node but with all files belonging to a package.
 	// Thus, the package scope can be found in the type-checked Package object.
@@ -273,6 +693,13 @@ type Info struct {
 	// in source order. Variables without an initialization expression do not
 	// appear in this list.
 	InitOrder []*Initializer
+
+	// InitCycles is the list of initialization cycles among package-level
+	// constants, variables, and functions; each cycle is reported as an
+	// error. A cycle starts with the object for which the error is reported,
+	// and each object refers to the next one; the last object refers to the
+	// first one.
+	InitCycles [][]Object
 }
 
 // TypeOf returns the type of expression e, or nil if not found.
@@ -305,6 +732,64 @@ func (info *Info) ObjectOf(id *ast.Ident) Object {
 	return info.Uses[id]
 }
 
+// Addressable reports whether the expression e is addressable
+// (https://golang.org/ref/spec#Address_operators), that is, whether
+// it denotes a variable. Composite literals, which may be the operand
+// of the & operator, are not addressable.
+//
+// Precondition: the Types, Uses and Defs maps are populated.
+//
+func (info *Info) Addressable(e ast.Expr) bool {
+	if t, ok := info.Types[e]; ok {
+		return t.Addressable()
+	}
+	// Identifiers defined by declarations are not recorded in Types.
+	if id, _ := e.(*ast.Ident); id != nil {
+		v, _ := info.ObjectOf(id).(*Var)
+		return v != nil && !v.IsField()
+	}
+	return false
+}
+
+// Assignable reports whether the expression e may appear on the
+// left-hand side of an assignment: it is addressable, a map index
+// expression, or the (possibly parenthesized) blank identifier.
+//
+// Precondition: the Types, Uses and Defs maps are populated.
+//
+func (info *Info) Assignable(e ast.Expr) bool {
+	if id, _ := unparen(e).(*ast.Ident); id != nil && id.Name == "_" {
+		return true
+	}
+	if t, ok := info.Types[e]; ok {
+		return t.Assignable()
+	}
+	return info.Addressable(e)
+}
+
+// ShortVarDeclarable reports whether the expression e may appear on the
+// left-hand side of a short variable declaration at position pos, where
+// scope is the innermost scope containing pos (see Scope.Innermost). If
+// so, redeclared reports whether e denotes a variable already declared in
+// scope at pos, to which the declaration assigns rather than declaring a
+// new variable. As for the type checker, e must be an identifier and the
+// declaration must declare at least one new (non-blank) variable.
+func ShortVarDeclarable(scope *Scope, pos token.Pos, e ast.Expr) (ok, redeclared bool) {
+	id, _ := e.(*ast.Ident)
+	if id == nil {
+		return false, false
+	}
+	if id.Name == "_" {
+		return true, false
+	}
+	alt := scope.Lookup(id.Name)
+	if alt == nil || pos.IsValid() && alt.scopePos() > pos {
+		return true, false
+	}
+	_, isVar := alt.(*Var)
+	return isVar, isVar
+}
+
 // TypeAndValue reports the type and value (for constants)
 // of the corresponding expression.
 type TypeAndValue struct {
@@ -372,6 +857,28 @@ type Inferred struct {
 	Sig   *Signature
 }
 
+// Untyped describes an untyped expression.
+type Untyped struct {
+	Type      *Basic // original untyped type of the expression
+	Default   Type   // default type for Type
+	Final     Type   // final type of the expression; untyped if it remains untyped, or nil if invalid in its context
+	Converted bool   // the context converted the expression to a type different from Default
+}
+
+// A LitElement describes the resolution of an element of a composite literal.
+type LitElement struct {
+	Field *Var  // for struct literals, the initialized field; nil otherwise
+	Index int64 // for array and slice literals, the index of the element; -1 otherwise
+	Key   Type  // for map literals, the key type; nil otherwise
+}
+
+// A Callee describes the statically known function, method, or built-in
+// called by a call expression.
+type Callee struct {
+	Obj   Object    // *Func or *Builtin
+	TArgs *TypeList // type arguments if Obj is a generic function; or nil
+}
+
 // An Initializer describes a package-level variable, or a list of variables in case
 // of a multi-valued initialization expression, and the corresponding initialization
 // expression.
@@ -403,23 +910,44 @@ func (init *Initializer) String() string {
 //
 // The package is specified by a list of *ast.Files and corresponding
 // file set, and the package path the package is identified with.
-// The clean path must not be empty or dot (".").
+// The clean path must not be empty or dot ("."). Each file must be
+// positioned in its own file of the file set. Files whose package clause
+// and end are not positioned in the same file of the file set, and files
+// positioned in the same file as another one, are reported and ignored.
+// Check cannot detect other mismatches: a syntax tree positioned in a
+// different file set whose positions happen to fall within a single file
+// of fset is checked, with wrong positions. Syntax trees positioned in
+// different file sets can be merged into one with MergeFiles.
 func (conf *Config) Check(path string, fset *token.FileSet, files []*ast.File, info *Info) (*Package, error) {
 	pkg := NewPackage(path, "")
 	return pkg, NewChecker(conf, fset, pkg, info).Files(files)
 }
 
+// CheckContext is like Check but type-checks the package in the given
+// context: the context is passed to an importer implementing
+// ImporterFromContext, and if it is cancelled, imports that have not
+// started yet fail and CheckContext returns ctx.Err() once the
+// imports have been resolved.
+func (conf *Config) CheckContext(ctx gocontext.Context, path string, fset *token.FileSet, files []*ast.File, info *Info) (*Package, error) {
+	pkg := NewPackage(path, "")
+	return pkg, NewChecker(conf, fset, pkg, info).FilesContext(ctx, files)
+}
+
 // AssertableTo reports whether a value of type V can be asserted to have type T.
+// If T is or contains type parameters, or is an uninstantiated generic type,
+// the result is that of AssertableToFor(V, T, ForAll). This reports the same
+// results as the original AssertableTo, except that a type parameter T whose
+// constraint lacks the methods of V is now assertable if its type set
+// consists of specific types only, each of which is assertable.
 func AssertableTo(V *Interface, T Type) bool {
-	m, _ := (*Checker)(nil).assertableTo(V, T)
-	return m == nil
+	return AssertableToFor(V, T, ForAll)
 }
 
 // AssignableTo reports whether a value of type V is assignable to a variable of type T.
+// If V or T are or contain type parameters, or are uninstantiated generic types,
+// the result is that of AssignableToFor(V, T, ForAll).
 func AssignableTo(V, T Type) bool {
-	x := operand{mode: value, typ: V}
-	ok, _ := x.assignableTo(nil, T, nil) // check not needed for non-constant x
-	return ok
+	return AssignableToFor(V, T, ForAll)
 }
 
 // ConvertibleTo reports whether a value of type V is convertible to a value of type T.
@@ -428,6 +956,14 @@ func ConvertibleTo(V, T Type) bool {
 	return x.convertibleTo(nil, T, nil) // check not needed for non-constant x
 }
 
+// ClassifyConversion describes the conversion of a value of type V to type T.
+// If the conversion is not valid, the result's Kind is InvalidConversion.
+func ClassifyConversion(V, T Type) Conversion {
+	x := operand{mode: value, typ: V}
+	kind := x.conversionKind(nil, T, nil) // check not needed for non-constant x
+	return Conversion{kind, kind != InvalidConversion && (asTypeParam(V) != nil || asTypeParam(T) != nil)}
+}
+
 // Implements reports whether type V implements interface T.
 func Implements(V Type, T *Interface) bool {
 	f, _ := MissingMethod(V, T, true)
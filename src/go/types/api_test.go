@@ -6,8 +6,12 @@ package types_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"go/ast"
+	"go/constant"
+	"go/format"
 	"go/importer"
 	"go/internal/typeparams"
 	"go/parser"
@@ -15,8 +19,10 @@ import (
 	"internal/testenv"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	. "go/types"
 )
@@ -1069,6 +1075,79 @@ func TestInitOrderInfo(t *testing.T) {
 	}
 }
 
+func TestUntypedInfo(t *testing.T) {
+	const src = `package p
+
+var f float64 = 1
+var i = 2
+const c = 8 >> 3
+var b = 1.5 == f
+var r = 'a' + 10
+var f32 float32
+var g = f32 == 4
+`
+	info := Info{Untyped: make(map[ast.Expr]Untyped)}
+	mustTypecheck(t, "p", src, &info)
+
+	got := make(map[string]string)
+	for e, u := range info.Untyped {
+		got[ExprString(e)] = fmt.Sprintf("%s %s %s %v", u.Type, u.Default, u.Final, u.Converted)
+	}
+	want := map[string]string{
+		"1":        "untyped int int float64 true",
+		"2":        "untyped int int int false",
+		"8 >> 3":   "untyped int int untyped int false",
+		"8":        "untyped int int untyped int false",
+		"3":        "untyped int int untyped int false",
+		"1.5":      "untyped float float64 float64 false",
+		"1.5 == f": "untyped bool bool bool false",
+		"'a'":      "untyped rune rune untyped rune false", // operands of constant expressions remain untyped
+		"10":       "untyped int int untyped rune false",
+		"'a' + 10": "untyped rune rune rune false",
+		"4":        "untyped int int float32 true",
+		"f32 == 4": "untyped bool bool bool false",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestInitCycles(t *testing.T) {
+	const src = `package p
+
+var a = b
+var b = f()
+
+func f() int { return a }
+
+const c = d
+const d = c
+
+var x = 1
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := Config{Error: func(error) {}}
+	info := Info{}
+	conf.Check(f.Name.Name, fset, []*ast.File{f}, &info)
+
+	var got []string
+	for _, cycle := range info.InitCycles {
+		var names []string
+		for _, obj := range cycle {
+			names = append(names, obj.Name())
+		}
+		got = append(got, strings.Join(names, " -> "))
+	}
+	want := []string{"a -> b -> f", "c -> d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
 func TestMultiFileInitOrder(t *testing.T) {
 	fset := token.NewFileSet()
 	mustParse := func(src string) *ast.File {
@@ -1311,6 +1390,91 @@ func main() {
 	}
 }
 
+func TestSelectionImplicit(t *testing.T) {
+	const src = `package p
+
+type A struct {
+	*B
+	C
+}
+
+type B struct{ b int }
+
+func (B) f() {}
+
+type C struct{ c int }
+
+func (C) g()  {}
+func (*C) h() {}
+
+type I interface{ m() }
+
+type P *C
+
+func _(a A, pa *A, c C, i I, p P) {
+	_ = a.b
+	_ = pa.b
+	_ = pa.c
+	_ = a.f
+	_ = pa.g
+	_ = a.h
+	_ = pa.h
+	_ = c.h
+	_ = i.m
+	_ = p.c
+	_ = (*C).g
+	_ = (*C).h
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{Selections: make(map[*ast.SelectorExpr]*Selection)}
+	if _, err := new(Config).Check("p", fset, []*ast.File{f}, &info); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each step is described by "*" for a dereference, "&" for an
+	// address-of, and "." otherwise.
+	want := map[string]string{
+		"a.b":    ".*",
+		"pa.b":   "**",
+		"pa.c":   "*.",
+		"a.f":    ".*",
+		"pa.g":   "*.",
+		"a.h":    ".&",
+		"pa.h":   "*&",
+		"c.h":    "&",
+		"i.m":    ".",
+		"p.c":    "*",
+		"(*C).g": "*",
+		"(*C).h": ".",
+	}
+	for e, sel := range info.Selections {
+		syntax := ExprString(e)
+		var got string
+		for _, op := range sel.Implicit() {
+			switch op {
+			case ImplicitDeref:
+				got += "*"
+			case ImplicitAddr:
+				got += "&"
+			default:
+				got += "."
+			}
+		}
+		if got != want[syntax] {
+			t.Errorf("%s: got %q, want %q", syntax, got, want[syntax])
+		}
+		delete(want, syntax)
+	}
+	for syntax := range want {
+		t.Errorf("no selection found for %s", syntax)
+	}
+}
+
 func TestIssue8518(t *testing.T) {
 	fset := token.NewFileSet()
 	imports := make(testImporter)
@@ -1409,6 +1573,285 @@ func TestLookupFieldOrMethod(t *testing.T) {
 	}
 }
 
+func TestLookupCandidates(t *testing.T) {
+	// Each candidate is described by the names of the embedded fields on its
+	// path and its own name, followed by its index.
+	var tests = []struct {
+		src  string
+		want []string
+	}{
+		{"var x T; type T struct{}", nil},
+		{"var x T; type T struct{ a, f int }", []string{"f [1]"}},
+		{"var x T; type T struct{}; func (*T) f() {}", []string{"f [0]"}},
+		{"var x T; type T struct{ E }; type E struct{ f int }", []string{"E.f [0 0]"}},
+
+		// shallower entries shadow deeper ones
+		{"var x T; type T struct{ E; f int }; type E struct{ f int }", []string{"f [1]"}},
+
+		// ambiguities
+		{"var x struct{ E1; *E2 }; type E1 struct{ f int }; type E2 struct{ f int }", []string{"E1.f [0 0]", "E2.f [1 0]"}},
+		{"var x struct{ E1; *E2 }; type E1 struct{ f int }; type E2 struct{}; func (E2) f() {}", []string{"E1.f [0 0]", "E2.f [1 0]"}},
+		{"var x struct{ A; B }; type A struct{ C }; type B struct{ C }; type C struct{ f int }", []string{"A.C.f [0 0 0]", "B.C.f [1 0 0]"}},
+
+		// methods of named pointer types are not found
+		{"var x P; type P *T; type T struct{ f int }", []string{"f [0]"}},
+		{"var x P; type P *T; type T struct{}; func (T) f() {}", nil},
+	}
+
+	for _, test := range tests {
+		pkg, err := pkgFor("test", "package p;"+test.src, nil)
+		if err != nil {
+			t.Errorf("%s: incorrect test case: %s", test.src, err)
+			continue
+		}
+
+		var got []string
+		for _, c := range LookupCandidates(pkg.Scope().Lookup("x").Type(), pkg, "f") {
+			var names []string
+			for _, f := range c.Path {
+				names = append(names, f.Name())
+			}
+			if c.Depth() != len(c.Index)-1 {
+				t.Errorf("%s: depth %d does not match index %v", test.src, c.Depth(), c.Index)
+			}
+			got = append(got, fmt.Sprintf("%s %v", strings.Join(append(names, c.Obj.Name()), "."), c.Index))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.src, got, test.want)
+		}
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	const src = `package p
+
+type T struct {
+	A
+	*B
+	x int
+}
+
+type A struct {
+	C
+	x, y string
+}
+
+type B struct {
+	y int
+	z bool
+}
+
+type C struct {
+	x int
+	_ int
+}
+
+func (A) m()  {}
+func (*B) m() {}
+func (*T) n() {}
+func (C) c()  {}
+
+var x T
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each selector is described by its name and the depths of its
+	// candidates and shadowed entries.
+	var got []string
+	for _, s := range Selectors(pkg.Scope().Lookup("x").Type()) {
+		desc := s.Name
+		if s.Ambiguous() {
+			desc += " ambiguous"
+		}
+		for _, c := range s.Candidates {
+			desc += fmt.Sprintf(" %d", c.Depth())
+		}
+		if len(s.Shadowed) > 0 {
+			desc += " shadows"
+			for _, c := range s.Shadowed {
+				desc += fmt.Sprintf(" %d", c.Depth())
+			}
+		}
+		got = append(got, desc)
+	}
+	want := []string{
+		"A 0",
+		"B 0",
+		"C 1",
+		"c 2",
+		"m ambiguous 1 1",
+		"n 0",
+		"x 0 shadows 1 2",
+		"y ambiguous 1 1",
+		"z 1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRounding(t *testing.T) {
+	const src = `package p
+
+const (
+	_ = 1.0 / 3
+	_ = 1e1000 * 1e1000
+	c float64 = 1
+	_ = c / 3
+	_ complex128 = 1 + 0.1i
+	d float64 = 0.1
+)
+
+var (
+	_ float64 = 0.5
+	_ float64 = 0.1
+	_ = float32(1.0 / 3)
+	_ = float32(d)
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	conf := Config{
+		Rounding: func(r Rounding) {
+			desc := fmt.Sprintf("%d:%d %s", fset.Position(r.Pos).Line, fset.Position(r.Pos).Column, r.Type)
+			if r.Conversion {
+				desc += " conversion of " + r.Value.String()
+			}
+			got = append(got, desc+" to "+r.Rounded.String())
+		},
+	}
+	if _, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"5:13 untyped float to 1e+2000",
+		"7:6 float64 conversion of 0.333333 to 0.333333",
+		"8:17 complex128 conversion of (1 + 0.1i) to (1 + 0.1i)",
+		"9:14 float64 conversion of 0.1 to 0.1",
+		"14:14 float64 conversion of 0.1 to 0.1",
+		"15:14 float32 conversion of 0.333333 to 0.333333",
+		"16:14 float32 conversion of 0.1 to 0.1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCallees(t *testing.T) {
+	const src = genericPkg + `p
+
+import (
+	"strings"
+	"unsafe"
+)
+
+type T struct{ f func() }
+
+func (T) m()  {}
+func (*T) n() {}
+
+type I interface{ m() }
+
+func g[P, Q any](P) Q { panic(0) }
+
+func k[P, Q any](P, Q) {}
+
+func h() func() { return nil }
+
+func _(x T, i I) {
+	_ = len("foo")
+	_ = unsafe.Sizeof(x)
+	_ = strings.ToUpper("bar")
+	x.m()
+	x.n()
+	(T).m(x)
+	T.m(x)
+	x.f()
+	i.m()
+	I.m(i)
+	_ = g[int, string](0)
+	_ = g[float64, bool](1.0)
+	k[int](1, "baz")
+	h()()
+	func() {}()
+	_ = int(0)
+}
+`
+	info := Info{Callees: make(map[*ast.CallExpr]Callee)}
+	mustTypecheck(t, "p", src, &info)
+
+	var calls []*ast.CallExpr
+	for call := range info.Callees {
+		calls = append(calls, call)
+	}
+	sort.Slice(calls, func(i, j int) bool { return calls[i].Pos() < calls[j].Pos() })
+
+	var got []string
+	for _, call := range calls {
+		c := info.Callees[call]
+		desc := ExprString(call) + ": " + c.Obj.Name()
+		for i := 0; i < c.TArgs.Len(); i++ {
+			desc += " " + c.TArgs.At(i).String()
+		}
+		got = append(got, desc)
+	}
+	want := []string{
+		`panic(0): panic`,
+		`len("foo"): len`,
+		`unsafe.Sizeof(x): Sizeof`,
+		`strings.ToUpper("bar"): ToUpper`,
+		`x.m(): m`,
+		`x.n(): n`,
+		`(T).m(x): m`,
+		`T.m(x): m`,
+		`g[int, string](0): g int string`,
+		`g[float64, bool](1.0): g float64 bool`,
+		`k[int](1, "baz"): k int string`,
+		`h(): h`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Functions of package C are declared in the current package, as in
+	// the files generated by cgo.
+	const cgoSrc = `package p
+
+import "C"
+
+func _Cfunc_f() {}
+
+func _() { C.f() }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", cgoSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conf Config
+	SetUsesCgo(&conf)
+	info = Info{Callees: make(map[*ast.CallExpr]Callee)}
+	if _, err := conf.Check("p", fset, []*ast.File{f}, &info); err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for call, c := range info.Callees {
+		got = append(got, ExprString(call)+": "+c.Obj.Name())
+	}
+	if want := []string{"C.f(): _Cfunc_f"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
 func sameSlice(a, b []int) bool {
 	if len(a) != len(b) {
 		return false
@@ -1423,6 +1866,148 @@ func sameSlice(a, b []int) bool {
 
 // TestScopeLookupParent ensures that (*Scope).LookupParent returns
 // the correct result at various positions with the source.
+func TestAddressableAssignable(t *testing.T) {
+	const src = `package p
+
+type T struct{ f int }
+
+var m map[string]int
+
+func f(x int, p *T) {
+	var a [2]int
+	s := []int{}
+	const c = 1
+	_, _, _, _ = a[1], s[0], *p, p.f
+	_, _, _ = T{f: 1}, m["k"], (x)
+	_ = f
+	{ /* inner */
+		y := 0
+		_ = y /* after y */
+	}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{
+		Types:  make(map[ast.Expr]TypeAndValue),
+		Defs:   make(map[*ast.Ident]Object),
+		Uses:   make(map[*ast.Ident]Object),
+		Scopes: make(map[ast.Node]*Scope),
+	}
+	var conf Config
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// find returns the first expression in the function body with the given string form.
+	body := f.Decls[len(f.Decls)-1].(*ast.FuncDecl).Body
+	find := func(str string) (found ast.Expr) {
+		ast.Inspect(body, func(n ast.Node) bool {
+			if e, _ := n.(ast.Expr); e != nil && found == nil && ExprString(e) == str {
+				found = e
+			}
+			return found == nil
+		})
+		if found == nil {
+			t.Fatalf("%s not found", str)
+		}
+		return
+	}
+
+	for _, test := range []struct {
+		expr                    string
+		addressable, assignable bool
+	}{
+		{"x", true, true},
+		{"(x)", true, true},
+		{"a", true, true},
+		{"a[1]", true, true},
+		{"s", true, true},
+		{"s[0]", true, true},
+		{"*p", true, true},
+		{"p.f", true, true},
+		{"c", false, false},
+		{"(T literal)", false, false},
+		{"m[\"k\"]", false, true},
+		{"f", false, false},
+		{"_", false, true},
+	} {
+		e := find(test.expr)
+		if got := info.Addressable(e); got != test.addressable {
+			t.Errorf("Addressable(%s) = %t, want %t", test.expr, got, test.addressable)
+		}
+		if got := info.Assignable(e); got != test.assignable {
+			t.Errorf("Assignable(%s) = %t, want %t", test.expr, got, test.assignable)
+		}
+	}
+
+	pos := func(s string) token.Pos {
+		return f.Pos() + token.Pos(strings.Index(src, s))
+	}
+	for _, test := range []struct {
+		at, expr       string
+		ok, redeclared bool
+	}{
+		{"_ = f", "x", true, true}, // parameters are declared in the function scope
+		{"_ = f", "a", true, true},
+		{"_ = f", "c", false, false},
+		{"_ = f", "m", true, false},
+		{"_ = f", "y", true, false},
+		{"_ = f", "_", true, false},
+		{"_ = f", "p.f", false, false},
+		{"/* inner */", "x", true, false},
+		{"/* inner */", "y", true, false},
+		{"/* after y */", "y", true, true},
+	} {
+		scope := pkg.Scope().Innermost(pos(test.at))
+		ok, redeclared := ShortVarDeclarable(scope, pos(test.at), find(test.expr))
+		if ok != test.ok || redeclared != test.redeclared {
+			t.Errorf("ShortVarDeclarable(%s) at %q = %t, %t; want %t, %t", test.expr, test.at, ok, redeclared, test.ok, test.redeclared)
+		}
+	}
+}
+
+func TestIsTerminating(t *testing.T) {
+	const src = `package p
+
+func _() { return }
+func _() { panic(0) }
+func _() { (panic)("x") }
+func _() { panic := func(int) {}; panic(1) }
+func _() { for {} }
+func _() { for { break } }
+func _() { L: for { for { break L } } }
+func _() { if true { return } else { panic(1) } }
+func _() { if true { return } }
+func _() { switch { case true: return; default: panic(0) } }
+func _() { switch { case true: return } }
+func _() { select {} }
+func _() { return; ; }
+`
+	want := []bool{true, true, true, false, true, false, false, true, false, true, false, true, true}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{Uses: make(map[*ast.Ident]Object)}
+	var conf Config
+	if _, err := conf.Check("p", fset, []*ast.File{f}, &info); err != nil {
+		t.Fatal(err)
+	}
+	for i, decl := range f.Decls {
+		body := decl.(*ast.FuncDecl).Body
+		if got := IsTerminating(&info, body); got != want[i] {
+			t.Errorf("%s: got %t, want %t", fset.Position(body.Pos()), got, want[i])
+		}
+	}
+}
+
 func TestScopeLookupParent(t *testing.T) {
 	fset := token.NewFileSet()
 	imports := make(testImporter)
@@ -1550,45 +2135,224 @@ func F(){
 	}
 }
 
-// newDefined creates a new defined type named T with the given underlying type.
-// Helper function for use with TestIncompleteInterfaces only.
-func newDefined(underlying Type) *Named {
-	tname := NewTypeName(token.NoPos, nil, "T", nil)
-	return NewNamed(tname, underlying, nil)
-}
+func TestPackageObjects(t *testing.T) {
+	const src = `package p
 
-func TestConvertibleTo(t *testing.T) {
+var z, a int
+
+type T struct{}
+
+func (T) m() {}
+func (T) M() {}
+func F() {}
+const C = 0
+type alias = T
+
+func init() {}
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 	for _, test := range []struct {
-		v, t Type
-		want bool
+		filter ObjectFilter
+		want   string
 	}{
-		{Typ[Int], Typ[Int], true},
-		{Typ[Int], Typ[Float32], true},
-		{newDefined(Typ[Int]), Typ[Int], true},
-		{newDefined(new(Struct)), new(Struct), true},
-		{newDefined(Typ[Int]), new(Struct), false},
-		{Typ[UntypedInt], Typ[Int], true},
-		{NewSlice(Typ[Int]), NewPointer(NewArray(Typ[Int], 10)), true},
-		{NewSlice(Typ[Int]), NewArray(Typ[Int], 10), false},
-		{NewSlice(Typ[Int]), NewPointer(NewArray(Typ[Uint], 10)), false},
-		// Untyped string values are not permitted by the spec, so the below
-		// behavior is undefined.
-		{Typ[UntypedString], Typ[String], true},
+		{ObjectFilter{}, "z a T F C alias"},
+		{ObjectFilter{Methods: true}, "z a T m M F C alias"},
+		{ObjectFilter{ExportedOnly: true, Methods: true}, "T M F C"},
+		{ObjectFilter{Kinds: TypeObjects | ConstObjects}, "T C alias"},
+		{ObjectFilter{Kinds: FuncObjects, Methods: true}, "F"},
 	} {
-		if got := ConvertibleTo(test.v, test.t); got != test.want {
-			t.Errorf("ConvertibleTo(%v, %v) = %t, want %t", test.v, test.t, got, test.want)
+		var names []string
+		for _, obj := range pkg.Objects(test.filter) {
+			names = append(names, obj.Name())
+		}
+		if got := strings.Join(names, " "); got != test.want {
+			t.Errorf("Objects(%+v) = %s, want %s", test.filter, got, test.want)
 		}
 	}
 }
 
-func TestAssignableTo(t *testing.T) {
+func TestInnermostChain(t *testing.T) {
+	const src = genericPkg + `p
+
+type T[P any] struct{ f func() /* in T */ }
+
+func (T[_]) m() {
+	if true {
+		_ = func() { /* in literal */ }
+	}
+}
+
+type I interface {
+	n(x int /* in n */)
+}
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos := func(marker string) token.Pos {
+		return token.Pos(strings.Index(src, marker) + 1) // the file base is 1
+	}
+	T := pkg.Scope().Lookup("T")
+	m, _, _ := LookupFieldOrMethod(T.Type(), false, pkg, "m")
+	n, _, _ := LookupFieldOrMethod(pkg.Scope().Lookup("I").Type(), false, pkg, "n")
+
+	type link struct {
+		kind  ScopeKind
+		owner Object
+	}
 	for _, test := range []struct {
-		v, t Type
-		want bool
+		at   string
+		want []link
 	}{
-		{Typ[Int], Typ[Int], true},
-		{Typ[Int], Typ[Float32], false},
-		{newDefined(Typ[Int]), Typ[Int], false},
+		{"/* in T */", []link{{TypeParamScope, T}, {FileScope, nil}, {PackageScope, nil}, {UniverseScope, nil}}},
+		{"/* in literal */", []link{{FuncScope, nil}, {BlockScope, nil}, {BlockScope, nil}, {FuncScope, m}, {FileScope, nil}, {PackageScope, nil}, {UniverseScope, nil}}},
+		{"/* in n */", []link{{FuncScope, n}, {FileScope, nil}, {PackageScope, nil}, {UniverseScope, nil}}},
+	} {
+		chain := pkg.Scope().InnermostChain(pos(test.at))
+		var got []link
+		for _, l := range chain {
+			got = append(got, link{l.Kind, l.Owner})
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("at %s: got chain %v, want %v", test.at, got, test.want)
+			continue
+		}
+		if chain[0].Scope != pkg.Scope().Innermost(pos(test.at)) || chain[len(chain)-1].Scope != Universe {
+			t.Errorf("at %s: chain does not extend from the innermost scope to Universe", test.at)
+		}
+	}
+
+	if chain := pkg.Scope().InnermostChain(token.NoPos); chain != nil {
+		t.Errorf("got chain %v for invalid position", chain)
+	}
+}
+
+// newDefined creates a new defined type named T with the given underlying type.
+// Helper function for use with TestIncompleteInterfaces only.
+func newDefined(underlying Type) *Named {
+	tname := NewTypeName(token.NoPos, nil, "T", nil)
+	return NewNamed(tname, underlying, nil)
+}
+
+func TestScopeInsertLookup(t *testing.T) {
+	// Insert enough objects, in non-sorted order, that the scope has to
+	// switch representations along the way.
+	scope := NewScope(nil, token.NoPos, token.NoPos, "test")
+	var names []string
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("x%d", (i*37)%50)
+		names = append(names, name)
+		if alt := scope.Insert(NewVar(token.NoPos, nil, name, Typ[Int])); alt != nil {
+			t.Fatalf("%s: unexpected alternative object %s", name, alt)
+		}
+		if got := scope.Len(); got != i+1 {
+			t.Fatalf("Len() = %d, want %d", got, i+1)
+		}
+		for _, name := range names {
+			if obj := scope.Lookup(name); obj == nil || obj.Name() != name {
+				t.Fatalf("Lookup(%q) = %v", name, obj)
+			}
+		}
+		if got := scope.Names(); !sort.StringsAreSorted(got) || len(got) != i+1 {
+			t.Fatalf("Names() = %v, want %d sorted names", got, i+1)
+		}
+	}
+
+	if alt := scope.Insert(NewVar(token.NoPos, nil, "x0", Typ[Int])); alt == nil {
+		t.Errorf("duplicate insertion of x0 succeeded")
+	}
+	if obj := scope.Lookup("y"); obj != nil {
+		t.Errorf("Lookup(%q) = %v, want nil", "y", obj)
+	}
+}
+
+func TestConvertibleTo(t *testing.T) {
+	for _, test := range []struct {
+		v, t Type
+		want bool
+	}{
+		{Typ[Int], Typ[Int], true},
+		{Typ[Int], Typ[Float32], true},
+		{newDefined(Typ[Int]), Typ[Int], true},
+		{newDefined(new(Struct)), new(Struct), true},
+		{newDefined(Typ[Int]), new(Struct), false},
+		{Typ[UntypedInt], Typ[Int], true},
+		{NewSlice(Typ[Int]), NewPointer(NewArray(Typ[Int], 10)), true},
+		{NewSlice(Typ[Int]), NewArray(Typ[Int], 10), false},
+		{NewSlice(Typ[Int]), NewPointer(NewArray(Typ[Uint], 10)), false},
+		// Untyped string values are not permitted by the spec, so the below
+		// behavior is undefined.
+		{Typ[UntypedString], Typ[String], true},
+	} {
+		if got := ConvertibleTo(test.v, test.t); got != test.want {
+			t.Errorf("ConvertibleTo(%v, %v) = %t, want %t", test.v, test.t, got, test.want)
+		}
+	}
+}
+
+func TestClassifyConversion(t *testing.T) {
+	bytes := NewSlice(Typ[Byte])
+	uptr := Typ[UnsafePointer]
+	for _, test := range []struct {
+		v, t Type
+		want ConversionKind
+	}{
+		{Typ[Int], Typ[Int], AssignableConversion},
+		{newDefined(Typ[Int]), Typ[Int], UnderlyingConversion},
+		{NewPointer(newDefined(Typ[Int])), NewPointer(Typ[Int]), PointerBaseConversion},
+		{Typ[Int], Typ[Float32], NumericConversion},
+		{Typ[Complex64], Typ[Complex128], ComplexConversion},
+		{Typ[Int], Typ[String], IntegerToStringConversion},
+		{bytes, Typ[String], SliceToStringConversion},
+		{Typ[String], bytes, StringToSliceConversion},
+		{NewPointer(Typ[Int]), uptr, UnsafePointerConversion},
+		{uptr, Typ[Uintptr], UnsafePointerConversion},
+		{NewSlice(Typ[Int]), NewPointer(NewArray(Typ[Int], 10)), SliceToArrayPointerConversion},
+		{NewSlice(Typ[Int]), NewArray(Typ[Int], 10), InvalidConversion},
+		{Typ[Float64], Typ[Complex128], InvalidConversion},
+	} {
+		if got := ClassifyConversion(test.v, test.t); got != (Conversion{test.want, false}) {
+			t.Errorf("ClassifyConversion(%v, %v) = %v, want %v", test.v, test.t, got, test.want)
+		}
+	}
+
+	const src = genericPkg + `p
+
+func f[P interface{ ~int | ~float64 }, S interface{ ~[]byte }](P, S) {}
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tparams := pkg.Scope().Lookup("f").Type().(*Signature).TypeParams()
+	P, S := tparams.At(0), tparams.At(1)
+	for _, test := range []struct {
+		v, t Type
+		want Conversion
+	}{
+		{P, Typ[Int8], Conversion{NumericConversion, true}},
+		{Typ[Int], P, Conversion{NumericConversion, true}},
+		{S, Typ[String], Conversion{SliceToStringConversion, true}},
+		{P, Typ[String], Conversion{InvalidConversion, false}},
+	} {
+		if got := ClassifyConversion(test.v, test.t); got != test.want {
+			t.Errorf("ClassifyConversion(%v, %v) = %v, want %v", test.v, test.t, got, test.want)
+		}
+	}
+}
+
+func TestAssignableTo(t *testing.T) {
+	for _, test := range []struct {
+		v, t Type
+		want bool
+	}{
+		{Typ[Int], Typ[Int], true},
+		{Typ[Int], Typ[Float32], false},
+		{newDefined(Typ[Int]), Typ[Int], false},
 		{newDefined(new(Struct)), new(Struct), true},
 		{Typ[UntypedBool], Typ[Bool], true},
 		{Typ[UntypedString], Typ[Bool], false},
@@ -1604,6 +2368,140 @@ func TestAssignableTo(t *testing.T) {
 	}
 }
 
+func TestAssertableTo(t *testing.T) {
+	const src = genericPkg + `p
+
+type Stringer interface{ String() string }
+type MyInt int
+func (MyInt) String() string { return "" }
+type MyInt2 int
+func (MyInt2) String() string { return "" }
+type G[T any] struct{}
+func (G[T]) String() string { return "" }
+type H[T any] int
+
+func f[P any, Q interface{ ~int | ~float64 }, R interface{ int | MyInt }, S Stringer, T interface{ ~int; String() string }, U interface{ MyInt | MyInt2 }]() {}
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := pkg.Scope()
+	lookup := func(name string) Type { return scope.Lookup(name).Type() }
+	tparams := lookup("f").(*Signature).TypeParams()
+	V := lookup("Stringer").Underlying().(*Interface)
+	for _, test := range []struct {
+		T    Type
+		want bool
+	}{
+		{lookup("MyInt"), true},
+		{Typ[Int], false},
+		{lookup("Stringer"), true},
+		{NewInterfaceType(nil, nil), true},
+		{lookup("G"), true},
+		{NewPointer(lookup("G")), true},
+		{lookup("H"), false},
+		{tparams.At(0), false},
+		{tparams.At(1), false},
+		{tparams.At(2), false},
+		{tparams.At(3), true},
+		{tparams.At(4), true},
+		// Unlike the original AssertableTo, type parameters whose type
+		// sets consist of assertable specific types are assertable.
+		{tparams.At(5), true},
+	} {
+		if got := AssertableTo(V, test.T); got != test.want {
+			t.Errorf("AssertableTo(%s, %s) = %t, want %t", V, test.T, got, test.want)
+		}
+	}
+}
+
+func TestAssignableToFor(t *testing.T) {
+	const src = genericPkg + `p
+
+type List[T any] struct{ next *List[T]; val T }
+type Number interface{ ~int | ~float64 }
+type Stringer interface{ String() string }
+type MyInt int
+func (MyInt) String() string { return "" }
+type Getter[T any] interface{ Get() T }
+type IntGetter struct{}
+func (IntGetter) Get() int { return 0 }
+
+func f[P any, Q Number, R interface{ int | MyInt }, S Stringer]() {}
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := pkg.Scope()
+	lookup := func(name string) Type { return scope.Lookup(name).Type() }
+	tparams := lookup("f").(*Signature).TypeParams()
+	P, Q, R, S := tparams.At(0), tparams.At(1), tparams.At(2), tparams.At(3)
+	list := lookup("List")
+	listInt, err := Instantiate(nil, list, []Type{Typ[Int]}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stringer := lookup("Stringer")
+
+	for _, test := range []struct {
+		V, T            Type
+		forAll, forSome bool
+	}{
+		{list, listInt, false, true},
+		{listInt, list, false, true},
+		{list, list, true, true},
+		{P, Typ[Int], false, true},
+		{Q, Typ[Int], false, true},
+		{Q, Typ[String], false, false},
+		{Typ[Int], Q, false, true},
+		{NewSlice(P), NewSlice(Typ[Int]), false, true},
+		{NewSlice(Q), NewSlice(Typ[String]), false, false},
+		{P, NewInterfaceType(nil, nil), true, true},
+		{S, stringer, true, true},
+	} {
+		if got := AssignableToFor(test.V, test.T, ForAll); got != test.forAll {
+			t.Errorf("AssignableToFor(%s, %s, ForAll) = %t, want %t", test.V, test.T, got, test.forAll)
+		}
+		if got := AssignableToFor(test.V, test.T, ForSome); got != test.forSome {
+			t.Errorf("AssignableToFor(%s, %s, ForSome) = %t, want %t", test.V, test.T, got, test.forSome)
+		}
+		if got := AssignableTo(test.V, test.T); got != test.forAll {
+			t.Errorf("AssignableTo(%s, %s) = %t, want %t", test.V, test.T, got, test.forAll)
+		}
+	}
+
+	getterP, err := Instantiate(nil, lookup("Getter"), []Type{P}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		V, T            Type
+		forAll, forSome bool
+	}{
+		{stringer, P, false, true},
+		{stringer, Q, false, true},
+		{stringer, R, false, true},
+		{stringer, S, true, true},
+		{stringer, Typ[Int], false, false},
+		{stringer, lookup("MyInt"), true, true},
+		{getterP, lookup("IntGetter"), false, true},
+		{getterP, lookup("MyInt"), false, false},
+	} {
+		V := test.V.Underlying().(*Interface)
+		if got := AssertableToFor(V, test.T, ForAll); got != test.forAll {
+			t.Errorf("AssertableToFor(%s, %s, ForAll) = %t, want %t", test.V, test.T, got, test.forAll)
+		}
+		if got := AssertableToFor(V, test.T, ForSome); got != test.forSome {
+			t.Errorf("AssertableToFor(%s, %s, ForSome) = %t, want %t", test.V, test.T, got, test.forSome)
+		}
+		if got := AssertableTo(V, test.T); got != test.forAll {
+			t.Errorf("AssertableTo(%s, %s) = %t, want %t", test.V, test.T, got, test.forAll)
+		}
+	}
+}
+
 func TestIdentical_issue15173(t *testing.T) {
 	// Identical should allow nil arguments and be symmetric.
 	for _, test := range []struct {
@@ -1926,3 +2824,3256 @@ func TestInstanceIdentity(t *testing.T) {
 		t.Errorf("mismatching types: a.A: %s, b.B: %s", a.Type(), b.Type())
 	}
 }
+
+func TestEnvironmentMissingMethod(t *testing.T) {
+	const src = `package p
+
+type I interface{ m(); n() }
+type J interface{ m() }
+type T int
+func (T) m() {}
+func (*T) n() {}
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := func(name string) Type { return pkg.Scope().Lookup(name).Type() }
+	I := lookup("I").Underlying().(*Interface)
+	J := lookup("J").Underlying().(*Interface)
+	T := lookup("T")
+	ptrT := NewPointer(T)
+
+	env := NewEnvironment()
+	for i := 0; i < 2; i++ { // the second iteration uses memoized results
+		for _, test := range []struct {
+			V    Type
+			T    *Interface
+			want bool
+		}{
+			{T, I, false},
+			{ptrT, I, true},
+			{T, J, true},
+			{I, J, true},
+			{J, I, false},
+		} {
+			if got := env.Implements(test.V, test.T); got != test.want {
+				t.Errorf("env.Implements(%s, %s) = %v, want %v", test.V, test.T, got, test.want)
+			}
+			if got := Implements(test.V, test.T); got != test.want {
+				t.Errorf("Implements(%s, %s) = %v, want %v", test.V, test.T, got, test.want)
+			}
+		}
+		// n exists, but only for *T
+		if m, wrong := env.MissingMethod(T, I, true); m == nil || m.Name() != "n" || !wrong {
+			t.Errorf("env.MissingMethod(T, I, true) = %v, %v; want n, true", m, wrong)
+		}
+	}
+}
+
+func TestMissingMethods(t *testing.T) {
+	const src = genericPkg + `p
+
+type I interface{ a(); b(int); c(); d() string; e() }
+type T int
+func (T) a() {}
+func (T) b(string) {}
+func (*T) c() {}
+func (*T) d() int { return 0 }
+
+type G[P any] struct{}
+func (G[P]) b(P) {}
+
+type J interface{ b(string); e() }
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := func(name string) Type { return pkg.Scope().Lookup(name).Type() }
+	I := lookup("I").Underlying().(*Interface)
+	J := lookup("J").Underlying().(*Interface)
+	T := lookup("T")
+	Gint, err := Instantiate(nil, lookup("G"), []Type{Typ[Int]}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// describe formats each problem as "name: type (ptr)"
+	describe := func(list []MethodProblem) []string {
+		var res []string
+		for _, p := range list {
+			s := p.Method.Name() + ":"
+			if p.Have != nil {
+				s += " " + p.Type.String()
+			}
+			if p.Ptr {
+				s += " (ptr)"
+			}
+			res = append(res, s)
+		}
+		return res
+	}
+
+	for _, test := range []struct {
+		V      Type
+		T      *Interface
+		static bool
+		want   []string
+	}{
+		{T, I, true, []string{"b: func(string)", "c: func() (ptr)", "d: func() int (ptr)", "e:"}},
+		{NewPointer(T), I, true, []string{"b: func(string)", "d: func() int", "e:"}},
+		{Gint, J, true, []string{"b: func(int)", "e:"}},
+		{J, I, true, []string{"a:", "b: func(string)", "c:", "d:"}},
+		{J, I, false, []string{"b: func(string)"}},
+		{T, J, false, []string{"e:"}},
+		{I, I, true, nil},
+	} {
+		got := describe(MissingMethods(test.V, test.T, test.static))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("MissingMethods(%s, %s, %v) = %q, want %q", test.V, test.T, test.static, got, test.want)
+		}
+
+		// the first problem is the one reported by MissingMethod
+		m, wrong := MissingMethod(test.V, test.T, test.static)
+		if list := MissingMethods(test.V, test.T, test.static); len(list) == 0 {
+			if m != nil {
+				t.Errorf("MissingMethod(%s, %s, %v) = %s, want nil", test.V, test.T, test.static, m.Name())
+			}
+		} else if m != list[0].Method || wrong != (list[0].Have != nil) {
+			t.Errorf("MissingMethod(%s, %s, %v) = %v, %v; want %s", test.V, test.T, test.static, m, wrong, list[0].Method.Name())
+		}
+	}
+}
+
+// checkErrors type-checks the package source src with conf (whose Error
+// field is overwritten) and returns the reported errors.
+func checkErrors(t *testing.T, src string, conf Config) []Error {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, modeForSource(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []Error
+	conf.Error = func(err error) { errs = append(errs, err.(Error)) }
+	conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	return errs
+}
+
+func TestErrorVerbosity(t *testing.T) {
+	const src = genericPkg + `p
+
+type T struct{ x int }
+type Number interface{ ~int | ~float64 }
+
+func f[P any](P, *int) {}
+func g[P Number]() {}
+
+var _ T = 0
+var _ = f(1, nil) + 0
+func _() { g[string]() }
+`
+	tests := []struct {
+		verbosity ErrorVerbosity
+		want      []string
+	}{
+		{CompactErrors, []string{
+			"as T value in variable declaration",
+			"f(1, nil) (no value) used as value",
+			"string does not satisfy Number\x00",
+		}},
+		{VerboseErrors, []string{
+			"as T (struct{x int}) value in variable declaration",
+			"f(1, nil) (no value) used as value",
+			"string does not satisfy Number (string not in type set {~int ∪ ~float64})",
+		}},
+	}
+	for _, test := range tests {
+		errs := checkErrors(t, src, Config{ErrorVerbosity: test.verbosity})
+		if len(errs) != len(test.want) {
+			t.Fatalf("verbosity %d: got %d errors (%v), want %d", test.verbosity, len(errs), errs, len(test.want))
+		}
+		for i, want := range test.want {
+			msg := errs[i].Msg + "\x00"
+			if !strings.Contains(msg, want) {
+				t.Errorf("verbosity %d: got error %q, want it to contain %q", test.verbosity, errs[i].Msg, strings.TrimSuffix(want, "\x00"))
+			}
+		}
+	}
+
+	// inferred type arguments
+	const src2 = genericPkg + `p
+func h[P any](P, string) {}
+func _(x int) { h(x, x) }
+`
+	errs := checkErrors(t, src2, Config{ErrorVerbosity: VerboseErrors})
+	if len(errs) != 1 || !strings.Contains(errs[0].Msg, "in argument to h (with inferred type arguments [int])") {
+		t.Errorf("got errors %v, want an error mentioning the inferred type arguments", errs)
+	}
+}
+
+func TestFollowOnErrors(t *testing.T) {
+	const src = `package p
+
+var p *undefined
+var _ int = p
+
+func f() (undefined, int)
+var _ string = f
+
+var s []undefined
+func _() { s.foo(); _ = s + 1 }
+`
+	for _, test := range []struct {
+		report bool
+		want   int
+	}{
+		{false, 3},
+		{true, 7},
+	} {
+		errs := checkErrors(t, src, Config{ReportFollowOnErrors: test.report})
+		if len(errs) != test.want {
+			t.Errorf("ReportFollowOnErrors = %v: got %d errors (%v), want %d", test.report, len(errs), errs, test.want)
+		}
+		undeclared := 0
+		for _, err := range errs {
+			if strings.Contains(err.Msg, "undeclared name") {
+				undeclared++
+			}
+		}
+		if undeclared != 3 {
+			t.Errorf("ReportFollowOnErrors = %v: got %d undeclared name errors, want 3", test.report, undeclared)
+		}
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	const src = `package p
+
+import "strings"
+
+type T struct {
+	count int
+	Name  string
+}
+
+func (*T) Close() {}
+
+func _(t T, value int) {
+	_ = valeu
+	_ = t.cuont
+	_ = t.name
+	t.close()
+	_ = T.Clos
+	_ = strings.Contians
+	_ = completelyUnrelated
+}
+`
+	tests := []struct {
+		msg, suggestion string
+	}{
+		{"undeclared name: valeu (did you mean value?)", "value"},
+		{"t.cuont undefined (type T has no field or method cuont, but does have count)", "count"},
+		{"t.name undefined (type T has no field or method name, but does have Name)", "Name"},
+		{"t.close undefined (type T has no field or method close, but does have Close)", "Close"},
+		{"T.Clos undefined (type T has no field or method Clos, but does have Close)", "Close"},
+		{"Contians not declared by package strings (did you mean Contains?)", "Contains"},
+		{"undeclared name: completelyUnrelated", ""},
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []Error
+	conf := Config{
+		Importer: importer.Default(),
+		Error:    func(err error) { errs = append(errs, err.(Error)) },
+	}
+	conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+
+	if len(errs) != len(tests) {
+		t.Fatalf("got %d errors (%v), want %d", len(errs), errs, len(tests))
+	}
+	for i, test := range tests {
+		if errs[i].Msg != test.msg || errs[i].Suggestion != test.suggestion {
+			t.Errorf("got error %q with suggestion %q, want %q with suggestion %q", errs[i].Msg, errs[i].Suggestion, test.msg, test.suggestion)
+		}
+	}
+}
+
+func TestConstraintChain(t *testing.T) {
+	const src = genericPkg + `p
+
+type Ordered[T any] interface{ ~int | ~string }
+type Key[T any] interface{ comparable; Ordered[T] }
+type MapKey[K any] interface{ Key[K] }
+
+func f[K MapKey[K]]() {}
+
+var _ = f[func()]
+var _ = f[float64]
+`
+	want := []string{
+		"func() does not satisfy MapKey[func()]",
+		"\tMapKey[func()] embeds Key[func()]",
+		"\tKey[func()] embeds comparable",
+		"\tfunc() does not satisfy comparable",
+		"float64 does not satisfy MapKey[float64]",
+		"\tMapKey[float64] embeds Key[float64]",
+		"\tKey[float64] embeds Ordered[float64]",
+		"\tfloat64 does not satisfy Ordered[float64] (float64 not in type set {~int ∪ ~string})",
+	}
+	errs := checkErrors(t, src, Config{})
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors (%v), want %d", len(errs), errs, len(want))
+	}
+	for i, err := range errs {
+		if err.Msg != want[i] {
+			t.Errorf("got error\n%s\nwant\n%s", err.Msg, want[i])
+		}
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	const src = "package p\n\nfunc _() {\n\tvar x int\n\t_ = x + \"foo\"\n\tvar x string\n}\n"
+	errs := checkErrors(t, src, Config{})
+	if len(errs) != 3 {
+		t.Fatalf("got %d errors (%v), want 3", len(errs), errs)
+	}
+	readFile := func(filename string) ([]byte, error) {
+		if filename != "p.go" {
+			return nil, fmt.Errorf("unexpected file %s", filename)
+		}
+		return []byte(src), nil
+	}
+
+	const want0 = `p.go:5:6: invalid operation: mismatched types int and untyped string
+5 | 	_ = x + "foo"
+  | 	    ^~~~~~~~~
+`
+	if got := FormatError(errs[0], readFile); got != want0 {
+		t.Errorf("got\n%s\nwant\n%s", got, want0)
+	}
+
+	const want1 = `p.go:6:6: x redeclared in this block
+6 | 	var x string
+  | 	    ^
+p.go:4:6: other declaration of x
+4 | 	var x int
+  | 	    ^
+`
+	if got := FormatError(errs[1], readFile, errs[2]); got != want1 {
+		t.Errorf("got\n%s\nwant\n%s", got, want1)
+	}
+
+	// without source, only the messages are shown
+	const want2 = "p.go:6:6: x redeclared in this block\n"
+	if got := FormatError(errs[1], nil); got != want2 {
+		t.Errorf("got\n%s\nwant\n%s", got, want2)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	const src = "package p\n\nvar _ = \"ä𝔸\" + x\n//line other.go:10:20\nvar _ = \"𝔸\" + y\n"
+	errs := checkErrors(t, src, Config{})
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors (%v), want 2", len(errs), errs)
+	}
+	for i, want := range []struct{ bytes, runes, utf16 int }{
+		{20, 16, 17}, // after ä (2 bytes, 1 unit) and 𝔸 (4 bytes, 2 units)
+		{18, 15, 16}, // the line directive is ignored
+	} {
+		err := errs[i]
+		if got := err.Fset.PositionFor(err.Pos, false).Column; got != want.bytes {
+			t.Errorf("%s: byte column %d, want %d", err.Msg, got, want.bytes)
+		}
+		if got, _ := RuneColumn(err.Fset, err.Pos, []byte(src)); got != want.runes {
+			t.Errorf("%s: rune column %d, want %d", err.Msg, got, want.runes)
+		}
+		if got, _ := UTF16Column(err.Fset, err.Pos, []byte(src)); got != want.utf16 {
+			t.Errorf("%s: UTF-16 column %d, want %d", err.Msg, got, want.utf16)
+		}
+	}
+
+	if _, err := UTF16Column(errs[0].Fset, errs[0].Pos, []byte("package p")); err == nil {
+		t.Errorf("UTF16Column succeeded with mismatched file contents")
+	}
+}
+
+func TestLineDirectiveErrors(t *testing.T) {
+	const src = `package p
+
+func _(x int) {
+//line template.tmpl:10:5
+	switch x {
+	default:
+	default:
+	}
+	_ = undefined
+}
+`
+	errs := checkErrors(t, src, Config{})
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors (%v), want 2", len(errs), errs)
+	}
+	for i, want := range []string{
+		"template.tmpl:12:2: multiple defaults (first at template.tmpl:11:2)",
+		"template.tmpl:14:6: undeclared name: undefined",
+	} {
+		if got := errs[i].Error(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	if got := errs[1].Fset.PositionFor(errs[1].Pos, false).String(); got != "p.go:9:6" {
+		t.Errorf("unadjusted position %s, want p.go:9:6", got)
+	}
+
+	// So do the positions of objects.
+	fset := errs[0].Fset
+	f, err := parser.ParseFile(fset, "q.go", "package p\n//line template.tmpl:20:1\nvar v = 1\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := (&Config{}).Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := pkg.Scope().Lookup("v")
+	if got := fset.Position(v.Pos()).String(); got != "template.tmpl:20:5" {
+		t.Errorf("position of v is %s, want template.tmpl:20:5", got)
+	}
+	if got := fset.PositionFor(v.Pos(), false).String(); got != "q.go:3:5" {
+		t.Errorf("unadjusted position of v is %s, want q.go:3:5", got)
+	}
+}
+
+func TestParseStructTag(t *testing.T) {
+	for _, test := range []struct {
+		tag     string
+		entries string // key=value@offset, space-separated
+		err     string // error message and offset, if any
+	}{
+		{``, ``, ``},
+		{`json:"a,omitempty"  xml:"b\tc"`, `json=a,omitempty@0 xml=b	c@20`, ``},
+		{`json:"a" json:"b"`, `json=a@0 json=b@9`, ``},
+		{`json:"a"xml:"b"`, `json=a@0`, `key:"value" pairs not separated by spaces@8`},
+		{`json:a`, ``, `value of key json is not quoted@5`},
+		{`json`, ``, `missing ':' after key json@4`},
+		{`x:"a" :"b"`, `x=a@0`, `invalid key@6`},
+		{`json:"a`, ``, `unterminated value of key json@5`},
+		{`json:"\q"`, ``, `invalid value of key json@5`},
+	} {
+		tag, err := ParseStructTag(test.tag)
+		var entries []string
+		for _, e := range tag.Entries {
+			entries = append(entries, fmt.Sprintf("%s=%s@%d", e.Key, e.Value, e.Offset))
+		}
+		if got := strings.Join(entries, " "); got != test.entries {
+			t.Errorf("%s: got entries %s, want %s", test.tag, got, test.entries)
+		}
+		var gotErr string
+		if err != nil {
+			err := err.(*StructTagError)
+			gotErr = fmt.Sprintf("%s@%d", err.Msg, err.Offset)
+		}
+		if gotErr != test.err {
+			t.Errorf("%s: got error %q, want %q", test.tag, gotErr, test.err)
+		}
+	}
+
+	tag, _ := ParseStructTag(`a:"1" b:"2" a:"3"`)
+	if got := tag.Get("a"); got != "1" {
+		t.Errorf("Get(a) = %q, want 1", got)
+	}
+	if _, ok := tag.Lookup("c"); ok {
+		t.Errorf("Lookup(c) succeeded")
+	}
+	if dups := tag.Duplicates(); len(dups) != 1 || dups[0].Value != "3" {
+		t.Errorf("Duplicates() = %v, want the entry a:\"3\"", dups)
+	}
+}
+
+func TestCheckStructTags(t *testing.T) {
+	const src = "package p\n\n" +
+		"type T struct {\n" +
+		"\tA int `json:\"a\" json:\"b\"`\n" +
+		"\tB int `json:\"b\"xml:\"b\"`\n" +
+		"\tC int \"json:\\\"c\\\"\"\n" +
+		"\tD int `lang:\"fr\"`\n" +
+		"\tE int `lang:\"en\"`\n" +
+		"}\n"
+
+	conf := Config{
+		CheckStructTags: true,
+		ValidateStructTag: func(field *Var, tag StructTag) error {
+			if lang, ok := tag.Lookup("lang"); ok && lang != "en" {
+				return &StructTagError{Offset: tag.Entries[0].Offset + 6, Msg: fmt.Sprintf("unknown language %s for %s", lang, field.Name())}
+			}
+			return nil
+		},
+	}
+	errs := checkErrors(t, src, conf)
+	var got []string
+	for _, err := range errs {
+		if err.Severity != SeverityWarning {
+			t.Errorf("%s: not a warning", err)
+		}
+		got = append(got, err.Error())
+	}
+	want := []string{
+		`p.go:4:18: struct tag has duplicate key json`,
+		`p.go:5:17: invalid struct tag: key:"value" pairs not separated by spaces`,
+		`p.go:7:15: invalid struct tag: unknown language fr for D`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got errors\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Tags are not checked by default.
+	if errs := checkErrors(t, src, Config{}); len(errs) != 0 {
+		t.Errorf("got errors %v without CheckStructTags", errs)
+	}
+}
+
+func TestInterfaceSetOps(t *testing.T) {
+	const src = genericPkg + `p
+
+type Integer interface{ ~int | ~int8 | ~uint }
+type Signed interface{ ~int | ~int8 | int16 }
+type Stringer interface{ String() string }
+type OtherString interface{ String() []byte }
+type Cmp interface{ comparable }
+type Str interface{ ~string }
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iface := func(name string) *Interface {
+		return pkg.Scope().Lookup(name).Type().Underlying().(*Interface)
+	}
+	qf := RelativeTo(pkg)
+
+	for _, test := range []struct {
+		op   func(...*Interface) (*Interface, error)
+		args []string
+		want string // or error substring, if err is set
+		err  bool
+	}{
+		{IntersectInterfaces, nil, "interface{}", false},
+		{IntersectInterfaces, []string{"Integer", "Signed"}, "interface{~int|~int8}", false},
+		{IntersectInterfaces, []string{"Integer", "Stringer", "Cmp"}, "interface{String() string; comparable; ~int|~int8|~uint}", false},
+		{IntersectInterfaces, []string{"Integer", "Str"}, "interface{int; string}", false},
+		{IntersectInterfaces, []string{"Stringer", "OtherString"}, "method String has signatures", true},
+		{UnionInterfaces, nil, "interface{int; string}", false},
+		{UnionInterfaces, []string{"Integer", "Signed"}, "interface{~int|~int8|~uint|int16}", false},
+		{UnionInterfaces, []string{"Integer", "Str"}, "interface{~int|~int8|~uint|~string}", false},
+		{UnionInterfaces, []string{"Integer", "Stringer"}, "interface has methods", true},
+		{UnionInterfaces, []string{"Cmp"}, "interface is comparable", true},
+	} {
+		var args []*Interface
+		for _, name := range test.args {
+			args = append(args, iface(name))
+		}
+		res, err := test.op(args...)
+		if test.err {
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("%v: got error %v, want error containing %q", test.args, err, test.want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", test.args, err)
+			continue
+		}
+		if got := TypeString(res, qf); got != test.want {
+			t.Errorf("%v: got %s, want %s", test.args, got, test.want)
+		}
+	}
+
+	// The results have the type sets of constraints.
+	x, _ := IntersectInterfaces(iface("Integer"), iface("Signed"))
+	if !x.IsConstraint() || !x.IsComparable() {
+		t.Errorf("%s is not a comparable constraint", x)
+	}
+}
+
+func TestEmptyTypeSets(t *testing.T) {
+	const src = genericPkg + `p
+
+type Number interface{ ~int | ~float64 }
+type Stringer interface{ String() string }
+
+type (
+	_ interface{ int; string }
+	_ interface{ Number; ~string }
+	_ interface{ []int | map[int]int; comparable }
+	_ interface{ int | float64; Stringer }
+)
+
+type (
+	_ interface{ Number; ~int }
+	_ interface{ []int | int; comparable }
+	_ interface{ ~int; Stringer }
+	_ interface{ comparable; Stringer }
+)
+
+func _[P interface{ int; string }, Q Number]() {}
+type _[P interface{ Number; Stringer }, Q interface{ int; Stringer }] struct{}
+`
+	conf := Config{ReportEmptyTypeSets: true}
+	errs := checkErrors(t, src, conf)
+	var got []string
+	for _, err := range errs {
+		if err.Severity != SeverityWarning {
+			t.Errorf("%s: not a warning", err)
+		}
+		got = append(got, err.Fset.Position(err.Pos).String())
+	}
+	if want := []string{"p.go:20:10", "p.go:21:43"}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got warnings at %v (%v), want %v", got, errs, want)
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &Info{Types: make(map[ast.Expr]TypeAndValue)}
+	if _, err := new(Config).Check("p", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+	// The first group of declarations has empty type sets, the second one doesn't.
+	for i, want := range []bool{true, false} {
+		for _, spec := range f.Decls[i+2].(*ast.GenDecl).Specs {
+			typ := info.Types[spec.(*ast.TypeSpec).Type].Type.(*Interface)
+			if got := typ.IsEmptyTypeSet(); got != want {
+				t.Errorf("%s.IsEmptyTypeSet() = %v, want %v", typ, got, want)
+			}
+		}
+	}
+}
+
+func TestFreeTypeParams(t *testing.T) {
+	const src = genericPkg + `p
+
+type List[T any] struct{ next *List[T]; val T }
+type Rec struct{ next *Rec }
+
+func F[P comparable, Q interface{ ~[]P }, R comparable](P, Q, R) {
+	type Local struct{ next *Local; q Q }
+	var (
+		_ = map[P]List[R]{}
+		_ = func(func(P) int) Local { return Local{} }
+		_ = Rec{}
+		_ = List[int]{}
+		_ = F[int, []int, bool]
+		_ = F[R, []R, P]
+	)
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &Info{Types: make(map[ast.Expr]TypeAndValue)}
+	pkg, err := new(Config).Check("p", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fdecl := f.Decls[2].(*ast.FuncDecl)
+	decl := fdecl.Body.List[1].(*ast.DeclStmt).Decl.(*ast.GenDecl)
+	for i, want := range []string{
+		"P R",
+		"P Q", // Q through Local
+		"",
+		"",
+		"",
+		"R P",
+	} {
+		typ := info.Types[decl.Specs[i].(*ast.ValueSpec).Values[0]].Type
+		var names []string
+		for _, tpar := range FreeTypeParams(typ) {
+			names = append(names, tpar.Obj().Name())
+		}
+		if got := strings.Join(names, " "); got != want {
+			t.Errorf("FreeTypeParams(%s) = %s, want %s", typ, got, want)
+		}
+		if got := IsParameterized(typ); got != (want != "") {
+			t.Errorf("IsParameterized(%s) = %v", typ, got)
+		}
+	}
+
+	// Generic functions and types bind their type parameters.
+	for _, name := range []string{"F", "List"} {
+		if typ := pkg.Scope().Lookup(name).Type(); IsParameterized(typ) {
+			t.Errorf("%s is parameterized", typ)
+		}
+	}
+}
+
+func TestMethodInterface(t *testing.T) {
+	const src = genericPkg + `p
+
+type Stringer interface{ String() string }
+type Number interface{ ~int | ~float64 }
+type C interface{ Number; Stringer; comparable; Less(int) bool }
+
+func F[P C, Q Number, R Stringer]() {}
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tparams := pkg.Scope().Lookup("F").Type().(*Signature).TypeParams()
+	for i, want := range []string{
+		"interface{Less(int) bool; String() string}",
+		"interface{}",
+		"interface{String() string}",
+	} {
+		tpar := tparams.At(i)
+		m := tpar.MethodInterface()
+		if got := TypeString(m, RelativeTo(pkg)); got != want {
+			t.Errorf("%s.MethodInterface() = %s, want %s", tpar, got, want)
+		}
+		if m.IsConstraint() {
+			t.Errorf("%s.MethodInterface() is a constraint", tpar)
+		}
+	}
+
+	// Method sets are their own method interfaces.
+	stringer := pkg.Scope().Lookup("Stringer").Type().Underlying().(*Interface)
+	if got := stringer.MethodInterface(); got != stringer {
+		t.Errorf("MethodInterface() of %s is %s", stringer, got)
+	}
+}
+
+func TestLitElements(t *testing.T) {
+	const src = `package p
+
+type T struct{ a, b int }
+
+var (
+	_ = T{1, 2}
+	_ = T{b: 1}
+	_ = []T{{a: 1}, 2: {}, {}}
+	_ = [...]string{5: "a", "b"}
+	_ = map[string]T{"x": {}}
+	_ = T{c: 1}
+	_ = T{a: 1, a: 2}
+	_ = []int{0: 1, 0: 2}
+	_ = map[string]int{"a": 1, "a": 2, 3: 4}
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &Info{LitElements: make(map[ast.Expr]LitElement)}
+	conf := Config{Error: func(error) {}}
+	conf.Check("p", fset, []*ast.File{f}, info)
+
+	var got []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		lit, _ := n.(*ast.CompositeLit)
+		if lit == nil {
+			return true
+		}
+		for _, e := range lit.Elts {
+			elem, ok := info.LitElements[e]
+			switch {
+			case !ok:
+				got = append(got, "none")
+			case elem.Field != nil:
+				got = append(got, "field "+elem.Field.Name())
+			case elem.Key != nil:
+				got = append(got, "key "+elem.Key.String())
+			default:
+				got = append(got, fmt.Sprintf("index %d", elem.Index))
+			}
+		}
+		return true
+	})
+	want := "field a, field b, field b, index 0, index 2, index 3, field a, index 5, index 6, key string, none, field a, none, index 0, none, key string, none, none"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("got elements\n%s\nwant\n%s", strings.Join(got, ", "), want)
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	const src = genericPkg + `p
+
+type Stringer interface{ String() string }
+type Closer interface{ Close() error }
+type Sizer interface{ Size() int }
+type Lener interface{ Len() int }
+
+type T int
+
+func (T) String() string { return "" }
+func (*T) Close() error  { return nil }
+func (T) Size() int      { return 0 }
+func (T) Len() int       { return 0 }
+func (T) Unused()        {}
+
+func size[P Sizer](P) {}
+
+func _(x interface{}) {
+	var s Stringer = T(0) // assignment
+	_ = Closer(new(T))    // conversion
+	_ = x.(T)             // assertion of the empty interface: no methods
+	_ = s.(T)             // assertion
+	size(T(0))            // constraint
+	var l Lener = T(0)
+	_ = l
+	l = T(1)              // repeated
+}
+`
+	info := Info{
+		Satisfies: make(map[*Func][]*Func),
+	}
+	pkg, err := pkgFor("p", src, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	T := pkg.Scope().Lookup("T").Type().(*Named)
+
+	got := make(map[string]string)
+	for f, list := range info.Satisfies {
+		var ms []string
+		for _, im := range list {
+			ms = append(ms, im.FullName())
+		}
+		got[f.FullName()] = strings.Join(ms, ", ")
+	}
+	want := map[string]string{
+		"(generic_p.T).String": "(generic_p.Stringer).String",
+		"(*generic_p.T).Close": "(generic_p.Closer).Close",
+		"(generic_p.T).Size":   "(generic_p.Sizer).Size",
+		"(generic_p.T).Len":    "(generic_p.Lener).Len",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for i := 0; i < T.NumMethods(); i++ {
+		if m := T.Method(i); m.Name() == "Unused" && info.Satisfies[m] != nil {
+			t.Errorf("%s recorded as satisfying %v", m, info.Satisfies[m])
+		}
+	}
+}
+
+func TestLookupPath(t *testing.T) {
+	const src = `package p
+
+type A struct{ x int }
+func (*A) m() {}
+
+type B struct{ *A }
+type C struct{ B; y int }
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	C := pkg.Scope().Lookup("C").Type()
+
+	for _, test := range []struct {
+		name        string
+		addressable bool
+		want        string // embedded fields, * for pointer ones
+		indirect    bool
+	}{
+		{"y", false, "", false},
+		{"B", false, "", false},
+		{"x", false, "B *A", true},
+		{"m", false, "B *A", true},
+		{"m", true, "B *A", true},
+	} {
+		obj, path, indirect := LookupPath(C, test.addressable, pkg, test.name)
+		if obj == nil || obj.Name() != test.name {
+			t.Errorf("%s: got %v", test.name, obj)
+			continue
+		}
+		var got []string
+		for _, e := range path {
+			s := e.Field.Name()
+			if e.Indirect {
+				s = "*" + s
+			}
+			got = append(got, s)
+		}
+		if strings.Join(got, " ") != test.want || indirect != test.indirect {
+			t.Errorf("%s: got path %v, indirect %v; want %q, %v", test.name, got, indirect, test.want, test.indirect)
+		}
+		_, index, _ := LookupFieldOrMethod(C, test.addressable, pkg, test.name)
+		if len(path) != len(index)-1 {
+			t.Errorf("%s: path %v does not match index %v", test.name, got, index)
+		}
+	}
+
+	if obj, path, _ := LookupPath(C, false, pkg, "z"); obj != nil || path != nil {
+		t.Errorf("z: got %v, %v", obj, path)
+	}
+}
+
+func TestNewSignatureType(t *testing.T) {
+	// type T[P any] struct{}
+	// func (T[P]) m(P)
+	obj := NewTypeName(token.NoPos, nil, "T", nil)
+	P := NewTypeParam(NewTypeName(token.NoPos, nil, "P", nil), NewInterfaceType(nil, nil))
+	T := NewNamed(obj, NewStruct(nil, nil), nil)
+	T.SetTypeParams([]*TypeParam{P})
+
+	// The receiver type parameter is distinct from the one of T.
+	Q := NewTypeParam(NewTypeName(token.NoPos, nil, "P", nil), NewInterfaceType(nil, nil))
+	recv := NewVar(token.NoPos, nil, "", T)
+	m := NewSignatureType(recv, []*TypeParam{Q}, nil, NewTuple(NewVar(token.NoPos, nil, "", Q)), nil, false)
+	if got := m.RecvTypeParams().Len(); got != 1 || m.RecvTypeParams().At(0) != Q {
+		t.Errorf("got %d receiver type parameters, want [%s]", got, Q)
+	}
+	if m.TypeParams() != nil {
+		t.Errorf("got type parameters %v, want none", m.TypeParams())
+	}
+
+	// func f[E any](...E) E
+	E := NewTypeParam(NewTypeName(token.NoPos, nil, "E", nil), NewInterfaceType(nil, nil))
+	f := NewSignatureType(nil, nil, []*TypeParam{E}, NewTuple(NewVar(token.NoPos, nil, "", NewSlice(E))), NewTuple(NewVar(token.NoPos, nil, "", E)), true)
+	if f.TypeParams().Len() != 1 || f.TypeParams().At(0) != E || !f.Variadic() {
+		t.Errorf("got %s, want func[E any](...E) E", f)
+	}
+
+	mustPanic := func(name string, f func()) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: no panic", name)
+			}
+		}()
+		f()
+	}
+	mustPanic("type parameters with receiver", func() {
+		Y := NewTypeParam(NewTypeName(token.NoPos, nil, "Y", nil), NewInterfaceType(nil, nil))
+		NewSignatureType(recv, nil, []*TypeParam{Y}, nil, nil, false)
+	})
+	mustPanic("receiver type parameters without receiver", func() {
+		Y := NewTypeParam(NewTypeName(token.NoPos, nil, "Y", nil), NewInterfaceType(nil, nil))
+		NewSignatureType(nil, []*TypeParam{Y}, nil, nil, nil, false)
+	})
+	mustPanic("bound type parameter", func() {
+		NewSignatureType(nil, nil, []*TypeParam{E}, nil, nil, false)
+	})
+}
+
+func TestConstantsOnly(t *testing.T) {
+	const src = `package p
+
+import "unsafe"
+
+const (
+	A = 1 << iota
+	B
+)
+
+type Kind int
+
+const K Kind = B + 1
+
+var arr [4]int
+
+const N = len(arr)
+
+type T struct{ a, b int32 }
+
+const S = unsafe.Sizeof(T{})
+
+var V int = "not checked"
+
+func f() { undefined }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := Config{Importer: importer.Default(), ConstantsOnly: true}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Complete() {
+		t.Errorf("package is complete")
+	}
+
+	for _, test := range []struct {
+		name, typ, val string
+	}{
+		{"A", "untyped int", "1"},
+		{"B", "untyped int", "2"},
+		{"K", "p.Kind", "3"},
+		{"N", "int", "4"},
+		{"S", "uintptr", "8"},
+	} {
+		obj := pkg.Scope().Lookup(test.name).(*Const)
+		if got := obj.Type().String(); got != test.typ {
+			t.Errorf("%s: got type %s, want %s", test.name, got, test.typ)
+		}
+		if got := obj.Val().String(); got != test.val {
+			t.Errorf("%s: got value %s, want %s", test.name, got, test.val)
+		}
+	}
+
+	// Declarations constants depend on are checked, others are not.
+	if typ := pkg.Scope().Lookup("arr").Type(); typ == nil || typ.String() != "[4]int" {
+		t.Errorf("arr has type %v, want [4]int", typ)
+	}
+	for _, name := range []string{"V", "f"} {
+		if typ := pkg.Scope().Lookup(name).Type(); typ != nil {
+			t.Errorf("%s has type %s, want none", name, typ)
+		}
+	}
+}
+
+func TestWalkDependencies(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+
+	const src = `package p
+
+import (
+	"fmt"
+	_ "os"
+	. "strings"
+)
+
+var _ = fmt.Sprint(fmt.Println, ToUpper)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pkg.WalkDependencies(func(Dependency) bool { return true }); err == nil {
+		t.Errorf("no error for package checked without RecordDependencies")
+	}
+
+	conf.RecordDependencies = true
+	pkg, err = conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// direct dependencies only
+	var direct []string
+	err = pkg.WalkDependencies(func(dep Dependency) bool {
+		if !dep.Direct {
+			t.Errorf("indirect dependency %s visited", dep.Package.Path())
+		}
+		var used []string
+		for _, obj := range dep.Used {
+			used = append(used, obj.Name())
+		}
+		direct = append(direct, fmt.Sprintf("%s%v", dep.Package.Path(), used))
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(direct, " "), "fmt[Println Sprint] os[] strings[ToUpper]"; got != want {
+		t.Errorf("got direct dependencies %s, want %s", got, want)
+	}
+
+	// full closure
+	seen := make(map[string]bool)
+	err = pkg.WalkDependencies(func(dep Dependency) bool {
+		path := dep.Package.Path()
+		if seen[path] {
+			t.Errorf("%s visited twice", path)
+		}
+		seen[path] = true
+		if !dep.Package.Complete() {
+			t.Errorf("%s is incomplete", path)
+		}
+		if !dep.Direct && len(dep.Used) > 0 {
+			t.Errorf("indirect dependency %s has used objects %v", path, dep.Used)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"io", "unicode"} {
+		if !seen[path] {
+			t.Errorf("indirect dependency %s not visited", path)
+		}
+	}
+
+	if err := NewPackage("q", "q").WalkDependencies(func(Dependency) bool { return true }); err == nil {
+		t.Errorf("no error for package not checked from source")
+	}
+}
+
+func TestImportOrder(t *testing.T) {
+	// d and b both import a; the stub of a imported by b is distinct.
+	newPkg := func(path string, imports ...*Package) *Package {
+		pkg := NewPackage(path, path)
+		pkg.SetImports(imports)
+		pkg.MarkComplete()
+		return pkg
+	}
+	a := newPkg("a")
+	stub := NewPackage("a", "a")
+	pkgs := map[string]*Package{
+		"a": a,
+		"b": newPkg("b", stub),
+		"c": newPkg("c"),
+		"d": newPkg("d", a),
+	}
+	imp := testImporter(pkgs)
+
+	const src1 = `package p; import (_ "d"; _ "b")`
+	const src2 = `package p; import (_ "c"; _ "d"; _ "a")`
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, src := range []string{src1, src2} {
+		f, err := parser.ParseFile(fset, "", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	for _, concurrent := range []bool{false, true} {
+		conf := Config{Importer: imp, ConcurrentImports: concurrent}
+		pkg, err := conf.Check("p", fset, files, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, imp := range pkg.Imports() {
+			got = append(got, imp.Path())
+		}
+		if got, want := strings.Join(got, " "), "d b c a"; got != want {
+			t.Errorf("concurrent=%v: got imports %s, want %s", concurrent, got, want)
+		}
+
+		list := pkg.TransitiveImports()
+		got = nil
+		for _, imp := range list {
+			got = append(got, imp.Path())
+		}
+		if got, want := strings.Join(got, " "), "a b c d"; got != want || list[0] != a {
+			t.Errorf("concurrent=%v: got transitive imports %s, want %s", concurrent, got, want)
+		}
+	}
+}
+
+func TestReportImpossibleAssertions(t *testing.T) {
+	// the reported assertions are tested in testdata/check/impossible*.src
+	const src = `package p
+
+type Reader interface{ Read([]byte) (int, error) }
+type OtherReader interface{ Read() []byte }
+
+func _(r Reader) { _ = r.(OtherReader) }
+`
+	errs := checkErrors(t, src, Config{ReportImpossibleAssertions: true})
+	if len(errs) != 1 || errs[0].Severity != SeverityWarning {
+		t.Errorf("got errors %v, want one warning", errs)
+	}
+}
+
+func TestDeclTimes(t *testing.T) {
+	var body strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&body, "\tx = x + T[int]{}.m() + len(string(rune(%d)))\n", i)
+	}
+	src := genericPkg + `p
+
+const c = 1
+var v = f()
+type T[P any] struct{ p P }
+func (T[P]) m() P { var p P; return p }
+func f() int { return c }
+func slow() {
+	var x int
+` + body.String() + `	_ = func() {}
+}
+`
+	info := &Info{DeclTimes: make(map[Object]time.Duration)}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conf Config
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for obj := range info.DeclTimes {
+		got = append(got, obj.Name())
+	}
+	sort.Strings(got)
+	if want := "T c f m slow v"; strings.Join(got, " ") != want {
+		t.Errorf("got declarations %v, want %s", got, want)
+	}
+	if slowest := info.SlowestDecls(1); len(slowest) != 1 || slowest[0].Obj != pkg.Scope().Lookup("slow") {
+		t.Errorf("got slowest declarations %v, want slow", slowest)
+	}
+	if n := len(info.SlowestDecls(10)); n != len(info.DeclTimes) {
+		t.Errorf("got %d declarations, want %d", n, len(info.DeclTimes))
+	}
+}
+
+func TestSkipped(t *testing.T) {
+	for _, test := range []struct {
+		conf Config
+		src  string
+		want []string // object name (or "-"), position, and reason
+	}{
+		{Config{IgnoreFuncBodies: true}, `package p
+var v = func() {}
+type T int
+func (T) m() {}
+func f()`,
+			[]string{"- 2:16 function body ignored", "m 4:14 function body ignored"},
+		},
+		{Config{ConstantsOnly: true}, `package p
+type T int
+const c T = 1
+var v = c
+func f() {}
+type U int`,
+			[]string{"v 4:5 not a constant", "f 5:6 not a constant", "U 6:6 not a constant"},
+		},
+		{Config{FakeImportC: true}, `package p
+import "C"
+var v = C.f()
+func f() { _ = C.g }`,
+			[]string{"- 3:9 fake import", "- 4:16 fake import"},
+		},
+		// Without an Error function, checking stops after the first error.
+		{Config{}, `package p
+var v = f()
+func f() int { var _ int = "bad"; _ = func() {}; return 0 }
+func g() {}
+var w = 0
+var h = func() {}`,
+			[]string{"f 3:14 checking stopped", "g 4:10 checking stopped", "- 6:16 checking stopped"},
+		},
+	} {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", test.src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info := new(Info)
+		test.conf.Check("p", fset, []*ast.File{f}, info)
+		if info.Skipped != nil {
+			t.Errorf("%s: Skipped recorded although nil", test.src)
+		}
+		info.Skipped = make([]Skipped, 0)
+		test.conf.Check("p", fset, []*ast.File{f}, info)
+		var got []string
+		for _, s := range info.Skipped {
+			name := "-"
+			if s.Obj != nil {
+				name = s.Obj.Name()
+			}
+			pos := fset.Position(s.Pos)
+			got = append(got, fmt.Sprintf("%s %d:%d %s", name, pos.Line, pos.Column, s.Reason))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s:\ngot  %q\nwant %q", test.src, got, test.want)
+		}
+	}
+}
+
+func TestForwardCompatible(t *testing.T) {
+	// the reported uses are tested in testdata/check/forwardcompat.go2
+	const src = `package p; var c = 0b101`
+	conf := Config{GoVersion: "go1.12", ForwardCompatible: true}
+	errs := checkErrors(t, src, conf)
+	if len(errs) != 1 || errs[0].Severity != SeverityWarning || errs[0].RequiresVersion != "go1.13" {
+		t.Errorf("got errors %v, want one warning requiring go1.13", errs)
+	}
+
+	// Without ForwardCompatible, there are errors.
+	conf.ForwardCompatible = false
+	errs = checkErrors(t, src, conf)
+	if len(errs) != 1 || errs[0].Severity == SeverityWarning || errs[0].RequiresVersion != "" {
+		t.Errorf("got errors %v, want one error without RequiresVersion", errs)
+	}
+}
+
+func TestTypeArgCountRecovery(t *testing.T) {
+	const src = genericPkg + `p
+
+type Pair[K comparable, V any] struct{ Key K; Val V }
+type Num[A any, B interface{ ~int }] struct{ a A; b B }
+
+func f[A, B any](A) B { panic(0) }
+
+var p Pair[string]
+var n Num[string]
+var key = p.Key
+var val = p.Val.foo // no follow-on error for the missing V
+var b = n.b + 1
+
+var s = f[int, string, bool](0)
+var h = f[int]
+var _ = h(0) + 1 // no follow-on error for the missing B
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, modeForSource(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []string
+	conf := Config{Error: func(err error) { errs = append(errs, err.(Error).Msg) }}
+	pkg, _ := conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+
+	want := []string{
+		"got 1 arguments but 2 type parameters",
+		"got 1 arguments but 2 type parameters",
+		"got 3 type arguments but want 2",
+		"cannot infer B",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got errors %q, want %q", errs, want)
+	}
+	for i, msg := range errs {
+		if !strings.HasPrefix(msg, want[i]) {
+			t.Errorf("got error %q, want %q", msg, want[i])
+		}
+	}
+
+	for name, want := range map[string]string{
+		"key": "string",
+		"b":   "int",
+		"s":   "string",
+		"h":   "func(int) invalid type",
+	} {
+		if got := pkg.Scope().Lookup(name).Type().String(); got != want {
+			t.Errorf("type of %s = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	const src = genericPkg + `p
+
+func f[P any](P) {}
+
+var _ = f[int]
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, modeForSource(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// with a Warning handler
+	var errs, warnings []Error
+	conf := Config{
+		GoVersion: "go1.17",
+		Error:     func(err error) { errs = append(errs, err.(Error)) },
+		Warning:   func(err error) { warnings = append(warnings, err.(Error)) },
+	}
+	if _, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("Check returned error %v, want none", err)
+	}
+	if len(errs) != 0 {
+		t.Errorf("got errors %v, want none", errs)
+	}
+	want := []string{
+		"type parameters require go1.18 or later",
+		"function instantiation requires go1.18 or later",
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("got warnings %v, want %q", warnings, want)
+	}
+	for i, w := range warnings {
+		if w.Msg != want[i] || w.Severity != SeverityWarning || !w.Soft {
+			t.Errorf("got warning %q (severity %d, soft %v), want soft warning %q", w.Msg, w.Severity, w.Soft, want[i])
+		}
+	}
+
+	// without a Warning handler, warnings are reported as errors
+	errs = nil
+	conf.Warning = nil
+	if _, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, nil); err == nil {
+		t.Error("Check returned no error")
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got errors %v, want %q", errs, want)
+	}
+	for _, err := range errs {
+		if err.Severity != SeverityWarning {
+			t.Errorf("got error %q with severity %d, want %d", err.Msg, err.Severity, SeverityWarning)
+		}
+	}
+}
+
+func TestUnusedCheck(t *testing.T) {
+	const src = `package p
+
+import "fmt"
+
+func _() {
+	var x int
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		vars, imports    UnusedCheck
+		errors, warnings int
+	}{
+		{UnusedAsError, UnusedAsError, 2, 0},
+		{UnusedAsWarning, UnusedAsError, 1, 1},
+		{UnusedAsError, UnusedAsWarning, 1, 1},
+		{UnusedAsWarning, UnusedAsWarning, 0, 2},
+		{UnusedIgnored, UnusedAsWarning, 0, 1},
+		{UnusedIgnored, UnusedIgnored, 0, 0},
+	} {
+		var errs, warnings []error
+		conf := Config{
+			Importer:      importer.Default(),
+			UnusedVars:    test.vars,
+			UnusedImports: test.imports,
+			Error:         func(err error) { errs = append(errs, err) },
+			Warning:       func(err error) { warnings = append(warnings, err) },
+		}
+		conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+		if len(errs) != test.errors || len(warnings) != test.warnings {
+			t.Errorf("UnusedVars = %d, UnusedImports = %d: got errors %v and warnings %v, want %d errors and %d warnings",
+				test.vars, test.imports, errs, warnings, test.errors, test.warnings)
+		}
+	}
+}
+
+func TestMessageRenderer(t *testing.T) {
+	const src = `package p
+
+func _() {
+	var x int
+	_ = -"foo"
+}
+`
+	var operands []MessageOperand
+	render := func(m Message) string {
+		if m.Format == "%s declared but not used" {
+			return fmt.Sprintf("%s wird nicht verwendet", m.Args[0])
+		}
+		for _, arg := range m.Args {
+			if x, ok := arg.(MessageOperand); ok {
+				operands = append(operands, x)
+			}
+		}
+		return "" // use default
+	}
+	errs := checkErrors(t, src, Config{MessageRenderer: render})
+
+	want := []string{
+		"invalid operation: operator - not defined for \"foo\" (untyped string constant)",
+		"x wird nicht verwendet",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got errors %v, want %q", errs, want)
+	}
+	for i, err := range errs {
+		if err.Msg != want[i] {
+			t.Errorf("got error %q, want %q", err.Msg, want[i])
+		}
+	}
+
+	if len(operands) != 1 || ExprString(operands[0].Expr) != `"foo"` || !operands[0].IsValue() || operands[0].Type != Typ[UntypedString] {
+		t.Errorf("got message operands %v, want the untyped string constant \"foo\"", operands)
+	}
+}
+
+func TestErrorInstantiations(t *testing.T) {
+	const src = genericPkg + `p
+
+func f[P comparable]() {}
+
+var _ = f[func()]
+
+type T[P any] struct{ f P }
+type S struct{ t T[S] }
+`
+	errs := checkErrors(t, src, Config{})
+	if len(errs) < 2 {
+		t.Fatalf("got errors %v, want at least 2", errs)
+	}
+
+	// constraint satisfaction failure
+	insts := errs[0].Instantiations
+	if len(insts) != 1 {
+		t.Fatalf("got instantiations %v for error %q, want 1", insts, errs[0].Msg)
+	}
+	if sig, _ := insts[0].Orig.(*Signature); sig == nil || sig.TypeParams().Len() != 1 || insts[0].TypeArgs.Len() != 1 || insts[0].TypeArgs.At(0).String() != "func()" {
+		t.Errorf("got instantiation of %v with %v, want f[func()]", insts[0].Orig, insts[0].TypeArgs)
+	}
+	if got := FormatError(errs[0], nil); !strings.Contains(got, "\tin instantiation of func[P comparable]() with [func()] at p.go:5:9\n") {
+		t.Errorf("FormatError does not mention the instantiation:\n%s", got)
+	}
+
+	// invalid recursive type via an instance
+	var cycle *Error
+	for i := range errs {
+		if strings.Contains(errs[i].Msg, "illegal cycle") {
+			cycle = &errs[i]
+			break
+		}
+	}
+	if cycle == nil {
+		t.Fatalf("got errors %v, want illegal cycle error", errs)
+	}
+	if len(cycle.Instantiations) != 1 || cycle.Instantiations[0].Orig.(*Named).Obj().Name() != "T" || cycle.Instantiations[0].TypeArgs.At(0).String() != "generic_p.S" {
+		t.Errorf("got instantiations %v, want T[S]", cycle.Instantiations)
+	}
+}
+
+func TestGenericMethods(t *testing.T) {
+	const src = genericPkg + `p
+
+type T struct{}
+
+func (T) Map[P any](x P) []P { return []P{x} }
+
+type List[E any] struct{ e E }
+
+func (l List[E]) Pair[F any](f F) (E, F) { return l.e, f }
+
+type I interface{ Map[Q any](Q) []Q }
+
+var _ I = T{}
+
+var a = T{}.Map(1)
+var b = T{}.Map[string]("")
+var c, d = List[int]{}.Pair("x")
+
+var x1 = T{}.Map(1.0)
+var x2 = T{}.Map(2.0)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, modeForSource(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without the experiment, generic methods are rejected
+	errs := checkErrors(t, src, Config{})
+	if len(errs) == 0 || errs[0].Msg != "methods cannot have type parameters" {
+		t.Errorf("got errors %v, want methods cannot have type parameters", errs)
+	}
+
+	inferred := make(map[ast.Expr]Inferred)
+	conf := Config{
+		Environment: NewEnvironment(),
+		Experiments: Experiments{GenericMethods: true},
+	}
+	pkg, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, &Info{Inferred: inferred})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"a": "[]int",
+		"b": "[]string",
+		"c": "int",
+		"d": "string",
+	} {
+		if got := pkg.Scope().Lookup(name).Type().String(); got != want {
+			t.Errorf("type of %s = %s, want %s", name, got, want)
+		}
+	}
+
+	// identical instantiations share their signature
+	var sigs []*Signature
+	for call, inf := range inferred {
+		if strings.Contains(ExprString(call), "Map(1.0)") || strings.Contains(ExprString(call), "Map(2.0)") {
+			sigs = append(sigs, inf.Sig)
+		}
+	}
+	if len(sigs) != 2 || sigs[0] != sigs[1] {
+		t.Errorf("got signatures %v, want two identical signatures", sigs)
+	}
+
+	// method type parameter constraints must not depend on receiver type parameters
+	const src2 = genericPkg + `p
+type List[E any] struct{}
+func (List[E]) m[P interface{ ~[]E }]() {}
+`
+	errs = checkErrors(t, src2, Config{Experiments: Experiments{GenericMethods: true}})
+	if len(errs) != 1 || !strings.Contains(errs[0].Msg, "must not refer to receiver type parameters") {
+		t.Errorf("got errors %v, want error about receiver type parameters", errs)
+	}
+}
+
+func TestMethodUnions(t *testing.T) {
+	const src = genericPkg + `p
+
+type Stringer interface{ String() string }
+
+type S struct{}
+
+func (S) String() string { return "" }
+
+type Number interface{ ~int | ~float64 }
+
+type C interface{ Stringer | Number }
+
+func f[P C](x P) {}
+
+func g[P interface{ Stringer | ~int; String() string }](x P) string { return x.String() }
+
+type MyInt int
+
+func (MyInt) String() string { return "" }
+
+var _ = f[S]
+var _ = f[int]
+var _ = f[float64]
+var _ = g[MyInt]
+var _ = f[string /* ERROR string does not satisfy */ ]
+var _ = f[*S]
+var _ = g[int /* ERROR missing method String */ ]
+`
+	// without the experiment, interfaces with methods are rejected in unions
+	errs := checkErrors(t, src, Config{})
+	if len(errs) == 0 || !strings.Contains(errs[0].Msg, "interface contains methods") {
+		t.Errorf("got errors %v, want interface contains methods", errs)
+	}
+
+	errs = checkErrors(t, src, Config{Experiments: Experiments{MethodUnions: true}})
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Msg)
+	}
+	want := []string{
+		"string does not satisfy C (string is not in Stringer|Number)",
+		"int does not satisfy interface{String() string; Stringer|~int} (missing method String)",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got errors %q, want %q", got, want)
+	}
+	for i := range want {
+		if !strings.Contains(got[i], want[i]) {
+			t.Errorf("error %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	// comparable remains excluded
+	errs = checkErrors(t, genericPkg+`p; type _ interface{ comparable | int }`, Config{Experiments: Experiments{MethodUnions: true}})
+	if len(errs) == 0 {
+		t.Errorf("comparable in union: no error reported")
+	}
+}
+
+func TestComparableVersion(t *testing.T) {
+	const src = genericPkg + `p
+
+func f[P comparable]() {}
+
+type S struct{ x interface{} }
+
+var _ = f[int]
+var _ = f[[2]int]
+var _ = f[interface{}]
+var _ = f[S]
+`
+	for _, test := range []struct {
+		goVersion string
+		want      []string
+	}{
+		{"go1.19", []string{
+			"interface{} does not satisfy comparable (interface{} is not strictly comparable; requires go1.20 or later)",
+			"S does not satisfy comparable (S is not strictly comparable; requires go1.20 or later)",
+		}},
+		{"go1.20", nil},
+		{"", nil},
+	} {
+		var got []string
+		for _, err := range checkErrors(t, src, Config{GoVersion: test.goVersion}) {
+			got = append(got, err.Msg)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("GoVersion %q: got errors %q, want %q", test.goVersion, got, test.want)
+		}
+	}
+}
+
+func TestRangeOverIntFunc(t *testing.T) {
+	const src = `package p
+
+type N int8
+
+func _(n N, seq func(func(string) bool), seq2 func(func(int, []byte) bool), seq0 func(func() bool)) {
+	for i := range n { _ = i }
+	for j := range 10 { _ = j }
+	for s := range seq { _ = s }
+	for k, v := range seq2 { _, _ = k, v }
+	for range seq0 {}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{Defs: make(map[*ast.Ident]Object)}
+	if _, err := (&Config{}).Check("p", fset, []*ast.File{f}, &info); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"i": "p.N",
+		"j": "int",
+		"s": "string",
+		"k": "int",
+		"v": "[]byte",
+	}
+	for id, obj := range info.Defs {
+		if w, ok := want[id.Name]; ok {
+			if got := obj.Type().String(); got != w {
+				t.Errorf("type of %s = %s, want %s", id.Name, got, w)
+			}
+			delete(want, id.Name)
+		}
+	}
+	for name := range want {
+		t.Errorf("%s not defined", name)
+	}
+
+	for _, test := range []struct {
+		src, goVersion, want string
+	}{
+		{"func _() { for range 10 {} }", "go1.21", "range over 10 (untyped int constant) requires go1.22 or later"},
+		{"func _(f func(func() bool)) { for range f {} }", "go1.22", "range over f (variable of type func(func() bool)) requires go1.23 or later"},
+		{"func _(f func(func() bool)) { for x := range f {} }", "", "range over f (variable of type func(func() bool)) permits no iteration variables"},
+		{"func _(f func(func(int) bool)) { for _, _ = range f {} }", "", "range over f (variable of type func(func(int) bool)) permits only one iteration variable"},
+		{"func _(f func(func(int))) { for range f {} }", "", "cannot range over f (variable of type func(func(int))): func must be func(yield func(...) bool): yield func does not return bool"},
+		{"func _(f func() bool) { for range f {} }", "", "cannot range over f (variable of type func() bool): func must be func(yield func(...) bool): wrong argument count"},
+	} {
+		errs := checkErrors(t, "package p; "+test.src, Config{GoVersion: test.goVersion})
+		if len(errs) == 0 || errs[0].Msg != test.want {
+			t.Errorf("%s: got errors %v, want %q", test.src, errs, test.want)
+		}
+	}
+}
+
+func TestVariadicTypeParams(t *testing.T) {
+	// language rules are tested in testdata/check/vartparams*.go2
+	const src = genericPkg + `p
+
+func F[Ts ...any](args ...Ts) {}
+
+func _() { F(1, "foo", 2.0) }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := Config{Experiments: Experiments{VariadicTypeParams: true}}
+	inferred := make(map[ast.Expr]Inferred)
+	pkg, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, &Info{Inferred: inferred})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	F := pkg.Scope().Lookup("F").Type().(*Signature)
+	if tpar := F.TypeParams().At(0); !tpar.Variadic() {
+		t.Errorf("%s is not variadic", tpar)
+	}
+	if got := F.String(); !strings.Contains(got, " ...interface{}](args ...") {
+		t.Errorf("F.String() = %s, want variadic type parameter list", got)
+	}
+
+	var calls []string
+	for _, inf := range inferred {
+		calls = append(calls, inf.Sig.String())
+		if targs := inf.TArgs; targs.Len() != 1 || targs.At(0).String() != "(int, string, float64)" {
+			t.Errorf("inferred type arguments %v, want a tuple (int, string, float64)", targs)
+		}
+	}
+	if want := []string{"func(args int, args string, args float64)"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("inferred signatures = %v, want %v", calls, want)
+	}
+}
+
+func TestEnableAlias(t *testing.T) {
+	const src = `package p
+
+type T struct{ f int }
+
+type (
+	A = T
+	B = A
+	P = *A
+)
+
+func (A) m() {}
+
+var (
+	a A
+	b B
+	ptr P
+	_ = a.f + b.f + ptr.f
+	_ T = b
+)
+
+func _() {
+	type L = []B
+	var l L
+	_ = l[0].m
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, enable := range []bool{false, true} {
+		info := Info{Defs: make(map[*ast.Ident]Object)}
+		conf := Config{EnableAlias: enable}
+		if _, err := conf.Check("p", fset, []*ast.File{f}, &info); err != nil {
+			t.Fatal(err)
+		}
+
+		want := map[string]string{
+			"a":   "p.T",
+			"b":   "p.T",
+			"ptr": "*p.T",
+			"l":   "[]p.T",
+		}
+		if enable {
+			want = map[string]string{
+				"a":   "p.A",
+				"b":   "p.B",
+				"ptr": "p.P",
+				"l":   "p.L",
+			}
+		}
+		objs := make(map[string]Object)
+		for id, obj := range info.Defs {
+			if id.Name != "_" {
+				objs[id.Name] = obj
+			}
+		}
+		for name, w := range want {
+			obj := objs[name]
+			if obj == nil {
+				t.Errorf("EnableAlias = %v: %s not defined", enable, name)
+				continue
+			}
+			if got := obj.Type().String(); got != w {
+				t.Errorf("EnableAlias = %v: type of %s = %s, want %s", enable, name, got, w)
+			}
+		}
+
+		B := objs["B"].(*TypeName)
+		if !B.IsAlias() {
+			t.Errorf("EnableAlias = %v: B is not an alias", enable)
+		}
+		alias, _ := B.Type().(*Alias)
+		if enable != (alias != nil) {
+			t.Errorf("EnableAlias = %v: type of B is %T", enable, B.Type())
+		}
+		if alias != nil {
+			if alias.Obj() != B {
+				t.Errorf("B.Type().Obj() = %v, want %v", alias.Obj(), B)
+			}
+			T := objs["T"].Type()
+			if got := Unalias(alias); got != T {
+				t.Errorf("Unalias(B) = %s, want %s", got, T)
+			}
+			if !Identical(alias, T) || alias.Underlying() != T.Underlying() {
+				t.Errorf("B is not identical to T")
+			}
+			if got, want := ObjectString(B, nil), "type p.B = p.A"; got != want {
+				t.Errorf("ObjectString(B) = %s, want %s", got, want)
+			}
+		}
+	}
+}
+
+// Large unions with alias elements are normalized like those with the
+// aliased types.
+func TestEnableAliasLargeUnion(t *testing.T) {
+	var terms, defs strings.Builder
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&terms, " | E%d", i)
+		fmt.Fprintf(&defs, "type E%d int\n", i)
+	}
+	src := genericPkg + "p\n\ntype A = int\n" + defs.String() +
+		"type I1 interface{ []A" + terms.String() + " }\n" +
+		"type I2 interface{ []int" + terms.String() + " }\n"
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := Config{EnableAlias: true}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	I1 := pkg.Scope().Lookup("I1").Type().Underlying()
+	I2 := pkg.Scope().Lookup("I2").Type().Underlying()
+	if !Identical(I1, I2) {
+		t.Errorf("%s and %s are not identical", I1, I2)
+	}
+}
+
+func TestDiffInterfaces(t *testing.T) {
+	const src = `package p
+
+type R interface{ Read([]byte) (int, error) }
+
+type A interface {
+	R
+	Close() error
+	Len() int
+	a()
+}
+
+type B interface {
+	Close() error
+	Len() int64
+	Read(p []byte) (n int, err error)
+	Write([]byte) (int, error)
+}
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	A := pkg.Scope().Lookup("A").Type().Underlying().(*Interface)
+	B := pkg.Scope().Lookup("B").Type().Underlying().(*Interface)
+
+	names := func(list []*Func) (res []string) {
+		for _, m := range list {
+			res = append(res, m.Name())
+		}
+		return
+	}
+
+	onlyA, onlyB, mismatched := DiffInterfaces(A, B)
+	if got, want := names(onlyA), []string{"a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("only in A: got %v, want %v", got, want)
+	}
+	if got, want := names(onlyB), []string{"Write"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("only in B: got %v, want %v", got, want)
+	}
+	if len(mismatched) != 1 || mismatched[0][0].Name() != "Len" || mismatched[0][0] != A.Method(1) || mismatched[0][1] != B.Method(1) {
+		t.Errorf("mismatched: got %v, want Len of A and B", mismatched)
+	}
+
+	// The difference of an interface with itself is empty.
+	if onlyA, onlyB, mismatched := DiffInterfaces(A, A); onlyA != nil || onlyB != nil || mismatched != nil {
+		t.Errorf("DiffInterfaces(A, A) = %v, %v, %v; want nothing", onlyA, onlyB, mismatched)
+	}
+}
+
+func TestInterfaceMethods(t *testing.T) {
+	const src = genericPkg + `p
+
+type Reader interface{ Read([]byte) (int, error) }
+type Closer interface{ Close() error }
+type ReadCloser interface {
+	Reader
+	Closer
+}
+
+type I interface {
+	ReadCloser
+	Reader
+	interface{ Len() int }
+	Reset()
+}
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	I := pkg.Scope().Lookup("I").Type().Underlying().(*Interface)
+
+	var got []string
+	for i, m := range InterfaceMethods(I) {
+		if m.Func != I.Method(i) {
+			t.Errorf("method %d: got %s, want %s", i, m.Func.Name(), I.Method(i).Name())
+		}
+		var via []string
+		for _, typ := range m.Via {
+			via = append(via, TypeString(typ, RelativeTo(pkg)))
+		}
+		got = append(got, fmt.Sprintf("%s %d [%s]", m.Func.Name(), m.Depth(), strings.Join(via, " ")))
+	}
+	want := []string{
+		"Close 2 [ReadCloser Closer]",
+		"Len 1 [interface{Len() int}]",
+		"Read 1 [Reader]",
+		"Reset 0 []",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestImplementers(t *testing.T) {
+	fset := token.NewFileSet()
+	imports := make(testImporter)
+	conf := Config{Importer: imports}
+	makePkg := func(path, src string) *Package {
+		f, err := parser.ParseFile(fset, path+".go", src, modeForSource(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg, err := conf.Check(path, fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		imports[path] = pkg
+		return pkg
+	}
+
+	lib := makePkg("lib", `package lib
+
+type Stringer interface{ String() string }
+
+type V int
+func (V) String() string { return "" }
+
+type P struct{}
+func (*P) String() string { return "" }
+
+type Other struct{}
+func (Other) Len() int { return 0 }
+`)
+	main := makePkg("generic_main", `package generic_main
+
+import "lib"
+
+type E struct{ lib.V }
+
+type I interface {
+	lib.Stringer
+	Len() int
+}
+
+type G[T any] struct{}
+func (G[T]) String() string { return "" }
+
+type W struct{ *lib.P }
+`)
+
+	index := NewImplementerIndex([]*Package{lib, main})
+	describe := func(list []Implementer) (res []string) {
+		for _, impl := range list {
+			s := impl.Type.String()
+			if impl.Ptr {
+				s = "*" + s
+			}
+			res = append(res, s)
+		}
+		return
+	}
+
+	stringer := lib.Scope().Lookup("Stringer").Type().Underlying().(*Interface)
+	want := []string{"*lib.P", "lib.Stringer", "lib.V", "generic_main.E", "generic_main.I", "generic_main.W"}
+	if got := describe(index.Implementers(stringer)); !reflect.DeepEqual(got, want) {
+		t.Errorf("implementers of Stringer: got %v, want %v", got, want)
+	}
+
+	I := main.Scope().Lookup("I").Type().Underlying().(*Interface)
+	want = []string{"generic_main.I"}
+	if got := describe(index.Implementers(I)); !reflect.DeepEqual(got, want) {
+		t.Errorf("implementers of I: got %v, want %v", got, want)
+	}
+
+	if got := index.Implementers(NewInterfaceType([]*Func{NewFunc(token.NoPos, nil, "Missing", NewSignature(nil, nil, nil, false))}, nil).Complete()); got != nil {
+		t.Errorf("implementers of interface{Missing()}: got %v, want none", describe(got))
+	}
+	if got := len(index.Implementers(NewInterfaceType(nil, nil).Complete())); got != 7 {
+		t.Errorf("got %d implementers of the empty interface, want 7", got)
+	}
+}
+
+func TestAPIFingerprint(t *testing.T) {
+	const base = `package generic_p
+
+import "strings"
+
+type T[P interface{ ~int | ~string }] struct {
+	X P ` + "`json:\"x\"`" + `
+	y *u
+}
+
+type u struct{ b strings.Builder }
+
+func (T[P]) M(...P) (int, error) { return 0, nil }
+func (*T[_]) m()                  {}
+
+type I interface {
+	M(...int) (int, error)
+	error
+}
+
+type A = map[string]T[int]
+
+const C = 1 << 70
+
+var V <-chan func(A) []I
+
+func F[P any](x P) P { return x }
+
+func unexported() int { return 0 }
+`
+	fingerprint := func(src string) [16]byte {
+		pkg, err := pkgFor("p.go", src, nil)
+		if err != nil {
+			t.Fatalf("%s\n%s", err, src)
+		}
+		return APIFingerprint(pkg)
+	}
+	want := fingerprint(base)
+
+	for _, test := range []struct {
+		old, new string
+		same     bool
+	}{
+		// positions, bodies, and unexported objects not in the API
+		{"package generic_p\n", "package generic_p\n\n\n", true},
+		{"return x }", "var y P = x; return y }", true},
+		{"func unexported() int { return 0 }", "func unexported() string { return \"\" }", true},
+		{"func unexported() int { return 0 }", "var unexported, other int", true},
+		{"func F[P any](x P) P { return x }", "func F[Q any](y Q) Q { return y }", true},
+
+		// the API and the unexported objects it refers to
+		{"const C = 1 << 70", "const C = 1 << 71", false},
+		{"const C = 1 << 70", "const C = float64(1 << 70)", false},
+		{"X P", "X2 P", false},
+		{"`json:\"x\"`", "`json:\"y\"`", false},
+		{"b strings.Builder", "b strings.Reader", false},
+		{"func (*T[_]) m()", "func (T[_]) m()", false},
+		{"M(...P)", "M([]P)", false},
+		{"\terror", "\tinterface{ Error() int }", false},
+		{"~int | ~string", "int | ~string", false},
+		{"map[string]T[int]", "map[string]T[string]", false},
+		{"<-chan", "chan", false},
+		{"func F[P any]", "func F[P comparable]", false},
+		{"func unexported", "func Exported", false},
+	} {
+		src := strings.Replace(base, test.old, test.new, 1)
+		if src == base {
+			t.Fatalf("%q not found in source", test.old)
+		}
+		if got := fingerprint(src); (got == want) != test.same {
+			t.Errorf("replacing %q with %q: fingerprint changed = %v, want %v", test.old, test.new, got != want, !test.same)
+		}
+	}
+}
+
+func TestAPIDiff(t *testing.T) {
+	const old = `package generic_p
+
+type T struct {
+	A, B int
+	C    struct{ X int }
+	u    int
+}
+
+func (T) M(int)   {}
+func (T) N()      {}
+func (*T) P()     {}
+func (T) Q() bool { return false }
+
+type I interface{ M() }
+type J interface {
+	M()
+	m()
+}
+type K interface{ ~int }
+
+type S struct{ A int }
+type U struct{ A int }
+
+type G[P any] []P
+type A = T
+
+const C, D = 1, 2
+var V, W int
+
+func F(int) string { return "" }
+func H()           {}
+func unexported()  {}
+`
+	const new = `package generic_p
+
+type T struct {
+	A    int
+	C    struct{ X int }
+	D    string
+	v    bool
+}
+
+func (T) M(int)    {}
+func (T) N(...int) {}
+func (T) P()       {}
+func (*T) Q() bool { return false }
+func (T) R()       {}
+
+type I interface{ M(); N() }
+type J interface {
+	M()
+	N()
+	m()
+}
+type K interface{ ~int | ~string }
+
+type S struct {
+	A int
+	F []int
+}
+type U struct {
+	A int
+	f func()
+}
+
+type G[P comparable] []P
+type A = I
+
+const C, D = 1, 3.0
+var V, W = 0, ""
+
+func F(int) string { return "" }
+func H2()          {}
+func unexported(string) {}
+`
+	oldPkg, err := pkgFor("p.go", old, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPkg, err := pkgFor("p.go", new, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, c := range APIDiff(oldPkg, newPkg) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"A: type changed from T to I (incompatible)",
+		"D: type changed from untyped int to untyped float (incompatible)",
+		"G: constraint of type parameter P changed from interface{} to comparable (incompatible)",
+		"H: removed (incompatible)",
+		"H2: added (compatible)",
+		"I.N: added (incompatible)",
+		"J.N: added (compatible)",
+		"K: underlying type changed from interface{~int} to interface{~int|~string} (incompatible)",
+		"S.F: added, making S incomparable (incompatible)",
+		"T.B: removed (incompatible)",
+		"T.D: added (compatible)",
+		"T.N: signature changed from func() to func(...int) (incompatible)",
+		"T.Q: receiver changed from value to pointer (incompatible)",
+		"T.R: added (compatible)",
+		"U: no longer comparable (incompatible)",
+		"W: type changed from int to string (incompatible)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got changes\n\t%s\nwant\n\t%s", strings.Join(got, "\n\t"), strings.Join(want, "\n\t"))
+	}
+
+	if changes := APIDiff(oldPkg, oldPkg); len(changes) != 0 {
+		t.Errorf("got changes %v comparing package with itself", changes)
+	}
+}
+
+type testFact struct{ N int }
+
+func (*testFact) AFact() {}
+
+type otherFact struct{ S string }
+
+func (*otherFact) AFact() {}
+
+func TestFacts(t *testing.T) {
+	pkg, err := pkgFor("p.go", "package p; type T int; func (T) M() {}; var V T", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	T := pkg.Scope().Lookup("T")
+	V := pkg.Scope().Lookup("V")
+
+	pkg.SetFact(nil, &testFact{1})
+	pkg.SetFact(V, &testFact{2})
+	pkg.SetFact(T, &otherFact{"t"})
+	pkg.SetFact(T, &testFact{3})
+	pkg.SetFact(T, &testFact{4}) // replaces the previous fact
+
+	var f testFact
+	for _, test := range []struct {
+		obj  Object
+		want int
+	}{{nil, 1}, {V, 2}, {T, 4}} {
+		if !pkg.Fact(test.obj, &f) || f.N != test.want {
+			t.Errorf("Fact(%v) = %v, %v; want %d", test.obj, f.N, pkg.Fact(test.obj, &f), test.want)
+		}
+	}
+	if pkg.Fact(V, new(otherFact)) {
+		t.Errorf("Fact(V) found an otherFact")
+	}
+
+	var got []string
+	for _, f := range pkg.Facts() {
+		name := "<package>"
+		if f.Object != nil {
+			name = f.Object.Name()
+		}
+		got = append(got, fmt.Sprintf("%s: %T%v", name, f.Fact, f.Fact))
+	}
+	want := []string{
+		"<package>: *types_test.testFact&{1}",
+		"T: *types_test.otherFact&{t}",
+		"T: *types_test.testFact&{4}",
+		"V: *types_test.testFact&{2}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got facts\n\t%s\nwant\n\t%s", strings.Join(got, "\n\t"), strings.Join(want, "\n\t"))
+	}
+
+	// Facts may only be attached to objects of the package.
+	defer func() {
+		if recover() == nil {
+			t.Errorf("SetFact succeeded for object of another package")
+		}
+	}()
+	pkg.SetFact(Universe.Lookup("int"), &testFact{})
+}
+
+func TestCheckVariants(t *testing.T) {
+	const src = `
+package p
+
+import "unsafe"
+
+const _ = 1 / (unsafe.Sizeof(uintptr(0)) - 4) // division by zero on 32-bit platforms
+
+var _ = "abc"[0] + 'x'
+`
+	const src2 = `
+package p
+
+func f[P any](x P) P { return x }
+
+var _ = f(0)
+`
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for i, src := range []string{src, src2} {
+		f, err := parser.ParseFile(fset, fmt.Sprintf("p%d.go", i), src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	imp := &countingImporter{fallback: importer.Default()}
+	conf := Config{Importer: imp}
+	nogeneric := func(f *ast.File) bool { return fset.File(f.Pos()).Name() == "p0.go" }
+	variants := []BuildVariant{
+		{Name: "linux/amd64", Sizes: SizesFor("gc", "amd64")},
+		{Name: "linux/386", Sizes: SizesFor("gc", "386")},
+		{Name: "linux/arm", Sizes: SizesFor("gc", "arm"), GoVersion: "go1.17"},
+		{Name: "go1.17", GoVersion: "go1.17", Files: nogeneric},
+		{Name: "darwin/amd64", Sizes: SizesFor("gc", "amd64")},
+	}
+	want := [][]string{
+		nil,
+		{"p0.go:6:15: invalid operation: division by zero"},
+		{
+			"p1.go:4:8: type parameters require go1.18 or later",
+			"p0.go:6:15: invalid operation: division by zero",
+			"p1.go:6:10: implicit function instantiation requires go1.18 or later",
+		},
+		nil,
+		nil,
+	}
+
+	results := conf.CheckVariants("p", fset, files, variants)
+	for i, res := range results {
+		if res.Variant != &variants[i] {
+			t.Errorf("result %d has variant %s, want %s", i, res.Variant.Name, variants[i].Name)
+		}
+		if res.Package == nil {
+			t.Errorf("%s: no package", variants[i].Name)
+		}
+		var errs []string
+		for _, err := range res.Errors {
+			errs = append(errs, err.Error())
+		}
+		if !reflect.DeepEqual(errs, want[i]) {
+			t.Errorf("%s: got errors %q, want %q", variants[i].Name, errs, want[i])
+		}
+	}
+	// Packages are imported once per Sizes.
+	if imp.count != 4 {
+		t.Errorf("package unsafe imported %d times, want 4", imp.count)
+	}
+
+	// Importers accepting a context are called with it.
+	ctxImp := &contextImporter{fallback: importer.Default()}
+	conf = Config{Importer: ctxImp}
+	for _, res := range conf.CheckVariants("p", fset, files, variants[:1]) {
+		if len(res.Errors) > 0 {
+			t.Errorf("%s: unexpected errors %v", res.Variant.Name, res.Errors)
+		}
+	}
+	if ctxImp.count != 1 {
+		t.Errorf("ImportFromContext called %d times, want 1", ctxImp.count)
+	}
+}
+
+// A contextImporter counts the calls of its ImportFromContext method.
+type contextImporter struct {
+	count    int
+	fallback Importer
+}
+
+func (imp *contextImporter) Import(path string) (*Package, error) {
+	panic("Import called")
+}
+
+func (imp *contextImporter) ImportFrom(path, dir string, mode ImportMode) (*Package, error) {
+	panic("ImportFrom called")
+}
+
+func (imp *contextImporter) ImportFromContext(ctx context.Context, path, dir string, mode ImportMode) (*Package, error) {
+	imp.count++
+	return imp.fallback.Import(path)
+}
+
+// A countingImporter counts the calls of its Import method.
+type countingImporter struct {
+	count    int
+	fallback Importer
+}
+
+func (imp *countingImporter) Import(path string) (*Package, error) {
+	imp.count++
+	return imp.fallback.Import(path)
+}
+
+func TestClonePackage(t *testing.T) {
+	const src = `
+package p
+
+import "fmt"
+
+type T struct {
+	fmt.Stringer
+	next *T
+}
+
+func (t *T) M(x int) int { return x }
+
+type G[P interface{ ~int | ~string }] struct{ list []P }
+
+func (g G[P]) First() P { return g.list[0] }
+
+type I interface{ M(int) int }
+
+var V G[int]
+
+func F() { const local = 1 }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg.SetFact(pkg.Scope().Lookup("T"), &testFact{1})
+
+	clone := ClonePackage(pkg)
+	if clone == pkg || clone.Scope() == pkg.Scope() {
+		t.Fatal("ClonePackage returned the original package")
+	}
+	if clone.Path() != pkg.Path() || !clone.Complete() || len(clone.Imports()) != 1 || clone.Imports()[0] == pkg.Imports()[0] {
+		t.Errorf("clone has path %q, complete %v, imports %v", clone.Path(), clone.Complete(), clone.Imports())
+	}
+	for _, name := range pkg.Scope().Names() {
+		obj, cobj := pkg.Scope().Lookup(name), clone.Scope().Lookup(name)
+		if cobj == obj || cobj.Type() == obj.Type() {
+			t.Errorf("%s is shared with the clone", obj)
+		}
+		if got, want := ObjectString(cobj, nil), ObjectString(obj, nil); got != want {
+			t.Errorf("clone of %s is %s", want, got)
+		}
+		if cobj.Pos() != obj.Pos() || cobj.Pkg() != clone || cobj.Parent() != clone.Scope() {
+			t.Errorf("clone of %s has position %v, package %v, parent %p", obj, cobj.Pos(), cobj.Pkg(), cobj.Parent())
+		}
+	}
+
+	// The clone is usable like the original.
+	T := clone.Scope().Lookup("T").Type()
+	I := clone.Scope().Lookup("I").Type().Underlying().(*Interface)
+	if !Implements(NewPointer(T), I) {
+		t.Errorf("*T does not implement I in the clone")
+	}
+	V := clone.Scope().Lookup("V").Type().(*Named)
+	if V.Underlying() == pkg.Scope().Lookup("V").Type().Underlying() {
+		t.Errorf("underlying type of V is shared with the clone")
+	}
+	if got, want := V.TypeArgs().At(0), Typ[Int]; got != want {
+		t.Errorf("type argument of V is %s, want %s", got, want)
+	}
+	if m, _, _ := LookupFieldOrMethod(V, false, clone, "First"); m == nil || m.Pkg() != clone {
+		t.Errorf("method First of V not found in clone")
+	}
+	F := clone.Scope().Lookup("F").(*Func)
+	if local := F.Scope().Lookup("local"); local == nil || local == pkg.Scope().Lookup("F").(*Func).Scope().Lookup("local") {
+		t.Errorf("local constant of F not copied")
+	}
+
+	// Facts are copied, and the copies are independent.
+	var fact testFact
+	if !clone.Fact(T.(*Named).Obj(), &fact) || fact.N != 1 {
+		t.Errorf("fact of T not copied")
+	}
+	pkg.SetFact(nil, &testFact{2})
+	if clone.Fact(nil, &fact) {
+		t.Errorf("package fact set on the original is attached to the clone")
+	}
+}
+
+func TestCanonicalPackage(t *testing.T) {
+	const src = `package p
+
+type G[P any] struct{ p P }
+
+func (G[Q]) m() {}
+
+func F[P interface{ ~int }](x P) {
+	type L G[P]
+	var _ L
+}
+`
+	// describe returns a description of the objects in s and its children.
+	var describe func(s *Scope) string
+	describe = func(s *Scope) string {
+		var buf bytes.Buffer
+		for _, name := range s.Names() {
+			obj := s.Lookup(name)
+			fmt.Fprintf(&buf, "%s @%d\n", ObjectString(obj, nil), obj.Pos())
+			if named, _ := obj.Type().(*Named); named != nil && named.Obj() == obj {
+				for i := 0; i < named.NumMethods(); i++ {
+					fmt.Fprintf(&buf, "%s @%d\n", ObjectString(named.Method(i), nil), named.Method(i).Pos())
+				}
+			}
+		}
+		for i := 0; i < s.NumChildren(); i++ {
+			fmt.Fprintf(&buf, "{@%d\n%s}\n", s.Child(i).Pos(), describe(s.Child(i)))
+		}
+		return buf.String()
+	}
+
+	// Check the package at different positions, with a type parameter
+	// declared by a separate check in between.
+	var descs []string
+	for _, offset := range []int{0, 100} {
+		fset := token.NewFileSet()
+		fset.AddFile("other.go", -1, offset)
+		f, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg, err := new(Config).Check("p", fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		NewTypeParam(NewTypeName(token.NoPos, nil, "X", nil), nil)
+
+		canon := CanonicalPackage(pkg)
+		if canon == pkg || canon.Scope() == pkg.Scope() {
+			t.Fatal("CanonicalPackage returned the original package")
+		}
+		descs = append(descs, describe(canon.Scope()))
+	}
+	if descs[0] != descs[1] {
+		t.Errorf("canonical packages differ:\n%s\nand\n%s", descs[0], descs[1])
+	}
+	if strings.Contains(strings.ReplaceAll(descs[0], "@0", ""), "@") {
+		t.Errorf("canonical package has positions:\n%s", descs[0])
+	}
+	if want := "func p.F[p.P₃ interface{~int}](x p.P₃)"; !strings.Contains(descs[0], want) {
+		t.Errorf("canonical package does not contain %s:\n%s", want, descs[0])
+	}
+}
+
+// The type parameters of canonical packages have the same IDs, but are
+// distinct types, also for instantiation.
+func TestCanonicalPackageInstantiate(t *testing.T) {
+	pkg, err := pkgFor("p", genericPkg+"p; type H[T any] struct{}; func F[P any]() {}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	H := pkg.Scope().Lookup("H").Type()
+	env := NewEnvironment()
+	var insts []Type
+	for _, p := range []*Package{pkg, CanonicalPackage(pkg), CanonicalPackage(pkg)} {
+		P := p.Scope().Lookup("F").Type().(*Signature).TypeParams().At(0)
+		inst, err := Instantiate(env, H, []Type{P}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := inst.(*Named).TypeArgs().At(0); got != P {
+			t.Errorf("instance %s of package %p has type argument %p, want %p", inst, p, got, P)
+		}
+		insts = append(insts, inst)
+	}
+	if insts[0] == insts[1] || insts[1] == insts[2] {
+		t.Errorf("instances with distinct type arguments are shared")
+	}
+}
+
+func TestCheckTest(t *testing.T) {
+	const (
+		src = `package p
+
+func F() int { return 1 }
+`
+		testSrc = `package p
+
+// Helper is only declared by the test-augmented package.
+func Helper() int { return F() }
+`
+		xtestSrc = `package p_test
+
+import (
+	"fmt"
+	"p"
+)
+
+var _ = fmt.Sprint(p.Helper())
+`
+	)
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, src := range []string{src, testSrc, xtestSrc} {
+		f, err := parser.ParseFile(fset, "", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	conf := Config{Importer: importer.Default()}
+	info := &Info{Uses: make(map[*ast.Ident]Object)}
+	xinfo := &Info{Uses: make(map[*ast.Ident]Object)}
+	pkg, xpkg, err := conf.CheckTest("p", fset, files[:2], files[2:], info, xinfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := xpkg.Path(), "p_test"; got != want {
+		t.Errorf("external test package has path %q, want %q", got, want)
+	}
+	helper := pkg.Scope().Lookup("Helper")
+	var found bool
+	for id, obj := range xinfo.Uses {
+		if id.Name == "Helper" {
+			found = obj == helper
+		}
+	}
+	if !found {
+		t.Errorf("use of p.Helper in the external test package does not denote %v", helper)
+	}
+	for _, imp := range xpkg.Imports() {
+		if imp.Path() == "p" && imp != pkg {
+			t.Errorf("external test package imports %p, want the test-augmented package %p", imp, pkg)
+		}
+	}
+
+	// Without the in-package test files, Helper is not declared.
+	_, _, err = conf.CheckTest("p", fset, files[:1], files[2:], nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "Helper not declared by package p") {
+		t.Errorf("got error %v, want undeclared p.Helper", err)
+	}
+
+	// Importing a package that imports the package under test without its
+	// test files is an error.
+	p, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qf, err := parser.ParseFile(fset, "q.go", `package q; import "p"; var V = p.F()`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := (&Config{Importer: importHelper{pkg: p}}).Check("q", fset, []*ast.File{qf}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xf, err := parser.ParseFile(fset, "x_test.go", `package p_test; import "q"; var _ = q.V`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Importer = importHelper{pkg: q}
+	_, _, err = conf.CheckTest("p", fset, files[:2], []*ast.File{xf}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "package q imports p, which is not checked with its test files") {
+		t.Errorf("got error %v, want error for package q importing p", err)
+	}
+
+	// A TestImporter checks such packages again against the test-augmented
+	// package.
+	var qtest *Package
+	conf.Importer = testImporterFunc(func(path string, test *Package) (*Package, error) {
+		if path != "q" {
+			return nil, fmt.Errorf("unexpected import of %s", path)
+		}
+		qtest, err = (&Config{Importer: importHelper{pkg: test}}).Check("q", fset, []*ast.File{qf}, nil)
+		return qtest, err
+	})
+	pkg, _, err = conf.CheckTest("p", fset, files[:2], []*ast.File{xf}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imps := qtest.Imports(); len(imps) != 1 || imps[0] != pkg {
+		t.Errorf("package q imports %v, want the test-augmented package %p", imps, pkg)
+	}
+}
+
+// A testImporterFunc is a TestImporter for the dependencies of external
+// test packages only.
+type testImporterFunc func(path string, test *Package) (*Package, error)
+
+func (f testImporterFunc) Import(path string) (*Package, error) {
+	return nil, fmt.Errorf("unexpected import of %s", path)
+}
+
+func (f testImporterFunc) ImportForTest(path, dir string, mode ImportMode, test *Package) (*Package, error) {
+	return f(path, test)
+}
+
+func TestNewTypeNameLazy(t *testing.T) {
+	// Construct package a with a lazily resolved type
+	//
+	//	type T[P any] struct{ f P }
+	//	func (T[P]) M() P
+	a := NewPackage("a", "a")
+	var calls int
+	obj := NewTypeNameLazy(token.NoPos, a, "T", func(named *Named) ([]*TypeParam, Type, []*Func) {
+		calls++
+		if named.Obj().Name() != "T" {
+			t.Errorf("resolving %s, want T", named.Obj().Name())
+		}
+		P := NewTypeParam(NewTypeName(token.NoPos, a, "P", nil), NewInterfaceType(nil, nil))
+		rtarg := NewTypeParam(NewTypeName(token.NoPos, a, "P", nil), NewInterfaceType(nil, nil))
+		recv, _ := Instantiate(nil, named, []Type{rtarg}, false)
+		sig := NewSignatureType(NewVar(token.NoPos, a, "", recv), []*TypeParam{rtarg}, nil, nil, NewTuple(NewVar(token.NoPos, a, "", rtarg)), false)
+		under := NewStruct([]*Var{NewField(token.NoPos, a, "f", P, false)}, nil)
+		return []*TypeParam{P}, under, []*Func{NewFunc(token.NoPos, a, "M", sig)}
+	})
+	a.Scope().Insert(obj)
+	a.MarkComplete()
+	if calls != 0 {
+		t.Fatalf("constructing T resolved it")
+	}
+
+	const src = `
+package b
+
+import "a"
+
+var x a.T[int]
+var y = x.M()
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "b.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := Config{Importer: importHelper{pkg: a}}
+	b, err := conf.Check("b", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("T resolved %d times, want 1", calls)
+	}
+	if got := b.Scope().Lookup("y").Type(); got != Typ[Int] {
+		t.Errorf("type of y = %s, want int", got)
+	}
+	T := obj.Type().(*Named)
+	if T.TypeParams().Len() != 1 || T.NumMethods() != 1 || T.Method(0).Name() != "M" {
+		t.Errorf("resolved T has %d type parameters and %d methods", T.TypeParams().Len(), T.NumMethods())
+	}
+}
+
+func TestNewBuiltin(t *testing.T) {
+	// zero(T) T
+	zero := NewBuiltin("zero", 1, false, func(call *ast.CallExpr, args []TypeAndValue) (BuiltinResult, error) {
+		if !args[0].IsType() {
+			return BuiltinResult{}, fmt.Errorf("%s is not a type", ExprString(call.Args[0]))
+		}
+		return BuiltinResult{Result: args[0].Type}, nil
+	})
+	// name(T) untyped string constant
+	name := NewBuiltin("name", 1, false, func(call *ast.CallExpr, args []TypeAndValue) (BuiltinResult, error) {
+		if !args[0].IsType() {
+			return BuiltinResult{}, fmt.Errorf("%s is not a type", ExprString(call.Args[0]))
+		}
+		return BuiltinResult{Result: Typ[UntypedString], Value: constant.MakeString(args[0].Type.String())}, nil
+	})
+	// equal(x, y T, ...string), where T is the (default) type of x
+	equal := NewBuiltin("equal", 2, true, func(call *ast.CallExpr, args []TypeAndValue) (BuiltinResult, error) {
+		params := make([]Type, len(args))
+		params[0] = Default(args[0].Type)
+		params[1] = params[0]
+		for i := 2; i < len(params); i++ {
+			params[i] = Typ[String]
+		}
+		return BuiltinResult{Params: params}, nil
+	})
+	conf := Config{Predeclared: []Object{zero, name, equal}}
+
+	const src = `
+package p
+
+var x = zero(int)
+
+const n = name([]string)
+
+func f() {
+	equal(x, 1, "x", "ok")
+	equal(2, x)
+	equal(x, "s")
+	zero(x)
+	equal(x)
+	_ = equal(x, x)
+	equal(x, x...)
+	_ = name
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []string
+	conf.Error = func(err error) { errs = append(errs, err.Error()) }
+	info := &Info{Types: make(map[ast.Expr]TypeAndValue), Uses: make(map[*ast.Ident]Object)}
+	pkg, _ := conf.Check("p", fset, []*ast.File{f}, info)
+
+	want := []string{
+		`p.go:11:11: cannot use "s" (untyped string constant) as int value in argument to equal`,
+		`p.go:12:2: invalid call of zero: x is not a type`,
+		`p.go:13:9: invalid operation: not enough arguments for equal(x) (expected 2, found 1)`,
+		`p.go:14:6: equal(x, x) (no value) used as value`,
+		`p.go:15:12: invalid operation: invalid use of ... with built-in equal`,
+		`p.go:16:6: name (built-in) must be called`,
+	}
+	if strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got errors\n%s\nwant\n%s", strings.Join(errs, "\n"), strings.Join(want, "\n"))
+	}
+
+	if got := pkg.Scope().Lookup("x").Type(); got != Typ[Int] {
+		t.Errorf("type of x = %s, want int", got)
+	}
+	if got := pkg.Scope().Lookup("n").(*Const).Val(); constant.StringVal(got) != "[]string" {
+		t.Errorf("n = %s, want \"[]string\"", got)
+	}
+	found := false
+	for e, tv := range info.Types {
+		if id, _ := e.(*ast.Ident); id != nil && id.Name == "equal" && fset.Position(id.Pos()).Line == 9 {
+			found = true
+			if got, want := tv.Type.String(), "func(int, int, string, string)"; got != want || !tv.IsBuiltin() {
+				t.Errorf("type of equal = %s, want built-in %s", got, want)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("no type recorded for equal")
+	}
+	if info := equal.Info(); info.MinArgs != 2 || !info.Variadic || info.Params != nil {
+		t.Errorf("equal.Info() = %+v", info)
+	}
+	for id, obj := range info.Uses {
+		if id.Name == "zero" && obj != zero {
+			t.Errorf("zero denotes %v", obj)
+		}
+	}
+
+	// Custom built-ins cannot be declared in packages.
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("no panic inserting custom built-in in package scope")
+			}
+		}()
+		NewPackage("q", "q").Scope().Insert(zero)
+	}()
+}
+
+func TestMergeFiles(t *testing.T) {
+	const asrc = `package p
+
+var A int = B
+`
+	const bsrc = `package p
+
+// A generated declaration.
+//line gen.y:10
+var B int = "b"
+
+var C /*line gen.y:20:5*/ string = 0
+`
+	// Parse a and b with different file sets.
+	fset := token.NewFileSet()
+	a, err := parser.ParseFile(fset, "a.go", asrc, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bset := token.NewFileSet()
+	b, err := parser.ParseFile(bset, "b.go", bsrc, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(files ...*ast.File) []string {
+		var errs []string
+		conf := Config{Error: func(err error) { errs = append(errs, err.Error()) }}
+		conf.Check("p", fset, files, nil)
+		return errs
+	}
+
+	// The file b is not positioned in fset.
+	if errs := check(a, b); len(errs) == 0 || errs[0] != "-: syntax tree 1 (package p) is not positioned in the file set" {
+		t.Errorf("checking files of different file sets: got errors %q", errs)
+	}
+
+	if errs := check(a, a); len(errs) == 0 || errs[0] != "-: syntax trees 0 and 1 are positioned in the same file a.go" {
+		t.Errorf("checking a file twice: got errors %q", errs)
+	}
+
+	bpos := b.Package
+	files, err := MergeFiles(fset, bset, []*ast.File{b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2 := files[0]
+	if b.Package != bpos {
+		t.Errorf("MergeFiles modified b")
+	}
+
+	// The copy has the same positions as b, and shares no nodes with b.
+	var orig, copied []ast.Node
+	ast.Inspect(b, func(n ast.Node) bool { orig = append(orig, n); return true })
+	ast.Inspect(b2, func(n ast.Node) bool { copied = append(copied, n); return true })
+	if len(orig) != len(copied) {
+		t.Fatalf("copy of b has %d nodes, want %d", len(copied), len(orig))
+	}
+	for i, n := range orig {
+		if n == nil {
+			continue
+		}
+		if copied[i] == n {
+			t.Errorf("copy of b shares node %T", n)
+		}
+		for _, adjusted := range []bool{false, true} {
+			if got, want := fset.PositionFor(copied[i].Pos(), adjusted), bset.PositionFor(n.Pos(), adjusted); got != want {
+				t.Errorf("position of %T is %v in the copy, want %v", n, got, want)
+			}
+		}
+	}
+	if obj := b2.Scope.Lookup("B"); obj == nil || obj.Decl == b.Scope.Lookup("B").Decl {
+		t.Errorf("object B of the copy does not refer to the copied declaration")
+	}
+
+	want := []string{
+		`gen.y:10: cannot use "b" (untyped string constant) as int value in variable declaration`,
+		`gen.y:20:15: cannot use 0 (untyped int constant) as string value in variable declaration`,
+	}
+	if errs := check(a, b2); !reflect.DeepEqual(errs, want) {
+		t.Errorf("checking merged files: got errors\n%s\nwant\n%s", strings.Join(errs, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, err := MergeFiles(fset, token.NewFileSet(), []*ast.File{b}); err == nil {
+		t.Errorf("MergeFiles of a file not in the source file set succeeded")
+	}
+}
+
+func TestPredeclared(t *testing.T) {
+	float16 := NewTypeName(token.NoPos, nil, "float16", nil)
+	NewNamed(float16, Typ[Uint16], nil)
+	version := NewConst(token.NoPos, nil, "Version", Typ[UntypedString], constant.MakeString("dialect"))
+	assert := NewFunc(token.NoPos, nil, "assert", NewSignature(nil, NewTuple(NewVar(token.NoPos, nil, "cond", Typ[Bool])), nil, false))
+	conf := Config{Predeclared: []Object{float16, version, assert}}
+
+	const src = `
+package p
+
+var x float16 = 1
+
+func f() string {
+	assert(x > 0)
+	return Version
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &Info{Uses: make(map[*ast.Ident]Object)}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uses := make(map[Object]bool)
+	for _, obj := range info.Uses {
+		uses[obj] = true
+	}
+	for _, obj := range conf.Predeclared {
+		if !uses[obj] {
+			t.Errorf("predeclared %s not used", obj.Name())
+		}
+		if obj.Parent() != nil {
+			t.Errorf("predeclared %s has parent %p", obj.Name(), obj.Parent())
+		}
+	}
+	if got := pkg.Scope().Parent(); got.Parent() != Universe || got.Lookup("float16") != float16 {
+		t.Errorf("package scope has parent %s", got)
+	}
+	if got := pkg.Scope().Innermost(f.Decls[1].(*ast.FuncDecl).Body.Pos() + 1); got == nil || got.Parent() != pkg.Scope().Child(0) {
+		t.Errorf("Innermost(f) = %v, want the scope of f", got)
+	}
+
+	// Predeclared objects may be shadowed by package-level declarations.
+	f, err = parser.ParseFile(fset, "q.go", `package q; const Version = 2; var _ int = Version`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conf.Check("q", fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("shadowing predeclared Version: %v", err)
+	}
+}
+
+func TestMemoryBudget(t *testing.T) {
+	var src strings.Builder
+	src.WriteString("package p\n\n")
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&src, "var v%d = []int{%d, %d, %d}\n", i, i, i+1, i+2)
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src.String(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := Config{MemoryBudget: 1}
+	info := &Info{Types: make(map[ast.Expr]TypeAndValue)}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, info)
+	var berr *MemoryBudgetError
+	if !errors.As(err, &berr) {
+		t.Fatalf("got error %v, want a MemoryBudgetError", err)
+	}
+	if berr.Budget != 1 || berr.Used <= 1 {
+		t.Errorf("got budget %d, used %d", berr.Budget, berr.Used)
+	}
+	if pkg.Complete() {
+		t.Errorf("package complete after exceeding the budget")
+	}
+	if len(info.Types) == 0 {
+		t.Errorf("no partial results recorded")
+	}
+
+	// With a sufficient budget, the package is checked.
+	conf.MemoryBudget = 1 << 40
+	if _, err := conf.Check("p", fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("checking with large budget: %v", err)
+	}
+
+	// The budget error is reported, but doesn't replace the first error.
+	f, err = parser.ParseFile(fset, "q.go", "package p; var _ int = \"x\"\n"+src.String()[len("package p"):], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []error
+	conf = Config{MemoryBudget: 1, Error: func(err error) { errs = append(errs, err) }}
+	_, err = conf.Check("p", fset, []*ast.File{f}, nil)
+	if len(errs) != 2 || err == nil || errs[0].Error() != err.Error() || !errors.As(errs[1], &berr) {
+		t.Errorf("got errors %v, returned error %v; want a type error followed by a MemoryBudgetError", errs, err)
+	}
+}
+
+func TestTypeExpr(t *testing.T) {
+	const src = genericPkg + `p
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+type G[K comparable, V any] struct{ m map[K]V }
+
+type Number interface {
+	~int | ~float64
+}
+
+func F[P Number, Q fmt.Stringer](p P, qs ...Q) (P, error) { panic(0) }
+
+func H(p G[int, string], qs ...fmt.Stringer) (int, error) { panic(0) }
+
+var (
+	basic     byte
+	array     [4]rune
+	slice     []unsafe.Pointer
+	ptr       *fmt.State
+	strct     struct{ fmt.Stringer; x int ` + "`json:\"x\"`" + ` }
+	iface     interface{ M(x int) string; fmt.Stringer }
+	fun       func(int, ...string) (n int, err error)
+	mp        map[string]G[int, chan<- bool]
+	ch        chan (<-chan int)
+	inst      G[string, struct{}]
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, modeForSource(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qf := func(other *Package) string {
+		if other == pkg {
+			return ""
+		}
+		return other.Name()
+	}
+	for _, test := range []struct {
+		name, want string
+	}{
+		{"basic", "byte"},
+		{"array", "[4]rune"},
+		{"slice", "[]unsafe.Pointer"},
+		{"ptr", "*fmt.State"},
+		{"strct", "struct {\n\tfmt.Stringer\n\tx int `json:\"x\"`\n}"},
+		{"iface", "interface {\n\tM(x int) string\n\tfmt.Stringer\n}"},
+		{"fun", "func(int, ...string) (n int, err error)"},
+		{"mp", "map[string]G[int, chan<- bool]"},
+		{"ch", "chan (<-chan int)"},
+		{"inst", "G[string, struct {\n}]"}, // empty field lists without positions span lines
+		{"H", "func(p G[int, string], qs ...fmt.Stringer) (int, error)"},
+		{"Number", "interface {\n\t~int | ~float64\n}"},
+	} {
+		obj := pkg.Scope().Lookup(test.name)
+		typ := obj.Type()
+		if _, ok := obj.(*TypeName); ok {
+			typ = typ.Underlying()
+		}
+		x, err := TypeExpr(typ, qf)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), x); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	// Without a qualifier, packages are referred to by name.
+	x, _ := TypeExpr(pkg.Scope().Lookup("inst").Type(), nil)
+	if got, want := ExprString(x), pkg.Name()+".G[string, struct{}]"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	for _, typ := range []Type{Typ[UntypedInt], Typ[Invalid], NewTuple(), pkg.Scope().Lookup("F").Type()} {
+		if _, err := TypeExpr(typ, nil); err == nil {
+			t.Errorf("TypeExpr(%s) succeeded", typ)
+		}
+	}
+}
+
+func TestFileQualifier(t *testing.T) {
+	const src = `package p
+
+import (
+	"fmt"
+	str "strings"
+	. "bytes"
+	_ "sort"
+	xstr "strings"
+	"io"
+)
+
+type T struct{}
+
+var (
+	a fmt.Stringer
+	b *str.Builder
+	c Buffer
+	d T
+	e io.Writer
+	g = io.Pipe // returns *io.PipeReader
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &Info{Defs: make(map[*ast.Ident]Object), Implicits: make(map[ast.Node]Object)}
+	conf := Config{Importer: importer.Default(), Error: func(error) {}}
+	pkg, _ := conf.Check("p", fset, []*ast.File{f}, info)
+
+	qf := FileQualifier(f, pkg, info)
+	for name, want := range map[string]string{
+		"a": "fmt.Stringer",
+		"b": "*str.Builder",
+		"c": "Buffer",
+		"d": "T",
+		"e": "io.Writer",
+		"g": "func() (*io.PipeReader, *io.PipeWriter)",
+	} {
+		if got := TypeString(pkg.Scope().Lookup(name).Type(), qf); got != want {
+			t.Errorf("type of %s = %s, want %s", name, got, want)
+		}
+	}
+
+	// Packages not imported by the file are qualified by their path.
+	if got, want := qf(NewPackage("example.com/sort", "sort")), "example.com/sort"; got != want {
+		t.Errorf("qualifier of unimported package = %q, want %q", got, want)
+	}
+}
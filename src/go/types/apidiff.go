@@ -0,0 +1,323 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the comparison of the APIs of packages.
+
+package types
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"sort"
+)
+
+// An APIChange describes a difference between the APIs of two versions
+// of a package.
+type APIChange struct {
+	Name       string // name of the changed object; "T.F" for field or method F of type T
+	Old, New   Object // changed object in the old and new version; Old (New) is nil if it was added (removed)
+	Compatible bool   // whether clients of the old version continue to work with the new one
+	Message    string // description of the change, such as "removed"
+}
+
+// String returns a description of the change c.
+func (c APIChange) String() string {
+	kind := "incompatible"
+	if c.Compatible {
+		kind = "compatible"
+	}
+	return fmt.Sprintf("%s: %s (%s)", c.Name, c.Message, kind)
+}
+
+// APIDiff reports the changes from the exported API of the checked package
+// old to that of the checked package new, which are usually two versions
+// of the package with the same import path, sorted by name.
+//
+// An exported object that was added, removed, or whose kind, type, or
+// constant value changed is reported, as are the added, removed, and
+// changed exported fields and methods of exported defined types.
+// Additions are compatible, except for methods added to an interface
+// that may be implemented by other packages, and fields that make a
+// comparable struct type incomparable; all other changes are
+// incompatible. Types are compared structurally, except that defined
+// types are compared by package path and name only, and type parameters
+// by their index. Methods promoted from embedded fields are not compared.
+func APIDiff(old, new *Package) []APIChange {
+	var d apiDiffer
+	d.old, d.new = old, new
+	for _, name := range old.scope.Names() {
+		if !token.IsExported(name) {
+			continue
+		}
+		o := old.scope.Lookup(name)
+		if n := new.scope.Lookup(name); n != nil {
+			d.object(name, o, n)
+		} else {
+			d.report(name, o, nil, false, "removed")
+		}
+	}
+	for _, name := range new.scope.Names() {
+		if token.IsExported(name) && old.scope.Lookup(name) == nil {
+			d.report(name, nil, new.scope.Lookup(name), true, "added")
+		}
+	}
+	sort.SliceStable(d.changes, func(i, j int) bool { return d.changes[i].Name < d.changes[j].Name })
+	return d.changes
+}
+
+// An apiDiffer collects the API changes between two packages.
+type apiDiffer struct {
+	old, new *Package
+	changes  []APIChange
+}
+
+func (d *apiDiffer) report(name string, old, new Object, compatible bool, format string, args ...interface{}) {
+	d.changes = append(d.changes, APIChange{name, old, new, compatible, fmt.Sprintf(format, args...)})
+}
+
+// describe returns the canonical description of typ used by
+// APIFingerprint; it is the same for corresponding types of
+// different versions of a package.
+func describe(typ Type) string {
+	var f fingerprinter
+	f.typ(typ)
+	return f.buf.String()
+}
+
+// changed reports whether the types o and n of the old and new
+// package differ.
+func changed(o, n Type) bool {
+	return describe(o) != describe(n)
+}
+
+// typeStrings returns the strings of o and n, qualified relative to
+// the old and new package, respectively.
+func (d *apiDiffer) typeStrings(o, n Type) (string, string) {
+	return TypeString(o, RelativeTo(d.old)), TypeString(n, RelativeTo(d.new))
+}
+
+func (d *apiDiffer) typeChanged(name string, o, n Object, what string, otyp, ntyp Type) {
+	os, ns := d.typeStrings(otyp, ntyp)
+	d.report(name, o, n, false, "%s changed from %s to %s", what, os, ns)
+}
+
+// object compares the corresponding exported objects o and n.
+func (d *apiDiffer) object(name string, o, n Object) {
+	switch o := o.(type) {
+	case *Const:
+		if n, _ := n.(*Const); n != nil {
+			if changed(o.typ, n.typ) {
+				d.typeChanged(name, o, n, "type", o.typ, n.typ)
+			} else if !constant.Compare(o.val, token.EQL, n.val) {
+				d.report(name, o, n, false, "value changed from %s to %s", o.val, n.val)
+			}
+			return
+		}
+
+	case *Var:
+		if n, _ := n.(*Var); n != nil {
+			if changed(o.typ, n.typ) {
+				d.typeChanged(name, o, n, "type", o.typ, n.typ)
+			}
+			return
+		}
+
+	case *Func:
+		if n, _ := n.(*Func); n != nil {
+			if changed(o.typ, n.typ) {
+				d.typeChanged(name, o, n, "signature", o.typ, n.typ)
+			}
+			return
+		}
+
+	case *TypeName:
+		if n, _ := n.(*TypeName); n != nil {
+			d.typeName(name, o, n)
+			return
+		}
+	}
+	d.report(name, o, n, false, "changed from %s to %s", objectKind(o), objectKind(n))
+}
+
+// objectKind returns a description of the kind of obj.
+func objectKind(obj Object) string {
+	switch obj.(type) {
+	case *Const:
+		return "constant"
+	case *Var:
+		return "variable"
+	case *Func:
+		return "function"
+	case *TypeName:
+		return "type"
+	}
+	return "object"
+}
+
+// typeName compares the corresponding type names o and n.
+func (d *apiDiffer) typeName(name string, o, n *TypeName) {
+	// An alias denotes the same type as the type it refers to,
+	// and a defined type is only identical to itself.
+	if o.IsAlias() || n.IsAlias() {
+		if changed(o.typ, n.typ) {
+			d.typeChanged(name, o, n, "type", o.typ, n.typ)
+		}
+		return
+	}
+	on, _ := o.typ.(*Named)
+	nn, _ := n.typ.(*Named)
+	if on == nil || nn == nil {
+		return // not a defined type; should not happen at package level
+	}
+
+	otp, ntp := on.TypeParams(), nn.TypeParams()
+	if otp.Len() != ntp.Len() {
+		d.report(name, o, n, false, "number of type parameters changed from %d to %d", otp.Len(), ntp.Len())
+		return
+	}
+	for i := 0; i < otp.Len(); i++ {
+		if oc, nc := otp.At(i).Constraint(), ntp.At(i).Constraint(); changed(oc, nc) {
+			d.typeChanged(name, o, n, "constraint of type parameter "+otp.At(i).obj.name, oc, nc)
+		}
+	}
+
+	ou, nu := on.Underlying(), nn.Underlying()
+	switch ou := ou.(type) {
+	case *Struct:
+		if nu, _ := nu.(*Struct); nu != nil {
+			d.structType(name, o, n, ou, nu)
+			break
+		}
+		d.typeChanged(name, o, n, "underlying type", ou, nu)
+
+	case *Interface:
+		if nu, _ := nu.(*Interface); nu != nil && !ou.IsConstraint() && !nu.IsConstraint() {
+			d.interfaceType(name, ou, nu)
+			break
+		}
+		if changed(ou, nu) {
+			d.typeChanged(name, o, n, "underlying type", ou, nu)
+		}
+
+	default:
+		if changed(ou, nu) {
+			d.typeChanged(name, o, n, "underlying type", ou, nu)
+		}
+	}
+
+	d.methods(name, on, nn)
+}
+
+// structType compares the exported fields of the corresponding
+// struct types o and n of the type names otn and ntn named name.
+func (d *apiDiffer) structType(name string, otn, ntn *TypeName, o, n *Struct) {
+	fields := func(s *Struct) map[string]*Var {
+		m := make(map[string]*Var)
+		for _, f := range s.fields {
+			if f.Exported() {
+				m[f.name] = f
+			}
+		}
+		return m
+	}
+	ofields, nfields := fields(o), fields(n)
+	for _, of := range o.fields {
+		nf := nfields[of.name]
+		switch {
+		case !of.Exported():
+		case nf == nil:
+			d.report(name+"."+of.name, of, nil, false, "removed")
+		case of.embedded && !nf.embedded:
+			d.report(name+"."+of.name, of, nf, false, "no longer embedded")
+		case !of.embedded && nf.embedded:
+			d.report(name+"."+of.name, of, nf, false, "now embedded")
+		case changed(of.typ, nf.typ):
+			d.typeChanged(name+"."+of.name, of, nf, "type", of.typ, nf.typ)
+		}
+	}
+	// Values of a struct type that is no longer comparable can no longer
+	// be compared or used as map keys.
+	incomparable := Comparable(o) && !Comparable(n)
+	for _, nf := range n.fields {
+		if nf.Exported() && ofields[nf.name] == nil {
+			if incomparable && !Comparable(nf.typ) {
+				d.report(name+"."+nf.name, nil, nf, false, "added, making %s incomparable", name)
+				incomparable = false
+				continue
+			}
+			d.report(name+"."+nf.name, nil, nf, true, "added")
+		}
+	}
+	if incomparable {
+		d.report(name, otn, ntn, false, "no longer comparable")
+	}
+}
+
+// interfaceType compares the methods of the corresponding
+// basic interfaces o and n of the type named name.
+func (d *apiDiffer) interfaceType(name string, o, n *Interface) {
+	// Methods may be added to an interface that cannot be
+	// implemented outside of the package.
+	sealed := false
+	for _, m := range o.typeSet().methods {
+		if !m.Exported() {
+			sealed = true
+		}
+	}
+	for _, om := range o.typeSet().methods {
+		if !om.Exported() {
+			continue
+		}
+		_, nm := n.typeSet().LookupMethod(om.pkg, om.name)
+		switch {
+		case nm == nil:
+			d.report(name+"."+om.name, om, nil, false, "removed")
+		case changed(om.typ, nm.typ):
+			d.typeChanged(name+"."+om.name, om, nm, "signature", om.typ, nm.typ)
+		}
+	}
+	for _, nm := range n.typeSet().methods {
+		if !nm.Exported() {
+			continue
+		}
+		if _, om := o.typeSet().LookupMethod(nm.pkg, nm.name); om == nil {
+			d.report(name+"."+nm.name, nil, nm, sealed, "added")
+		}
+	}
+}
+
+// methods compares the exported methods declared for the
+// corresponding defined types o and n named name.
+func (d *apiDiffer) methods(name string, o, n *Named) {
+	lookup := func(t *Named, m string) *Func {
+		for i := 0; i < t.NumMethods(); i++ {
+			if f := t.Method(i); f.name == m {
+				return f
+			}
+		}
+		return nil
+	}
+	for i := 0; i < o.NumMethods(); i++ {
+		om := o.Method(i)
+		if !om.Exported() {
+			continue
+		}
+		nm := lookup(n, om.name)
+		switch {
+		case nm == nil:
+			d.report(name+"."+om.name, om, nil, false, "removed")
+		case !ptrRecv(om) && ptrRecv(nm):
+			// The method is no longer in the method set of values of the type.
+			d.report(name+"."+om.name, om, nm, false, "receiver changed from value to pointer")
+		case changed(om.typ, nm.typ):
+			d.typeChanged(name+"."+om.name, om, nm, "signature", om.typ, nm.typ)
+		}
+	}
+	for i := 0; i < n.NumMethods(); i++ {
+		if nm := n.Method(i); nm.Exported() && lookup(o, nm.name) == nil {
+			d.report(name+"."+nm.name, nil, nm, true, "added")
+		}
+	}
+}
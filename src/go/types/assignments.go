@@ -311,6 +311,8 @@ func (check *Checker) shortVarDecl(pos positioner, lhs, rhs []ast.Expr) {
 	seen := make(map[string]bool, len(lhs))
 	lhsVars := make([]*Var, len(lhs))
 	newVars := make([]*Var, 0, len(lhs))
+	var newInits []ast.Expr // rhs expressions of newVars, for Config.ReportShadowing
+	oneToOne := len(lhs) == len(rhs)
 	hasErr := false
 	for i, lhs := range lhs {
 		ident, _ := lhs.(*ast.Ident)
@@ -353,6 +355,11 @@ func (check *Checker) shortVarDecl(pos positioner, lhs, rhs []ast.Expr) {
 		lhsVars[i] = obj
 		if name != "_" {
 			newVars = append(newVars, obj)
+			var init ast.Expr
+			if oneToOne {
+				init = rhs[i]
+			}
+			newInits = append(newInits, init)
 		}
 		check.recordDef(ident, obj)
 	}
@@ -380,7 +387,8 @@ func (check *Checker) shortVarDecl(pos positioner, lhs, rhs []ast.Expr) {
 	// for short variable declarations) and ends at the end of the innermost
 	// containing block."
 	scopePos := rhs[len(rhs)-1].End()
-	for _, obj := range newVars {
+	for i, obj := range newVars {
 		check.declare(scope, nil, obj, scopePos) // id = nil: recordDef already called
+		check.reportShadowing(obj, newInits[i])
 	}
 }
@@ -0,0 +1,71 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the memory budget of Config.MemoryBudget.
+
+package types
+
+import (
+	"fmt"
+	"runtime/metrics"
+)
+
+// A MemoryBudgetError is returned by the type checker if it stopped
+// because it exceeded Config.MemoryBudget.
+type MemoryBudgetError struct {
+	Budget uint64 // the budget, in bytes
+	Used   uint64 // growth of the heap when checking stopped, in bytes
+}
+
+func (err *MemoryBudgetError) Error() string {
+	return fmt.Sprintf("type checking exceeded memory budget (heap grew by %d bytes, budget %d bytes)", err.Used, err.Budget)
+}
+
+// memoryCheckInterval is the number of expressions and types checked
+// between two reads of the heap size.
+const memoryCheckInterval = 1 << 10
+
+// A memoryBudget accounts for the memory used by a checker.
+type memoryBudget struct {
+	base  uint64 // smallest heap size since the start of checking
+	ticks uint64 // number of calls of checkMemory since the start
+}
+
+func (m *memoryBudget) start() {
+	m.base = heapBytes()
+	m.ticks = 0
+}
+
+// checkMemory stops type checking with a MemoryBudgetError if the heap has
+// grown by more than Config.MemoryBudget since the start of checking, or
+// since it was smallest, after garbage collections freed memory allocated
+// before. To keep the overhead small, the heap size is only read every
+// memoryCheckInterval calls.
+func (check *Checker) checkMemory() {
+	if check.conf.MemoryBudget == 0 {
+		return
+	}
+	check.memory.ticks++
+	if check.memory.ticks%memoryCheckInterval != 0 {
+		return
+	}
+	h := heapBytes()
+	if h < check.memory.base {
+		check.memory.base = h
+	}
+	if used := h - check.memory.base; used > check.conf.MemoryBudget {
+		check.err(&MemoryBudgetError{check.conf.MemoryBudget, used})
+		panic(bailout{}) // if the error was reported to Config.Error
+	}
+}
+
+// heapBytes returns the number of bytes occupied by heap objects,
+// including dead objects not yet freed by the garbage collector, in the
+// process. Unlike the number of allocated bytes, it decreases when memory
+// is freed. It does not stop the world.
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+	return sample[0].Value.Uint64()
+}
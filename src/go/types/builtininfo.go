@@ -0,0 +1,206 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements descriptions of the predeclared built-in functions.
+
+package types
+
+// A BuiltinParam describes a parameter of a built-in function.
+// Types are given in the notation of the documentation of packages
+// builtin and unsafe: Type and Type1 stand for arbitrary types,
+// IntegerType, FloatType, and ComplexType for any integer, floating-point,
+// or complex type, ArbitraryType for any type, and Pointer for
+// unsafe.Pointer. A variadic parameter has a type starting with "...".
+type BuiltinParam struct {
+	Name string // "" for the type argument of new
+	Type string
+}
+
+// A BuiltinInfo describes a predeclared built-in function. Since the
+// built-ins are generic in ways that cannot be expressed by a Signature,
+// their parameters and results are described informally; the remaining
+// fields describe how the type checker treats calls.
+type BuiltinInfo struct {
+	Params  []BuiltinParam
+	Results []string // result types, in the notation of BuiltinParam
+
+	MinArgs   int  // minimum number of arguments
+	Variadic  bool // more than MinArgs arguments are permitted
+	TypeArg   bool // the first argument is a type rather than a value
+	Statement bool // a call may be used as an expression statement
+
+	// Constant describes when a call is a constant expression;
+	// it is empty if calls are never constant.
+	Constant string
+
+	// Special lists argument forms that are accepted in addition to, or
+	// instead of, what the parameter types suggest.
+	Special []string
+}
+
+// Info returns the description of the built-in function b.
+// The result must not be modified. For a built-in created with
+// NewBuiltin, only MinArgs and Variadic are set.
+func (b *Builtin) Info() BuiltinInfo {
+	if c := b.custom; c != nil {
+		return BuiltinInfo{MinArgs: c.nargs, Variadic: c.variadic}
+	}
+	info := builtinInfos[b.id]
+	bin := predeclaredFuncs[b.id]
+	info.MinArgs = bin.nargs
+	info.Variadic = bin.variadic
+	info.Statement = bin.kind == statement
+	return info
+}
+
+// builtinParams returns the parameter list of the given name and type pairs.
+func builtinParams(list ...string) []BuiltinParam {
+	res := make([]BuiltinParam, len(list)/2)
+	for i := range res {
+		res[i] = BuiltinParam{list[2*i], list[2*i+1]}
+	}
+	return res
+}
+
+// builtinInfos describes the built-ins; the fields derived from
+// predeclaredFuncs are filled in by Builtin.Info.
+var builtinInfos = [...]BuiltinInfo{
+	_Append: {
+		Params:  builtinParams("slice", "[]Type", "elems", "...Type"),
+		Results: []string{"[]Type"},
+		Special: []string{
+			"append(s, x...) passes the elements of the slice x",
+			"append([]byte, string...) appends the bytes of a string",
+		},
+	},
+	_Cap: {
+		Params:   builtinParams("v", "Type"),
+		Results:  []string{"int"},
+		Constant: "if v is an array or pointer to an array and contains no channel receives or non-constant function calls",
+	},
+	_Clear: {
+		Params: builtinParams("t", "Type"),
+		Special: []string{
+			"t may be a map, a slice, or a type parameter whose type set contains only maps and slices",
+		},
+	},
+	_Close: {
+		Params: builtinParams("c", "chan<- Type"),
+	},
+	_Complex: {
+		Params:   builtinParams("r", "FloatType", "i", "FloatType"),
+		Results:  []string{"ComplexType"},
+		Constant: "if both arguments are constants",
+		Special:  []string{"untyped constant arguments result in an untyped complex constant"},
+	},
+	_Copy: {
+		Params:  builtinParams("dst", "[]Type", "src", "[]Type"),
+		Results: []string{"int"},
+		Special: []string{"copy([]byte, string) copies the bytes of a string"},
+	},
+	_Delete: {
+		Params: builtinParams("m", "map[Type]Type1", "key", "Type"),
+	},
+	_Imag: {
+		Params:   builtinParams("c", "ComplexType"),
+		Results:  []string{"FloatType"},
+		Constant: "if the argument is a constant",
+		Special:  []string{"an untyped constant argument results in an untyped float constant"},
+	},
+	_Len: {
+		Params:   builtinParams("v", "Type"),
+		Results:  []string{"int"},
+		Constant: "if v is a constant string, or an array or pointer to an array and contains no channel receives or non-constant function calls",
+	},
+	_Make: {
+		Params:  builtinParams("t", "Type", "size", "...IntegerType"),
+		Results: []string{"Type"},
+		TypeArg: true,
+		Special: []string{
+			"make(slice type, len) and make(slice type, len, cap)",
+			"make(map type) and make(map type, size)",
+			"make(channel type) and make(channel type, size)",
+			"constant sizes must be non-negative and representable by int",
+		},
+	},
+	_Max: {
+		Params:   builtinParams("x", "Type", "y", "...Type"),
+		Results:  []string{"Type"},
+		Constant: "if all arguments are constants",
+		Special:  []string{"the arguments must be of the same ordered type, or untyped constants"},
+	},
+	_Min: {
+		Params:   builtinParams("x", "Type", "y", "...Type"),
+		Results:  []string{"Type"},
+		Constant: "if all arguments are constants",
+		Special:  []string{"the arguments must be of the same ordered type, or untyped constants"},
+	},
+	_New: {
+		Params:  builtinParams("", "Type"),
+		Results: []string{"*Type"},
+		TypeArg: true,
+	},
+	_Panic: {
+		Params: builtinParams("v", "interface{}"),
+	},
+	_Print: {
+		Params: builtinParams("args", "...Type"),
+	},
+	_Println: {
+		Params: builtinParams("args", "...Type"),
+	},
+	_Real: {
+		Params:   builtinParams("c", "ComplexType"),
+		Results:  []string{"FloatType"},
+		Constant: "if the argument is a constant",
+		Special:  []string{"an untyped constant argument results in an untyped float constant"},
+	},
+	_Recover: {
+		Results: []string{"interface{}"},
+	},
+
+	_Add: {
+		Params:  builtinParams("ptr", "Pointer", "len", "IntegerType"),
+		Results: []string{"Pointer"},
+	},
+	_Alignof: {
+		Params:   builtinParams("x", "ArbitraryType"),
+		Results:  []string{"uintptr"},
+		Constant: "if the type of x does not have variable size",
+	},
+	_Offsetof: {
+		Params:   builtinParams("x", "ArbitraryType"),
+		Results:  []string{"uintptr"},
+		Constant: "if the type of the struct does not have variable size",
+		Special:  []string{"x must be a selector s.f denoting a field f of the struct s or *s"},
+	},
+	_Sizeof: {
+		Params:   builtinParams("x", "ArbitraryType"),
+		Results:  []string{"uintptr"},
+		Constant: "if the type of x does not have variable size",
+	},
+	_Slice: {
+		Params:  builtinParams("ptr", "*ArbitraryType", "len", "IntegerType"),
+		Results: []string{"[]ArbitraryType"},
+	},
+	_SliceData: {
+		Params:  builtinParams("slice", "[]ArbitraryType"),
+		Results: []string{"*ArbitraryType"},
+	},
+	_String: {
+		Params:  builtinParams("ptr", "*byte", "len", "IntegerType"),
+		Results: []string{"string"},
+	},
+	_StringData: {
+		Params:  builtinParams("str", "string"),
+		Results: []string{"*byte"},
+	},
+
+	_Assert: {
+		Params: builtinParams("x", "bool"),
+	},
+	_Trace: {
+		Params: builtinParams("x", "...Type"),
+	},
+}
@@ -120,15 +120,15 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		// check general case by creating custom signature
 		sig := makeSig(S, S, NewSlice(T)) // []T required for variadic signature
 		sig.variadic = true
-		var xlist []*operand
 		// convert []operand to []*operand
+		xlist := check.newOperandList(nargs)
 		for i := range alist {
-			xlist = append(xlist, &alist[i])
+			xlist[i] = &alist[i]
 		}
 		for i := len(alist); i < nargs; i++ {
-			var x operand
-			arg(&x, i)
-			xlist = append(xlist, &x)
+			x := check.newOperand()
+			arg(x, i)
+			xlist[i] = x
 		}
 		check.arguments(call, sig, nil, xlist) // discard result (we know the result type)
 		// ok to continue even if check.arguments reported errors
@@ -215,6 +215,29 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			check.recordBuiltinType(call.Fun, makeSig(x.typ, typ))
 		}
 
+	case _Clear:
+		// clear(m)
+		if !check.allowVersion(check.pkg, 1, 21) && !check.forwardCompat(call.Fun, 1, 21, "clear") {
+			check.errorf(call.Fun, _InvalidClear, "clear requires go1.21 or later")
+			return
+		}
+
+		if !underIs(x.typ, func(u Type) bool {
+			switch u.(type) {
+			case *Map, *Slice:
+				return true
+			}
+			check.invalidArg(x, _InvalidClear, "cannot clear %s: argument must be (or constrained by) map or slice", x)
+			return false
+		}) {
+			return
+		}
+
+		x.mode = novalue
+		if check.Types != nil {
+			check.recordBuiltinType(call.Fun, makeSig(nil, x.typ))
+		}
+
 	case _Close:
 		// close(c)
 		if !underIs(x.typ, func(u Type) bool {
@@ -518,6 +541,91 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			check.recordBuiltinType(call.Fun, makeSig(x.typ, types...))
 		}
 
+	case _Max, _Min:
+		// max(x, ...)
+		// min(x, ...)
+		if !check.allowVersion(check.pkg, 1, 21) && !check.forwardCompat(call.Fun, 1, 21, "%s", bin.name) {
+			check.errorf(call.Fun, _InvalidMinMaxOperand, "%s requires go1.21 or later", bin.name)
+			return
+		}
+
+		op := token.LSS
+		if id == _Max {
+			op = token.GTR
+		}
+
+		for i := 0; i < nargs; i++ {
+			var a operand
+			if i == 0 {
+				a = *x
+			} else {
+				arg(&a, i)
+				if a.mode == invalid {
+					return
+				}
+			}
+
+			if !isOrdered(a.typ) {
+				check.invalidArg(&a, _InvalidMinMaxOperand, "%s cannot be ordered", &a)
+				return
+			}
+
+			// The first argument is already in x and there's nothing left to do.
+			if i == 0 {
+				continue
+			}
+
+			// Like for binary operations, untyped operands assume the type
+			// of the other operand and all operands must have identical types.
+			if canMix(x, &a) {
+				check.convertUntyped(x, a.typ)
+				if x.mode == invalid {
+					return
+				}
+				check.convertUntyped(&a, x.typ)
+				if a.mode == invalid {
+					return
+				}
+			}
+			if !Identical(x.typ, a.typ) {
+				check.invalidArg(&a, _MismatchedTypes, "mismatched types %s (previous argument) and %s (type of %s)", x.typ, a.typ, a.expr)
+				return
+			}
+
+			if x.mode == constant_ && a.mode == constant_ {
+				if constant.Compare(a.val, op, x.val) {
+					*x = a
+				}
+			} else {
+				x.mode = value
+			}
+		}
+
+		// If nargs == 1, make sure x.mode is either a value or a constant.
+		if x.mode != constant_ {
+			x.mode = value
+			// A value must not be untyped.
+			check.assignment(x, &emptyInterface, "argument to "+bin.name)
+			if x.mode == invalid {
+				return
+			}
+		}
+
+		// Use the final type computed above for all arguments.
+		if nargs == len(call.Args) {
+			for _, arg := range call.Args {
+				check.updateExprType(arg, x.typ, true)
+			}
+		}
+
+		if check.Types != nil && x.mode != constant_ {
+			types := make([]Type, nargs)
+			for i := range types {
+				types[i] = x.typ
+			}
+			check.recordBuiltinType(call.Fun, makeSig(x.typ, types...))
+		}
+
 	case _New:
 		// new(T)
 		// (no argument evaluated yet)
@@ -591,7 +699,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 
 	case _Add:
 		// unsafe.Add(ptr unsafe.Pointer, len IntegerType) unsafe.Pointer
-		if !check.allowVersion(check.pkg, 1, 17) {
+		if !check.allowVersion(check.pkg, 1, 17) && !check.forwardCompat(call.Fun, 1, 17, "unsafe.Add") {
 			check.errorf(call.Fun, _InvalidUnsafeAdd, "unsafe.Add requires go1.17 or later")
 			return
 		}
@@ -677,7 +785,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			if x.mode == variable || indirect {
 				mode = variable
 			}
-			check.record(&operand{mode, selx, obj.Type(), nil, 0})
+			check.record(&operand{mode, selx, obj.Type(), nil, 0, nil})
 		}
 
 		// The field offset is considered a variable even if the field is declared before
@@ -717,7 +825,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 
 	case _Slice:
 		// unsafe.Slice(ptr *T, len IntegerType) []T
-		if !check.allowVersion(check.pkg, 1, 17) {
+		if !check.allowVersion(check.pkg, 1, 17) && !check.forwardCompat(call.Fun, 1, 17, "unsafe.Slice") {
 			check.errorf(call.Fun, _InvalidUnsafeSlice, "unsafe.Slice requires go1.17 or later")
 			return
 		}
@@ -740,6 +848,68 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			check.recordBuiltinType(call.Fun, makeSig(x.typ, typ, y.typ))
 		}
 
+	case _SliceData:
+		// unsafe.SliceData(slice []T) *T
+		if !check.allowVersion(check.pkg, 1, 20) && !check.forwardCompat(call.Fun, 1, 20, "unsafe.SliceData") {
+			check.errorf(call.Fun, _InvalidUnsafeSliceData, "unsafe.SliceData requires go1.20 or later")
+			return
+		}
+
+		slice, _ := coreType(x.typ).(*Slice)
+		if slice == nil {
+			check.invalidArg(x, _InvalidUnsafeSliceData, "%s is not a slice", x)
+			return
+		}
+
+		if check.Types != nil {
+			check.recordBuiltinType(call.Fun, makeSig(NewPointer(slice.elem), x.typ))
+		}
+		x.mode = value
+		x.typ = NewPointer(slice.elem)
+
+	case _String:
+		// unsafe.String(ptr *byte, len IntegerType) string
+		if !check.allowVersion(check.pkg, 1, 20) && !check.forwardCompat(call.Fun, 1, 20, "unsafe.String") {
+			check.errorf(call.Fun, _InvalidUnsafeString, "unsafe.String requires go1.20 or later")
+			return
+		}
+
+		check.assignment(x, NewPointer(universeByte), "argument to unsafe.String")
+		if x.mode == invalid {
+			return
+		}
+
+		var y operand
+		arg(&y, 1)
+		if !check.isValidIndex(&y, _InvalidUnsafeString, "length", false) {
+			return
+		}
+
+		// The result is never constant, even if the arguments are.
+		x.mode = value
+		x.typ = Typ[String]
+		if check.Types != nil {
+			check.recordBuiltinType(call.Fun, makeSig(x.typ, NewPointer(universeByte), y.typ))
+		}
+
+	case _StringData:
+		// unsafe.StringData(str string) *byte
+		if !check.allowVersion(check.pkg, 1, 20) && !check.forwardCompat(call.Fun, 1, 20, "unsafe.StringData") {
+			check.errorf(call.Fun, _InvalidUnsafeStringData, "unsafe.StringData requires go1.20 or later")
+			return
+		}
+
+		check.assignment(x, Typ[String], "argument to unsafe.StringData")
+		if x.mode == invalid {
+			return
+		}
+
+		x.mode = value
+		x.typ = NewPointer(universeByte)
+		if check.Types != nil {
+			check.recordBuiltinType(call.Fun, makeSig(x.typ, Typ[String]))
+		}
+
 	case _Assert:
 		// assert(pred) causes a typechecker error if pred is false.
 		// The result of assert is the value of pred if there is no error.
@@ -858,7 +1028,7 @@ func makeSig(res Type, args ...Type) *Signature {
 // arrayPtrDeref returns A if typ is of the form *A and A is an array;
 // otherwise it returns typ.
 func arrayPtrDeref(typ Type) Type {
-	if p, ok := typ.(*Pointer); ok {
+	if p, ok := Unalias(typ).(*Pointer); ok {
 		if a := asArray(p.base); a != nil {
 			return a
 		}
@@ -9,6 +9,7 @@ import (
 	"go/ast"
 	"go/importer"
 	"go/parser"
+	"strings"
 	"testing"
 
 	. "go/types"
@@ -38,6 +39,9 @@ var builtinCalls = []struct {
 	{"len", `var c chan<-bool; _ = len(c)`, `func(chan<- bool) int`},
 	{"len", `var m map[string]float32; _ = len(m)`, `func(map[string]float32) int`},
 
+	{"clear", `var m map[float64]int; clear(m)`, `func(map[float64]int)`},
+	{"clear", `var s []byte; clear(s)`, `func([]byte)`},
+
 	{"close", `var c chan int; close(c)`, `func(chan int)`},
 	{"close", `var c chan<- chan string; close(c)`, `func(chan<- chan string)`},
 
@@ -53,6 +57,15 @@ var builtinCalls = []struct {
 	{"copy", `type T string; type U []byte; var src T; var dst U; copy(dst, src)`, `func(p.U, p.T) int`},
 	{"copy", `var dst []byte; copy(dst, "hello")`, `func([]byte, string) int`},
 
+	{"max", `var x int; _ = max(x)`, `func(int) int`},
+	{"max", `var x, y float64; _ = max(x, y, 1)`, `func(float64, float64, float64) float64`},
+	{"max", `_ = max(1, 2.5)`, `invalid type`}, // constant
+	{"max", `type S string; var x S; _ = max(x, "a")`, `func(p.S, p.S) p.S`},
+
+	{"min", `var x int; _ = min(x)`, `func(int) int`},
+	{"min", `var x, y uint8; _ = min(x, y)`, `func(uint8, uint8) uint8`},
+	{"min", `_ = min("a", "b")`, `invalid type`}, // constant
+
 	{"delete", `var m map[string]bool; delete(m, "foo")`, `func(map[string]bool, string)`},
 	{"delete", `type (K string; V int); var m map[K]V; delete(m, "foo")`, `func(map[p.K]p.V, p.K)`},
 
@@ -126,6 +139,15 @@ var builtinCalls = []struct {
 	{"Slice", `var p *int; _ = unsafe.Slice(p, 1)`, `func(*int, int) []int`},
 	{"Slice", `var p *byte; var n uintptr; _ = unsafe.Slice(p, n)`, `func(*byte, uintptr) []byte`},
 
+	{"SliceData", `var s []int; _ = unsafe.SliceData(s)`, `func([]int) *int`},
+	{"SliceData", `type S []byte; var s S; _ = unsafe.SliceData(s)`, `func(p.S) *byte`},
+
+	{"String", `var p *byte; _ = unsafe.String(p, 1)`, `func(*byte, int) string`},
+	{"String", `var p *byte; var n uintptr; _ = unsafe.String(p, n)`, `func(*byte, uintptr) string`},
+
+	{"StringData", `var s string; _ = unsafe.StringData(s)`, `func(string) *byte`},
+	{"StringData", `_ = unsafe.StringData("foo")`, `func(string) *byte`},
+
 	{"assert", `assert(true)`, `invalid type`},                                    // constant
 	{"assert", `type B bool; const pred B = 1 < 2; assert(pred)`, `invalid type`}, // constant
 
@@ -154,6 +176,52 @@ func TestBuiltinSignatures(t *testing.T) {
 	}
 }
 
+func TestBuiltinInfo(t *testing.T) {
+	DefPredeclaredTestFuncs()
+
+	var builtins []*Builtin
+	for _, scope := range []*Scope{Universe, Unsafe.Scope()} {
+		for _, name := range scope.Names() {
+			if bin, _ := scope.Lookup(name).(*Builtin); bin != nil {
+				builtins = append(builtins, bin)
+			}
+		}
+	}
+
+	// The parameter lists must agree with the argument counts.
+	for _, bin := range builtins {
+		info := bin.Info()
+		nparams, variadic := len(info.Params), false
+		if nparams > 0 && strings.HasPrefix(info.Params[nparams-1].Type, "...") {
+			nparams--
+			variadic = true
+		}
+		if nparams != info.MinArgs || variadic != info.Variadic {
+			t.Errorf("%s: %d parameters (variadic = %t) for %d arguments (variadic = %t)", bin.Name(), nparams, variadic, info.MinArgs, info.Variadic)
+		}
+	}
+
+	for _, test := range []struct {
+		name                         string
+		typeArg, statement, constant bool
+	}{
+		{"append", false, false, false},
+		{"copy", false, true, false},
+		{"len", false, false, true},
+		{"make", true, false, false},
+		{"new", true, false, false},
+		{"panic", false, true, false},
+	} {
+		info := Universe.Lookup(test.name).(*Builtin).Info()
+		if info.TypeArg != test.typeArg || info.Statement != test.statement || (info.Constant != "") != test.constant {
+			t.Errorf("%s: got %+v", test.name, info)
+		}
+	}
+	if info := Unsafe.Scope().Lookup("Sizeof").(*Builtin).Info(); info.Constant == "" || info.Results[0] != "uintptr" {
+		t.Errorf("unsafe.Sizeof: got %+v", info)
+	}
+}
+
 // parseGenericSrc in types2 is not necessary. We can just parse in testBuiltinSignature below.
 
 func testBuiltinSignature(t *testing.T, name, src0, want string) {
@@ -7,6 +7,7 @@
 package types
 
 import (
+	"bytes"
 	"go/ast"
 	"go/internal/typeparams"
 	"go/token"
@@ -17,8 +18,8 @@ import (
 // funcInst type-checks a function instantiation inst and returns the result in x.
 // The operand x must be the evaluation of inst.X and its type must be a signature.
 func (check *Checker) funcInst(x *operand, ix *typeparams.IndexExpr) {
-	if !check.allowVersion(check.pkg, 1, 18) {
-		check.softErrorf(inNode(ix.Orig, ix.Lbrack), _Todo, "function instantiation requires go1.18 or later")
+	if !check.allowVersion(check.pkg, 1, 18) && !check.forwardCompat(inNode(ix.Orig, ix.Lbrack), 1, 18, "function instantiation") {
+		check.warnf(inNode(ix.Orig, ix.Lbrack), _Todo, "function instantiation requires go1.18 or later")
 	}
 
 	targs := check.typeList(ix.Indices)
@@ -32,10 +33,14 @@ func (check *Checker) funcInst(x *operand, ix *typeparams.IndexExpr) {
 	// check number of type arguments (got) vs number of type parameters (want)
 	sig := x.typ.(*Signature)
 	got, want := len(targs), sig.TypeParams().Len()
+	variadic := want > 0 && sig.TypeParams().At(want-1).variadic
+	if variadic && got >= want-1 {
+		targs = packTArgs(sig.TypeParams().list(), targs)
+		got = want
+	}
 	if got > want {
 		check.errorf(ix.Indices[got-1], _Todo, "got %d type arguments but want %d", got, want)
-		x.mode = invalid
-		x.expr = ix.Orig
+		check.recoverFuncInst(x, ix, sig, targs)
 		return
 	}
 
@@ -43,11 +48,19 @@ func (check *Checker) funcInst(x *operand, ix *typeparams.IndexExpr) {
 	inferred := false
 
 	if got < want {
-		targs = check.infer(ix.Orig, sig.TypeParams().list(), targs, nil, nil, true)
+		given := targs
+		if variadic {
+			// There are no arguments for the variadic type parameter.
+			targs = check.infer(ix.Orig, sig.TypeParams().list()[:want-1], targs, nil, nil, true)
+			if targs != nil {
+				targs = append(targs, &Tuple{})
+			}
+		} else {
+			targs = check.infer(ix.Orig, sig.TypeParams().list(), targs, nil, nil, true)
+		}
 		if targs == nil {
 			// error was already reported
-			x.mode = invalid
-			x.expr = ix.Orig
+			check.recoverFuncInst(x, ix, sig, given)
 			return
 		}
 		got = len(targs)
@@ -58,10 +71,13 @@ func (check *Checker) funcInst(x *operand, ix *typeparams.IndexExpr) {
 	// determine argument positions (for error reporting)
 	// TODO(rFindley) use a positioner here? instantiate would need to be
 	//                updated accordingly.
-	poslist := make([]token.Pos, len(ix.Indices))
+	poslist := check.newPosList(len(ix.Indices))
 	for i, x := range ix.Indices {
 		poslist[i] = x.Pos()
 	}
+	if len(poslist) > len(targs) {
+		poslist = poslist[:len(targs)] // targs are packed; see packTArgs
+	}
 
 	// instantiate function signature
 	res := check.instantiate(x.Pos(), sig, targs, poslist).(*Signature)
@@ -74,7 +90,26 @@ func (check *Checker) funcInst(x *operand, ix *typeparams.IndexExpr) {
 	x.expr = ix.Orig
 }
 
+// recoverFuncInst sets x to an instance of sig for the function instantiation
+// ix after an error about the type arguments targs was reported. The instance
+// uses recoveryTArgs and is not verified against the constraints, so that
+// uses of the instance can still be checked.
+func (check *Checker) recoverFuncInst(x *operand, ix *typeparams.IndexExpr, sig *Signature, targs []Type) {
+	x.typ = check.instance(ix.Pos(), sig, recoveryTArgs(sig.TypeParams().list(), targs), check.conf.Environment)
+	x.mode = value
+	x.expr = ix.Orig
+}
+
 func (check *Checker) callExpr(x *operand, call *ast.CallExpr) exprKind {
+	if check.Callees != nil {
+		// Have recordUse remember the object denoted by the callee's
+		// identifier, if any.
+		defer func(id *ast.Ident, obj Object) {
+			check.calleeIdent, check.calleeObj = id, obj
+		}(check.calleeIdent, check.calleeObj)
+		check.calleeIdent, check.calleeObj = calleeIdent(call.Fun), nil
+	}
+
 	ix := typeparams.UnpackIndexExpr(call.Fun)
 	if ix != nil {
 		if check.indexExpr(x, ix) {
@@ -93,6 +128,11 @@ func (check *Checker) callExpr(x *operand, call *ast.CallExpr) exprKind {
 	}
 	// x.typ map be generic
 
+	var callee Object
+	if check.Callees != nil && x.mode != invalid {
+		callee = staticCallee(check.calleeObj)
+	}
+
 	switch x.mode {
 	case invalid:
 		check.use(call.Args...)
@@ -134,9 +174,27 @@ func (check *Checker) callExpr(x *operand, call *ast.CallExpr) exprKind {
 
 	case builtin:
 		// no need to check for non-genericity here
+		if bin := x.custom; bin != nil {
+			if !check.customBuiltinCall(x, call, bin) {
+				x.mode = invalid
+			} else if callee != nil {
+				check.recordCallee(call, callee, nil)
+			}
+			x.expr = call
+			x.custom = nil
+			if x.mode != invalid && x.mode != constant_ {
+				check.hasCallOrRecv = true
+			}
+			if x.mode == novalue {
+				return statement
+			}
+			return expression
+		}
 		id := x.id
 		if !check.builtin(x, call, id) {
 			x.mode = invalid
+		} else if callee != nil {
+			check.recordCallee(call, callee, nil)
 		}
 		x.expr = call
 		// a non-constant result implies a function call
@@ -172,18 +230,19 @@ func (check *Checker) callExpr(x *operand, call *ast.CallExpr) exprKind {
 
 		// check number of type arguments (got) vs number of type parameters (want)
 		got, want := len(targs), sig.TypeParams().Len()
-		if got > want {
+		if got > want && !sig.TypeParams().At(want-1).variadic {
 			check.errorf(ix.Indices[want], _Todo, "got %d type arguments but want %d", got, want)
-			check.use(call.Args...)
-			x.mode = invalid
-			x.expr = call
-			return statement
+			targs = targs[:want] // recover by ignoring the extra type arguments
 		}
 	}
 
 	// evaluate arguments
 	args, _ := check.exprList(call.Args, false)
-	sig = check.arguments(call, sig, targs, args)
+	generic := sig.TypeParams().Len() > 0
+	sig, targs = check.arguments(call, sig, targs, args)
+	if callee != nil && (!generic || sig.TypeParams().Len() == 0) {
+		check.recordCallee(call, callee, targs)
+	}
 
 	// determine result
 	switch sig.results.Len() {
@@ -220,21 +279,25 @@ func (check *Checker) exprList(elist []ast.Expr, allowCommaOk bool) (xlist []*op
 	case 1:
 		// single (possibly comma-ok) value, or function returning multiple values
 		e := elist[0]
-		var x operand
-		check.multiExpr(&x, e)
+		x := check.newOperand()
+		check.multiExpr(x, e)
 		if t, ok := x.typ.(*Tuple); ok && x.mode != invalid {
 			// multiple values
-			xlist = make([]*operand, t.Len())
+			xlist = check.newOperandList(t.Len())
 			for i, v := range t.vars {
-				xlist[i] = &operand{mode: value, expr: e, typ: v.typ}
+				x := check.newOperand()
+				x.mode, x.expr, x.typ = value, e, v.typ
+				xlist[i] = x
 			}
 			break
 		}
 
 		// exactly one (possibly invalid or comma-ok) value
-		xlist = []*operand{&x}
+		xlist = check.newOperandList(1)
+		xlist[0] = x
 		if allowCommaOk && (x.mode == mapindex || x.mode == commaok || x.mode == commaerr) {
-			x2 := &operand{mode: value, expr: e, typ: Typ[UntypedBool]}
+			x2 := check.newOperand()
+			x2.mode, x2.expr, x2.typ = value, e, Typ[UntypedBool]
 			if x.mode == commaerr {
 				x2.typ = universeError
 			}
@@ -244,18 +307,22 @@ func (check *Checker) exprList(elist []ast.Expr, allowCommaOk bool) (xlist []*op
 
 	default:
 		// multiple (possibly invalid) values
-		xlist = make([]*operand, len(elist))
+		xlist = check.newOperandList(len(elist))
 		for i, e := range elist {
-			var x operand
-			check.expr(&x, e)
-			xlist[i] = &x
+			x := check.newOperand()
+			check.expr(x, e)
+			xlist[i] = x
 		}
 	}
 
 	return
 }
 
-func (check *Checker) arguments(call *ast.CallExpr, sig *Signature, targs []Type, args []*operand) (rsig *Signature) {
+// arguments checks the arguments args of the call of a function with signature sig
+// and the (possibly partial) type arguments targs. The result is the signature of
+// the call, instantiated if sig is generic and all type arguments could be inferred,
+// and the respective complete type argument list.
+func (check *Checker) arguments(call *ast.CallExpr, sig *Signature, targs []Type, args []*operand) (rsig *Signature, rtargs []Type) {
 	rsig = sig
 
 	// TODO(gri) try to eliminate this extra verification loop
@@ -334,32 +401,57 @@ func (check *Checker) arguments(call *ast.CallExpr, sig *Signature, targs []Type
 	}
 
 	// infer type arguments and instantiate signature if necessary
+	var inferredTargs []Type // all type arguments, if some were inferred
 	if sig.TypeParams().Len() > 0 {
 		if !check.allowVersion(check.pkg, 1, 18) {
 			switch call.Fun.(type) {
 			case *ast.IndexExpr, *ast.IndexListExpr:
 				ix := typeparams.UnpackIndexExpr(call.Fun)
-				check.softErrorf(inNode(call.Fun, ix.Lbrack), _Todo, "function instantiation requires go1.18 or later")
+				if !check.forwardCompat(inNode(call.Fun, ix.Lbrack), 1, 18, "function instantiation") {
+					check.warnf(inNode(call.Fun, ix.Lbrack), _Todo, "function instantiation requires go1.18 or later")
+				}
 			default:
-				check.softErrorf(inNode(call, call.Lparen), _Todo, "implicit function instantiation requires go1.18 or later")
+				if !check.forwardCompat(inNode(call, call.Lparen), 1, 18, "implicit function instantiation") {
+					check.warnf(inNode(call, call.Lparen), _Todo, "implicit function instantiation requires go1.18 or later")
+				}
 			}
 		}
 		// TODO(gri) provide position information for targs so we can feed
 		//           it to the instantiate call for better error reporting
-		targs := check.infer(call, sig.TypeParams().list(), targs, sigParams, args, true)
+		partial := len(targs) < sig.TypeParams().Len()
+		if n := sig.TypeParams().Len(); sig.TypeParams().At(n - 1).variadic {
+			targs = check.inferVariadic(call, sig, targs, sigParams, args)
+		} else {
+			targs = check.infer(call, sig.TypeParams().list(), targs, sigParams, args, true)
+		}
 		if targs == nil {
 			return // error already reported
 		}
+		if partial {
+			inferredTargs = targs
+		}
 
 		// compute result signature
 		rsig = check.instantiate(call.Pos(), sig, targs, nil).(*Signature)
 		assert(rsig.TypeParams().Len() == 0) // signature is not generic anymore
+		rtargs = targs
 		check.recordInferred(call, targs, rsig)
 
 		// Optimization: Only if the parameter list was adjusted do we
 		// need to compute it from the adjusted list; otherwise we can
 		// simply use the result signature's parameter list.
-		if adjusted {
+		if rsig.variadic != sig.variadic {
+			// The final parameter was expanded for a variadic type parameter.
+			sigParams = rsig.params
+			if n := sigParams.Len(); nargs != n {
+				if nargs < n {
+					check.errorf(inNode(call, call.Rparen), _WrongArgCount, "not enough arguments in call to %s", call.Fun)
+				} else {
+					check.errorf(args[n], _WrongArgCount, "too many arguments in call to %s", call.Fun)
+				}
+				return
+			}
+		} else if adjusted {
 			sigParams = check.subst(call.Pos(), sigParams, makeSubstMap(sig.TypeParams().list(), targs), nil).(*Tuple)
 		} else {
 			sigParams = rsig.params
@@ -369,6 +461,11 @@ func (check *Checker) arguments(call *ast.CallExpr, sig *Signature, targs []Type
 	// check arguments
 	if len(args) > 0 {
 		context := check.sprintf("argument to %s", call.Fun)
+		if inferredTargs != nil && check.verbose() {
+			var buf bytes.Buffer
+			newTypeWriter(&buf, check.qualifier).typeList(inferredTargs)
+			context += " (with inferred type arguments " + buf.String() + ")"
+		}
 		for i, a := range args {
 			check.assignment(a, sigParams.vars[i].typ, context)
 		}
@@ -377,6 +474,83 @@ func (check *Checker) arguments(call *ast.CallExpr, sig *Signature, targs []Type
 	return
 }
 
+// inferVariadic is like infer for the arguments of a call of the generic
+// function sig with a variadic last type parameter Ts. The type arguments
+// for Ts are the provided type arguments beyond the other type parameters.
+// If there are none, and the final parameter of sig is of the form ...Ts,
+// they are the (default) types of the arguments for the final parameter.
+// The remaining type arguments are inferred from the other arguments.
+// The result is packed (see packTArgs), or nil if inference failed.
+func (check *Checker) inferVariadic(call *ast.CallExpr, sig *Signature, targs []Type, params *Tuple, args []*operand) []Type {
+	tparams := sig.TypeParams().list()
+	n := len(tparams)
+	if len(targs) > n-1 {
+		return packTArgs(tparams, targs)
+	}
+
+	var pack []Type
+	if npars := sig.params.Len(); sig.variadic {
+		if s, _ := sig.params.vars[npars-1].typ.(*Slice); s != nil && s.elem == tparams[n-1] {
+			if call.Ellipsis.IsValid() {
+				check.errorf(inNode(call, call.Ellipsis), _InvalidDotDotDot, "cannot use ... with variadic type parameter %s", tparams[n-1])
+				return nil
+			}
+			for _, a := range args[npars-1:] {
+				pack = append(pack, Default(a.typ))
+			}
+			if npars > 1 {
+				params = NewTuple(params.vars[:npars-1]...)
+			} else {
+				params = nil
+			}
+			args = args[:npars-1]
+		}
+	}
+
+	if n > 1 {
+		targs = check.infer(call, tparams[:n-1], targs, params, args, true)
+		if targs == nil {
+			return nil
+		}
+	}
+	return packTArgs(tparams, append(targs, pack...))
+}
+
+// calleeIdent returns the identifier denoting the function, method, or
+// built-in called via the function expression fun of a call, or nil.
+func calleeIdent(fun ast.Expr) *ast.Ident {
+	fun = unparen(fun)
+	if ix := typeparams.UnpackIndexExpr(fun); ix != nil {
+		fun = unparen(ix.X)
+	}
+	switch e := fun.(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	}
+	return nil
+}
+
+// staticCallee returns obj, the object denoted by the callee identifier of
+// a call, if it is a function, method, or built-in that is statically known
+// to be called, or nil. Calls of interface methods are dynamic and have no
+// such callee.
+func staticCallee(obj Object) Object {
+	switch obj := obj.(type) {
+	case *Builtin:
+		return obj
+	case *Func:
+		if sig, _ := obj.typ.(*Signature); sig != nil {
+			if sig.recv != nil && IsInterface(sig.recv.typ) {
+				return nil // dynamic call
+			}
+			return obj
+		}
+	}
+	return nil
+}
+
 var cgoPrefixes = [...]string{
 	"_Ciconst_",
 	"_Cfconst_",
@@ -436,8 +610,14 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 			} else {
 				exp = pkg.scope.Lookup(sel)
 				if exp == nil {
-					if !pkg.fake {
-						check.errorf(e.Sel, _UndeclaredImportedName, "%s not declared by package %s", sel, pkg.name)
+					if pkg.fake {
+						check.recordSkipped(nil, e.Pos(), SkipFakeImport)
+					} else {
+						if alt := suggestImported(pkg, sel); alt != "" {
+							check.errorWithSuggestion(e.Sel, _UndeclaredImportedName, alt, "%s not declared by package %s (did you mean %s?)", sel, pkg.name, alt)
+						} else {
+							check.errorf(e.Sel, _UndeclaredImportedName, "%s not declared by package %s", sel, pkg.name)
+						}
 					}
 					goto Error
 				}
@@ -447,6 +627,9 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 				}
 			}
 			check.recordUse(e.Sel, exp)
+			if !pkg.cgo {
+				check.useImported(exp)
+			}
 
 			// Simplified version of the code for *ast.Idents:
 			// - imported objects are always fully initialized
@@ -476,6 +659,7 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 				x.mode = builtin
 				x.typ = exp.typ
 				x.id = exp.id
+				x.custom = exp.custom
 			default:
 				check.dump("%v: unexpected object %v", e.Sel.Pos(), exp)
 				unreachable()
@@ -512,6 +696,7 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 			}
 
 			// Check if capitalization of sel matters and provide better error message in that case.
+			var alt string
 			if len(sel) > 0 {
 				var changeCase string
 				if r := rune(sel[0]); unicode.IsUpper(r) {
@@ -520,11 +705,16 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 					changeCase = string(unicode.ToUpper(r)) + sel[1:]
 				}
 				if obj, _, _ = LookupFieldOrMethod(x.typ, x.mode == variable, check.pkg, changeCase); obj != nil {
-					why += ", but does have " + changeCase
+					alt = changeCase
+				} else {
+					alt = check.suggestSelector(x.typ, sel, false)
 				}
 			}
+			if alt != "" {
+				why += ", but does have " + alt
+			}
 
-			check.errorf(e.Sel, _MissingFieldOrMethod, "%s.%s undefined (%s)", x.expr, sel, why)
+			check.errorWithSuggestion(e.Sel, _MissingFieldOrMethod, alt, "%s.%s undefined (%s)", x.expr, sel, why)
 		}
 		goto Error
 	}
@@ -558,7 +748,7 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 				if ptrRecv {
 					recv = NewPointer(recv)
 				} else {
-					recv = recv.(*Pointer).base
+					recv = Unalias(recv).(*Pointer).base
 				}
 			}
 			// Disable reporting of errors during inference below. If we're unable to infer
@@ -570,12 +760,7 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 				// We may reach here if there were other errors (see issue #40056).
 				goto Error
 			}
-			// Don't modify m. Instead - for now - make a copy of m and use that instead.
-			// (If we modify m, some tests will fail; possibly because the m is in use.)
-			// TODO(gri) investigate and provide a correct explanation here
-			copy := *m
-			copy.typ = check.subst(e.Pos(), m.typ, makeSubstMap(sig.RecvTypeParams().list(), targs), nil)
-			obj = &copy
+			obj = check.instantiateMethod(e.Pos(), m, targs)
 		}
 		// TODO(gri) we also need to do substitution for parameterized interface methods
 		//           (this breaks code in testdata/linalg.go2 at the moment)
@@ -586,8 +771,11 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 		// method expression
 		m, _ := obj.(*Func)
 		if m == nil {
-			// TODO(gri) should check if capitalization of sel matters and provide better error message in that case
-			check.errorf(e.Sel, _MissingFieldOrMethod, "%s.%s undefined (type %s has no method %s)", x.expr, sel, x.typ, sel)
+			if alt := check.suggestSelector(x.typ, sel, true); alt != "" {
+				check.errorWithSuggestion(e.Sel, _MissingFieldOrMethod, alt, "%s.%s undefined (type %s has no method %s, but does have %s)", x.expr, sel, x.typ, sel, alt)
+			} else {
+				check.errorf(e.Sel, _MissingFieldOrMethod, "%s.%s undefined (type %s has no method %s)", x.expr, sel, x.typ, sel)
+			}
 			goto Error
 		}
 
@@ -667,7 +855,7 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 					// includes the methods of typ.
 					// Variables are addressable, so we can always take their
 					// address.
-					if _, ok := typ.(*Pointer); !ok && !IsInterface(typ) {
+					if _, ok := Unalias(typ).(*Pointer); !ok && !IsInterface(typ) {
 						typ = &Pointer{base: typ}
 					}
 				}
@@ -715,6 +903,40 @@ Error:
 	x.expr = e
 }
 
+// instantiateMethod returns method m of a generic type, with its receiver
+// type parameters substituted by targs. If the signature of m does not
+// depend on its receiver type parameters, the result is m itself. Otherwise
+// the result is a copy of m, shared by all selections of m with the same
+// type arguments, and by the method sets of instances (see
+// Named.instanceMethod). check may be nil, in which case the result is not
+// shared.
+func (check *Checker) instantiateMethod(pos token.Pos, m *Func, targs []Type) *Func {
+	var env *Environment
+	var key methodKey
+	if check != nil {
+		env = check.conf.Environment
+		key = methodKey{m, env.targsHash(targs)}
+		if inst := env.methodForKey(key, nil); inst != nil {
+			return inst
+		}
+	}
+
+	sig := m.typ.(*Signature)
+	inst := m
+	if typ := check.subst(pos, sig, makeSubstMap(sig.RecvTypeParams().list(), targs), env); typ != m.typ {
+		// Don't modify m. Instead - for now - make a copy of m and use that instead.
+		// (If we modify m, some tests will fail; possibly because the m is in use.)
+		// TODO(gri) investigate and provide a correct explanation here
+		copy := *m
+		copy.typ = typ
+		inst = &copy
+	}
+	if env == nil {
+		return inst
+	}
+	return env.methodForKey(key, inst)
+}
+
 // use type-checks each argument.
 // Useful to make sure expressions are evaluated
 // (and variables are "used") in the presence of other errors.
@@ -7,6 +7,7 @@
 package types
 
 import (
+	gocontext "context" // package types declares context
 	"errors"
 	"fmt"
 	"go/ast"
@@ -52,6 +53,7 @@ type context struct {
 	isPanic       map[*ast.CallExpr]bool // set of panic call expressions (used for termination check)
 	hasLabel      bool                   // set if a function makes use of labels (only ~1% of functions); unused outside functions
 	hasCallOrRecv bool                   // set if an expression contains a function call or channel receive operation
+	variadicElt   ast.Expr               // if set, the element type of the final ... parameter being collected
 }
 
 // lookup looks up name in the current context and returns the matching object, or nil.
@@ -89,6 +91,11 @@ type Checker struct {
 	nextID  uint64                 // unique Id for type parameters (first valid Id is 1)
 	objMap  map[Object]*declInfo   // maps package-level objects and (non-interface) methods to declaration info
 	impMap  map[importKey]*Package // maps (import path, source directory) to (complete or fake) package
+	literal map[string]Type        // canonical struct and interface types, by type hash (see Checker.internLiteral)
+
+	// prefetched holds the results of concurrent imports not yet consumed
+	// by importPackage; it is only set while collecting objects.
+	prefetched map[importKey]importResult
 
 	// pkgPathMap maps package names to the set of distinct import paths we've
 	// seen for that name, anywhere in the import graph. It is used for
@@ -103,6 +110,7 @@ type Checker struct {
 	// information collected during type-checking of a set of package files
 	// (initialized by Files, valid only for the duration of check.Files;
 	// maps and lists are allocated on demand)
+	ctx          gocontext.Context         // context passed to importers
 	files        []*ast.File               // package files
 	imports      []*PkgName                // list of imported packages
 	dotImportMap map[dotImportKey]*PkgName // maps dot-imported objects to the package they were dot-imported through
@@ -112,6 +120,23 @@ type Checker struct {
 	untyped  map[ast.Expr]exprInfo // map of expressions without final type
 	delayed  []func()              // stack of delayed action segments; segments are processed in FIFO order
 	objPath  []Object              // path of object dependencies during type inference (for cycle reporting)
+	instPath []Instantiation       // path of instantiations being verified or validated (for error context)
+	slabs                          // batch allocation of short-lived data (see alloc.go)
+	memory   memoryBudget          // accounting for Config.MemoryBudget (see budget.go)
+	timer    declTimer             // accounting for Info.DeclTimes (see timing.go)
+
+	// function bodies scheduled for checking but not yet fully checked,
+	// mapped to their package-level function, if any (see skipped.go)
+	bodies map[*ast.BlockStmt]Object
+
+	// positions of the uses of language features reported as
+	// requiring a newer version (see Checker.forwardCompat)
+	tooNew map[token.Pos]bool
+
+	// identifier denoting the callee of the innermost call being checked,
+	// and the object recorded for it (used to determine the callees of calls)
+	calleeIdent *ast.Ident
+	calleeObj   Object
 
 	// context within which the current object is type-checked
 	// (valid only for the duration of type-checking a specific object)
@@ -140,14 +165,22 @@ func (check *Checker) rememberUntyped(e ast.Expr, lhs bool, mode operandMode, ty
 		check.untyped = m
 	}
 	m[e] = exprInfo{lhs, mode, typ, val}
+	if m := check.Untyped; m != nil {
+		if _, found := m[e]; !found {
+			m[e] = Untyped{Type: typ, Default: Default(typ)}
+		}
+	}
 }
 
 // later pushes f on to the stack of actions that will be processed later;
 // either at the end of the current statement, or in case of a local constant
 // or variable declaration, before the constant or variable is in scope
 // (so that f still sees the scope before any new declarations).
+//
+// If declaration times are recorded, the time spent in f is charged to the
+// package-level declaration being checked, if any.
 func (check *Checker) later(f func()) {
-	check.delayed = append(check.delayed, f)
+	check.delayed = append(check.delayed, check.timedDecl(f))
 }
 
 // push pushes obj onto the object path and returns its index in the path.
@@ -214,7 +247,12 @@ func (check *Checker) initFiles(files []*ast.File) {
 
 	// determine package name and collect valid files
 	pkg := check.pkg
-	for _, file := range files {
+	check.declarePredeclared()
+	seen := make(map[*token.File]int)
+	for i, file := range files {
+		if !check.checkFileSet(i, file, seen) {
+			continue // ignore this file
+		}
 		switch name := file.Name.Name; pkg.name {
 		case "":
 			if name != "_" {
@@ -234,6 +272,26 @@ func (check *Checker) initFiles(files []*ast.File) {
 	}
 }
 
+// declarePredeclared declares the objects of Config.Predeclared, if any,
+// in a new universe scope of the package.
+func (check *Checker) declarePredeclared() {
+	if len(check.conf.Predeclared) == 0 {
+		return
+	}
+	univ := NewScope(Universe, token.NoPos, token.NoPos, "universe")
+	univ.isUniv = true
+	for _, obj := range check.conf.Predeclared {
+		if obj.Pkg() != nil {
+			panic(fmt.Sprintf("predeclared object %s belongs to package %s", obj.Name(), obj.Pkg().Path()))
+		}
+		if univ.Lookup(obj.Name()) != nil {
+			panic(fmt.Sprintf("predeclared object %s declared twice", obj.Name()))
+		}
+		univ.insert(obj.Name(), obj) // leave obj.parent alone; obj may be shared
+	}
+	check.pkg.scope.parent = univ
+}
+
 // A bailout panic is used for early termination.
 type bailout struct{}
 
@@ -249,26 +307,53 @@ func (check *Checker) handleBailout(err *error) {
 }
 
 // Files checks the provided files as part of the checker's package.
-func (check *Checker) Files(files []*ast.File) error { return check.checkFiles(files) }
+func (check *Checker) Files(files []*ast.File) error {
+	return check.checkFiles(gocontext.Background(), files)
+}
+
+// FilesContext is like Files but checks the files in the given context.
+// See Config.CheckContext for details.
+func (check *Checker) FilesContext(ctx gocontext.Context, files []*ast.File) error {
+	return check.checkFiles(ctx, files)
+}
 
 var errBadCgo = errors.New("cannot use FakeImportC and go115UsesCgo together")
 
-func (check *Checker) checkFiles(files []*ast.File) (err error) {
+func (check *Checker) checkFiles(ctx gocontext.Context, files []*ast.File) (err error) {
 	if check.conf.FakeImportC && check.conf.go115UsesCgo {
 		return errBadCgo
 	}
 
+	// runs after handleBailout, to also record what was not checked
+	// if checking stopped early
+	defer check.recordUnchecked()
 	defer check.handleBailout(&err)
 
+	check.ctx = ctx
+	defer func() { check.ctx = nil }()
+
 	check.initFiles(files)
+	check.initDeps(files)
+	if check.conf.MemoryBudget > 0 {
+		check.memory.start()
+	}
+	check.timer = declTimer{}
 
 	check.collectObjects()
 
+	if ctx.Err() != nil {
+		// imports may have been abandoned; don't continue
+		check.firstErr = ctx.Err()
+		return
+	}
+
 	check.packageObjects()
 
 	check.processDelayed(0) // incl. all functions
 
-	check.initOrder()
+	if !check.conf.ConstantsOnly {
+		check.initOrder()
+	}
 
 	if !check.conf.DisableUnusedImportCheck {
 		check.unusedImports()
@@ -276,13 +361,14 @@ func (check *Checker) checkFiles(files []*ast.File) (err error) {
 
 	check.recordUntyped()
 
-	check.pkg.complete = true
+	check.pkg.complete = !check.conf.ConstantsOnly
 
 	// no longer needed - release memory
 	check.imports = nil
 	check.dotImportMap = nil
 	check.pkgPathMap = nil
 	check.seenPkgMap = nil
+	check.slabs = slabs{}
 
 	// TODO(rFindley) There's more memory we should release at this point.
 
@@ -332,7 +418,7 @@ func (check *Checker) record(x *operand) {
 }
 
 func (check *Checker) recordUntyped() {
-	if !debug && check.Types == nil {
+	if !debug && check.Types == nil && check.Untyped == nil {
 		return // nothing to do
 	}
 
@@ -342,6 +428,18 @@ func (check *Checker) recordUntyped() {
 			unreachable()
 		}
 		check.recordTypeAndValue(x, info.mode, info.typ, info.val)
+		check.recordFinalType(x, info.typ)
+	}
+}
+
+// recordFinalType records the final type typ of the untyped expression x.
+func (check *Checker) recordFinalType(x ast.Expr, typ Type) {
+	if m := check.Untyped; m != nil {
+		if u, found := m[x]; found {
+			u.Final = typ
+			u.Converted = isTyped(typ) && !Identical(typ, u.Default)
+			m[x] = u
+		}
 	}
 }
 
@@ -427,6 +525,9 @@ func (check *Checker) recordUse(id *ast.Ident, obj Object) {
 	if m := check.Uses; m != nil {
 		m[id] = obj
 	}
+	if id == check.calleeIdent {
+		check.calleeObj = obj
+	}
 }
 
 func (check *Checker) recordImplicit(node ast.Node, obj Object) {
@@ -445,6 +546,54 @@ func (check *Checker) recordSelection(x *ast.SelectorExpr, kind SelectionKind, r
 	}
 }
 
+func (check *Checker) recordCallee(call *ast.CallExpr, obj Object, targs []Type) {
+	assert(call != nil)
+	assert(obj != nil)
+	if m := check.Callees; m != nil {
+		var list *TypeList
+		if len(targs) > 0 {
+			list = NewTypeList(targs)
+		}
+		m[call] = Callee{obj, list}
+	}
+}
+
+func (check *Checker) recordLitElement(e ast.Expr, elem LitElement) {
+	assert(e != nil)
+	if m := check.LitElements; m != nil {
+		m[e] = elem
+	}
+}
+
+// recordSatisfies records the methods of the concrete type V that satisfy
+// the methods of the interface T, which V implements.
+func (check *Checker) recordSatisfies(V Type, T *Interface) {
+	if check == nil {
+		return
+	}
+	m := check.Satisfies
+	if m == nil || IsInterface(V) || asTypeParam(V) != nil {
+		return
+	}
+next:
+	for _, im := range T.typeSet().methods {
+		obj, _, _ := lookupFieldOrMethod(V, false, im.pkg, im.name)
+		if obj == nil {
+			obj, _, _ = lookupFieldOrMethod(NewPointer(V), false, im.pkg, im.name)
+		}
+		f, _ := obj.(*Func)
+		if f == nil {
+			continue
+		}
+		for _, g := range m[f] {
+			if g == im {
+				continue next
+			}
+		}
+		m[f] = append(m[f], im)
+	}
+}
+
 func (check *Checker) recordScope(node ast.Node, scope *Scope) {
 	assert(node != nil)
 	assert(scope != nil)
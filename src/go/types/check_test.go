@@ -19,10 +19,14 @@
 //	func f() {
 //		_ = x /* ERROR "not declared" */ + 1
 //	}
+//
+// A leading comment of the form // -flag1 -flag2=value sets Config
+// options for a test file (see parseFlags).
 
 package types_test
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"go/ast"
@@ -199,6 +203,38 @@ func asGoVersion(s string) string {
 	return ""
 }
 
+// parseFlags sets the Config options given by the flags in the leading
+// comment of src, which has the form
+//
+//	// -flag1 -flag2=value
+//
+// Files without such a comment are checked with the default options.
+func parseFlags(t *testing.T, src []byte, conf *Config) {
+	const prefix = "// -"
+	if !bytes.HasPrefix(src, []byte(prefix)) {
+		return
+	}
+	line := src[len("//"):]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	var exempt string
+	flags := flag.NewFlagSet("", flag.ContinueOnError)
+	flags.BoolVar(&conf.ReportShadowing, "reportShadowing", false, "")
+	flags.StringVar(&exempt, "shadowingExempt", "", "comma-separated names")
+	flags.BoolVar(&conf.Experiments.VariadicTypeParams, "variadicTypeParams", false, "")
+	flags.BoolVar(&conf.Experiments.EmbeddedTypeParams, "embeddedTypeParams", false, "")
+	flags.BoolVar(&conf.ReportImpossibleAssertions, "reportImpossibleAssertions", false, "")
+	flags.BoolVar(&conf.ForwardCompatible, "forwardCompatible", false, "")
+	if err := flags.Parse(strings.Fields(string(line))); err != nil {
+		t.Fatalf("invalid flags: %v", err)
+	}
+	if exempt != "" {
+		conf.ShadowingExempt = strings.Split(exempt, ",")
+	}
+}
+
 func testFiles(t *testing.T, sizes Sizes, filenames []string, srcs [][]byte, manual bool, imp Importer) {
 	if len(filenames) == 0 {
 		t.Fatal("no source files")
@@ -240,6 +276,7 @@ func testFiles(t *testing.T, sizes Sizes, filenames []string, srcs [][]byte, man
 	var conf Config
 	conf.Sizes = sizes
 	conf.GoVersion = goVersion
+	parseFlags(t, srcs[0], &conf)
 
 	// special case for importC.src
 	if len(filenames) == 1 {
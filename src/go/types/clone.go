@@ -0,0 +1,367 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the deep copying of packages.
+
+package types
+
+import (
+	"fmt"
+	"go/token"
+	"reflect"
+)
+
+// ClonePackage returns a deep copy of the type-checked package pkg: the
+// returned package, the packages it depends on, and their scopes, objects,
+// and types form a new object graph that shares nothing with the original
+// one except for the immutable predeclared objects and types of Universe
+// and package unsafe. Facts attached to the packages and their objects are
+// copied, too. Objects keep their positions, which refer to the file set
+// pkg was checked with.
+//
+// Information computed lazily, such as type sets and the methods of
+// instances, is recomputed for the copy as needed. Instances of generic
+// types in the copy are not part of any Environment.
+//
+// ClonePackage must not be called while pkg or one of its dependencies
+// is being type-checked.
+func ClonePackage(pkg *Package) *Package {
+	return newCloner(false).clone(pkg)
+}
+
+// CanonicalPackage returns a copy of the type-checked package pkg as made by
+// ClonePackage, but in canonical form: the positions of all objects and
+// scopes are token.NoPos, and the IDs of type parameters, which are shown
+// as subscripts by TypeString, are numbered from 1 in the order in which
+// the package's scopes are traversed, with package-level objects in name
+// order. Checking the same package twice, even from files at different
+// positions or within different file sets, yields canonical packages that
+// differ only in their identities, so that descriptions of their objects
+// and types, such as those produced by ObjectString, can be hashed or
+// compared with golden files. Type parameters with the same IDs remain
+// distinct types, also for instantiation in an Environment.
+//
+// Since they have no positions, the scopes of a canonical package can't be
+// searched with Scope.Innermost and Scope.LookupParent with a valid position.
+func CanonicalPackage(pkg *Package) *Package {
+	return newCloner(true).clone(pkg)
+}
+
+func newCloner(canonical bool) *cloner {
+	return &cloner{
+		canonical: canonical,
+		pkgs:      make(map[*Package]*Package),
+		scopes:    make(map[*Scope]*Scope),
+		objs:      make(map[Object]Object),
+		typs:      make(map[Type]Type),
+	}
+}
+
+func (c *cloner) clone(pkg *Package) *Package {
+	p := c.pkg(pkg)
+
+	// The methods of instances are those of their origin (see Named.load),
+	// which may not have been copied yet when the instances were.
+	for _, inst := range c.insts {
+		inst.methods = inst.orig.methods[:len(inst.orig.methods):len(inst.orig.methods)]
+	}
+	return p
+}
+
+// A cloner copies packages, scopes, objects, and types, and remembers
+// the copies it made so that the copied graph has the shape of the
+// original one.
+type cloner struct {
+	canonical bool   // if set, positions are dropped and type parameter ids renumbered
+	lastID    uint64 // most recently assigned type parameter ID, if canonical
+	pkgs      map[*Package]*Package
+	scopes    map[*Scope]*Scope
+	objs      map[Object]Object
+	typs      map[Type]Type
+	insts     []*Named // copied instances, whose methods are set last
+}
+
+func (c *cloner) pkg(pkg *Package) *Package {
+	if pkg == nil || pkg == Unsafe {
+		return pkg
+	}
+	if p := c.pkgs[pkg]; p != nil {
+		return p
+	}
+	p := &Package{path: pkg.path, name: pkg.name, complete: pkg.complete, fake: pkg.fake, cgo: pkg.cgo}
+	c.pkgs[pkg] = p
+	p.scope = c.scope(pkg.scope)
+	for _, imp := range pkg.imports {
+		p.imports = append(p.imports, c.pkg(imp))
+	}
+	for _, f := range pkg.Facts() {
+		// Facts are pointers (see SetFact); copy the values they point to.
+		v := reflect.New(reflect.TypeOf(f.Fact).Elem())
+		v.Elem().Set(reflect.ValueOf(f.Fact).Elem())
+		p.facts.set(factKey{c.object(f.Object), reflect.TypeOf(f.Fact)}, v.Interface().(Fact))
+	}
+	return p
+}
+
+func (c *cloner) scope(s *Scope) *Scope {
+	if s == nil || s == Universe || s == Unsafe.scope {
+		return s
+	}
+	if t := c.scopes[s]; t != nil {
+		return t
+	}
+	t := &Scope{number: s.number, pos: s.pos, end: s.end, comment: s.comment, isFunc: s.isFunc, isUniv: s.isUniv}
+	if c.canonical {
+		t.pos, t.end = token.NoPos, token.NoPos
+	}
+	c.scopes[s] = t
+	t.parent = c.scope(s.parent)
+	for _, child := range s.children {
+		t.children = append(t.children, c.scope(child))
+	}
+	for _, name := range s.Names() {
+		t.insert(name, c.object(s.Lookup(name))) // resolves lazy objects
+	}
+	return t
+}
+
+func (c *cloner) object(obj Object) Object {
+	if obj == nil || obj.Pkg() == nil || obj.Pkg() == Unsafe {
+		return obj // predeclared, or belonging to a predeclared type
+	}
+	if o := c.objs[obj]; o != nil {
+		return o
+	}
+
+	// Record the copy before copying its type,
+	// which may refer back to the object.
+	var o Object
+	var base *object
+	switch obj := obj.(type) {
+	case *PkgName:
+		x := *obj
+		x.imported = c.pkg(obj.imported)
+		o, base = &x, &x.object
+	case *Const:
+		x := *obj
+		o, base = &x, &x.object
+	case *TypeName:
+		x := *obj
+		o, base = &x, &x.object
+	case *Var:
+		x := *obj
+		o, base = &x, &x.object
+	case *Func:
+		x := *obj
+		o, base = &x, &x.object
+	case *Label:
+		x := *obj
+		o, base = &x, &x.object
+	default:
+		panic(fmt.Sprintf("ClonePackage: unexpected object %v", obj))
+	}
+	if c.canonical {
+		base.pos, base.scopePos_ = token.NoPos, token.NoPos
+	}
+	c.objs[obj] = o
+	base.parent = c.scope(base.parent)
+	base.pkg = c.pkg(base.pkg)
+	base.typ = c.typ(base.typ)
+	return o
+}
+
+func (c *cloner) typ(typ Type) Type {
+	switch t := typ.(type) {
+	case nil, *Basic:
+		return typ
+	case *Interface:
+		if t == &emptyInterface {
+			return typ
+		}
+	case *Named:
+		if t.obj.pkg == nil {
+			return typ // error, comparable
+		}
+	}
+	if t := c.typs[typ]; t != nil {
+		return t
+	}
+
+	// As for objects, composite types are recorded
+	// before their components are copied.
+	switch t := typ.(type) {
+	case *Array:
+		x := &Array{len: t.len}
+		c.typs[t] = x
+		x.elem = c.typ(t.elem)
+		return x
+
+	case *Slice:
+		x := new(Slice)
+		c.typs[t] = x
+		x.elem = c.typ(t.elem)
+		return x
+
+	case *Struct:
+		x := &Struct{tags: append([]string(nil), t.tags...)}
+		c.typs[t] = x
+		x.fields = c.vars(t.fields)
+		return x
+
+	case *Pointer:
+		x := new(Pointer)
+		c.typs[t] = x
+		x.base = c.typ(t.base)
+		return x
+
+	case *Tuple:
+		if t == nil {
+			return t
+		}
+		x := new(Tuple)
+		c.typs[t] = x
+		x.vars = c.vars(t.vars)
+		return x
+
+	case *Signature:
+		x := &Signature{variadic: t.variadic}
+		c.typs[t] = x
+		x.rparams = c.typeParams(t.rparams)
+		x.tparams = c.typeParams(t.tparams)
+		x.scope = c.scope(t.scope)
+		if t.recv != nil {
+			x.recv = c.object(t.recv).(*Var)
+		}
+		x.params = c.tuple(t.params)
+		x.results = c.tuple(t.results)
+		return x
+
+	case *Union:
+		x := new(Union)
+		c.typs[t] = x
+		for _, term := range t.terms {
+			x.terms = append(x.terms, NewTerm(term.tilde, c.typ(term.typ)))
+		}
+		return x
+
+	case *Interface:
+		x := &Interface{complete: t.complete}
+		c.typs[t] = x
+		if t.obj != nil {
+			x.obj = c.object(t.obj).(*TypeName)
+		}
+		x.methods = c.funcs(t.methods)
+		for _, e := range t.embeddeds {
+			x.embeddeds = append(x.embeddeds, c.typ(e))
+		}
+		if t.embedPos != nil && !c.canonical {
+			pos := append([]token.Pos(nil), *t.embedPos...)
+			x.embedPos = &pos
+		}
+		return x
+
+	case *Map:
+		x := new(Map)
+		c.typs[t] = x
+		x.key = c.typ(t.key)
+		x.elem = c.typ(t.elem)
+		return x
+
+	case *Chan:
+		x := &Chan{dir: t.dir}
+		c.typs[t] = x
+		x.elem = c.typ(t.elem)
+		return x
+
+	case *Named:
+		t.load().expand(nil)
+		x := &Named{state: namedExpanded}
+		c.typs[t] = x
+		x.obj = c.object(t.obj).(*TypeName)
+		x.orig = c.typ(t.orig).(*Named)
+		x.fromRHS = c.typ(t.fromRHS)
+		x.underlying = c.typ(t.underlying)
+		x.tparams = c.typeParams(t.tparams)
+		if t.targs != nil {
+			x.targs = &TypeList{c.types(t.targs.types)}
+		}
+		if x.orig == x {
+			x.methods = c.funcs(t.methods)
+		} else {
+			c.insts = append(c.insts, x)
+		}
+		return x
+
+	case *Alias:
+		x := new(Alias)
+		c.typs[t] = x
+		x.obj = c.object(t.obj).(*TypeName)
+		x.fromRHS = c.typ(t.fromRHS)
+		x.actual = c.typ(t.actual)
+		return x
+
+	case *TypeParam:
+		x := &TypeParam{id: t.id, uid: nextID(), index: t.index, variadic: t.variadic}
+		if c.canonical {
+			c.lastID++
+			x.id = c.lastID
+		}
+		c.typs[t] = x
+		x.obj = c.object(t.obj).(*TypeName)
+		x.bound = c.typ(t.bound)
+		return x
+	}
+	panic(fmt.Sprintf("ClonePackage: unexpected type %T", typ))
+}
+
+func (c *cloner) tuple(t *Tuple) *Tuple {
+	if t == nil {
+		return nil
+	}
+	return c.typ(t).(*Tuple)
+}
+
+func (c *cloner) vars(list []*Var) []*Var {
+	if list == nil {
+		return nil
+	}
+	res := make([]*Var, len(list))
+	for i, v := range list {
+		res[i] = c.object(v).(*Var)
+	}
+	return res
+}
+
+func (c *cloner) funcs(list []*Func) []*Func {
+	if list == nil {
+		return nil
+	}
+	res := make([]*Func, len(list))
+	for i, f := range list {
+		res[i] = c.object(f).(*Func)
+	}
+	return res
+}
+
+func (c *cloner) types(list []Type) []Type {
+	if list == nil {
+		return nil
+	}
+	res := make([]Type, len(list))
+	for i, t := range list {
+		res[i] = c.typ(t)
+	}
+	return res
+}
+
+func (c *cloner) typeParams(list *TypeParamList) *TypeParamList {
+	if list == nil {
+		return nil
+	}
+	res := &TypeParamList{make([]*TypeParam, len(list.tparams))}
+	for i, tpar := range list.tparams {
+		res.tparams[i] = c.typ(tpar).(*TypeParam)
+	}
+	return res
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the conversion of positions to character columns.
+
+package types
+
+import (
+	"fmt"
+	"go/token"
+	"unicode/utf8"
+)
+
+// Columns of token.Position, and hence of positions reported in Errors,
+// count bytes. RuneColumn and UTF16Column convert them to columns counting
+// characters, as used by editors and protocols such as LSP. Both need the
+// contents src of the file containing pos, and count columns relative to
+// the lines of the file itself, ignoring line directives. Bytes that are
+// not valid UTF-8 count as one character each.
+
+// RuneColumn returns the 1-based column of the position pos of fset in
+// the file with contents src, counted in Unicode code points (runes).
+func RuneColumn(fset *token.FileSet, pos token.Pos, src []byte) (int, error) {
+	prefix, err := linePrefix(fset, pos, src)
+	if err != nil {
+		return 0, err
+	}
+	return utf8.RuneCount(prefix) + 1, nil
+}
+
+// UTF16Column returns the 1-based column of the position pos of fset in
+// the file with contents src, counted in UTF-16 code units: characters
+// outside the Basic Multilingual Plane count twice.
+func UTF16Column(fset *token.FileSet, pos token.Pos, src []byte) (int, error) {
+	prefix, err := linePrefix(fset, pos, src)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for len(prefix) > 0 {
+		r, size := utf8.DecodeRune(prefix)
+		if r >= 0x10000 {
+			n += 2 // surrogate pair
+		} else {
+			n++
+		}
+		prefix = prefix[size:]
+	}
+	return n + 1, nil
+}
+
+// linePrefix returns the contents of the line containing pos up to pos.
+func linePrefix(fset *token.FileSet, pos token.Pos, src []byte) ([]byte, error) {
+	file := fset.File(pos)
+	if file == nil {
+		return nil, fmt.Errorf("invalid position %d", pos)
+	}
+	if len(src) != file.Size() {
+		return nil, fmt.Errorf("contents of %s have %d bytes, want %d", file.Name(), len(src), file.Size())
+	}
+	line := file.PositionFor(pos, false).Line
+	return src[file.Offset(file.LineStart(line)):file.Offset(pos)], nil
+}
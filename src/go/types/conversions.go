@@ -7,6 +7,7 @@
 package types
 
 import (
+	"fmt"
 	"go/constant"
 	"unicode"
 )
@@ -21,8 +22,13 @@ func (check *Checker) conversion(x *operand, T Type) {
 	switch {
 	case constArg && isConstType(T):
 		// constant conversion
-		switch t := asBasic(T); {
+		switch t, val := asBasic(T), x.val; {
 		case representableConst(x.val, check, t, &x.val):
+			if isTyped(x.typ) {
+				// untyped arguments are checked for rounding
+				// when their final type is recorded
+				check.roundedConversion(x.Pos(), T, val, x.val)
+			}
 			ok = true
 		case isInteger(x.typ) && isString(t):
 			codepoint := unicode.ReplacementChar
@@ -81,13 +87,66 @@ func (check *Checker) conversion(x *operand, T Type) {
 // is tricky because we'd have to run updateExprType on the argument first.
 // (Issue #21982.)
 
+// A ConversionKind describes the rule of the spec permitting a conversion.
+type ConversionKind int
+
+// The conversion rules, in the order in which they are checked.
+const (
+	InvalidConversion             ConversionKind = iota // the conversion is not valid
+	AssignableConversion                                // the value is assignable to the target type
+	UnderlyingConversion                                // identical underlying types, ignoring struct tags
+	PointerBaseConversion                               // unnamed pointers with identical underlying base types
+	NumericConversion                                   // between integer or floating-point types
+	ComplexConversion                                   // between complex types
+	IntegerToStringConversion                           // from an integer to a string type
+	SliceToStringConversion                             // from a slice of bytes or runes to a string type
+	StringToSliceConversion                             // from a string to a slice of bytes or runes
+	UnsafePointerConversion                             // between a pointer or uintptr and unsafe.Pointer
+	SliceToArrayPointerConversion                       // from a slice to a pointer to an array
+)
+
+var conversionKindNames = [...]string{
+	InvalidConversion:             "invalid",
+	AssignableConversion:          "assignable",
+	UnderlyingConversion:          "underlying",
+	PointerBaseConversion:         "pointer base",
+	NumericConversion:             "numeric",
+	ComplexConversion:             "complex",
+	IntegerToStringConversion:     "integer to string",
+	SliceToStringConversion:       "slice to string",
+	StringToSliceConversion:       "string to slice",
+	UnsafePointerConversion:       "unsafe.Pointer",
+	SliceToArrayPointerConversion: "slice to array pointer",
+}
+
+func (k ConversionKind) String() string {
+	if 0 <= k && int(k) < len(conversionKindNames) {
+		return conversionKindNames[k]
+	}
+	return fmt.Sprintf("ConversionKind(%d)", int(k))
+}
+
+// A Conversion describes a conversion T(x) of a value x of type V.
+type Conversion struct {
+	Kind ConversionKind
+	// TypeParam reports whether V or T is a type parameter. In that case,
+	// Kind holds for each pair of types in the respective type sets.
+	TypeParam bool
+}
+
 // convertibleTo reports whether T(x) is valid.
 // The check parameter may be nil if convertibleTo is invoked through an
 // exported API call, i.e., when all methods have been type-checked.
 func (x *operand) convertibleTo(check *Checker, T Type, reason *string) bool {
+	return x.conversionKind(check, T, reason) != InvalidConversion
+}
+
+// conversionKind returns the rule permitting T(x), or InvalidConversion.
+// The check parameter may be nil, as for convertibleTo.
+func (x *operand) conversionKind(check *Checker, T Type, reason *string) ConversionKind {
 	// "x is assignable to T"
 	if ok, _ := x.assignableTo(check, T, nil); ok {
-		return true
+		return AssignableConversion
 	}
 
 	// "x's type and T have identical underlying types if tags are ignored"
@@ -95,47 +154,50 @@ func (x *operand) convertibleTo(check *Checker, T Type, reason *string) bool {
 	Vu := under(V)
 	Tu := under(T)
 	if IdenticalIgnoreTags(Vu, Tu) {
-		return true
+		return UnderlyingConversion
 	}
 
 	// "x's type and T are unnamed pointer types and their pointer base types
 	// have identical underlying types if tags are ignored"
-	if V, ok := V.(*Pointer); ok {
-		if T, ok := T.(*Pointer); ok {
+	if V, ok := Unalias(V).(*Pointer); ok {
+		if T, ok := Unalias(T).(*Pointer); ok {
 			if IdenticalIgnoreTags(under(V.base), under(T.base)) {
-				return true
+				return PointerBaseConversion
 			}
 		}
 	}
 
 	// "x's type and T are both integer or floating point types"
 	if isIntegerOrFloat(V) && isIntegerOrFloat(T) {
-		return true
+		return NumericConversion
 	}
 
 	// "x's type and T are both complex types"
 	if isComplex(V) && isComplex(T) {
-		return true
+		return ComplexConversion
 	}
 
 	// "x is an integer or a slice of bytes or runes and T is a string type"
-	if (isInteger(V) || isBytesOrRunes(Vu)) && isString(T) {
-		return true
+	if isInteger(V) && isString(T) {
+		return IntegerToStringConversion
+	}
+	if isBytesOrRunes(Vu) && isString(T) {
+		return SliceToStringConversion
 	}
 
 	// "x is a string and T is a slice of bytes or runes"
 	if isString(V) && isBytesOrRunes(Tu) {
-		return true
+		return StringToSliceConversion
 	}
 
 	// package unsafe:
 	// "any pointer or value of underlying type uintptr can be converted into a unsafe.Pointer"
 	if (isPointer(Vu) || isUintptr(Vu)) && isUnsafePointer(T) {
-		return true
+		return UnsafePointerConversion
 	}
 	// "and vice versa"
 	if isUnsafePointer(V) && (isPointer(Tu) || isUintptr(Tu)) {
-		return true
+		return UnsafePointerConversion
 	}
 
 	// "x is a slice, T is a pointer-to-array type,
@@ -144,8 +206,8 @@ func (x *operand) convertibleTo(check *Checker, T Type, reason *string) bool {
 		if p := asPointer(T); p != nil {
 			if a := asArray(p.Elem()); a != nil {
 				if Identical(s.Elem(), a.Elem()) {
-					if check == nil || check.allowVersion(check.pkg, 1, 17) {
-						return true
+					if check == nil || check.allowVersion(check.pkg, 1, 17) || check.forwardCompat(x, 1, 17, "conversion of slices to array pointers") {
+						return SliceToArrayPointerConversion
 					}
 					if reason != nil {
 						*reason = "conversion of slices to array pointers requires go1.17 or later"
@@ -155,7 +217,7 @@ func (x *operand) convertibleTo(check *Checker, T Type, reason *string) bool {
 		}
 	}
 
-	return false
+	return InvalidConversion
 }
 
 func isUintptr(typ Type) bool {
@@ -0,0 +1,152 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements custom built-in functions.
+
+package types
+
+import (
+	"go/ast"
+	"go/constant"
+)
+
+// A BuiltinFunc type-checks a call of a custom built-in function created
+// with NewBuiltin. It is called with the call expression and its
+// arguments, once they have been evaluated and their number has been
+// checked: args[i].IsType() reports whether the i'th argument is a type;
+// otherwise it is a value or constant, and untyped constants have their
+// untyped type. The call is invalid if err != nil; err is reported as
+// the error of the call.
+type BuiltinFunc func(call *ast.CallExpr, args []TypeAndValue) (res BuiltinResult, err error)
+
+// A BuiltinResult describes a valid call of a custom built-in function.
+type BuiltinResult struct {
+	// Params lists the types of the parameters to which the argument
+	// values are passed, following the rules for function arguments:
+	// untyped constants are converted to the parameter type. A nil
+	// entry, or a nil Params, stands for the argument's type, or for
+	// its default type if it is untyped. Params must be nil or have one
+	// entry per argument; entries for type arguments are ignored.
+	Params []Type
+
+	// Result is the type of the call's value, or nil if the call has no
+	// value and may only be used as a statement. If Value != nil, the
+	// call is a constant with the given value; Result must then be a
+	// basic type that can represent it.
+	Result Type
+	Value  constant.Value
+}
+
+// A customBuiltin describes a built-in function created with NewBuiltin.
+type customBuiltin struct {
+	name     string
+	nargs    int
+	variadic bool
+	fn       BuiltinFunc
+}
+
+// NewBuiltin returns a new built-in function with the given name, to be
+// declared with Config.Predeclared. Like the built-in functions of
+// Universe, it belongs to no package; Scope.Insert panics if it is inserted
+// in a scope other than Universe, as APIFingerprint and the writers of
+// export data don't support built-in functions as package-level objects.
+// Calls of the function must have at least nargs arguments, and exactly
+// nargs arguments unless variadic is set; they are checked by fn. Each
+// argument may be a type or a single value. As for the built-in functions
+// of Universe, the function has no type, so that it can only be called.
+func NewBuiltin(name string, nargs int, variadic bool, fn BuiltinFunc) *Builtin {
+	obj := &Builtin{object{name: name, typ: Typ[Invalid], color_: black}, 0, nil}
+	obj.custom = &customBuiltin{name, nargs, variadic, fn}
+	return obj
+}
+
+// customBuiltinCall type-checks a call of the custom built-in bin and
+// reports whether the call is valid, with *x holding the result, as for
+// Checker.builtin.
+func (check *Checker) customBuiltinCall(x *operand, call *ast.CallExpr, bin *customBuiltin) (_ bool) {
+	if call.Ellipsis.IsValid() {
+		check.invalidOp(atPos(call.Ellipsis), _InvalidDotDotDot, "invalid use of ... with built-in %s", bin.name)
+		check.use(call.Args...)
+		return
+	}
+
+	args := make([]*operand, len(call.Args))
+	valid := true
+	for i, e := range call.Args {
+		args[i] = new(operand)
+		check.exprOrType(args[i], e, false)
+		check.exclude(args[i], 1<<builtin)
+		if args[i].mode == invalid {
+			valid = false
+		}
+	}
+	if !valid {
+		return
+	}
+
+	// check argument count
+	{
+		msg := ""
+		if len(args) < bin.nargs {
+			msg = "not enough"
+		} else if !bin.variadic && len(args) > bin.nargs {
+			msg = "too many"
+		}
+		if msg != "" {
+			check.invalidOp(inNode(call, call.Rparen), _WrongArgCount, "%s arguments for %s (expected %d, found %d)", msg, call, bin.nargs, len(args))
+			return
+		}
+	}
+
+	targs := make([]TypeAndValue, len(args))
+	for i, a := range args {
+		targs[i] = TypeAndValue{a.mode, a.typ, a.val}
+	}
+	res, err := bin.fn(call, targs)
+	if err != nil {
+		check.errorf(call, _InvalidCustomBuiltin, "invalid call of %s: %v", bin.name, err)
+		return
+	}
+	if res.Params != nil && len(res.Params) != len(args) {
+		panic("custom built-in " + bin.name + " returned the wrong number of parameter types")
+	}
+
+	// pass the argument values
+	params := make([]Type, len(args))
+	for i, a := range args {
+		if a.mode == typexpr {
+			params[i] = a.typ
+			continue
+		}
+		var T Type
+		if res.Params != nil {
+			T = res.Params[i]
+		}
+		if T != nil || isUntyped(a.typ) {
+			check.assignment(a, T, "argument to "+bin.name)
+			if a.mode == invalid {
+				return
+			}
+		}
+		params[i] = a.typ
+	}
+
+	switch {
+	case res.Value != nil:
+		if t, _ := under(res.Result).(*Basic); t == nil || !representableConst(res.Value, check, t, nil) {
+			panic("custom built-in " + bin.name + " returned an invalid constant")
+		}
+		x.mode = constant_
+		x.val = res.Value
+	case res.Result != nil:
+		x.mode = value
+	default:
+		x.mode = novalue
+	}
+	x.typ = res.Result
+	if check.Types != nil && x.mode != constant_ {
+		check.recordBuiltinType(call.Fun, makeSig(Default(res.Result), params...))
+	}
+	return true
+}
@@ -107,6 +107,10 @@ func (check *Checker) objDecl(obj Object, def *Named) {
 		defer func() {
 			check.pop().setColor(black)
 		}()
+		if check.DeclTimes != nil && check.objMap[obj] != nil {
+			check.beginDecl(obj)
+			defer check.endDecl()
+		}
 
 	case black:
 		assert(obj.Type() != nil)
@@ -297,7 +301,7 @@ func (check *Checker) validType(typ Type, path []Object) typeInfo {
 		invalid
 	)
 
-	switch t := typ.(type) {
+	switch t := Unalias(typ).(type) {
 	case *Array:
 		return check.validType(t.elem, path)
 
@@ -333,6 +337,9 @@ func (check *Checker) validType(typ Type, path []Object) typeInfo {
 		switch t.info {
 		case unknown:
 			t.info = marked
+			if t.targs != nil {
+				defer check.pushInst(t.orig, t.targs, t.obj.pos)()
+			}
 			t.info = check.validType(t.fromRHS, append(path, t.obj)) // only types of current package added to path
 		case marked:
 			// cycle detected
@@ -583,7 +590,7 @@ func (check *Checker) typeDecl(obj *TypeName, tdecl *ast.TypeSpec, def *Named) {
 	check.later(func() {
 		check.validType(obj.typ, nil)
 		// If typ is local, an error was already reported where typ is specified/defined.
-		if check.isImportedConstraint(rhs) && !check.allowVersion(check.pkg, 1, 18) {
+		if check.isImportedConstraint(rhs) && !check.allowVersion(check.pkg, 1, 18) && !check.forwardCompat(tdecl.Type, 1, 18, "using type constraint %s", rhs) {
 			check.errorf(tdecl.Type, _Todo, "using type constraint %s requires go1.18 or later", rhs)
 		}
 	})
@@ -598,13 +605,17 @@ func (check *Checker) typeDecl(obj *TypeName, tdecl *ast.TypeSpec, def *Named) {
 
 	// alias declaration
 	if alias {
-		if !check.allowVersion(check.pkg, 1, 9) {
+		if !check.allowVersion(check.pkg, 1, 9) && !check.forwardCompat(atPos(tdecl.Assign), 1, 9, "type aliases") {
 			check.errorf(atPos(tdecl.Assign), _BadDecl, "type aliases requires go1.9 or later")
 		}
 
 		obj.typ = Typ[Invalid]
 		rhs = check.varType(tdecl.Type)
-		obj.typ = rhs
+		if check.conf.EnableAlias && rhs != Typ[Invalid] {
+			obj.typ = NewAlias(obj, rhs)
+		} else {
+			obj.typ = rhs
+		}
 		return
 	}
 
@@ -615,7 +626,7 @@ func (check *Checker) typeDecl(obj *TypeName, tdecl *ast.TypeSpec, def *Named) {
 	if tdecl.TypeParams != nil {
 		check.openScope(tdecl, "type parameters")
 		defer check.closeScope()
-		check.collectTypeParams(&named.tparams, tdecl.TypeParams)
+		check.collectTypeParams(&named.tparams, tdecl.TypeParams, false)
 	}
 
 	// determine underlying type of named
@@ -647,7 +658,9 @@ func (check *Checker) typeDecl(obj *TypeName, tdecl *ast.TypeSpec, def *Named) {
 	}
 }
 
-func (check *Checker) collectTypeParams(dst **TypeParamList, list *ast.FieldList) {
+// collectTypeParams declares the type parameters of list and sets *dst to them.
+// If variadicOk is set, the last type parameter may be variadic.
+func (check *Checker) collectTypeParams(dst **TypeParamList, list *ast.FieldList, variadicOk bool) {
 	var tparams []*TypeParam
 	// Declare type parameters up-front, with empty interface as type bound.
 	// The scope of type parameters starts at the beginning of the type parameter
@@ -665,19 +678,30 @@ func (check *Checker) collectTypeParams(dst **TypeParamList, list *ast.FieldList
 	var bound Type
 	var bounds []Type
 	var posns []positioner // bound positions
-	for _, f := range list.List {
+	var btyp ast.Expr      // bound type expression
+	for i, f := range list.List {
 		if f.Type == nil {
 			goto next
 		}
+		btyp = f.Type
+		if t, _ := btyp.(*ast.Ellipsis); t != nil && check.conf.Experiments.VariadicTypeParams {
+			btyp = t.Elt
+			if variadicOk && i == len(list.List)-1 && len(f.Names) == 1 {
+				tparams[index].variadic = true
+			} else {
+				check.softErrorf(t, _MisplacedDotDotDot, "can only use ... with final type parameter of a function")
+				// ignore ... and continue
+			}
+		}
 		// The predeclared identifier "any" is visible only as a type bound in a type parameter list.
 		// If we allow "any" for general use, this if-statement can be removed (issue #33232).
-		if name, _ := unparen(f.Type).(*ast.Ident); name != nil && name.Name == "any" && check.lookup("any") == universeAny {
+		if name, _ := unparen(btyp).(*ast.Ident); name != nil && name.Name == "any" && check.lookup("any") == universeAny {
 			bound = universeAny.Type()
 		} else {
-			bound = check.typ(f.Type)
+			bound = check.typ(btyp)
 		}
 		bounds = append(bounds, bound)
-		posns = append(posns, f.Type)
+		posns = append(posns, btyp)
 		for i := range f.Names {
 			tparams[index+i].bound = bound
 		}
@@ -689,9 +713,13 @@ func (check *Checker) collectTypeParams(dst **TypeParamList, list *ast.FieldList
 	check.later(func() {
 		for i, bound := range bounds {
 			u := under(bound)
-			if _, ok := u.(*Interface); !ok && u != Typ[Invalid] {
+			iface, _ := u.(*Interface)
+			if iface == nil && u != Typ[Invalid] {
 				check.errorf(posns[i], _Todo, "%s is not an interface", bound)
 			}
+			if iface != nil && check.conf.ReportEmptyTypeSets && iface.IsEmptyTypeSet() {
+				check.warnf(posns[i], _UnsatisfiableConstraint, "constraint %s has an empty type set and can't be satisfied", bound)
+			}
 		}
 	})
 }
@@ -806,9 +834,11 @@ func (check *Checker) funcDecl(obj *Func, decl *declInfo) {
 
 	// function body must be type-checked after global declarations
 	// (functions implemented elsewhere have no body)
-	if !check.conf.IgnoreFuncBodies && fdecl.Body != nil {
+	if !check.conf.IgnoreFuncBodies && !check.conf.ConstantsOnly && fdecl.Body != nil {
+		check.pendBody(obj, fdecl.Body)
 		check.later(func() {
 			check.funcBody(decl, obj.name, sig, fdecl.Body, nil)
+			check.bodyDone(fdecl.Body)
 		})
 	}
 }
@@ -845,6 +875,7 @@ func (check *Checker) declStmt(d ast.Decl) {
 			scopePos := d.spec.End()
 			for i, name := range d.spec.Names {
 				check.declare(check.scope, name, lhs[i], scopePos)
+				check.reportShadowing(lhs[i], nil)
 			}
 
 		case varDecl:
@@ -897,6 +928,11 @@ func (check *Checker) declStmt(d ast.Decl) {
 			for i, name := range d.spec.Names {
 				// see constant declarations
 				check.declare(check.scope, name, lhs0[i], scopePos)
+				var init ast.Expr
+				if len(d.spec.Values) == len(d.spec.Names) {
+					init = d.spec.Values[i]
+				}
+				check.reportShadowing(lhs0[i], init)
 			}
 
 		case typeDecl:
@@ -906,6 +942,7 @@ func (check *Checker) declStmt(d ast.Decl) {
 			// the innermost containing block."
 			scopePos := d.spec.Name.Pos()
 			check.declare(check.scope, d.spec.Name, obj, scopePos)
+			check.reportShadowing(obj, nil)
 			// mark and unmark type before calling typeDecl; its type is still nil (see Checker.objDecl)
 			obj.setColor(grey + color(check.push(obj)))
 			check.typeDecl(obj, d.spec, nil)
@@ -0,0 +1,139 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Package.WalkDependencies.
+
+package types
+
+import (
+	"fmt"
+	"go/ast"
+	"sort"
+)
+
+// A depInfo holds the information needed to walk the dependencies
+// of a package checked from source.
+type depInfo struct {
+	importer Importer        // Config.Importer the package was checked with
+	dir      string          // directory of the package files, for ImporterFrom
+	used     map[Object]bool // imported package-level objects referred to by the package
+}
+
+// initDeps sets up the dependency information of the checked package,
+// if Config.RecordDependencies is set.
+func (check *Checker) initDeps(files []*ast.File) {
+	if !check.conf.RecordDependencies {
+		return
+	}
+	pkg := check.pkg
+	if pkg.deps == nil {
+		pkg.deps = &depInfo{used: make(map[Object]bool)}
+	}
+	pkg.deps.importer = check.conf.Importer
+	if len(files) > 0 && pkg.deps.dir == "" {
+		pkg.deps.dir = dir(check.fset.Position(files[0].Name.Pos()).Filename)
+	}
+}
+
+// useImported records that the package-level object obj of an
+// imported package is referred to by the checked package.
+func (check *Checker) useImported(obj Object) {
+	if d := check.pkg.deps; d != nil && obj.Pkg() != check.pkg {
+		d.used[obj] = true
+	}
+}
+
+// A Dependency describes a package in the import closure of a package
+// checked from source (see Package.WalkDependencies).
+type Dependency struct {
+	Package *Package
+
+	// Direct reports whether Package is imported by the checked
+	// package itself.
+	Direct bool
+
+	// Used holds the package-level objects of Package that the checked
+	// package refers to, by qualified identifiers or through dot-imports,
+	// sorted by name. It is empty for indirect dependencies, and for
+	// direct dependencies that are imported only for their side effects
+	// or not used at all.
+	Used []Object
+}
+
+// WalkDependencies calls visit for each package in the import closure of
+// pkg, which must have been type-checked from source with
+// Config.RecordDependencies set, in breadth-first order starting with the
+// packages pkg imports, in source order. Each package is visited once. If
+// visit returns false, the packages imported by the visited package are
+// not visited, unless they are reachable through other packages.
+//
+// Packages loaded from export data may be incomplete if they only provide
+// the objects of a package that its importers refer to; the packages they
+// import are then unknown. Such a package is loaded with the importer pkg
+// was checked with (Config.Importer), if any, when the walk reaches it,
+// and the loaded package is visited instead. Thus packages are only
+// loaded if the walk is not pruned before them. If loading fails, the walk
+// stops and WalkDependencies returns the error.
+func (pkg *Package) WalkDependencies(visit func(dep Dependency) bool) error {
+	d := pkg.deps
+	if d == nil {
+		return fmt.Errorf("package %s was not type-checked from source with dependencies recorded", pkg.path)
+	}
+
+	used := make(map[*Package][]Object)
+	for obj := range d.used {
+		used[obj.Pkg()] = append(used[obj.Pkg()], obj)
+	}
+
+	seen := map[string]bool{pkg.path: true}
+	type item struct {
+		pkg    *Package
+		direct bool
+	}
+	var queue []item
+	for _, imp := range pkg.imports {
+		queue = append(queue, item{imp, true})
+	}
+
+	for len(queue) > 0 {
+		it := queue[0]
+		queue = queue[1:]
+		p := it.pkg
+		if seen[p.path] {
+			continue
+		}
+		seen[p.path] = true
+
+		list := used[p]
+		sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+
+		if !p.complete && !p.fake && d.importer != nil {
+			var err error
+			if p, err = d.load(p.path); err != nil {
+				return fmt.Errorf("loading dependency %s of %s: %v", it.pkg.path, pkg.path, err)
+			}
+		}
+
+		if !visit(Dependency{p, it.direct, list}) {
+			continue
+		}
+		for _, imp := range p.imports {
+			queue = append(queue, item{imp, false})
+		}
+	}
+	return nil
+}
+
+// load loads the package with the given import path with d's importer.
+func (d *depInfo) load(path string) (pkg *Package, err error) {
+	if from, ok := d.importer.(ImporterFrom); ok {
+		pkg, err = from.ImportFrom(path, d.dir, 0)
+	} else {
+		pkg, err = d.importer.Import(path)
+	}
+	if pkg == nil && err == nil {
+		err = fmt.Errorf("importer returned nil but no error")
+	}
+	return
+}
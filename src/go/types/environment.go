@@ -16,16 +16,31 @@ import (
 // It is safe for concurrent use.
 type Environment struct {
 	mu      sync.Mutex
-	typeMap map[string]*Named // type hash -> instance
-	nextID  int               // next unique ID
-	seen    map[*Named]int    // assigned unique IDs
+	typeMap map[string]*Named   // type hash -> instance
+	methods map[methodKey]*Func // instantiated methods, by generic method and receiver type arguments
+	funcs   sync.Map            // funcKey -> *Signature; instantiated generic signatures
+
+	// canonical unnamed composite types with predeclared components
+	pointers sync.Map // *Basic -> *Pointer
+	slices   sync.Map // *Basic -> *Slice
+	maps     sync.Map // [2]*Basic{key, elem} -> *Map
+
+	missing sync.Map  // missingKey -> missingResult; see Environment.MissingMethod
+	msets   sync.Map  // msetKey -> *MethodSet; see Environment.MethodSet
+	evals   evalCache // see Environment.Eval
+
+	// Insertions since the first live snapshot; see Environment.Snapshot.
+	jmu        sync.Mutex
+	journal    []func()       // functions undoing the insertions, in order
+	snapshots  []*EnvSnapshot // live snapshots, in order
+	journaling int32          // atomic; set if there are live snapshots
 }
 
 // NewEnvironment creates a new Environment.
 func NewEnvironment() *Environment {
 	return &Environment{
 		typeMap: make(map[string]*Named),
-		seen:    make(map[*Named]int),
+		methods: make(map[methodKey]*Func),
 	}
 }
 
@@ -70,19 +85,194 @@ func (env *Environment) typeForHash(h string, n *Named) *Named {
 	}
 	if n != nil {
 		env.typeMap[h] = n
+		env.logInsert(func() { delete(env.typeMap, h) })
 	}
 	return n
 }
 
-// idForType returns a unique ID for the pointer n.
-func (env *Environment) idForType(n *Named) int {
+// A methodKey identifies a method instantiated with specific receiver
+// type arguments.
+type methodKey struct {
+	orig  *Func  // generic method
+	targs string // type hash of the receiver type arguments
+}
+
+// A funcKey identifies an instantiation of a generic signature. Copies of a
+// signature sharing its components, such as the signatures of method values,
+// share their instances.
+type funcKey struct {
+	tparams         *TypeParamList
+	recv            *Var
+	params, results *Tuple
+	targs           string // type hash of the type arguments
+}
+
+// targsHash returns a type hash for the list of type arguments targs.
+func (env *Environment) targsHash(targs []Type) string {
+	var buf bytes.Buffer
+	newTypeHasher(&buf, env).typeList(targs)
+	return buf.String()
+}
+
+// methodForKey returns the recorded instantiated method for key, if it
+// exists. If no method exists for key and m is non-nil, m is recorded for key.
+func (env *Environment) methodForKey(key methodKey, m *Func) *Func {
 	env.mu.Lock()
 	defer env.mu.Unlock()
-	id, ok := env.seen[n]
-	if !ok {
-		id = env.nextID
-		env.seen[n] = id
-		env.nextID++
+	if existing := env.methods[key]; existing != nil {
+		return existing
+	}
+	if m != nil {
+		env.methods[key] = m
+		env.logInsert(func() { delete(env.methods, key) })
+	}
+	return m
+}
+
+// intern returns the canonical representative of typ if typ is an
+// unnamed pointer, slice, or map type whose component types are all
+// predeclared (such as *int, []byte, or map[string]bool). Otherwise
+// it returns typ. Such types are by far the most commonly written ones,
+// and since they cannot be modified after construction, they may be
+// shared by all packages checked with env.
+func (env *Environment) intern(typ Type) Type {
+	var m *sync.Map
+	var key interface{}
+	switch t := typ.(type) {
+	case *Pointer:
+		if b := internable(t.base); b != nil {
+			m, key = &env.pointers, b
+		}
+	case *Slice:
+		if b := internable(t.elem); b != nil {
+			m, key = &env.slices, b
+		}
+	case *Map:
+		if k, e := internable(t.key), internable(t.elem); k != nil && e != nil {
+			m, key = &env.maps, [2]*Basic{k, e}
+		}
+	}
+	if m == nil {
+		return typ
+	}
+	return env.loadOrStore(m, key, typ).(Type)
+}
+
+// internable returns typ as a *Basic if typ is a valid, typed
+// predeclared type; otherwise it returns nil.
+func internable(typ Type) *Basic {
+	if b, _ := typ.(*Basic); b != nil && b.kind != Invalid && b.info&IsUntyped == 0 {
+		return b
+	}
+	return nil
+}
+
+// intern is like Environment.intern but uses the checker's environment.
+// In addition, anonymous struct and interface types whose component types
+// are all predeclared are linked to a canonical identical type of the
+// checked package (see Checker.internLiteral).
+// If typ is replaced with a canonical type, the underlying type of def
+// (if any) is updated accordingly.
+func (check *Checker) intern(typ Type, def *Named) Type {
+	c := check.conf.Environment.intern(typ)
+	if c == typ {
+		if def == nil {
+			check.internLiteral(typ)
+		}
+		return typ
+	}
+	def.setUnderlying(c)
+	return c
+}
+
+// internLiteral links typ to its canonical representative, the first
+// identical type checked, if typ is a struct or interface type all of whose
+// component types are predeclared or unnamed types composed of predeclared
+// types, recursively (such as struct{ x, y int } or interface{ Close() error }).
+// Identical checks such types, which are common in table-driven tests,
+// in constant time.
+//
+// Unlike the types interned by Environment.intern, struct and interface
+// types own objects (their fields and methods), which record positions,
+// and which Info maps to the identifiers declaring them. Therefore such
+// types are not replaced by their canonical representative, and
+// canonical types are not shared across packages.
+func (check *Checker) internLiteral(typ Type) {
+	switch typ.(type) {
+	case *Struct, *Interface:
+	default:
+		return
+	}
+	if !predeclaredOnly(typ) {
+		return
+	}
+	h := check.conf.Environment.typeHash(typ, nil)
+	c := check.literal[h]
+	if c == nil {
+		if check.literal == nil {
+			check.literal = make(map[string]Type)
+		}
+		check.literal[h] = typ
+		c = typ
+	}
+	switch t := typ.(type) {
+	case *Struct:
+		t.canon = c.(*Struct)
+	case *Interface:
+		t.canon = c.(*Interface)
+	}
+}
+
+// predeclaredOnly reports whether typ is a valid, typed predeclared type
+// or an unnamed type whose component types are all predeclaredOnly. Such
+// types are fully set up when constructed, and their type hashes are
+// exact. Interfaces qualify only if they have no embedded elements.
+func predeclaredOnly(typ Type) bool {
+	switch t := typ.(type) {
+	case *Basic:
+		return internable(t) != nil
+	case *Named:
+		// error is the only predeclared defined type
+		return t == universeError
+	case *Pointer:
+		return predeclaredOnly(t.base)
+	case *Array:
+		return t.len >= 0 && predeclaredOnly(t.elem)
+	case *Slice:
+		return predeclaredOnly(t.elem)
+	case *Map:
+		return predeclaredOnly(t.key) && predeclaredOnly(t.elem)
+	case *Chan:
+		return predeclaredOnly(t.elem)
+	case *Struct:
+		for _, f := range t.fields {
+			if !predeclaredOnly(f.typ) {
+				return false
+			}
+		}
+		return true
+	case *Signature:
+		// the receiver is not part of the type
+		return t.tparams == nil && predeclaredOnly(t.params) && predeclaredOnly(t.results)
+	case *Tuple:
+		if t != nil {
+			for _, v := range t.vars {
+				if !predeclaredOnly(v.typ) {
+					return false
+				}
+			}
+		}
+		return true
+	case *Interface:
+		if !t.complete || len(t.embeddeds) > 0 {
+			return false
+		}
+		for _, m := range t.methods {
+			if !predeclaredOnly(m.typ) {
+				return false
+			}
+		}
+		return true
 	}
-	return id
+	return false
 }
@@ -0,0 +1,137 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+)
+
+// Environment.Eval memoizes at most maxEvals results, discarding the
+// oldest ones first.
+func TestEvalCacheBound(t *testing.T) {
+	env := NewEnvironment()
+	fset := token.NewFileSet()
+	for i := 0; i <= maxEvals; i++ {
+		if _, err := env.Eval(fset, nil, token.NoPos, strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(env.evals.m); n != maxEvals {
+		t.Errorf("%d results memoized, want %d", n, maxEvals)
+	}
+	key := func(i int) evalKey { return evalKey{fset, nil, token.NoPos, strconv.Itoa(i)} }
+	if _, ok := env.evals.get(key(0)); ok {
+		t.Errorf("oldest result was not discarded")
+	}
+	if _, ok := env.evals.get(key(maxEvals)); !ok {
+		t.Errorf("newest result was discarded")
+	}
+
+	snap := env.Snapshot()
+	env.Eval(fset, nil, token.NoPos, "0")
+	env.Restore(snap)
+	if _, ok := env.evals.get(key(0)); ok {
+		t.Errorf("result memoized after the snapshot was not discarded")
+	}
+}
+
+func TestCanonicalLiterals(t *testing.T) {
+	const src = `package p
+type T struct{ x, y int }
+type I interface{ Close() error }
+var (
+	a struct{ x, y int }
+	b []struct{ x, y int }
+	c interface{ Close() error }
+	d struct{ x, y int "tag" }
+)
+func _() {
+	var a struct{ x, y int }
+	var c interface{ Close() error }
+	_, _ = a, c
+}
+`
+	check := func() *Package {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var conf Config
+		pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pkg
+	}
+	lookup := func(pkg *Package, name string) Type {
+		return pkg.Scope().Lookup(name).Type()
+	}
+	local := func(pkg *Package, name string) Type {
+		file := pkg.Scope().Child(0)
+		return file.Child(file.NumChildren() - 1).Lookup(name).Type() // scope of func _
+	}
+
+	pkg := check()
+	a := lookup(pkg, "a").(*Struct)
+	for _, typ := range []*Struct{lookup(pkg, "b").(*Slice).Elem().(*Struct), local(pkg, "a").(*Struct)} {
+		if typ == a || typ.Field(0) == a.Field(0) {
+			t.Errorf("struct type %s is shared", typ)
+		}
+		if typ.canon == nil || typ.canon != a.canon {
+			t.Errorf("struct type %s has no canonical type", typ)
+		}
+	}
+	if T := lookup(pkg, "T").Underlying().(*Struct); T.canon != nil {
+		t.Errorf("underlying struct of a defined type has a canonical type")
+	}
+	if c := lookup(pkg, "c").(*Interface); c.canon == nil || local(pkg, "c").(*Interface).canon != c.canon {
+		t.Errorf("interface type %s has no canonical type", c)
+	}
+	if I := lookup(pkg, "I").Underlying().(*Interface); I.canon != nil {
+		t.Errorf("underlying interface of a defined type has a canonical type")
+	}
+	if d := lookup(pkg, "d").(*Struct); d.canon == a.canon {
+		t.Errorf("struct types with different tags have the same canonical type")
+	}
+	// Canonical types are not shared across packages.
+	if lookup(check(), "a").(*Struct).canon == a.canon {
+		t.Errorf("struct type %s has a canonical type of another package", a)
+	}
+}
+
+// Identical underlying struct types of defined types have their own fields.
+func TestCanonicalLiteralsDefined(t *testing.T) {
+	const src = `package p
+
+type A struct {
+	x int
+}
+
+type B struct {
+	x int
+}
+
+func (B) x() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []Error
+	conf := Config{Error: func(err error) { errs = append(errs, err.(Error)) }}
+	conf.Check("p", fset, []*ast.File{f}, nil)
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2: %v", len(errs), errs)
+	}
+	if got := fset.Position(errs[1].Pos).String(); got != "p.go:8:2" {
+		t.Errorf("field x of B reported at %s, want p.go:8:2", got)
+	}
+}
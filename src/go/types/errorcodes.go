@@ -4,6 +4,8 @@
 
 package types
 
+//go:generate stringer -type=errorCode -trimprefix=_
+
 type errorCode int
 
 // This file defines the error codes that can be produced during type-checking.
@@ -985,7 +987,8 @@ const (
 	_ // _InvalidChanRange was removed.
 
 	// _InvalidIterVar occurs when two iteration variables are used while ranging
-	// over a channel.
+	// over a channel or integer, or more iteration variables are used than an
+	// iterator function provides.
 	//
 	// Example:
 	//  func f(c chan int) {
@@ -996,11 +999,11 @@ const (
 	_InvalidIterVar
 
 	// _InvalidRangeExpr occurs when the type of a range expression is not array,
-	// slice, string, map, or channel.
+	// slice, string, map, channel, integer, or iterator function.
 	//
 	// Example:
-	//  func f(i int) {
-	//  	for j := range i {
+	//  func f(x float64) {
+	//  	for j := range x {
 	//  		println(j)
 	//  	}
 	//  }
@@ -1301,6 +1304,102 @@ const (
 	//  var _ = unsafe.Slice(&x, uint64(1) << 63)
 	_InvalidUnsafeSlice
 
+	// _InvalidUnsafeSliceData occurs when unsafe.SliceData is called with an
+	// argument that is not of slice type.
+	//
+	// Example:
+	//  import "unsafe"
+	//
+	//  var x int
+	//  var _ = unsafe.SliceData(x)
+	_InvalidUnsafeSliceData
+
+	// _InvalidUnsafeString occurs when unsafe.String is called with a length
+	// argument that is not of integer type, negative, or out of bounds.
+	//
+	// Example:
+	//  import "unsafe"
+	//
+	//  var b byte
+	//  var n float64
+	//  var _ = unsafe.String(&b, n)
+	//
+	// Example:
+	//  import "unsafe"
+	//
+	//  var b byte
+	//  var _ = unsafe.String(&b, -1)
+	_InvalidUnsafeString
+
+	// _InvalidUnsafeStringData occurs when unsafe.StringData is used with a
+	// language version older than go1.20.
+	_InvalidUnsafeStringData
+
+	// _InvalidClear occurs when clear is called with an argument that is not
+	// of map or slice type.
+	//
+	// Example:
+	//  func _(x int) {
+	//  	clear(x)
+	//  }
+	_InvalidClear
+
+	// _InvalidMinMaxOperand occurs if min or max is called with an operand
+	// that cannot be ordered because it does not support the < operator.
+	//
+	// Example:
+	//  const _ = min(true)
+	//
+	// Example:
+	//  var s, t []byte
+	//  var _ = max(s, t)
+	_InvalidMinMaxOperand
+
+	// _NotStrictlyComparable occurs when a type argument that is comparable
+	// but not strictly comparable, such as an interface type or a struct
+	// type with interface fields, is used for a type parameter constrained
+	// by comparable, and the language version is older than go1.20. Since
+	// go1.20, such types satisfy comparable; comparison of their values may
+	// panic at run time.
+	_NotStrictlyComparable
+
+	// _InvalidStructTag occurs when a struct tag does not follow the
+	// conventions of reflect.StructTag, or has duplicate keys. It is only
+	// reported if Config.CheckStructTags is set.
+	_InvalidStructTag
+
+	// _UnsatisfiableConstraint occurs when the constraint of a type
+	// parameter has an empty type set, so that the type parameter can't be
+	// instantiated. It is only reported if Config.ReportEmptyTypeSets is
+	// set.
+	_UnsatisfiableConstraint
+
+	// _ImpossibleIfaceAssert occurs for a type assertion x.(T) where T is
+	// an interface type with a method that has the same name as a method
+	// of the type of x, but a different signature, so that no type can
+	// implement both. It is only reported if
+	// Config.ReportImpossibleAssertions is set.
+	_ImpossibleIfaceAssert
+
+	// _ShadowedDecl occurs when a local declaration shadows a local
+	// declaration of an enclosing block. It is only reported if
+	// Config.ReportShadowing is set.
+	_ShadowedDecl
+
+	// _UnsupportedFeature occurs when a language feature is used that
+	// requires a newer language version than the configured one. It is
+	// only reported as such if Config.ForwardCompatible is set.
+	_UnsupportedFeature
+
+	// _InvalidCustomBuiltin occurs when a call of a custom built-in
+	// function is rejected by the function that checks its calls.
+	_InvalidCustomBuiltin
+
+	// _InvalidFileSet occurs when the syntax tree of a file is not
+	// positioned in the file set of the package, or in the same file of
+	// the file set as another file.
+	_InvalidFileSet
+
 	// _Todo is a placeholder for error codes that have not been decided.
 	// TODO(rFindley) remove this error code after deciding on errors for generics code.
 	_Todo
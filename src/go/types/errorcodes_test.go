@@ -11,7 +11,6 @@ import (
 	"go/importer"
 	"go/parser"
 	"go/token"
-	"reflect"
 	"strings"
 	"testing"
 
@@ -36,6 +35,9 @@ func TestErrorCodeExamples(t *testing.T) {
 				if got := readCode(typerr); got != value {
 					t.Errorf("%s: example #%d returned code %d (%s), want %d", name, i, got, err, value)
 				}
+				if _, got := typerr.Code(); got != strings.TrimPrefix(name, "_") {
+					t.Errorf("%s: example #%d returned code name %s, want %s", name, i, got, strings.TrimPrefix(name, "_"))
+				}
 			}
 		})
 	})
@@ -84,8 +86,8 @@ func walkCodes(t *testing.T, f func(string, int, *ast.ValueSpec)) {
 }
 
 func readCode(err Error) int {
-	v := reflect.ValueOf(err)
-	return int(v.FieldByName("go116code").Int())
+	code, _ := err.Code()
+	return code
 }
 
 func checkExample(t *testing.T, example string) error {
@@ -0,0 +1,179 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements FormatError.
+
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+// maxSnippetLines is the maximum number of source lines shown for an error
+// span. Longer spans are shown by the line containing the error position only.
+const maxSnippetLines = 3
+
+// FormatError returns a multi-line description of err suitable for display
+// to a user: the error message with its position, followed by the source
+// line(s) the error refers to, with the error position marked by a caret
+// and the rest of the error span (if known) underlined, and by the
+// instantiations providing the context of the error. The related errors,
+// typically the continuation errors reported right after err (such as
+// "other declaration of x"), are formatted the same way and appended.
+//
+// The source of a file is obtained by calling readFile with the file name
+// recorded in err.Fset. If readFile is nil, or if it fails or returns source
+// that does not match the file set, no source lines are shown.
+func FormatError(err Error, readFile func(filename string) ([]byte, error), related ...Error) string {
+	f := errorFormatter{readFile: readFile, files: make(map[string][]byte)}
+	f.format(err)
+	for _, r := range related {
+		f.format(r)
+	}
+	return f.buf.String()
+}
+
+type errorFormatter struct {
+	buf      bytes.Buffer
+	readFile func(filename string) ([]byte, error)
+	files    map[string][]byte // cache of file contents; nil entries for unreadable files
+}
+
+func (f *errorFormatter) format(err Error) {
+	msg := strings.TrimLeft(err.Msg, "\t") // continuation errors start with a tab
+	if err.Fset == nil || !err.Pos.IsValid() {
+		fmt.Fprintln(&f.buf, msg)
+		return
+	}
+	fmt.Fprintf(&f.buf, "%s: %s\n", err.Fset.Position(err.Pos), msg)
+	defer f.formatInstantiations(err) // after the source lines
+
+	file := err.Fset.File(err.Pos)
+	if file == nil || f.readFile == nil {
+		return
+	}
+	src := f.source(file)
+	if src == nil {
+		return
+	}
+
+	start, end := err.Span()
+	if !start.IsValid() || !end.IsValid() || start > err.Pos || end < err.Pos || token.Pos(file.Base()) > start || end > token.Pos(file.Base()+file.Size()) {
+		start, end = err.Pos, err.Pos
+	}
+
+	first, last := file.Line(start), file.Line(err.Pos)
+	if end > start {
+		last = file.Line(end - 1)
+	}
+	if last < first || last-first+1 > maxSnippetLines {
+		first = file.Line(err.Pos)
+		last = first
+	}
+
+	width := len(fmt.Sprint(last))
+	for line := first; line <= last; line++ {
+		lineStart := file.Offset(file.LineStart(line))
+		lineEnd := len(src)
+		if line < file.LineCount() {
+			lineEnd = file.Offset(file.LineStart(line + 1))
+		}
+		text := strings.TrimRight(string(src[lineStart:lineEnd]), "\r\n")
+		fmt.Fprintf(&f.buf, "%*d | %s\n", width, line, text)
+		if mark := marker(text, lineStart, file.Offset(start), file.Offset(err.Pos), file.Offset(end)); mark != "" {
+			fmt.Fprintf(&f.buf, "%*s | %s\n", width, "", mark)
+		}
+	}
+}
+
+// formatInstantiations writes the instantiation context of err, if any.
+func (f *errorFormatter) formatInstantiations(err Error) {
+	for _, inst := range err.Instantiations {
+		fmt.Fprintf(&f.buf, "\t%s at %s\n", describeInstantiation(inst), err.Fset.Position(inst.Pos))
+	}
+}
+
+// describeInstantiation returns a description of inst of the form
+// "in instantiation of G with [int]".
+func describeInstantiation(inst Instantiation) string {
+	var targs []Type
+	if inst.TypeArgs != nil {
+		targs = inst.TypeArgs.list()
+	}
+	// The package being checked is not known here; don't qualify types.
+	var name string
+	if t, _ := inst.Orig.(*Named); t != nil {
+		name = t.obj.name
+	} else {
+		name = TypeString(inst.Orig, unqualified)
+	}
+	var buf bytes.Buffer
+	newTypeWriter(&buf, unqualified).typeList(targs)
+	return fmt.Sprintf("in instantiation of %s with %s", stripAnnotations(name), stripAnnotations(buf.String()))
+}
+
+func unqualified(*Package) string { return "" }
+
+// source returns the contents of file, or nil if they are not available.
+func (f *errorFormatter) source(file *token.File) []byte {
+	name := file.Name()
+	src, ok := f.files[name]
+	if !ok {
+		var err error
+		src, err = f.readFile(name)
+		if err != nil || len(src) != file.Size() {
+			src = nil
+		}
+		f.files[name] = src
+	}
+	return src
+}
+
+// marker returns the line marking the span [start, end) and the position pos
+// (all file offsets) within the source line text beginning at file offset
+// lineStart. Tabs preceding the span are preserved so that the marker lines
+// up with the source text. The result is empty if nothing on this line is
+// marked.
+func marker(text string, lineStart, start, pos, end int) string {
+	lineEnd := lineStart + len(text)
+	if start < lineStart {
+		start = lineStart
+	}
+	if end > lineEnd {
+		end = lineEnd
+	}
+	onLine := lineStart <= pos && pos <= lineEnd
+	if start >= end && !onLine {
+		return ""
+	}
+
+	hi := end
+	if onLine && pos >= hi {
+		hi = pos + 1 // include the caret
+	}
+	var b strings.Builder
+	for i, r := range text {
+		off := lineStart + i
+		if off >= hi {
+			break
+		}
+		switch {
+		case off == pos:
+			b.WriteByte('^')
+		case off >= start:
+			b.WriteByte('~')
+		case r == '\t':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte(' ')
+		}
+	}
+	if pos == lineEnd {
+		b.WriteByte('^') // position at end of line
+	}
+	return b.String()
+}
@@ -63,9 +63,33 @@ func (check *Checker) markImports(pkg *Package) {
 }
 
 func (check *Checker) sprintf(format string, args ...interface{}) string {
+	if check.verbose() {
+		args = append([]interface{}(nil), args...) // don't modify the caller's arguments
+		for i, arg := range args {
+			if t, _ := arg.(*Named); t != nil {
+				args[i] = check.expandedTypeString(t)
+			}
+		}
+	}
 	return sprintf(check.fset, check.qualifier, format, args...)
 }
 
+// verbose reports whether verbose error messages were requested.
+func (check *Checker) verbose() bool {
+	return check != nil && check.conf.ErrorVerbosity == VerboseErrors
+}
+
+// expandedTypeString returns the string for the defined type t followed by
+// its underlying type, as in "T (struct{x int})". Predeclared types such as
+// error are not expanded.
+func (check *Checker) expandedTypeString(t *Named) string {
+	s := TypeString(t, check.qualifier)
+	if t.obj.pkg == nil {
+		return s // predeclared
+	}
+	return s + " (" + TypeString(under(t), check.qualifier) + ")"
+}
+
 func sprintf(fset *token.FileSet, qf Qualifier, format string, args ...interface{}) string {
 	for i, arg := range args {
 		switch a := arg.(type) {
@@ -116,7 +140,7 @@ func (check *Checker) err(err error) {
 	// exclude them if these strings are not at the beginning,
 	// and only if we have at least one error already reported.
 	isInvalidErr := isInternal && (strings.Index(e.Msg, "invalid operand") > 0 || strings.Index(e.Msg, "invalid type") > 0)
-	if check.firstErr != nil && isInvalidErr {
+	if check.firstErr != nil && isInvalidErr && !check.conf.ReportFollowOnErrors {
 		return
 	}
 
@@ -132,7 +156,21 @@ func (check *Checker) err(err error) {
 			e.go116start = span.start
 			e.go116end = span.end
 		}
+		if n := len(check.instPath); n > 0 && e.Instantiations == nil {
+			e.Instantiations = make([]Instantiation, n)
+			for i, inst := range check.instPath {
+				e.Instantiations[n-1-i] = inst
+			}
+		}
 		err = e
+
+		if e.Severity == SeverityWarning && check.conf.Warning != nil {
+			if trace {
+				check.trace(e.Pos, "WARNING: %s", e.Msg)
+			}
+			check.conf.Warning(err)
+			return
+		}
 	}
 
 	if check.firstErr == nil {
@@ -171,22 +209,159 @@ func (check *Checker) newError(at positioner, code errorCode, soft bool, msg str
 
 // newErrorf creates a new Error, but does not handle it.
 func (check *Checker) newErrorf(at positioner, code errorCode, soft bool, format string, args ...interface{}) error {
-	msg := check.sprintf(format, args...)
+	msg := check.render(at, code, format, args)
 	return check.newError(at, code, soft, msg)
 }
 
 func (check *Checker) error(at positioner, code errorCode, msg string) {
+	if check.conf.MessageRenderer != nil {
+		msg = check.render(at, code, "%s", []interface{}{msg})
+	}
 	check.err(check.newError(at, code, false, msg))
 }
 
 func (check *Checker) errorf(at positioner, code errorCode, format string, args ...interface{}) {
-	check.error(at, code, check.sprintf(format, args...))
+	if check.isFollowOn(at, args) {
+		return
+	}
+	check.err(check.newErrorf(at, code, false, format, args...))
+}
+
+// render returns the text of the error message at the given position,
+// described by format and args. If a message renderer is configured,
+// it is asked to render the message.
+func (check *Checker) render(at positioner, code errorCode, format string, args []interface{}) string {
+	f := check.conf.MessageRenderer
+	if f == nil {
+		return check.sprintf(format, args...)
+	}
+
+	// Provide the original arguments to the renderer; sprintf
+	// replaces them with their strings.
+	margs := make([]interface{}, len(args))
+	for i, arg := range args {
+		if x, _ := arg.(*operand); x != nil {
+			arg = MessageOperand{x.expr, TypeAndValue{x.mode, x.typ, x.val}}
+		}
+		margs[i] = arg
+	}
+	text := check.sprintf(format, args...)
+	if msg := f(Message{spanOf(at).pos, int(code), format, margs, text}); msg != "" {
+		return msg
+	}
+	return text
 }
 
 func (check *Checker) softErrorf(at positioner, code errorCode, format string, args ...interface{}) {
+	if check.isFollowOn(at, args) {
+		return
+	}
 	check.err(check.newErrorf(at, code, true, format, args...))
 }
 
+// warnf reports a soft error of severity SeverityWarning.
+func (check *Checker) warnf(at positioner, code errorCode, format string, args ...interface{}) {
+	if check.isFollowOn(at, args) {
+		return
+	}
+	err := check.newErrorf(at, code, true, format, args...).(Error)
+	err.Severity = SeverityWarning
+	check.err(err)
+}
+
+// pushInst records that the instantiation of orig with targs at pos provides
+// the context for errors reported until the returned function is called.
+func (check *Checker) pushInst(orig Type, targs *TypeList, pos token.Pos) (pop func()) {
+	check.instPath = append(check.instPath, Instantiation{orig, targs, pos})
+	return func() { check.instPath = check.instPath[:len(check.instPath)-1] }
+}
+
+// reportUnused reports an unused variable or import as requested by mode.
+func (check *Checker) reportUnused(mode UnusedCheck, at positioner, code errorCode, format string, args ...interface{}) {
+	switch mode {
+	case UnusedAsWarning:
+		check.warnf(at, code, format, args...)
+	case UnusedIgnored:
+		// nothing to do
+	default:
+		check.softErrorf(at, code, format, args...)
+	}
+}
+
+// isFollowOn reports whether an error at the given position with the given
+// message arguments should be suppressed because it is derived from an
+// error that was reported before: that is, if an error was already reported,
+// follow-on errors are not requested, and the error refers to an operand,
+// type, or object which is invalid because of an earlier error.
+func (check *Checker) isFollowOn(at positioner, args []interface{}) bool {
+	if check.firstErr == nil || check.conf.ReportFollowOnErrors {
+		return false
+	}
+	if x, _ := at.(*operand); x != nil && isInvalidOperand(x) {
+		return true
+	}
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case *operand:
+			if a != nil && isInvalidOperand(a) {
+				return true
+			}
+		case Object:
+			if a != nil && containsInvalid(a.Type(), 0) {
+				return true
+			}
+		case Type:
+			if containsInvalid(a, 0) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isInvalidOperand reports whether x is invalid or has an invalid type.
+// Operands without a value (novalue, builtin) never have a valid type and
+// are not considered invalid because of that.
+func isInvalidOperand(x *operand) bool {
+	return x.mode == invalid || x.mode != novalue && x.mode != builtin && containsInvalid(x.typ, 0)
+}
+
+// containsInvalid reports whether typ is the invalid type or an unnamed
+// composite type with an invalid component. Components are only inspected
+// up to a small depth, and defined types are not looked into.
+func containsInvalid(typ Type, depth int) bool {
+	const maxDepth = 4
+	if typ == nil || depth > maxDepth {
+		return false
+	}
+	switch t := Unalias(typ).(type) {
+	case *Basic:
+		return t != nil && t.kind == Invalid
+	case *Pointer:
+		return t != nil && containsInvalid(t.base, depth+1)
+	case *Slice:
+		return t != nil && containsInvalid(t.elem, depth+1)
+	case *Array:
+		return t != nil && containsInvalid(t.elem, depth+1)
+	case *Map:
+		return t != nil && (containsInvalid(t.key, depth+1) || containsInvalid(t.elem, depth+1))
+	case *Chan:
+		return t != nil && containsInvalid(t.elem, depth+1)
+	case *Tuple:
+		if t == nil {
+			return false
+		}
+		for _, v := range t.vars {
+			if containsInvalid(v.typ, depth+1) {
+				return true
+			}
+		}
+	case *Signature:
+		return t != nil && (containsInvalid(t.params, depth+1) || containsInvalid(t.results, depth+1))
+	}
+	return false
+}
+
 func (check *Checker) invalidAST(at positioner, format string, args ...interface{}) {
 	check.errorf(at, 0, "invalid AST: "+format, args...)
 }
@@ -4,7 +4,13 @@
 
 package types
 
-import "testing"
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
 
 func TestStripAnnotations(t *testing.T) {
 	for _, test := range []struct {
@@ -23,3 +29,40 @@ func TestStripAnnotations(t *testing.T) {
 		}
 	}
 }
+
+func TestSprintfArgs(t *testing.T) {
+	pkg := NewPackage("p", "p")
+	T := NewNamed(NewTypeName(0, pkg, "T", nil), Typ[Int], nil)
+	check := NewChecker(&Config{ErrorVerbosity: VerboseErrors}, nil, pkg, nil)
+	args := []interface{}{T}
+	if got, want := check.sprintf("%s", args...), "T (int)"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if args[0] != T {
+		t.Errorf("sprintf modified its arguments")
+	}
+}
+
+// Suggestions include lazily resolved objects, without resolving them.
+func TestSuggestLazy(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", "package p; func _() {}", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := NewPackage("p", "p")
+	pkg.scope._InsertLazy("value", func() Object {
+		t.Error("lazy object resolved")
+		return NewVar(token.NoPos, pkg, "value", Typ[Int])
+	})
+	if err := NewChecker(nil, fset, pkg, nil).Files([]*ast.File{f}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Evaluate a misspelled name in the body of _.
+	body := f.Decls[0].(*ast.FuncDecl).Body
+	_, err = Eval(fset, pkg, body.Rbrace, "valeu")
+	if err == nil || !strings.Contains(err.Error(), "did you mean value?") {
+		t.Errorf("got error %v, want a suggestion of value", err)
+	}
+}
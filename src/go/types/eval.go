@@ -7,8 +7,10 @@ package types
 import (
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/parser"
 	"go/token"
+	"sync"
 )
 
 // Eval returns the type and, if constant, the value for the
@@ -35,6 +37,104 @@ func Eval(fset *token.FileSet, pkg *Package, pos token.Pos, expr string) (_ Type
 	return info.Types[node], err
 }
 
+// Eval is like the function Eval, but memoizes its results in env: an
+// expression that was evaluated before at the same position of the same
+// package is not evaluated again. Thus, for instance, a debugger may
+// evaluate the same watch expressions repeatedly at little cost.
+//
+// Results are keyed by the package, the position pos, and the expression
+// text. Packages must not be modified after they are evaluated against;
+// a package that is checked again (for instance by a Session after its
+// files changed) is a new package, for which expressions are evaluated
+// anew. The results for a package invalidated in a Session with
+// Environment env are discarded. At most the most recent 1024 results are
+// memoized, so that env doesn't keep arbitrarily many packages alive.
+func (env *Environment) Eval(fset *token.FileSet, pkg *Package, pos token.Pos, expr string) (TypeAndValue, error) {
+	key := evalKey{fset, pkg, pos, expr}
+	if r, ok := env.evals.get(key); ok {
+		return r.tv, r.err
+	}
+	tv, err := Eval(fset, pkg, pos, expr)
+	if env.evals.put(key, evalResult{tv, err}) {
+		env.logInsert(func() {
+			env.evals.forget(func(k evalKey) bool { return k == key })
+		})
+	}
+	return tv, err
+}
+
+// forgetEvals discards the memoized Eval results for pkg.
+func (env *Environment) forgetEvals(pkg *Package) {
+	env.evals.forget(func(key evalKey) bool { return key.pkg == pkg })
+}
+
+// maxEvals is the maximum number of Eval results memoized by an Environment.
+const maxEvals = 1024
+
+// An evalCache holds the memoized Eval results of an Environment. Once it
+// holds maxEvals results, the oldest result is discarded for each new one.
+type evalCache struct {
+	mu   sync.Mutex
+	m    map[evalKey]evalResult
+	keys []evalKey // keys of m in insertion order, as a ring buffer; zero if discarded
+	next int       // index of the oldest key, once len(keys) == maxEvals
+}
+
+func (c *evalCache) get(key evalKey) (evalResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.m[key]
+	return r, ok
+}
+
+// put records the result r for key, unless there is one already, and
+// reports whether it did.
+func (c *evalCache) put(key evalKey, r evalResult) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.m[key]; ok {
+		return false // memoized concurrently
+	}
+	if c.m == nil {
+		c.m = make(map[evalKey]evalResult)
+	}
+	if len(c.keys) < maxEvals {
+		c.keys = append(c.keys, key)
+	} else {
+		delete(c.m, c.keys[c.next])
+		c.keys[c.next] = key
+		c.next = (c.next + 1) % maxEvals
+	}
+	c.m[key] = r
+	return true
+}
+
+// forget discards the results for the keys for which discard returns true.
+func (c *evalCache) forget(discard func(key evalKey) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, key := range c.keys {
+		if key != (evalKey{}) && discard(key) {
+			delete(c.m, key)
+			c.keys[i] = evalKey{}
+		}
+	}
+}
+
+// An evalKey is the key for a memoized Eval result.
+type evalKey struct {
+	fset *token.FileSet
+	pkg  *Package
+	pos  token.Pos
+	expr string
+}
+
+// An evalResult is a memoized Eval result.
+type evalResult struct {
+	tv  TypeAndValue
+	err error
+}
+
 // CheckExpr type checks the expression expr as if it had appeared at position
 // pos of package pkg. Type information about the expression is recorded in
 // info. The expression may be an uninstantiated parameterized function or
@@ -55,6 +155,64 @@ func Eval(fset *token.FileSet, pkg *Package, pos token.Pos, expr string) (_ Type
 // untyped type rather then the respective context-specific type.
 //
 func CheckExpr(fset *token.FileSet, pkg *Package, pos token.Pos, expr ast.Expr, info *Info) (err error) {
+	check, err := newExprChecker(fset, pkg, pos, info)
+	if err != nil {
+		return err
+	}
+	defer check.handleBailout(&err)
+
+	// evaluate node
+	var x operand
+	check.rawExpr(&x, expr, nil, true) // allow generic expressions
+	check.processDelayed(0)            // incl. all functions
+	check.recordUntyped()
+
+	return nil
+}
+
+// EvalConst evaluates the constant expression expr as if it had appeared at
+// position pos of package pkg, and returns its value and type. The meaning of
+// the parameters fset, pkg, and pos is the same as in CheckExpr.
+//
+// If target is nil, the result is the value and (possibly untyped) type of
+// expr. Otherwise, the value is converted to target as in an assignment to a
+// variable of type target; the result type is then target, or the default
+// type of an untyped constant if target is an interface.
+//
+// An error is returned if expr cannot be type-checked, is not constant, or
+// cannot be represented as a value of type target.
+func EvalConst(fset *token.FileSet, pkg *Package, pos token.Pos, expr ast.Expr, target Type) (_ constant.Value, _ Type, err error) {
+	check, err := newExprChecker(fset, pkg, pos, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer check.handleBailout(&err)
+
+	var x operand
+	check.expr(&x, expr)
+	if x.mode != invalid && x.mode != constant_ {
+		check.errorf(&x, _InvalidConstInit, "%s is not constant", &x)
+		x.mode = invalid
+	}
+	if x.mode != invalid && target != nil {
+		check.assignment(&x, target, "constant evaluation")
+	}
+	check.processDelayed(0)
+
+	if x.mode != constant_ {
+		if check.firstErr == nil {
+			// x is invalid because of an error in pkg; e.g., expr
+			// refers to a variable of invalid type.
+			check.errorf(expr, _InvalidConstInit, "invalid constant expression %s", expr)
+		}
+		return nil, nil, check.firstErr
+	}
+	return x.val, x.typ, nil
+}
+
+// newExprChecker returns a checker for the evaluation of expressions
+// at position pos of package pkg, as described by CheckExpr.
+func newExprChecker(fset *token.FileSet, pkg *Package, pos token.Pos, info *Info) (*Checker, error) {
 	// determine scope
 	var scope *Scope
 	if pkg == nil {
@@ -79,7 +237,7 @@ func CheckExpr(fset *token.FileSet, pkg *Package, pos token.Pos, expr ast.Expr,
 			}
 			// s == nil || s == pkg.scope
 			if s == nil {
-				return fmt.Errorf("no position %s found in package %s", fset.Position(pos), pkg.name)
+				return nil, fmt.Errorf("no position %s found in package %s", fset.Position(pos), pkg.name)
 			}
 		}
 	}
@@ -88,13 +246,5 @@ func CheckExpr(fset *token.FileSet, pkg *Package, pos token.Pos, expr ast.Expr,
 	check := NewChecker(nil, fset, pkg, info)
 	check.scope = scope
 	check.pos = pos
-	defer check.handleBailout(&err)
-
-	// evaluate node
-	var x operand
-	check.rawExpr(&x, expr, nil, true) // allow generic expressions
-	check.processDelayed(0)            // incl. all functions
-	check.recordUntyped()
-
-	return nil
+	return check, nil
 }
@@ -295,3 +295,133 @@ func f(a int, s string) S {
 		}
 	}
 }
+
+func TestEvalConst(t *testing.T) {
+	const src = `
+package p
+
+const c = 1 << 10
+type T int8
+const d T = 3
+var v int
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conf Config
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		expr   string
+		target Type
+		want   string // value and type, or error
+	}{
+		{"c * 2", nil, "2048 untyped int"},
+		{`len("foo")`, nil, "3 int"},
+		{"d + 1", nil, "4 p.T"},
+		{"c / 3.0", Typ[Float32], "341.333 float32"},
+		{"'a'", NewInterfaceType(nil, nil), "97 rune"},
+		{"c", Typ[Int8], "cannot use c (untyped int constant 1024) as int8 value in constant evaluation (overflows)"},
+		{"1.5", Typ[Int], "cannot use 1.5 (untyped float constant) as int value in constant evaluation (truncated)"},
+		{"v + 1", nil, "v + 1 (value of type int) is not constant"},
+		{"x", nil, "undeclared name: x"},
+	} {
+		expr, err := parser.ParseExpr(test.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		val, typ, err := EvalConst(fset, pkg, token.NoPos, expr, test.target)
+		if err != nil {
+			got = err.(Error).Msg
+		} else {
+			got = val.String() + " " + typ.String()
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.expr, got, test.want)
+		}
+	}
+}
+
+// EvalConst reports an error for an expression that is invalid because of
+// errors in the package it is evaluated in.
+func TestEvalConstInvalid(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p", "package p; var v T", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := Config{Error: func(error) {}}
+	pkg, _ := conf.Check("p", fset, []*ast.File{f}, nil)
+
+	for _, src := range []string{"v", "v + 1", "-v"} {
+		expr, err := parser.ParseExpr(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		val, typ, err := EvalConst(fset, pkg, token.NoPos, expr, nil)
+		if err == nil {
+			t.Errorf("%s: got %v %v, want error", src, val, typ)
+		}
+	}
+}
+
+func TestEnvironmentEval(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p", "package p; type T struct{ f int }", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSession(&Config{}, fset)
+	s.SetFiles("p", []*ast.File{f})
+	pkg, _, err := s.Check("p")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := s.Environment()
+	tv, err := env.Eval(fset, pkg, token.NoPos, "struct{ T }{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := fset.Base()
+	tv2, err := env.Eval(fset, pkg, token.NoPos, "struct{ T }{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tv2.Type != tv.Type || fset.Base() != base {
+		t.Errorf("Eval result is not memoized")
+	}
+	if _, err := env.Eval(fset, pkg, token.NoPos, "T{}.g"); err == nil {
+		t.Errorf("got no error for T{}.g")
+	}
+	if _, err := env.Eval(fset, pkg, token.NoPos, "T{}.g"); err == nil {
+		t.Errorf("got no error for memoized T{}.g")
+	}
+
+	// A package checked again is evaluated against anew.
+	f, err = parser.ParseFile(fset, "p", "package p; type T struct{ g int }", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetFiles("p", []*ast.File{f})
+	pkg2, _, err := s.Check("p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tv2, err = env.Eval(fset, pkg2, token.NoPos, "struct{ T }{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tv2.Type == tv.Type {
+		t.Errorf("Eval result for the old package was used for the new one")
+	}
+	if _, err := env.Eval(fset, pkg2, token.NoPos, "T{}.g"); err != nil {
+		t.Errorf("T{}.g: %v", err)
+	}
+}
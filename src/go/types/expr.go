@@ -13,6 +13,7 @@ import (
 	"go/internal/typeparams"
 	"go/token"
 	"math"
+	"math/big"
 )
 
 /*
@@ -113,6 +114,85 @@ func (check *Checker) overflow(x *operand, op token.Token, opPos token.Pos) {
 	}
 }
 
+// roundedConversion reports a call of the Rounding hook if the conversion of
+// the constant value x to typ produced the (different) rounded value r.
+func (check *Checker) roundedConversion(pos token.Pos, typ Type, x, r constant.Value) {
+	if f := check.conf.Rounding; f != nil && (isFloat(typ) || isComplex(typ)) && x.Kind() != constant.Unknown {
+		if !constant.Compare(x, token.EQL, r) {
+			f(Rounding{Pos: pos, Conversion: true, Type: typ, Value: x, Rounded: r})
+		}
+	}
+}
+
+// roundedArith reports a call of the Rounding hook if the result z of the
+// floating-point or complex constant operation x op y is not exact.
+func (check *Checker) roundedArith(pos token.Pos, typ Type, x constant.Value, op token.Token, y, z constant.Value) {
+	if f := check.conf.Rounding; f != nil && (z.Kind() == constant.Float || z.Kind() == constant.Complex) {
+		if !exactArith(x, op, y, z) {
+			f(Rounding{Pos: pos, Type: typ, Rounded: z})
+		}
+	}
+}
+
+// exactArith reports whether z is the exact result of the numeric constant
+// operation x op y. If exactness cannot be determined (for instance, because
+// the operands' magnitudes are too extreme to compute with exactly), the
+// result is true.
+func exactArith(x constant.Value, op token.Token, y, z constant.Value) bool {
+	a, b := exactRat(constant.Real(x)), exactRat(constant.Imag(x))
+	c, d := exactRat(constant.Real(y)), exactRat(constant.Imag(y))
+	zre, zim := exactRat(constant.Real(z)), exactRat(constant.Imag(z))
+	if a == nil || b == nil || c == nil || d == nil || zre == nil || zim == nil {
+		return true
+	}
+
+	// (a + bi) op (c + di) = re + im*i
+	re, im := new(big.Rat), new(big.Rat)
+	switch op {
+	case token.ADD:
+		re.Add(a, c)
+		im.Add(b, d)
+	case token.SUB:
+		re.Sub(a, c)
+		im.Sub(b, d)
+	case token.MUL:
+		re.Sub(new(big.Rat).Mul(a, c), new(big.Rat).Mul(b, d))
+		im.Add(new(big.Rat).Mul(a, d), new(big.Rat).Mul(b, c))
+	case token.QUO:
+		den := new(big.Rat).Add(new(big.Rat).Mul(c, c), new(big.Rat).Mul(d, d))
+		if den.Sign() == 0 {
+			return true // division by zero is reported elsewhere
+		}
+		re.Add(new(big.Rat).Mul(a, c), new(big.Rat).Mul(b, d))
+		re.Quo(re, den)
+		im.Sub(new(big.Rat).Mul(b, c), new(big.Rat).Mul(a, d))
+		im.Quo(im, den)
+	default:
+		return true
+	}
+	return re.Cmp(zre) == 0 && im.Cmp(zim) == 0
+}
+
+// exactRat returns the exact value of the integer or floating-point constant
+// x as a fraction, or nil if x is unknown or its magnitude is too extreme.
+func exactRat(x constant.Value) *big.Rat {
+	switch v := constant.Val(x).(type) {
+	case int64:
+		return new(big.Rat).SetInt64(v)
+	case *big.Int:
+		return new(big.Rat).SetInt(v)
+	case *big.Rat:
+		return v
+	case *big.Float:
+		const maxExp = 1 << 14 // avoid huge fractions
+		if exp := v.MantExp(nil); -maxExp <= exp && exp <= maxExp {
+			r, _ := v.Rat(nil)
+			return r
+		}
+	}
+	return nil
+}
+
 // opName returns the name of an operation, or the empty string.
 // Only operations that might overflow are handled.
 func opName(e ast.Expr) string {
@@ -449,6 +529,7 @@ func (check *Checker) representation(x *operand, typ *Basic) (constant.Value, er
 		}
 		return nil, _InvalidConstVal
 	}
+	check.roundedConversion(x.Pos(), typ, x.val, v)
 	return v, 0
 }
 
@@ -575,7 +656,7 @@ func (check *Checker) updateExprType(x ast.Expr, typ Type, final bool) {
 	}
 	if old.val != nil {
 		// If x is a constant, it must be representable as a value of typ.
-		c := operand{old.mode, x, old.typ, old.val, 0}
+		c := operand{old.mode, x, old.typ, old.val, 0, nil}
 		check.convertUntyped(&c, typ)
 		if c.mode == invalid {
 			return
@@ -584,6 +665,7 @@ func (check *Checker) updateExprType(x ast.Expr, typ Type, final bool) {
 
 	// Everything's fine, record final type and value for x.
 	check.recordTypeAndValue(x, old.mode, typ, old.val)
+	check.recordFinalType(x, typ)
 }
 
 // updateExprVal updates the value of x to val.
@@ -817,7 +899,7 @@ func (check *Checker) shift(x, y *operand, e ast.Expr, op token.Token) {
 	// Check that RHS is otherwise at least of integer type.
 	switch {
 	case isInteger(y.typ):
-		if !isUnsigned(y.typ) && !check.allowVersion(check.pkg, 1, 13) {
+		if !isUnsigned(y.typ) && !check.allowVersion(check.pkg, 1, 13) && !check.forwardCompat(y, 1, 13, "signed shift count %s", y) {
 			check.invalidOp(y, _InvalidShiftCount, "signed shift count %s requires go1.13 or later", y)
 			x.mode = invalid
 			return
@@ -935,6 +1017,21 @@ func init() {
 	}
 }
 
+// canMix reports whether the operands x and y may be converted to
+// each other's type if they are untyped.
+func canMix(x, y *operand) bool {
+	if IsInterface(x.typ) || IsInterface(y.typ) {
+		return true
+	}
+	if isBoolean(x.typ) != isBoolean(y.typ) {
+		return false
+	}
+	if isString(x.typ) != isString(y.typ) {
+		return false
+	}
+	return true
+}
+
 // If e != nil, it must be the binary expression; it may be nil for non-constant expressions
 // (when invoked for an assignment operation where the binary expression is implicit).
 func (check *Checker) binary(x *operand, e ast.Expr, lhs, rhs ast.Expr, op token.Token, opPos token.Pos) {
@@ -957,18 +1054,6 @@ func (check *Checker) binary(x *operand, e ast.Expr, lhs, rhs ast.Expr, op token
 		return
 	}
 
-	canMix := func(x, y *operand) bool {
-		if IsInterface(x.typ) || IsInterface(y.typ) {
-			return true
-		}
-		if isBoolean(x.typ) != isBoolean(y.typ) {
-			return false
-		}
-		if isString(x.typ) != isString(y.typ) {
-			return false
-		}
-		return true
-	}
 	if canMix(x, &y) {
 		check.convertUntyped(x, y.typ)
 		if x.mode == invalid {
@@ -1036,8 +1121,10 @@ func (check *Checker) binary(x *operand, e ast.Expr, lhs, rhs ast.Expr, op token
 		if op == token.QUO && isInteger(x.typ) {
 			op = token.QUO_ASSIGN
 		}
+		xval := x.val
 		x.val = constant.BinaryOp(x.val, op, y.val)
 		x.expr = e
+		check.roundedArith(opPos, x.typ, xval, op, y.val, x.val)
 		check.overflow(x, op, opPos)
 		return
 	}
@@ -1072,6 +1159,7 @@ func (check *Checker) rawExpr(x *operand, e ast.Expr, hint Type, allowGeneric bo
 		}()
 	}
 
+	check.checkMemory()
 	kind := check.exprInternal(x, e, hint)
 
 	if !allowGeneric {
@@ -1160,6 +1248,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 
 	case *ast.FuncLit:
 		if sig, ok := check.typ(e.Type).(*Signature); ok {
+			check.reportShadowedParams(sig)
 			if !check.conf.IgnoreFuncBodies && e.Body != nil {
 				// Anonymous functions are considered part of the
 				// init expression/func declaration which contains
@@ -1170,9 +1259,13 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 				// be part of a type definition to which the function
 				// body refers. Instead, type-check as soon as possible,
 				// but before the enclosing scope contents changes (#22992).
+				check.pendBody(nil, e.Body)
 				check.later(func() {
 					check.funcBody(decl, "<function literal>", sig, e.Body, iota)
+					check.bodyDone(e.Body)
 				})
+			} else if e.Body != nil {
+				check.recordSkipped(nil, e.Body.Pos(), SkipFuncBody)
 			}
 			x.mode = value
 			x.typ = sig
@@ -1250,6 +1343,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 						check.errorf(kv, _DuplicateLitField, "duplicate field name %s in struct literal", key.Name)
 						continue
 					}
+					check.recordLitElement(kv, LitElement{Field: fld, Index: -1})
 					visited[i] = true
 				}
 			} else {
@@ -1272,6 +1366,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 							"implicit assignment to unexported field %s in %s literal", fld.name, typ)
 						continue
 					}
+					check.recordLitElement(e, LitElement{Field: fld, Index: -1})
 					etyp := fld.typ
 					check.assignment(x, etyp, "struct literal")
 				}
@@ -1358,6 +1453,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 						continue
 					}
 				}
+				check.recordLitElement(kv, LitElement{Index: -1, Key: utyp.key})
 				check.exprWithHint(x, kv.Value, utyp.elem)
 				check.assignment(x, utyp.elem, "map literal")
 			}
@@ -1534,6 +1630,12 @@ func keyVal(x constant.Value) interface{} {
 
 // typeAssertion checks that x.(T) is legal; xtyp must be the type of x.
 func (check *Checker) typeAssertion(at positioner, x *operand, xtyp *Interface, T Type) {
+	if check.conf.ReportImpossibleAssertions {
+		if Ti := asInterface(T); Ti != nil {
+			check.conflictingMethods(at, x, xtyp, T, Ti)
+		}
+	}
+
 	method, wrongType := check.assertableTo(xtyp, T)
 	if method == nil {
 		return
@@ -1551,6 +1653,18 @@ func (check *Checker) typeAssertion(at positioner, x *operand, xtyp *Interface,
 	check.errorf(at, _ImpossibleAssert, "%s cannot have dynamic type %s (%s)", x, T, msg)
 }
 
+// conflictingMethods reports a warning if the interfaces xtyp, the type of
+// x, and Ti, the underlying type of T, have a method with the same name but
+// different signatures (see Config.ReportImpossibleAssertions).
+func (check *Checker) conflictingMethods(at positioner, x *operand, xtyp *Interface, T Type, Ti *Interface) {
+	for _, m := range Ti.typeSet().methods {
+		if _, f := xtyp.typeSet().LookupMethod(m.pkg, m.name); f != nil && !Identical(f.typ, m.typ) {
+			check.warnf(at, _ImpossibleIfaceAssert, "impossible type assertion: %s cannot have dynamic type implementing %s (conflicting types for method %s: have %s, want %s)", x, T, m.name, f.typ, m.typ)
+			return
+		}
+	}
+}
+
 // expr typechecks expression e and initializes x with the expression value.
 // The result must be a single value.
 // If an error occurred, x.mode is set to invalid.
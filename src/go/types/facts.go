@@ -0,0 +1,111 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements facts attached to packages and objects.
+
+package types
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// A Fact is a piece of information about a package or one of its objects,
+// such as the result of analyzing the package, that may be exported along
+// with the export data of the package and imported by the analysis of
+// packages depending on it. Fact values are pointers to structs and are
+// identified by their type: at most one fact of each type is attached to
+// a package or object. To be exported, a fact type must be registered with
+// encoding/gob.
+type Fact interface {
+	AFact() // dummy method to avoid type errors
+}
+
+// An ObjectFact is a fact attached to an object, or to a package
+// if Object is nil.
+type ObjectFact struct {
+	Object Object
+	Fact   Fact
+}
+
+// A factSet holds the facts attached to a package and its objects.
+type factSet struct {
+	mu sync.Mutex
+	m  map[factKey]Fact // created lazily
+}
+
+type factKey struct {
+	obj Object // nil for package facts
+	typ reflect.Type
+}
+
+// SetFact attaches fact to the object obj of pkg, or to pkg itself if obj
+// is nil, replacing any fact of the same type attached to it before.
+// Facts are not part of the API of pkg and do not have to be consistent
+// with it. SetFact is safe for concurrent use.
+func (pkg *Package) SetFact(obj Object, fact Fact) {
+	if obj != nil && obj.Pkg() != pkg {
+		panic("SetFact: object " + obj.Name() + " does not belong to package " + pkg.path)
+	}
+	if reflect.TypeOf(fact).Kind() != reflect.Ptr {
+		panic("SetFact: fact is not a pointer")
+	}
+	pkg.facts.set(factKey{obj, reflect.TypeOf(fact)}, fact)
+}
+
+// Fact reports whether a fact of the type of fact is attached to the object
+// obj of pkg, or to pkg itself if obj is nil, and if so copies the fact to
+// *fact. Fact is safe for concurrent use.
+func (pkg *Package) Fact(obj Object, fact Fact) bool {
+	f := pkg.facts.get(factKey{obj, reflect.TypeOf(fact)})
+	if f == nil {
+		return false
+	}
+	reflect.ValueOf(fact).Elem().Set(reflect.ValueOf(f).Elem())
+	return true
+}
+
+// Facts returns the facts attached to pkg and its objects: the package
+// facts first, followed by the object facts in order of their positions,
+// and the facts of each object in order of their type names.
+func (pkg *Package) Facts() []ObjectFact {
+	s := &pkg.facts
+	s.mu.Lock()
+	var list []ObjectFact
+	for key, fact := range s.m {
+		list = append(list, ObjectFact{key.obj, fact})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		x, y := list[i].Object, list[j].Object
+		if (x == nil) != (y == nil) {
+			return x == nil
+		}
+		if x != nil && x != y {
+			if x.Pos() != y.Pos() {
+				return x.Pos() < y.Pos()
+			}
+			return x.Id() < y.Id()
+		}
+		return reflect.TypeOf(list[i].Fact).String() < reflect.TypeOf(list[j].Fact).String()
+	})
+	return list
+}
+
+func (s *factSet) set(key factKey, fact Fact) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.m == nil {
+		s.m = make(map[factKey]Fact)
+	}
+	s.m[key] = fact
+}
+
+func (s *factSet) get(key factKey) Fact {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m[key]
+}
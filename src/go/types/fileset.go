@@ -0,0 +1,175 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements MergeFiles and the check that the files of a
+// package are positioned in the checker's file set.
+
+package types
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MergeFiles adds the files of the syntax trees files, which are
+// positioned in the file set src, to the file set dst, and returns copies
+// of the trees positioned in dst. It permits checking syntax trees parsed
+// with different file sets, such as overlays, generated files, and cached
+// trees, together as one package: all files of a package must be
+// positioned in the file set given to Config.Check.
+//
+// The copies have the same line information as the originals, including
+// the alternative positions of line directives, provided that the /*line*/
+// comments not at the start of a line are included in the trees. Syntax
+// trees sharing a file of src share the file of dst. The original trees
+// and src are not modified; dst may be src.
+func MergeFiles(dst, src *token.FileSet, files []*ast.File) ([]*ast.File, error) {
+	m := fileMerger{dst: dst, files: make(map[*token.File]*token.File), copies: make(map[interface{}]reflect.Value)}
+	res := make([]*ast.File, len(files))
+	for i, f := range files {
+		from := src.File(f.Package)
+		if from == nil || src.File(f.End()) != from {
+			return nil, fmt.Errorf("syntax tree %d (package %s) is not positioned in the source file set", i, f.Name.Name)
+		}
+		to := m.files[from]
+		if to == nil {
+			to = m.addFile(from, f.Comments)
+			m.files[from] = to
+		}
+		m.delta = to.Base() - from.Base()
+		res[i] = m.copy(reflect.ValueOf(f)).Interface().(*ast.File)
+	}
+	return res, nil
+}
+
+// A fileMerger copies syntax trees into its file set dst.
+type fileMerger struct {
+	dst    *token.FileSet
+	files  map[*token.File]*token.File   // source file -> file in dst
+	copies map[interface{}]reflect.Value // copies of pointers, for shared nodes and cycles
+	delta  int                           // position offset of the current file
+}
+
+var posType = reflect.TypeOf(token.NoPos)
+
+// addFile adds a copy of the file from, with the given comments, to m.dst.
+func (m *fileMerger) addFile(from *token.File, comments []*ast.CommentGroup) *token.File {
+	to := m.dst.AddFile(from.Name(), -1, from.Size())
+	lines := make([]int, from.LineCount())
+	for i := range lines {
+		lines[i] = from.Offset(from.LineStart(i + 1))
+	}
+	to.SetLines(lines)
+
+	// Line directives take effect at the start of the line following a
+	// //line comment, or right after a /*line*/ comment. Since Files
+	// don't expose them, record the alternative positions at all such
+	// places (in increasing order) where they differ from those of to.
+	offsets := append([]int(nil), lines...)
+	for _, g := range comments {
+		for _, c := range g.List {
+			if strings.HasPrefix(c.Text, "/*line ") {
+				offsets = append(offsets, from.Offset(c.End()))
+			}
+		}
+	}
+	sort.Ints(offsets)
+	for _, offs := range offsets {
+		if offs >= from.Size() {
+			continue
+		}
+		want := from.PositionFor(from.Pos(offs), true)
+		got := to.PositionFor(to.Pos(offs), true)
+		if got.Filename != want.Filename || got.Line != want.Line || got.Column != want.Column {
+			to.AddLineColumnInfo(offs, want.Filename, want.Line, want.Column)
+		}
+	}
+	return to
+}
+
+// copy returns a deep copy of v with positions shifted by m.delta.
+func (m *fileMerger) copy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		if c, ok := m.copies[v.Interface()]; ok {
+			return c
+		}
+		c := reflect.New(v.Type().Elem())
+		m.copies[v.Interface()] = c
+		c.Elem().Set(m.copy(v.Elem()))
+		return c
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(m.copy(v.Elem()))
+		return c
+
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			c.Field(i).Set(m.copy(v.Field(i)))
+		}
+		return c
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(m.copy(v.Index(i)))
+		}
+		return c
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), m.copy(iter.Value()))
+		}
+		return c
+
+	case reflect.Int:
+		if v.Type() == posType && v.Int() != int64(token.NoPos) {
+			return reflect.ValueOf(token.Pos(int(v.Int()) + m.delta))
+		}
+	}
+	return v
+}
+
+// checkFileSet reports an error and returns false if the i'th file is
+// not positioned in the file set of check, or in the same file of the
+// file set as one of the files in seen; otherwise it adds the file to
+// seen. Syntax trees without positions are accepted. Only the positions
+// of the package clause and end of the file are checked; a tree positioned
+// in a different file set may go unnoticed.
+func (check *Checker) checkFileSet(i int, file *ast.File, seen map[*token.File]int) bool {
+	if check.fset == nil || !file.Package.IsValid() {
+		return true
+	}
+	f := check.fset.File(file.Package)
+	if f == nil || check.fset.File(file.End()) != f {
+		check.errorf(atPos(token.NoPos), _InvalidFileSet, "syntax tree %d (package %s) is not positioned in the file set", i, file.Name.Name)
+		return false
+	}
+	if j, dup := seen[f]; dup {
+		check.errorf(atPos(token.NoPos), _InvalidFileSet, "syntax trees %d and %d are positioned in the same file %s", j, i, f.Name())
+		return false
+	}
+	seen[f] = i
+	return true
+}
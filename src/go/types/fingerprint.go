@@ -0,0 +1,311 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements fingerprints of the API of packages.
+
+package types
+
+import (
+	"bytes"
+	"go/constant"
+	"go/token"
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// APIFingerprint returns a 128-bit hash of the API of the type-checked package pkg:
+// its exported package-level objects, and the package-level objects they
+// refer to, with their types, methods, and the values of constants. The
+// hash is stable across type checker runs and insensitive to positions and
+// to changes of unexported objects not referred to by the API, so that a
+// build system may skip recompiling packages depending on pkg if it is
+// unchanged.
+//
+// Objects of other packages are only identified by package path and name;
+// the fingerprints of dependencies must be taken into account separately.
+// Function bodies are not part of the fingerprint, even though compilers
+// may inline them into dependent packages.
+func APIFingerprint(pkg *Package) (sum [16]byte) {
+	f := fingerprinter{pkg: pkg, seen: make(map[Object]bool)}
+	f.buf.WriteString(pkg.path)
+	f.buf.WriteByte(' ')
+	f.buf.WriteString(pkg.name)
+	f.buf.WriteByte('\n')
+
+	// Describe the exported objects first, in order, and then
+	// the unexported objects they refer to, as they are found.
+	for _, name := range pkg.scope.Names() {
+		if token.IsExported(name) {
+			f.push(pkg.scope.Lookup(name))
+		}
+	}
+	for len(f.todo) > 0 {
+		obj := f.todo[0]
+		f.todo = f.todo[1:]
+		f.object(obj)
+	}
+	h := fnv.New128a()
+	h.Write(f.buf.Bytes())
+	h.Sum(sum[:0])
+	return
+}
+
+// A fingerprinter writes a canonical description of the API of pkg.
+type fingerprinter struct {
+	pkg  *Package
+	buf  bytes.Buffer
+	seen map[Object]bool // objects pushed on todo
+	todo []Object        // objects to describe
+}
+
+// push schedules the description of the package-level object obj of
+// f.pkg, unless it has been scheduled before.
+func (f *fingerprinter) push(obj Object) {
+	if !f.seen[obj] {
+		f.seen[obj] = true
+		f.todo = append(f.todo, obj)
+	}
+}
+
+func (f *fingerprinter) string(s string) { f.buf.WriteString(s) }
+
+// object writes the description of the package-level object obj.
+func (f *fingerprinter) object(obj Object) {
+	switch obj := obj.(type) {
+	case *Const:
+		f.string("const " + obj.name + " ")
+		f.typ(obj.typ)
+		f.string(" = ")
+		f.value(obj.val)
+
+	case *Var:
+		f.string("var " + obj.name + " ")
+		f.typ(obj.typ)
+
+	case *Func:
+		f.string("func " + obj.name)
+		f.signature(obj.typ.(*Signature))
+
+	case *TypeName:
+		if obj.IsAlias() {
+			f.string("type " + obj.name + " = ")
+			f.typ(obj.typ)
+			break
+		}
+		named, _ := obj.typ.(*Named)
+		if named == nil {
+			// not a defined type; should not happen at package level
+			f.string("type " + obj.name + " ")
+			f.typ(obj.typ)
+			break
+		}
+		f.string("type " + obj.name)
+		f.tparams(named.TypeParams())
+		f.string(" ")
+		f.typ(named.Underlying())
+		// Methods are described in the order of their names. Unexported
+		// methods are included: they matter for the interfaces
+		// implemented by the type.
+		methods := make([]*Func, named.NumMethods())
+		for i := range methods {
+			methods[i] = named.Method(i)
+		}
+		sort.Slice(methods, func(i, j int) bool { return methods[i].name < methods[j].name })
+		for _, m := range methods {
+			f.string("\n\tmethod ")
+			if sig, _ := m.typ.(*Signature); sig != nil && sig.recv != nil {
+				if _, ptr := deref(sig.recv.typ); ptr {
+					f.string("*")
+				}
+			}
+			f.string(m.name)
+			f.signature(m.typ.(*Signature))
+		}
+
+	default:
+		unreachable()
+	}
+	f.string("\n")
+}
+
+// value writes the exact value of a constant.
+func (f *fingerprinter) value(val constant.Value) {
+	f.string(val.Kind().String() + " " + val.ExactString())
+}
+
+// typeName writes the qualified name of a type, scheduling the
+// description of package-level objects of f.pkg.
+func (f *fingerprinter) typeName(obj *TypeName) {
+	if obj.pkg == nil {
+		f.string(obj.name) // predeclared
+		return
+	}
+	if obj.pkg == f.pkg && obj.parent == f.pkg.scope {
+		f.push(obj)
+	}
+	f.string(strconv.Quote(obj.pkg.path) + "." + obj.name)
+}
+
+// typ writes a description of typ. Named types are described by name.
+func (f *fingerprinter) typ(typ Type) {
+	switch t := typ.(type) {
+	case nil:
+		f.string("<nil>")
+
+	case *Basic:
+		if t.kind == UnsafePointer {
+			f.string("unsafe.Pointer")
+			break
+		}
+		f.string(t.name)
+
+	case *Array:
+		f.string("[" + strconv.FormatInt(t.len, 10) + "]")
+		f.typ(t.elem)
+
+	case *Slice:
+		f.string("[]")
+		f.typ(t.elem)
+
+	case *Struct:
+		f.string("struct{")
+		for i, fld := range t.fields {
+			if i > 0 {
+				f.string("; ")
+			}
+			if fld.embedded {
+				f.string("embedded ")
+			}
+			f.string(fld.name + " ")
+			f.typ(fld.typ)
+			if tag := t.Tag(i); tag != "" {
+				f.string(" " + strconv.Quote(tag))
+			}
+		}
+		f.string("}")
+
+	case *Pointer:
+		f.string("*")
+		f.typ(t.base)
+
+	case *Tuple:
+		f.tuple(t, false)
+
+	case *Signature:
+		f.string("func")
+		f.signature(t)
+
+	case *Union:
+		for i, term := range t.terms {
+			if i > 0 {
+				f.string(" | ")
+			}
+			if term.tilde {
+				f.string("~")
+			}
+			f.typ(term.typ)
+		}
+
+	case *Interface:
+		f.string("interface{")
+		for i, m := range t.methods {
+			if i > 0 {
+				f.string("; ")
+			}
+			f.string(m.name)
+			f.signature(m.typ.(*Signature))
+		}
+		for i, e := range t.embeddeds {
+			if i > 0 || len(t.methods) > 0 {
+				f.string("; ")
+			}
+			f.typ(e)
+		}
+		f.string("}")
+
+	case *Map:
+		f.string("map[")
+		f.typ(t.key)
+		f.string("]")
+		f.typ(t.elem)
+
+	case *Chan:
+		switch t.dir {
+		case SendRecv:
+			f.string("chan ")
+		case SendOnly:
+			f.string("chan<- ")
+		case RecvOnly:
+			f.string("<-chan ")
+		}
+		f.typ(t.elem)
+
+	case *Named:
+		f.typeName(t.obj)
+		if targs := t.TypeArgs(); targs.Len() > 0 {
+			f.string("[")
+			for i := 0; i < targs.Len(); i++ {
+				if i > 0 {
+					f.string(", ")
+				}
+				f.typ(targs.At(i))
+			}
+			f.string("]")
+		}
+
+	case *Alias:
+		f.typ(Unalias(t))
+
+	case *TypeParam:
+		// Type parameters are described by their index in the
+		// (only) type parameter list in scope, so that renaming
+		// them does not change the fingerprint.
+		f.string("$" + strconv.Itoa(t.index))
+
+	default:
+		f.string(t.String())
+	}
+}
+
+// signature writes the type parameters, parameters, and results of sig.
+// Parameter names are not part of the description.
+func (f *fingerprinter) signature(sig *Signature) {
+	if sig.TypeParams().Len() > 0 {
+		f.tparams(sig.TypeParams())
+	} else if sig.RecvTypeParams().Len() > 0 {
+		f.tparams(sig.RecvTypeParams())
+	}
+	f.tuple(sig.params, sig.variadic)
+	f.string(" ")
+	f.tuple(sig.results, false)
+}
+
+func (f *fingerprinter) tuple(tup *Tuple, variadic bool) {
+	f.string("(")
+	for i := 0; i < tup.Len(); i++ {
+		if i > 0 {
+			f.string(", ")
+		}
+		if variadic && i == tup.Len()-1 {
+			f.string("...")
+		}
+		f.typ(tup.At(i).typ)
+	}
+	f.string(")")
+}
+
+func (f *fingerprinter) tparams(list *TypeParamList) {
+	if list.Len() == 0 {
+		return
+	}
+	f.string("[")
+	for i := 0; i < list.Len(); i++ {
+		if i > 0 {
+			f.string(", ")
+		}
+		f.typ(list.At(i).Constraint())
+	}
+	f.string("]")
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements FreeTypeParams.
+
+package types
+
+// FreeTypeParams returns the distinct type parameters that occur free in
+// typ, in the order of their first occurrence, or nil if there are none.
+// Type parameters are bound, rather than free, within the signatures of
+// generic functions and methods that declare them (including receiver type
+// parameters) and within the generic types that declare them; instances of
+// generic types contain the type parameters of their type arguments.
+// The constraints of type parameters are not considered.
+//
+// FreeTypeParams doesn't expand the underlying types of defined types
+// other than those declared locally within (generic) functions, so it
+// handles recursive types and is cheap for types referring to large
+// packages.
+func FreeTypeParams(typ Type) []*TypeParam {
+	w := freeWalker{seen: make(map[Type]bool), bound: make(map[*TypeParam]bool)}
+	w.typ(typ)
+	return w.free
+}
+
+// IsParameterized reports whether type parameters occur free in typ
+// (see FreeTypeParams).
+func IsParameterized(typ Type) bool {
+	w := freeWalker{seen: make(map[Type]bool), bound: make(map[*TypeParam]bool), first: true}
+	w.typ(typ)
+	return len(w.free) > 0
+}
+
+// A freeWalker collects the free type parameters of types.
+type freeWalker struct {
+	seen  map[Type]bool       // visited local defined types
+	bound map[*TypeParam]bool // type parameters declared by visited signatures
+	first bool                // if set, stop at the first free type parameter
+	free  []*TypeParam        // free type parameters found, without duplicates
+}
+
+func (w *freeWalker) typ(typ Type) {
+	if w.first && len(w.free) > 0 {
+		return
+	}
+	switch t := typ.(type) {
+	case nil, *Basic:
+		// nothing to do
+
+	case *Array:
+		w.typ(t.elem)
+
+	case *Slice:
+		w.typ(t.elem)
+
+	case *Struct:
+		for _, f := range t.fields {
+			w.typ(f.typ)
+		}
+
+	case *Pointer:
+		w.typ(t.base)
+
+	case *Tuple:
+		if t != nil {
+			for _, v := range t.vars {
+				w.typ(v.typ)
+			}
+		}
+
+	case *Signature:
+		w.bind(t.rparams)
+		w.bind(t.tparams)
+		w.typ(t.params)
+		w.typ(t.results)
+
+	case *Union:
+		for _, term := range t.terms {
+			w.typ(term.typ)
+		}
+
+	case *Interface:
+		for _, m := range t.methods {
+			w.typ(m.typ)
+		}
+		for _, e := range t.embeddeds {
+			w.typ(e)
+		}
+
+	case *Map:
+		w.typ(t.key)
+		w.typ(t.elem)
+
+	case *Chan:
+		w.typ(t.elem)
+
+	case *Alias:
+		w.typ(t.actual)
+
+	case *Named:
+		if targs := t.TypeArgs(); targs.Len() > 0 {
+			for _, targ := range targs.list() {
+				w.typ(targ)
+			}
+			return
+		}
+		// Only types declared within functions may refer to the type
+		// parameters of those functions; generic types bind their own.
+		if obj := t.obj; obj.parent == nil || obj.pkg == nil || obj.parent == obj.pkg.scope || t.TypeParams().Len() > 0 {
+			return
+		}
+		if !w.seen[t] {
+			w.seen[t] = true
+			w.typ(t.Underlying())
+		}
+
+	case *TypeParam:
+		if !w.bound[t] {
+			w.bound[t] = true // report t once
+			w.free = append(w.free, t)
+		}
+
+	default:
+		unreachable()
+	}
+}
+
+// bind records that the type parameters in list are bound.
+func (w *freeWalker) bind(list *TypeParamList) {
+	for _, tpar := range list.list() {
+		w.bound[tpar] = true
+	}
+}
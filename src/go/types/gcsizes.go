@@ -0,0 +1,155 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements GCSizes.
+
+package types
+
+// GCSizes implements the sizes, alignments, and field offsets chosen by
+// the gc compiler, and thus by package reflect at run time, for an
+// architecture with the given word size and maximum alignment. It differs
+// from StdSizes as follows:
+//
+//	- The size of a struct includes the padding needed to make it a
+//	  multiple of the struct's alignment.
+//	- If the last field of a struct is zero-sized but not at offset 0,
+//	  it is padded to a size of 1 byte, so that taking its address
+//	  cannot produce a pointer past the end of the struct.
+//	- Consequently, the size of an array of n elements is n times the
+//	  size of its element type.
+//
+// For the architectures supported by GCSizesFor, the sizes, alignments,
+// and offsets computed by GCSizesFor(arch) are guaranteed to be those
+// computed by the gc compiler of the same release for GOARCH=arch (see
+// cmd/compile/internal/noder/sizes.go); any difference is a bug. Unlike
+// StdSizes, GCSizes is therefore suitable for tools that depend on the
+// exact memory layout of values, such as binary encoders.
+//
+// *GCSizes implements Sizes.
+//
+type GCSizes struct {
+	WordSize int64 // word size in bytes - must be >= 4 (32bits)
+	MaxAlign int64 // maximum alignment in bytes - must be >= 1
+}
+
+// GCSizesFor returns the GCSizes used by the gc compiler for an
+// architecture, or nil if the architecture is not known. The supported
+// architectures are the same as for SizesFor("gc", arch); "amd64p32"
+// and "sparc64" are no longer supported by the compiler and have no
+// layout guarantee.
+func GCSizesFor(arch string) *GCSizes {
+	s := gcArchSizes[arch]
+	if s == nil {
+		return nil
+	}
+	return &GCSizes{s.WordSize, s.MaxAlign}
+}
+
+func (s *GCSizes) Alignof(T Type) int64 { return s.alignof(s, T) }
+
+func (s *GCSizes) Offsetsof(fields []*Var) []int64 { return s.offsetsof(s, fields) }
+
+func (s *GCSizes) Sizeof(T Type) int64 { return s.sizeof(s, T) }
+
+func (s *GCSizes) structOffsets(t *Struct) []int64 { return s.Offsetsof(t.fields) }
+
+// A layoutSizes computes the layout of the components of a type on
+// behalf of GCSizes; see LayoutCache.
+type layoutSizes interface {
+	Sizes
+	structOffsets(t *Struct) []int64
+}
+
+// alignof, offsetsof, and sizeof implement the respective GCSizes methods;
+// the layout of component types is computed by rec.
+
+func (s *GCSizes) alignof(rec layoutSizes, T Type) int64 {
+	switch t := under(T).(type) {
+	case *Array:
+		return rec.Alignof(t.elem)
+	case *Struct:
+		max := int64(1)
+		for _, f := range t.fields {
+			if a := rec.Alignof(f.typ); a > max {
+				max = a
+			}
+		}
+		return max
+	case *Slice, *Interface:
+		return s.WordSize
+	case *Basic:
+		if t.Info()&IsString != 0 {
+			return s.WordSize
+		}
+	case *TypeParam, *Union:
+		unreachable()
+	}
+	a := rec.Sizeof(T) // may be 0
+	if a < 1 {
+		return 1
+	}
+	// complex{64,128} are aligned like [2]float{32,64}.
+	if isComplex(T) {
+		a /= 2
+	}
+	if a > s.MaxAlign {
+		return s.MaxAlign
+	}
+	return a
+}
+
+func (s *GCSizes) offsetsof(rec layoutSizes, fields []*Var) []int64 {
+	offsets := make([]int64, len(fields))
+	var o int64
+	for i, f := range fields {
+		o = align(o, rec.Alignof(f.typ))
+		offsets[i] = o
+		o += rec.Sizeof(f.typ)
+	}
+	return offsets
+}
+
+func (s *GCSizes) sizeof(rec layoutSizes, T Type) int64 {
+	switch t := under(T).(type) {
+	case *Basic:
+		assert(isTyped(T))
+		k := t.kind
+		if int(k) < len(basicSizes) {
+			if s := basicSizes[k]; s > 0 {
+				return int64(s)
+			}
+		}
+		if k == String {
+			return s.WordSize * 2
+		}
+	case *Array:
+		if t.len <= 0 {
+			return 0
+		}
+		// The element size includes its padding.
+		return rec.Sizeof(t.elem) * t.len
+	case *Slice:
+		return s.WordSize * 3
+	case *Struct:
+		n := t.NumFields()
+		if n == 0 {
+			return 0
+		}
+		offsets := rec.structOffsets(t)
+		offset := offsets[n-1]
+		size := rec.Sizeof(t.fields[n-1].typ)
+		// gc: The last field of a non-zero-sized struct
+		// must not have size 0.
+		if offset > 0 && size == 0 {
+			size = 1
+		}
+		// gc: The size includes alignment padding.
+		return align(offset+size, rec.Alignof(t))
+	case *Interface:
+		return s.WordSize * 2
+	case *TypeParam, *Union:
+		unreachable()
+	}
+	return s.WordSize // catch-all
+}
@@ -0,0 +1,9 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file exists only to drive go:generate, which generates the go/types
+// files that share their source with types2 (see generate_test.go).
+//go:generate go test -run=Generate -write=all
+
+package types
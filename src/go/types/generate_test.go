@@ -0,0 +1,130 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements a custom generator to create various go/types
+// source files from the corresponding types2 files.
+
+package types_test
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// genFset is the file set of the types2 files.
+var genFset = token.NewFileSet()
+
+var filesToWrite = flag.String("write", "", `go/types files to generate, or "all" for all files`)
+
+const (
+	srcDir = "/src/cmd/compile/internal/types2/"
+	dstDir = "/src/go/types/"
+)
+
+// TestGenerate verifies that generated files in go/types match their types2
+// counterpart. If -write is set, this test actually writes the expected
+// content to go/types; otherwise, it just compares with the existing content.
+func TestGenerate(t *testing.T) {
+	// If filesToWrite is set, write the generated content to disk.
+	// In the normal case, just check that the generated content matches the disk.
+	write := *filesToWrite != ""
+	var files []string // files to process
+	if *filesToWrite != "" && *filesToWrite != "all" {
+		files = strings.Split(*filesToWrite, ",")
+	} else {
+		for file := range filemap {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+	}
+
+	for _, filename := range files {
+		generate(t, filename, write)
+	}
+}
+
+func generate(t *testing.T, filename string, write bool) {
+	// parse src (cmd/compile/internal/types2)
+	srcFilename := filepath.FromSlash(runtime.GOROOT() + srcDir + filename)
+	file, err := parser.ParseFile(genFset, srcFilename, nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fix package name
+	file.Name.Name = strings.ReplaceAll(file.Name.Name, "types2", "types")
+
+	// rewrite AST as needed
+	if action, ok := filemap[filename]; !ok {
+		t.Fatalf("%s is not a generated file", filename)
+	} else if action != nil {
+		action(file)
+	}
+
+	// format AST
+	var buf bytes.Buffer
+	rel, _ := filepath.Rel(dstDir, srcDir)
+	fmt.Fprintf(&buf, "// Code generated by \"go test -run=Generate -write=all\"; DO NOT EDIT.\n")
+	fmt.Fprintf(&buf, "// Source: %s/%s\n\n", filepath.ToSlash(rel), filename)
+	if err := format.Node(&buf, genFset, file); err != nil {
+		t.Fatal(err)
+	}
+	generatedContent := buf.Bytes()
+
+	// read dst (go/types)
+	dstFilename := filepath.FromSlash(runtime.GOROOT() + dstDir + filename)
+	onDiskContent, err := os.ReadFile(dstFilename)
+	if err != nil {
+		t.Fatalf("reading %q: %v", filename, err)
+	}
+
+	// compare on-disk dst with buffer generated from src.
+	if bytes.Equal(onDiskContent, generatedContent) {
+		return // nothing to do
+	}
+
+	if write {
+		if err := os.WriteFile(dstFilename, generatedContent, 0o644); err != nil {
+			t.Fatalf("writing %q: %v", filename, err)
+		}
+		return
+	}
+
+	t.Errorf("generated file content does not match %q (run go generate in go/types)", filename)
+}
+
+type action func(in *ast.File)
+
+// filemap lists the files generated from types2 and the rewrites applied
+// to them, if any. Only the files that differ from their types2 counterpart
+// in the package name alone are generated so far. All other files, which
+// hold nearly all of the type checking logic, are written by hand; when one
+// of them is changed, its types2 counterpart has to be changed accordingly.
+// Even the closest of them, such as unify.go, depend on features that
+// types2 lacks (alias types, indexed type sets), so the two checkers must
+// converge before they can be generated.
+var filemap = map[string]action{
+	"array.go":      nil,
+	"basic.go":      nil,
+	"chan.go":       nil,
+	"gccgosizes.go": nil,
+	"map.go":        nil,
+	"objset.go":     nil,
+	"pointer.go":    nil,
+	"slice.go":      nil,
+	"tuple.go":      nil,
+	"typelists.go":  nil,
+	"typeterm.go":   nil,
+}
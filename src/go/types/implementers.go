@@ -0,0 +1,96 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements queries for the types implementing an interface.
+
+package types
+
+// An Implementer describes a named type implementing an interface.
+type Implementer struct {
+	Type *Named
+	Ptr  bool // only *Type implements the interface, because of methods with pointer receivers
+}
+
+// An ImplementerIndex indexes the named types declared in a set of packages
+// by their methods, to efficiently find the types implementing a given
+// interface. An ImplementerIndex must not be used after any of the indexed
+// types has been modified (for instance, by adding methods).
+type ImplementerIndex struct {
+	types   []*Named         // indexed types, in package and scope order
+	methods map[string][]int // method Id -> indices into types of types with that method
+}
+
+// NewImplementerIndex returns an index of the package-level named types
+// declared in pkgs, which must have been type-checked completely. Generic
+// types are not indexed since they implement interfaces only once they are
+// instantiated.
+func NewImplementerIndex(pkgs []*Package) *ImplementerIndex {
+	x := &ImplementerIndex{methods: make(map[string][]int)}
+	for _, pkg := range pkgs {
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			tname, _ := scope.Lookup(name).(*TypeName)
+			if tname == nil || tname.IsAlias() {
+				continue
+			}
+			named, _ := tname.Type().(*Named)
+			if named == nil || named.TypeParams().Len() > 0 {
+				continue
+			}
+			i := len(x.types)
+			x.types = append(x.types, named)
+
+			// The method set of *T includes all methods of T.
+			var mset *MethodSet
+			if IsInterface(named) {
+				mset = NewMethodSet(named)
+			} else {
+				mset = NewMethodSet(NewPointer(named))
+			}
+			for j := 0; j < mset.Len(); j++ {
+				id := mset.At(j).Obj().Id()
+				x.methods[id] = append(x.methods[id], i)
+			}
+		}
+	}
+	return x
+}
+
+// Implementers returns the indexed types that implement the interface T, in
+// the order of the packages passed to NewImplementerIndex and, within each
+// package, in the order of their names. For a type that implements T only if
+// used as a pointer, the respective Implementer has Ptr set. Interface types
+// implementing T are included as well.
+func (x *ImplementerIndex) Implementers(T *Interface) []Implementer {
+	// Only types that have the least common method of T need to be checked.
+	var candidates []int
+	if methods := T.typeSet().methods; len(methods) > 0 {
+		for i, m := range methods {
+			list := x.methods[m.Id()]
+			if i == 0 || len(list) < len(candidates) {
+				candidates = list
+			}
+			if len(candidates) == 0 {
+				return nil
+			}
+		}
+	} else {
+		candidates = make([]int, len(x.types))
+		for i := range candidates {
+			candidates[i] = i
+		}
+	}
+
+	var list []Implementer
+	for _, i := range candidates {
+		typ := x.types[i]
+		switch {
+		case Implements(typ, T):
+			list = append(list, Implementer{typ, false})
+		case !IsInterface(typ) && Implements(NewPointer(typ), T):
+			list = append(list, Implementer{typ, true})
+		}
+	}
+	return list
+}
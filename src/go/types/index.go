@@ -423,6 +423,8 @@ func (check *Checker) indexedElts(elts []ast.Expr, typ Type, length int64) int64
 		if validIndex {
 			if visited[index] {
 				check.errorf(e, _DuplicateLitKey, "duplicate index %d in array or slice literal", index)
+			} else {
+				check.recordLitElement(e, LitElement{Index: index})
 			}
 			visited[index] = true
 		}
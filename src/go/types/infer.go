@@ -330,6 +330,9 @@ func (w *tpWalker) isParameterized(typ Type) (res bool) {
 	case *Chan:
 		return w.isParameterized(t.elem)
 
+	case *Alias:
+		return w.isParameterized(t.actual)
+
 	case *Named:
 		return w.isParameterizedTypeList(t.targs.list())
 
@@ -556,6 +559,9 @@ func (w *cycleFinder) typ(typ Type) {
 	case *Chan:
 		w.typ(t.elem)
 
+	case *Alias:
+		w.typ(t.actual)
+
 	case *Named:
 		for _, tpar := range t.TypeArgs().list() {
 			w.typ(tpar)
@@ -14,6 +14,7 @@ func (check *Checker) initOrder() {
 	// An InitOrder may already have been computed if a package is
 	// built from several calls to (*Checker).Files. Clear it.
 	check.Info.InitOrder = check.Info.InitOrder[:0]
+	check.Info.InitCycles = check.Info.InitCycles[:0]
 
 	// Compute the object dependency graph and initialize
 	// a priority queue with the list of graph nodes.
@@ -148,9 +149,18 @@ func findPath(objMap map[Object]*declInfo, from, to Object, seen map[Object]bool
 	return nil
 }
 
-// reportCycle reports an error for the given cycle.
+// reportCycle reports an error for the given cycle and records it in
+// Info.InitCycles.
 func (check *Checker) reportCycle(cycle []Object) {
 	obj := cycle[0]
+
+	// cycle[0] refers to cycle[n-1], which refers to cycle[n-2], etc.
+	list := []Object{obj}
+	for i := len(cycle) - 1; i > 0; i-- {
+		list = append(list, cycle[i])
+	}
+	check.Info.InitCycles = append(check.Info.InitCycles, list)
+
 	check.errorf(obj, _InvalidInitCycle, "initialization cycle for %s", obj.Name())
 	// subtle loop: print cycle[i] for i = 0, n-1, n-2, ... 1 for len(cycle) = n
 	for i := len(cycle) - 1; i >= 0; i-- {
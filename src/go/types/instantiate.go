@@ -31,6 +31,9 @@ import (
 // TODO(rfindley): change this function to also return an error if lengths of
 // tparams and targs do not match.
 func Instantiate(env *Environment, typ Type, targs []Type, validate bool) (Type, error) {
+	if sig, _ := typ.(*Signature); sig != nil {
+		targs = packTArgs(sig.TypeParams().list(), targs)
+	}
 	inst := (*Checker)(nil).instance(token.NoPos, typ, targs, env)
 
 	var err error
@@ -87,13 +90,26 @@ func (check *Checker) instantiate(pos token.Pos, typ Type, targs []Type, posList
 		// Avoid duplicate errors; instantiate will have complained if tparams
 		// and targs do not have the same length.
 		if len(tparams) == len(targs) {
+			defer check.pushInst(typ, NewTypeList(targs), pos)()
 			if i, err := check.verify(pos, tparams, targs); err != nil {
 				// best position for error reporting
 				pos := pos
 				if i < len(posList) {
 					pos = posList[i]
 				}
-				check.softErrorf(atPos(pos), _Todo, err.Error())
+				code := _Todo
+				var cerr *codedError
+				if errors.As(err, &cerr) {
+					code = cerr.code
+				}
+				check.softErrorf(atPos(pos), code, err.Error())
+				var chain *chainError
+				if errors.As(err, &chain) {
+					for _, step := range chain.steps {
+						check.softErrorf(atPos(pos), code, "\t%s", step) // secondary error, \t indented
+					}
+					check.softErrorf(atPos(pos), code, "\t%s", chain.inner)
+				}
 			}
 		}
 	})
@@ -134,6 +150,13 @@ func (check *Checker) instance(pos token.Pos, typ Type, targs []Type, env *Envir
 		if tparams.Len() == 0 {
 			return typ // nothing to do (minor optimization)
 		}
+		var key funcKey
+		if env != nil {
+			key = funcKey{t.tparams, t.recv, t.params, t.results, env.targsHash(targs)}
+			if sig, _ := env.funcs.Load(key); sig != nil {
+				return sig.(*Signature)
+			}
+		}
 		sig := check.subst(pos, typ, makeSubstMap(tparams.list(), targs), env).(*Signature)
 		// If the signature doesn't use its type parameters, subst
 		// will not make a copy. In that case, make a copy now (so
@@ -145,6 +168,9 @@ func (check *Checker) instance(pos token.Pos, typ Type, targs []Type, env *Envir
 		// After instantiating a generic signature, it is not generic
 		// anymore; we need to set tparams to nil.
 		sig.tparams = nil
+		if env != nil {
+			sig = env.loadOrStore(&env.funcs, key, sig).(*Signature)
+		}
 		return sig
 	}
 	// only types and functions can be generic
@@ -166,9 +192,40 @@ func (check *Checker) validateTArgLen(pos token.Pos, ntparams, ntargs int) bool
 	return true
 }
 
+// recoveryTArgs adjusts targs to the number of type parameters in tparams
+// after a reported type argument count mismatch, so that type checking can
+// continue with an instance: extra type arguments are dropped, and each
+// missing type argument is replaced with the structural type of its
+// constraint if there is one that doesn't depend on type parameters, and
+// with Typ[Invalid] otherwise. Errors involving the latter are suppressed
+// as follow-on errors.
+func recoveryTArgs(tparams []*TypeParam, targs []Type) []Type {
+	if len(targs) >= len(tparams) {
+		return targs[:len(tparams)]
+	}
+	res := make([]Type, len(tparams))
+	copy(res, targs)
+	for i := len(targs); i < len(tparams); i++ {
+		res[i] = Typ[Invalid]
+		if t := tparams[i].structuralType(); t != nil && !isParameterized(tparams, t) {
+			res[i] = t
+		}
+	}
+	return res
+}
+
 func (check *Checker) verify(pos token.Pos, tparams []*TypeParam, targs []Type) (int, error) {
 	smap := makeSubstMap(tparams, targs)
 	for i, tpar := range tparams {
+		// each type argument for a variadic type parameter must satisfy its constraint
+		if pack, _ := targs[i].(*Tuple); pack != nil && tpar.variadic {
+			for _, v := range pack.vars {
+				if err := check.satisfies(pos, v.typ, tpar, smap); err != nil {
+					return i, err
+				}
+			}
+			continue
+		}
 		// stop checking bounds after the first failure
 		if err := check.satisfies(pos, targs[i], tpar, smap); err != nil {
 			return i, err
@@ -177,6 +234,31 @@ func (check *Checker) verify(pos token.Pos, tparams []*TypeParam, targs []Type)
 	return -1, nil
 }
 
+// packTArgs returns the type arguments targs for the type parameters tparams.
+// If the last type parameter is variadic, the type arguments for it (all type
+// arguments beyond the other type parameters) are packed into a *Tuple. If
+// targs is already packed, or if there is no variadic type parameter or not
+// enough type arguments, the result is targs.
+func packTArgs(tparams []*TypeParam, targs []Type) []Type {
+	n := len(tparams)
+	if n == 0 || !tparams[n-1].variadic || len(targs) < n-1 {
+		return targs
+	}
+	if len(targs) == n {
+		if _, ok := targs[n-1].(*Tuple); ok {
+			return targs // already packed
+		}
+	}
+	vars := make([]*Var, len(targs)-(n-1))
+	for i, targ := range targs[n-1:] {
+		vars[i] = NewParam(token.NoPos, nil, "", targ)
+	}
+	res := make([]Type, n)
+	copy(res, targs[:n-1])
+	res[n-1] = &Tuple{vars: vars}
+	return res
+}
+
 // satisfies reports whether the type argument targ satisfies the constraint of type parameter
 // parameter tpar (after any of its type parameters have been substituted through smap).
 // A suitable error is reported if the result is false.
@@ -187,6 +269,95 @@ func (check *Checker) satisfies(pos token.Pos, targ Type, tpar *TypeParam, smap
 		return nil // no type bound
 	}
 
+	// The type parameter bound is parameterized with the same type parameters
+	// as the instantiated type; before we can use it for bounds checking we
+	// need to instantiate it with the type arguments with which we instantiate
+	// the parameterized type.
+	iface = check.subst(pos, iface, smap, nil).(*Interface)
+
+	err := check.satisfiesIface(targ, tpar.bound, iface, check.verbose())
+	if err == nil {
+		// With Config.ForwardCompatible, satisfiesIface accepts type
+		// arguments that are not strictly comparable; report them here
+		// where the position is known.
+		if check != nil && check.conf.ForwardCompatible && iface.IsComparable() && !check.allowVersion(check.pkg, 1, 20) && !strictlyComparable(targ, nil) {
+			check.forwardCompat(atPos(pos), 1, 20, "satisfying comparable with %s, which is not strictly comparable,", targ)
+		}
+		return nil
+	}
+
+	// If the constraint failed because of a constraint it embeds, report
+	// the chain of embedded constraints down to the one that failed.
+	var qf Qualifier
+	if check != nil {
+		qf = check.qualifier
+	}
+	var chain []string
+	top := check.subst(pos, tpar.bound, smap, nil)
+	bound := top
+	for {
+		e, eiface := check.failingEmbedded(targ, iface)
+		if e == nil {
+			break
+		}
+		chain = append(chain, sprintf(nil, qf, "%s embeds %s", bound, e))
+		bound, iface = e, eiface
+	}
+	if len(chain) == 0 {
+		return err
+	}
+	inner := check.satisfiesIface(targ, bound, iface, true)
+	return &chainError{sprintf(nil, qf, "%s does not satisfy %s", targ, top), chain, inner}
+}
+
+// A chainError is a constraint satisfaction error caused by a constraint
+// embedded in the constraint, possibly several levels deep. Its message
+// only names the constraint; Checker.instantiate reports the embedding
+// steps and the innermost failure as secondary errors.
+type chainError struct {
+	msg   string
+	steps []string // "C embeds D" for each embedding step
+	inner error    // failure of the innermost constraint
+}
+
+func (e *chainError) Error() string { return e.msg }
+func (e *chainError) Unwrap() error { return e.inner }
+
+// A codedError is a constraint satisfaction error with a more specific
+// error code than _Todo.
+type codedError struct {
+	code errorCode
+	msg  string
+}
+
+func (e *codedError) Error() string { return e.msg }
+
+// failingEmbedded returns the first named constraint embedded in iface,
+// and its underlying interface, that targ does not satisfy by itself. If
+// there is no such constraint, the result is nil, nil. Embedded interface
+// literals and type terms are not considered; they are reported as part
+// of iface.
+func (check *Checker) failingEmbedded(targ Type, iface *Interface) (Type, *Interface) {
+	for _, e := range iface.embeddeds {
+		if _, ok := e.(*Named); !ok {
+			continue
+		}
+		eiface := asInterface(e)
+		if eiface == nil || eiface.Empty() {
+			continue
+		}
+		if check.satisfiesIface(targ, e, eiface, false) != nil {
+			return e, eiface
+		}
+	}
+	return nil, nil
+}
+
+// satisfiesIface reports whether targ satisfies the (already substituted)
+// constraint interface iface of the constraint type bound. A suitable error
+// is returned if the result is false. If detailed is set, errors caused by
+// the type set of iface mention the respective type sets.
+func (check *Checker) satisfiesIface(targ, bound Type, iface *Interface, detailed bool) error {
 	// TODO(rfindley): it would be great if users could pass in a qualifier here,
 	// rather than falling back to verbose qualification. Maybe this can be part
 	// of a the shared environment.
@@ -198,12 +369,6 @@ func (check *Checker) satisfies(pos token.Pos, targ Type, tpar *TypeParam, smap
 		return errors.New(sprintf(nil, qf, format, args...))
 	}
 
-	// The type parameter bound is parameterized with the same type parameters
-	// as the instantiated type; before we can use it for bounds checking we
-	// need to instantiate it with the type arguments with which we instantiate
-	// the parameterized type.
-	iface = check.subst(pos, iface, smap, nil).(*Interface)
-
 	// if iface is comparable, targ must be comparable
 	// TODO(gri) the error messages needs to be better, here
 	if iface.IsComparable() && !Comparable(targ) {
@@ -213,6 +378,11 @@ func (check *Checker) satisfies(pos token.Pos, targ Type, tpar *TypeParam, smap
 		return errorf("%s does not satisfy comparable", targ)
 	}
 
+	// Before go1.20, targ must be strictly comparable.
+	if iface.IsComparable() && check != nil && !check.allowVersion(check.pkg, 1, 20) && !check.conf.ForwardCompatible && !strictlyComparable(targ, nil) {
+		return &codedError{_NotStrictlyComparable, sprintf(nil, qf, "%s does not satisfy comparable (%s is not strictly comparable; requires go1.20 or later)", targ, targ)}
+	}
+
 	// targ must implement iface (methods)
 	// - check only if we have methods
 	if iface.NumMethods() > 0 {
@@ -226,16 +396,26 @@ func (check *Checker) satisfies(pos token.Pos, targ Type, tpar *TypeParam, smap
 			// TODO(gri) needs to print updated name to avoid major confusion in error message!
 			//           (print warning for now)
 			// Old warning:
-			// check.softErrorf(pos, "%s does not satisfy %s (warning: name not updated) = %s (missing method %s)", targ, tpar.bound, iface, m)
+			// check.softErrorf(pos, "%s does not satisfy %s (warning: name not updated) = %s (missing method %s)", targ, bound, iface, m)
 			if wrong != nil {
 				// TODO(gri) This can still report uninstantiated types which makes the error message
 				//           more difficult to read then necessary.
 				// TODO(rFindley) should this use parentheses rather than ':' for qualification?
 				return errorf("%s does not satisfy %s: wrong method signature\n\tgot  %s\n\twant %s",
-					targ, tpar.bound, wrong, m,
+					targ, bound, wrong, m,
 				)
 			}
-			return errorf("%s does not satisfy %s (missing method %s)", targ, tpar.bound, m.name)
+			return errorf("%s does not satisfy %s (missing method %s)", targ, bound, m.name)
+		}
+		if check != nil {
+			check.recordSatisfies(targ, iface)
+		}
+	}
+
+	// targ must be in the type set of a term of each union with method terms
+	for _, u := range iface.typeSet().unions {
+		if !check.inUnion(targ, u) {
+			return errorf("%s does not satisfy %s (%s is not in %s)", targ, bound, targ, u)
 		}
 	}
 
@@ -249,11 +429,14 @@ func (check *Checker) satisfies(pos token.Pos, targ Type, tpar *TypeParam, smap
 	if targ := asTypeParam(targ); targ != nil {
 		targBound := targ.iface()
 		if !targBound.typeSet().hasTerms() {
-			return errorf("%s does not satisfy %s (%s has no type constraints)", targ, tpar.bound, targ)
+			return errorf("%s does not satisfy %s (%s has no type constraints)", targ, bound, targ)
 		}
 		if !targBound.typeSet().subsetOf(iface.typeSet()) {
 			// TODO(gri) need better error message
-			return errorf("%s does not satisfy %s", targ, tpar.bound)
+			if detailed {
+				return errorf("%s does not satisfy %s (type set %s is not a subset of %s)", targ, bound, targBound.typeSet(), iface.typeSet())
+			}
+			return errorf("%s does not satisfy %s", targ, bound)
 		}
 		return nil
 	}
@@ -261,8 +444,62 @@ func (check *Checker) satisfies(pos token.Pos, targ Type, tpar *TypeParam, smap
 	// Otherwise, targ's type or underlying type must also be one of the interface types listed, if any.
 	if !iface.typeSet().includes(targ) {
 		// TODO(gri) better error message
-		return errorf("%s does not satisfy %s", targ, tpar.bound)
+		if detailed {
+			return errorf("%s does not satisfy %s (%s not in type set %s)", targ, bound, targ, iface.typeSet())
+		}
+		return errorf("%s does not satisfy %s", targ, bound)
 	}
 
 	return nil
 }
+
+// inUnion reports whether t is in the type set of one of the terms of u.
+// If some terms of u are interfaces with methods (see Experiments.MethodUnions),
+// the type set of u is not fully described by its methods and terms, and
+// this is the only way to test membership.
+func (check *Checker) inUnion(t Type, u *Union) bool {
+	for _, x := range u.terms {
+		var s *_TypeSet
+		if ityp, _ := under(x.typ).(*Interface); ityp != nil {
+			s = ityp.typeSet()
+		} else {
+			s = &_TypeSet{terms: termlist{(*term)(x)}}
+		}
+		if check.inTypeSet(t, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// inTypeSet reports whether t is in the type set s. If t is a type parameter,
+// all types in its type set must be in s.
+func (check *Checker) inTypeSet(t Type, s *_TypeSet) bool {
+	if s.comparable && !Comparable(t) {
+		return false
+	}
+	if len(s.methods) > 0 {
+		if base, isPtr := deref(t); isPtr && asTypeParam(base) != nil {
+			return false
+		}
+		if m, _ := check.missingMethod(t, &Interface{complete: true, tset: s}, true); m != nil {
+			return false
+		}
+	}
+	if s.hasTerms() {
+		if tpar := asTypeParam(t); tpar != nil {
+			tset := tpar.iface().typeSet()
+			if !tset.hasTerms() || !tset.subsetOf(s) {
+				return false
+			}
+		} else if !s.includes(t) {
+			return false
+		}
+	}
+	for _, u := range s.unions {
+		if !check.inUnion(t, u) {
+			return false
+		}
+	}
+	return true
+}
@@ -5,6 +5,9 @@
 package types_test
 
 import (
+	"go/ast"
+	"go/parser"
+	"go/token"
 	. "go/types"
 	"testing"
 )
@@ -70,3 +73,160 @@ func TestInstantiateNonEquality(t *testing.T) {
 		t.Errorf("instance from pkg1 (%s) is identical to instance from pkg2 (%s)", res1, res2)
 	}
 }
+
+func TestInstantiateConcurrent(t *testing.T) {
+	const src = genericPkg + "p; type T[P any] struct{ f P; next *T[P] }; func (T[P]) m() P"
+
+	pkg, err := pkgFor(".", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	T := pkg.Scope().Lookup("T").Type().(*Named)
+	env := NewEnvironment()
+	inst, err := Instantiate(env, T, []Type{Typ[Int]}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Expanding the same lazy instance from several goroutines must be safe
+	// and must produce a single underlying type.
+	const n = 8
+	results := make(chan Type, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			results <- inst.Underlying()
+		}()
+	}
+	want := <-results
+	for i := 1; i < n; i++ {
+		if got := <-results; got != want {
+			t.Errorf("concurrent expansion produced distinct underlying types %s and %s", got, want)
+		}
+	}
+}
+
+func TestInternedTypes(t *testing.T) {
+	const src = `package p
+type T struct{ f []byte }
+var (
+	a []byte
+	b *int
+	c map[string]bool
+	d []T
+	e []uint8
+)
+`
+	env := NewEnvironment()
+	lookup := func(pkg *Package, name string) Type {
+		return pkg.Scope().Lookup(name).Type()
+	}
+	var pkgs [2]*Package
+	for i := range pkgs {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf := Config{Environment: env}
+		pkgs[i], err = conf.Check("p", fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Types with predeclared components are shared, even across packages.
+	for _, name := range []string{"a", "b", "c", "e"} {
+		if x, y := lookup(pkgs[0], name), lookup(pkgs[1], name); x != y {
+			t.Errorf("%s: types %s and %s are not shared", name, x, y)
+		}
+	}
+	if x := lookup(pkgs[0], "T").Underlying().(*Struct).Field(0).Type(); x != lookup(pkgs[0], "a") {
+		t.Errorf("field type %s is not shared with variable type", x)
+	}
+
+	// []T is not shared across packages, and byte and uint8 are spelled differently.
+	if x, y := lookup(pkgs[0], "d"), lookup(pkgs[1], "d"); x == y {
+		t.Errorf("types %s and %s from different packages are shared", x, y)
+	}
+	if x, y := lookup(pkgs[0], "a"), lookup(pkgs[0], "e"); x == y || x.String() != "[]byte" {
+		t.Errorf("got types %s and %s, want distinct []byte and []uint8 types", x, y)
+	}
+}
+
+func TestInstantiatedMethodSharing(t *testing.T) {
+	const src = genericPkg + `p
+
+type T[P any] struct{}
+
+func (T[P]) m() int { return 0 }
+func (T[P]) n() P { var p P; return p }
+
+var x T[int]
+var _, _, _, _ = x.m, x.m, x.n, x.n
+`
+	info := &Info{Selections: make(map[*ast.SelectorExpr]*Selection)}
+	pkg, err := pkgFor(".", src, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	T := pkg.Scope().Lookup("T").Type().(*Named)
+
+	objs := make(map[string][]Object)
+	for e, sel := range info.Selections {
+		objs[e.Sel.Name] = append(objs[e.Sel.Name], sel.Obj())
+	}
+
+	// m does not depend on P, so both selections denote the declared method.
+	for _, obj := range objs["m"] {
+		if obj != T.Method(0) {
+			t.Errorf("x.m denotes %v, want the declared method", obj)
+		}
+	}
+
+	// n depends on P: both selections share one instantiated method.
+	if n := objs["n"]; len(n) != 2 || n[0] != n[1] || n[0] == T.Method(1) {
+		t.Errorf("got objects %v for x.n, want a single shared instantiated method", n)
+	} else if got := n[0].Type().String(); got != "func() int" {
+		t.Errorf("x.n has type %s, want func() int", got)
+	}
+}
+
+func TestInstanceMethods(t *testing.T) {
+	const src = genericPkg + `p
+
+type T[P any] struct{}
+
+func (T[P]) m(P) {}
+func (T[P]) n()  {}
+
+type I interface{ m(int) }
+`
+	pkg, err := pkgFor(".", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	T := pkg.Scope().Lookup("T").Type().(*Named)
+	I := pkg.Scope().Lookup("I").Type().Underlying().(*Interface)
+
+	inst, err := Instantiate(nil, T, []Type{Typ[Int]}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Method lookups on the instance use lazily instantiated signatures.
+	for i := 0; i < 2; i++ {
+		if m, _ := MissingMethod(inst, I, true); m != nil {
+			t.Fatalf("%s does not implement %s: missing method %s", inst, I, m.Name())
+		}
+	}
+
+	// Adding a method to the instance must not affect the generic type.
+	inst.(*Named).AddMethod(NewFunc(token.NoPos, pkg, "o", NewSignature(nil, nil, nil, false)))
+	if got := inst.(*Named).NumMethods(); got != 3 {
+		t.Errorf("instance has %d methods, want 3", got)
+	}
+	if got := T.NumMethods(); got != 2 {
+		t.Errorf("generic type has %d methods, want 2", got)
+	}
+}
@@ -21,7 +21,8 @@ type Interface struct {
 	embedPos  *[]token.Pos // positions of embedded elements; or nil (for error messages) - use pointer to save space
 	complete  bool         // indicates that obj, methods, and embeddeds are set and type set can be computed
 
-	tset *_TypeSet // type set described by this interface, computed lazily
+	tset  *_TypeSet  // type set described by this interface, computed lazily
+	canon *Interface // canonical identical interface of the checked package, or nil (see Checker.internLiteral)
 }
 
 // typeSet returns the type set for interface t.
@@ -107,6 +108,29 @@ func (t *Interface) IsComparable() bool { return t.typeSet().IsComparable() }
 // IsConstraint reports whether interface t is not just a method set.
 func (t *Interface) IsConstraint() bool { return t.typeSet().IsConstraint() }
 
+// IsEmptyTypeSet reports whether the type set of interface t is empty, so
+// that no type satisfies t: its type terms contradict each other, or none
+// of them can be comparable or have the methods of t, as for
+// interface{ []int; comparable } or interface{ int; String() string }.
+func (t *Interface) IsEmptyTypeSet() bool { return !t.typeSet().satisfiable() }
+
+// MethodInterface returns the method set of interface t as an interface:
+// the interface with all methods of t's type set and without type terms.
+// For a constraint, these are the methods that may be called on values of
+// a type parameter constrained by t within the generic function or type;
+// whether the type parameter supports operators such as == is not part of
+// the result. If t is not a constraint (see IsConstraint), the result
+// is t itself.
+func (t *Interface) MethodInterface() *Interface {
+	tset := t.typeSet()
+	if !tset.IsConstraint() {
+		return t
+	}
+	methods := make([]*Func, len(tset.methods))
+	copy(methods, tset.methods)
+	return NewInterfaceType(methods, nil).Complete()
+}
+
 // Complete computes the interface's type set. It must be called by users of
 // NewInterfaceType and NewInterface after the interface's embedded types are
 // fully defined and before using the interface type in any way other than to
@@ -122,6 +146,93 @@ func (t *Interface) Complete() *Interface {
 	return t
 }
 
+// An InterfaceMethod describes a method of an interface and the embedded
+// elements through which the interface obtained it.
+type InterfaceMethod struct {
+	Func *Func
+	Via  []Type // embedded elements, outermost first; empty for explicitly declared methods
+}
+
+// Depth returns the embedding depth of m, which is 0 for explicitly
+// declared methods.
+func (m InterfaceMethod) Depth() int { return len(m.Via) }
+
+// InterfaceMethods returns all methods of the interface t, ordered by their
+// unique Id like t.Method, together with the chain of embedded interfaces
+// (or other embedded elements, such as unions) that contributed them. If a
+// method is contributed by several embedded elements, the chain of least
+// depth (and among those, the first in source order) is reported.
+func InterfaceMethods(t *Interface) []InterfaceMethod {
+	methods := t.typeSet().methods
+	if len(methods) == 0 {
+		return nil
+	}
+
+	via := make(map[string][]Type, len(methods))
+
+	// Embedded elements are visited in breadth-first order.
+	type entry struct {
+		methods   []*Func
+		embeddeds []Type
+		path      []Type
+	}
+	current := []entry{{t.methods, t.embeddeds, nil}}
+	seen := make(map[*Interface]bool)
+	for len(current) > 0 && len(via) < len(methods) {
+		var next []entry
+		for _, e := range current {
+			for _, m := range e.methods {
+				if _, found := via[m.Id()]; !found {
+					via[m.Id()] = e.path
+				}
+			}
+			for _, typ := range e.embeddeds {
+				path := append(e.path[:len(e.path):len(e.path)], typ)
+				switch u := under(typ).(type) {
+				case *Interface:
+					if !seen[u] {
+						seen[u] = true
+						next = append(next, entry{u.methods, u.embeddeds, path})
+					}
+				case *Union:
+					next = append(next, entry{computeUnionTypeSet(nil, token.NoPos, u).methods, nil, path})
+				}
+			}
+		}
+		current = next
+	}
+
+	list := make([]InterfaceMethod, len(methods))
+	for i, m := range methods {
+		list[i] = InterfaceMethod{m, via[m.Id()]}
+	}
+	return list
+}
+
+// DiffInterfaces compares the methods of the interfaces a and b. It returns
+// the methods of a that b doesn't have, the methods of b that a doesn't
+// have, and the pairs of methods with the same name (the method of a first)
+// whose signatures are not identical. Each list is ordered by method Id.
+func DiffInterfaces(a, b *Interface) (onlyA, onlyB []*Func, mismatched [][2]*Func) {
+	ma, mb := a.typeSet().methods, b.typeSet().methods
+	for len(ma) > 0 || len(mb) > 0 {
+		switch {
+		case len(mb) == 0 || len(ma) > 0 && ma[0].Id() < mb[0].Id():
+			onlyA = append(onlyA, ma[0])
+			ma = ma[1:]
+		case len(ma) == 0 || mb[0].Id() < ma[0].Id():
+			onlyB = append(onlyB, mb[0])
+			mb = mb[1:]
+		default:
+			if !Identical(ma[0].typ, mb[0].typ) {
+				mismatched = append(mismatched, [2]*Func{ma[0], mb[0]})
+			}
+			ma, mb = ma[1:], mb[1:]
+		}
+	}
+	return
+}
+
 func (t *Interface) Underlying() Type { return t }
 func (t *Interface) String() string   { return TypeString(t, nil) }
 
@@ -193,7 +304,7 @@ func (check *Checker) interfaceType(ityp *Interface, iface *ast.InterfaceType, d
 		// Always type-check method type parameters but complain if they are not enabled.
 		// (This extra check is needed here because interface method signatures don't have
 		// a receiver specification.)
-		if sig.tparams != nil {
+		if sig.tparams != nil && !check.conf.Experiments.GenericMethods {
 			var at positioner = f.Type
 			if ftyp, _ := f.Type.(*ast.FuncType); ftyp != nil && ftyp.TypeParams != nil {
 				at = ftyp.TypeParams
@@ -36,12 +36,12 @@ func (check *Checker) labels(body *ast.BlockStmt) {
 	}
 
 	// spec: "It is illegal to define a label that is never used."
-	for name, obj := range all.elems {
+	all.forEach(func(name string, obj Object) {
 		obj = resolve(name, obj)
 		if lbl := obj.(*Label); !lbl.used {
 			check.softErrorf(lbl, _UnusedLabel, "label %s declared but not used", lbl.name)
 		}
-	}
+	})
 }
 
 // A block tracks label declarations in a block and its enclosing blocks.
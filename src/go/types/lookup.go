@@ -6,7 +6,10 @@
 
 package types
 
-import "go/token"
+import (
+	"go/token"
+	"sort"
+)
 
 // Internal use of LookupFieldOrMethod: If the obj result is a method
 // associated with a concrete (non-interface) type, the method's signature
@@ -78,7 +81,7 @@ func lookupFieldOrMethod(T Type, addressable bool, pkg *Package, name string) (o
 	// *typ where typ is an interface or type parameter has no methods.
 	if isPtr {
 		// don't look at under(typ) here - was bug (issue #47747)
-		if _, ok := typ.(*TypeParam); ok {
+		if _, ok := Unalias(typ).(*TypeParam); ok {
 			return
 		}
 		if _, ok := under(typ).(*Interface); ok {
@@ -105,7 +108,7 @@ func lookupFieldOrMethod(T Type, addressable bool, pkg *Package, name string) (o
 		// look for (pkg, name) in all types at current depth
 		var tpar *TypeParam // set if obj receiver is a type parameter
 		for _, e := range current {
-			typ := e.typ
+			typ := Unalias(e.typ)
 
 			// If we have a named type, we may have associated methods.
 			// Look for those first.
@@ -228,6 +231,222 @@ func lookupFieldOrMethod(T Type, addressable bool, pkg *Package, name string) (o
 	return nil, nil, false // not found
 }
 
+// A LookupCandidate describes a field or method found by LookupCandidates.
+type LookupCandidate struct {
+	Obj      Object // *Var or *Func
+	Index    []int  // index sequence, as for LookupFieldOrMethod
+	Path     []*Var // embedded fields traversed to get to Obj, starting at depth 0
+	Indirect bool   // set if there are pointer indirections on the path to Obj
+}
+
+// Depth returns the embedding depth of c, which is the number of embedded
+// fields traversed to get to c.Obj.
+func (c LookupCandidate) Depth() int { return len(c.Path) }
+
+// LookupCandidates looks up a field or method with given package and name
+// in T like LookupFieldOrMethod, but it returns all entries with that name
+// at the shallowest embedding depth at which the name appears, each with
+// the path of embedded fields leading to it. If the result has more than
+// one entry, the selector is ambiguous; the same field or method may then
+// be reached via different paths. The result is nil if there is no such
+// entry.
+//
+// Unlike LookupFieldOrMethod, LookupCandidates does not check whether a
+// method with a pointer receiver may be called on a value of type T.
+func LookupCandidates(T Type, pkg *Package, name string) []LookupCandidate {
+	// As for LookupFieldOrMethod, methods of named pointer types
+	// are never found (see the comment there).
+	if t := asNamed(T); t != nil {
+		if p, _ := safeUnderlying(t).(*Pointer); p != nil {
+			var fields []LookupCandidate
+			for _, c := range lookupCandidates(p, pkg, name) {
+				if _, ok := c.Obj.(*Func); !ok {
+					fields = append(fields, c)
+				}
+			}
+			return fields
+		}
+	}
+
+	return lookupCandidates(T, pkg, name)
+}
+
+// A Selector describes a field or method name of a struct type, and all
+// the fields and methods with that name reachable by promotion.
+type Selector struct {
+	Name string
+
+	// Candidates are the entries with the name at the shallowest
+	// embedding depth at which the name appears. If there is more than
+	// one, the name is ambiguous and cannot be selected.
+	Candidates []LookupCandidate
+
+	// Shadowed are the entries with the name at greater depths, which
+	// are hidden by the candidates.
+	Shadowed []LookupCandidate
+}
+
+// Ambiguous reports whether s cannot be selected because there is more
+// than one candidate.
+func (s *Selector) Ambiguous() bool { return len(s.Candidates) > 1 }
+
+// Selectors returns all field and method names of T, and of the types
+// embedded in T, ordered by their unique Id, together with the entries
+// they refer to, as found by LookupCandidates. Methods with pointer
+// receivers are included; the blank identifier is not. Tools may use the
+// result to predict which selectors break (become ambiguous or refer to a
+// different entry) when embedded fields are added to or removed from T.
+func Selectors(T Type) []*Selector {
+	// As for LookupFieldOrMethod, methods of named pointer types
+	// are never found (see the comment there).
+	noMethods := false
+	if t := asNamed(T); t != nil {
+		if p, _ := safeUnderlying(t).(*Pointer); p != nil {
+			T = p
+			noMethods = true
+		}
+	}
+
+	byId := make(map[string]*Selector)
+	walkSelectors(T, func(c LookupCandidate) {
+		if _, ok := c.Obj.(*Func); ok && noMethods || c.Obj.Name() == "_" {
+			return
+		}
+		id := c.Obj.Id()
+		s := byId[id]
+		if s == nil {
+			s = &Selector{Name: c.Obj.Name()}
+			byId[id] = s
+		}
+		if len(s.Candidates) == 0 || s.Candidates[0].Depth() == c.Depth() {
+			s.Candidates = append(s.Candidates, c)
+		} else {
+			s.Shadowed = append(s.Shadowed, c)
+		}
+	}, nil)
+
+	ids := make([]string, 0, len(byId))
+	for id := range byId {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	list := make([]*Selector, len(ids))
+	for i, id := range ids {
+		list[i] = byId[id]
+	}
+	return list
+}
+
+// lookupCandidates implements LookupCandidates.
+func lookupCandidates(T Type, pkg *Package, name string) []LookupCandidate {
+	if name == "_" {
+		return nil // blank fields/methods are never found
+	}
+	var found []LookupCandidate
+	walkSelectors(T, func(c LookupCandidate) {
+		if c.Obj.sameId(pkg, name) {
+			found = append(found, c)
+		}
+	}, func() bool { return found != nil })
+	return found
+}
+
+// walkSelectors calls visit for each field and method of T and of the
+// types embedded in T, in order of increasing embedding depth, and stops
+// after the current depth if done is not nil and returns true. It follows the structure
+// of lookupFieldOrMethod but keeps multiple occurrences of a type at the
+// same depth apart since their paths are reported separately. Named types
+// seen at a lesser depth are not visited again since all their fields
+// and methods are shadowed.
+func walkSelectors(T Type, visit func(c LookupCandidate), done func() bool) {
+	typ, isPtr := deref(T)
+
+	// *typ where typ is an interface or type parameter has no methods.
+	if isPtr {
+		if _, ok := Unalias(typ).(*TypeParam); ok {
+			return
+		}
+		if _, ok := under(typ).(*Interface); ok {
+			return
+		}
+	}
+
+	type entry struct {
+		typ      Type
+		index    []int
+		path     []*Var
+		indirect bool
+	}
+	current := []entry{{typ, nil, nil, isPtr}}
+
+	// Named types seen at a shallower depth; they shadow any occurrence
+	// at the current depth.
+	var seen map[*Named]bool
+
+	for len(current) > 0 {
+		var next []entry
+		var named []*Named // named types at current depth
+
+		for _, e := range current {
+			typ := Unalias(e.typ)
+			add := func(i int, obj Object) {
+				visit(LookupCandidate{obj, concat(e.index, i), e.path, e.indirect})
+			}
+
+			if n := asNamed(typ); n != nil {
+				if seen[n] {
+					continue
+				}
+				named = append(named, n)
+
+				n.load()
+				for i, m := range n.methods {
+					add(i, m)
+				}
+
+				typ = n.under()
+				if asTypeParam(typ) != nil {
+					continue
+				}
+			}
+
+			switch t := typ.(type) {
+			case *Struct:
+				for i, f := range t.fields {
+					add(i, f)
+					if f.embedded {
+						typ, isPtr := deref(f.typ)
+						path := append(e.path[:len(e.path):len(e.path)], f)
+						next = append(next, entry{typ, concat(e.index, i), path, e.indirect || isPtr})
+					}
+				}
+
+			case *Interface:
+				for i, m := range t.typeSet().methods {
+					add(i, m)
+				}
+
+			case *TypeParam:
+				for i, m := range t.iface().typeSet().methods {
+					add(i, m)
+				}
+			}
+		}
+
+		if done != nil && done() {
+			return
+		}
+
+		if seen == nil {
+			seen = make(map[*Named]bool)
+		}
+		for _, n := range named {
+			seen[n] = true
+		}
+		current = next
+	}
+}
+
 // embeddedType represents an embedded type
 type embeddedType struct {
 	typ       Type
@@ -289,6 +508,65 @@ func MissingMethod(V Type, T *Interface, static bool) (method *Func, wrongType b
 	return m, typ != nil
 }
 
+// A MethodProblem describes a method required by an interface that a type
+// doesn't have, or has with the wrong type.
+type MethodProblem struct {
+	Method *Func      // the method required by the interface
+	Have   *Func      // the method of the type with the same name, or nil if missing
+	Type   *Signature // the type of Have for the type's type arguments, or nil
+	Ptr    bool       // Have is in the method set of the pointer to the type only
+}
+
+// MissingMethods is like MissingMethod but reports all problems instead of
+// just the first one, in the order of the method set of T. The result is
+// nil if V implements T. For a method of V which is only in the method set
+// of *V (because it has a pointer receiver), the reported problem has Ptr
+// set, whether or not its type matches.
+func MissingMethods(V Type, T *Interface, static bool) []MethodProblem {
+	var list []MethodProblem
+	(*Checker)(nil).methodProblems(V, T, static, func(m, f *Func, ftyp *Signature, ptr bool) bool {
+		list = append(list, MethodProblem{m, f, ftyp, ptr})
+		return true
+	})
+	return list
+}
+
+// MissingMethod is like the function MissingMethod but memoizes its
+// results in env, so that repeated queries for the same V, T, and static
+// arguments are cheap. Types are matched by identity, not by structure.
+// The types involved must not be modified after they were first passed
+// to env.MissingMethod.
+func (env *Environment) MissingMethod(V Type, T *Interface, static bool) (method *Func, wrongType bool) {
+	key := missingKey{V, T, static}
+	if r, ok := env.missing.Load(key); ok {
+		r := r.(missingResult)
+		return r.method, r.wrongType
+	}
+	method, wrongType = MissingMethod(V, T, static)
+	env.loadOrStore(&env.missing, key, missingResult{method, wrongType})
+	return
+}
+
+// Implements is like the function Implements but memoizes its results in
+// env, like Environment.MissingMethod.
+func (env *Environment) Implements(V Type, T *Interface) bool {
+	f, _ := env.MissingMethod(V, T, true)
+	return f == nil
+}
+
+// A missingKey is the key for a memoized MissingMethod result.
+type missingKey struct {
+	V      Type
+	T      *Interface
+	static bool
+}
+
+// A missingResult is a memoized MissingMethod result.
+type missingResult struct {
+	method    *Func
+	wrongType bool
+}
+
 // missingMethod is like MissingMethod but accepts a *Checker as
 // receiver and an addressable flag.
 // The receiver may be nil if missingMethod is invoked through
@@ -299,6 +577,21 @@ func MissingMethod(V Type, T *Interface, static bool) (method *Func, wrongType b
 // To improve error messages, also report the wrong signature
 // when the method exists on *V instead of V.
 func (check *Checker) missingMethod(V Type, T *Interface, static bool) (method, wrongType *Func) {
+	check.methodProblems(V, T, static, func(m, f *Func, _ *Signature, _ bool) bool {
+		method, wrongType = m, f
+		return false
+	})
+	return
+}
+
+// methodProblems calls report for each method m of T (in the order of T's
+// method set) that V is missing, or that V has with the wrong type, until
+// report returns false. The static argument is as for missingMethod. The
+// arguments passed to report are m, the method f of V with the same name
+// (or nil if there is none), the type of f with the type arguments of V
+// substituted for the receiver type parameters (or nil if f is nil), and
+// whether f was found in the method set of *V rather than V.
+func (check *Checker) methodProblems(V Type, T *Interface, static bool, report func(m, f *Func, ftyp *Signature, ptr bool) bool) {
 	// fast path for common case
 	if T.Empty() {
 		return
@@ -310,31 +603,17 @@ func (check *Checker) missingMethod(V Type, T *Interface, static bool) (method,
 			_, f := ityp.typeSet().LookupMethod(m.pkg, m.name)
 
 			if f == nil {
-				if !static {
-					continue
+				if static && !report(m, nil, nil, false) {
+					return
 				}
-				return m, f
+				continue
 			}
 
 			// both methods must have the same number of type parameters
 			ftyp := f.typ.(*Signature)
 			mtyp := m.typ.(*Signature)
-			if ftyp.TypeParams().Len() != mtyp.TypeParams().Len() {
-				return m, f
-			}
-			if ftyp.TypeParams().Len() > 0 {
-				panic("method with type parameters")
-			}
-
-			// If the methods have type parameters we don't care whether they
-			// are the same or not, as long as they match up. Use unification
-			// to see if they can be made to match.
-			// TODO(gri) is this always correct? what about type bounds?
-			// (Alternative is to rename/subst type parameters and compare.)
-			u := newUnifier(true)
-			u.x.init(ftyp.TypeParams().list())
-			if !u.unify(ftyp, mtyp) {
-				return m, f
+			if !ftypMatches(ftyp, mtyp, ftyp.TypeParams()) && !report(m, f, ftyp, false) {
+				return
 			}
 		}
 
@@ -353,14 +632,24 @@ func (check *Checker) missingMethod(V Type, T *Interface, static bool) (method,
 			ptr := NewPointer(V)
 			obj, _, _ = lookupFieldOrMethod(ptr, false, m.pkg, m.name)
 			if obj != nil {
-				return m, obj.(*Func)
+				f := obj.(*Func)
+				if check != nil {
+					check.objDecl(f, nil)
+				}
+				if !report(m, f, f.typ.(*Signature), true) {
+					return
+				}
+				continue
 			}
 		}
 
 		// we must have a method (not a field of matching function type)
 		f, _ := obj.(*Func)
 		if f == nil {
-			return m, nil
+			if !report(m, nil, nil, false) {
+				return
+			}
+			continue
 		}
 
 		// methods may not have a fully set up signature yet
@@ -372,12 +661,11 @@ func (check *Checker) missingMethod(V Type, T *Interface, static bool) (method,
 		ftyp := f.typ.(*Signature)
 		mtyp := m.typ.(*Signature)
 		if ftyp.TypeParams().Len() != mtyp.TypeParams().Len() {
-			return m, f
-		}
-		if ftyp.TypeParams().Len() > 0 {
-			panic("method with type parameters")
+			if !report(m, f, ftyp, false) {
+				return
+			}
+			continue
 		}
-
 		// If V is a (instantiated) generic type, its methods are still
 		// parameterized using the original (declaration) receiver type
 		// parameters (subst simply copies the existing method list, it
@@ -395,22 +683,41 @@ func (check *Checker) missingMethod(V Type, T *Interface, static bool) (method,
 			if len(ftyp.RecvTypeParams().list()) != Vn.targs.Len() {
 				return
 			}
-			ftyp = check.subst(token.NoPos, ftyp, makeSubstMap(ftyp.RecvTypeParams().list(), Vn.targs.list()), nil).(*Signature)
+			if i, g := lookupMethod(Vn.methods, f.pkg, f.name); g == f {
+				// f is declared for V; use the (cached) instantiated method
+				ftyp = Vn.instanceMethod(check, i).typ.(*Signature)
+			} else {
+				ftyp = check.subst(token.NoPos, ftyp, makeSubstMap(ftyp.RecvTypeParams().list(), Vn.targs.list()), nil).(*Signature)
+			}
 		}
 
-		// If the methods have type parameters we don't care whether they
-		// are the same or not, as long as they match up. Use unification
-		// to see if they can be made to match.
-		// TODO(gri) is this always correct? what about type bounds?
-		// (Alternative is to rename/subst type parameters and compare.)
-		u := newUnifier(true)
-		u.x.init(ftyp.RecvTypeParams().list())
-		if !u.unify(ftyp, mtyp) {
-			return m, f
+		if !ftypMatches(ftyp, mtyp, ftyp.RecvTypeParams()) && !report(m, f, ftyp, false) {
+			return
 		}
 	}
+}
 
-	return
+// ftypMatches reports whether the signature ftyp of a method matches the
+// signature mtyp of the corresponding interface method. The type parameters
+// tparams of ftyp may be unified with what they correspond to in mtyp.
+func ftypMatches(ftyp, mtyp *Signature, tparams *TypeParamList) bool {
+	// both methods must have the same number of type parameters
+	if ftyp.TypeParams().Len() != mtyp.TypeParams().Len() {
+		return false
+	}
+	if ftyp.TypeParams().Len() > 0 {
+		// generic methods (experiment) must have identical signatures
+		return Identical(ftyp, mtyp)
+	}
+
+	// If the methods have type parameters we don't care whether they
+	// are the same or not, as long as they match up. Use unification
+	// to see if they can be made to match.
+	// TODO(gri) is this always correct? what about type bounds?
+	// (Alternative is to rename/subst type parameters and compare.)
+	u := newUnifier(true)
+	u.x.init(tparams.list())
+	return u.unify(ftyp, mtyp)
 }
 
 // assertableTo reports whether a value of type V can be asserted to have type T.
@@ -427,13 +734,17 @@ func (check *Checker) assertableTo(V *Interface, T Type) (method, wrongType *Fun
 	if asInterface(T) != nil && !forceStrict {
 		return
 	}
-	return check.missingMethod(T, V, false)
+	method, wrongType = check.missingMethod(T, V, false)
+	if method == nil && check != nil {
+		check.recordSatisfies(T, V)
+	}
+	return
 }
 
 // deref dereferences typ if it is a *Pointer and returns its base and true.
 // Otherwise it returns (typ, false).
 func deref(typ Type) (Type, bool) {
-	if p, _ := typ.(*Pointer); p != nil {
+	if p, _ := Unalias(typ).(*Pointer); p != nil {
 		return p.base, true
 	}
 	return typ, false
@@ -62,6 +62,30 @@ func (s *MethodSet) Lookup(pkg *Package, name string) *Selection {
 // Shared empty method set.
 var emptyMethodSet MethodSet
 
+// MethodSet is like NewMethodSet but memoizes its results in env, so that
+// repeated queries for the same type are cheap. Types are matched by
+// identity, not by structure, except that pointers to defined types are
+// matched by their base types. The types involved must not be modified
+// after they were first passed to env.MethodSet.
+func (env *Environment) MethodSet(T Type) *MethodSet {
+	key := msetKey{T, false}
+	if p, _ := T.(*Pointer); p != nil {
+		if n, _ := p.base.(*Named); n != nil {
+			key = msetKey{n, true}
+		}
+	}
+	if mset, ok := env.msets.Load(key); ok {
+		return mset.(*MethodSet)
+	}
+	return env.loadOrStore(&env.msets, key, NewMethodSet(T)).(*MethodSet)
+}
+
+// A msetKey is the key for a memoized method set.
+type msetKey struct {
+	T   Type
+	ptr bool // set if the method set is the one of *T
+}
+
 // Note: NewMethodSet is intended for external use only as it
 //       requires interfaces to be complete. It may be used
 //       internally if LookupFieldOrMethod completed the same
@@ -107,7 +131,7 @@ func NewMethodSet(T Type) *MethodSet {
 		var mset methodSet
 
 		for _, e := range current {
-			typ := e.typ
+			typ := Unalias(e.typ)
 
 			// If we have a named type, we may have associated methods.
 			// Look for those first.
@@ -5,6 +5,7 @@
 package types_test
 
 import (
+	"strings"
 	"testing"
 
 	. "go/types"
@@ -107,3 +108,56 @@ func TestNewMethodSet(t *testing.T) {
 		check(src, methods, true)
 	}
 }
+
+func TestSelectionPath(t *testing.T) {
+	const src = `package p
+
+type T struct {
+	A
+	*B
+}
+
+type A struct{ *C }
+type B struct{}
+type C struct{}
+
+func (T) t()  {}
+func (*B) b() {}
+func (C) c()  {}
+
+var x *T
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := NewMethodSet(pkg.Scope().Lookup("x").Type())
+
+	// Each method is described by the path it was promoted through,
+	// with pointer fields marked by a '*'.
+	want := map[string]string{
+		"t": "",
+		"b": "*B",
+		"c": "A.*C",
+	}
+	if ms.Len() != len(want) {
+		t.Fatalf("got %d methods, want %d", ms.Len(), len(want))
+	}
+	for i := 0; i < ms.Len(); i++ {
+		sel := ms.At(i)
+		var names []string
+		for _, e := range sel.Path() {
+			name := e.Field.Name()
+			if e.Indirect {
+				name = "*" + name
+			}
+			names = append(names, name)
+		}
+		if got := strings.Join(names, "."); got != want[sel.Obj().Name()] {
+			t.Errorf("%s: got path %q, want %q", sel.Obj().Name(), got, want[sel.Obj().Name()])
+		}
+		if len(sel.Path()) != len(sel.Index())-1 {
+			t.Errorf("%s: path %v does not match index %v", sel.Obj().Name(), sel.Path(), sel.Index())
+		}
+	}
+}
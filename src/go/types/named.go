@@ -7,10 +7,12 @@ package types
 import (
 	"go/token"
 	"sync"
+	"sync/atomic"
 )
 
 // A Named represents a named (defined) type.
 type Named struct {
+	id         uint64 // atomic; unique ID used for type hashing, or 0 (first field for 64-bit alignment)
 	check      *Checker
 	info       typeInfo       // for cycle detection
 	obj        *TypeName      // corresponding declared object for declared types; placeholder for instantiated types
@@ -23,9 +25,21 @@ type Named struct {
 	methods    []*Func        // methods declared for this type (not the method set of this type); signatures are type-checked lazily
 
 	resolve func(*Named) ([]*TypeParam, Type, []*Func)
-	once    sync.Once
+
+	// Loading and lazy expansion are synchronized per Named, so that
+	// concurrent users of distinct types never contend with each other.
+	state uint32     // atomic; one of the named* states below
+	mu    sync.Mutex // guards loading and lazy expansion
 }
 
+// Named type states, in increasing order of completion. A state only ever
+// advances.
+const (
+	namedUnloaded uint32 = iota // tparams, methods, and (for lazy types) underlying are not yet set
+	namedLoaded                 // tparams and methods are set; instances may still be unexpanded
+	namedExpanded               // the underlying type is set
+)
+
 // NewNamed returns a new named type for the given type name, underlying type, and associated methods.
 // If the given type name obj doesn't have a type yet, its type is set to the returned named type.
 // The underlying type must not be a *Named.
@@ -37,23 +51,32 @@ func NewNamed(obj *TypeName, underlying Type, methods []*Func) *Named {
 }
 
 func (t *Named) load() *Named {
+	if atomic.LoadUint32(&t.state) >= namedLoaded {
+		return t
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if atomic.LoadUint32(&t.state) >= namedLoaded {
+		return t // lost a race to another goroutine
+	}
+
 	// If t is an instantiated type, it derives its methods and tparams from its
 	// base type. Since we expect type parameters and methods to be set after a
 	// call to load, we must load the base and copy here.
 	//
 	// underlying is set when t is expanded.
 	//
-	// By convention, a type instance is loaded iff its tparams are set.
-	if t.targs.Len() > 0 && t.tparams == nil {
+	// The method list is shared with the base type; its capacity is clipped
+	// so that adding methods to t copies the list rather than modifying
+	// the base type's list.
+	if t.targs.Len() > 0 {
 		t.orig.load()
 		t.tparams = t.orig.tparams
-		t.methods = t.orig.methods
-	}
-	if t.resolve == nil {
-		return t
+		t.methods = t.orig.methods[:len(t.orig.methods):len(t.orig.methods)]
 	}
 
-	t.once.Do(func() {
+	if t.resolve != nil {
 		// TODO(mdempsky): Since we're passing t to resolve anyway
 		// (necessary because types2 expects the receiver type for methods
 		// on defined interface types to be the Named rather than the
@@ -73,7 +96,10 @@ func (t *Named) load() *Named {
 		t.tparams = bindTParams(tparams)
 		t.underlying = underlying
 		t.methods = methods
-	})
+		t.resolve = nil // allow the resolver's state to be collected
+	}
+
+	atomic.StoreUint32(&t.state, namedLoaded)
 	return t
 }
 
@@ -154,6 +180,21 @@ func (t *Named) AddMethod(m *Func) {
 	}
 }
 
+// instanceMethod returns the i'th method of the instance t, with the receiver
+// type parameters in its signature substituted by the type arguments of t,
+// as for Checker.instantiateMethod. Methods are instantiated one at a time,
+// on first request, and then shared with the selections of the method.
+// The method's signature must have been type-checked already.
+func (t *Named) instanceMethod(check *Checker, i int) *Func {
+	t.load()
+	m := t.methods[i]
+	sig, _ := m.typ.(*Signature)
+	if t.targs.Len() == 0 || sig == nil || sig.RecvTypeParams().Len() != t.targs.Len() {
+		return m
+	}
+	return check.instantiateMethod(m.pos, m, t.targs.list())
+}
+
 func (t *Named) Underlying() Type { return t.load().expand(nil).underlying }
 func (t *Named) String() string   { return TypeString(t, nil) }
 
@@ -167,7 +208,7 @@ func (t *Named) String() string   { return TypeString(t, nil) }
 // is detected, the result is Typ[Invalid]. If a cycle is detected and
 // n0.check != nil, the cycle is reported.
 func (n0 *Named) under() Type {
-	u := n0.Underlying()
+	u := Unalias(n0.Underlying())
 
 	// If the underlying type of a defined type is not a defined
 	// (incl. instance) type, then that is the desired underlying
@@ -207,7 +248,7 @@ loop:
 			u = Typ[Invalid]
 			break
 		}
-		u = n.Underlying()
+		u = Unalias(n.Underlying())
 		switch u1 := u.(type) {
 		case nil:
 			u = Typ[Invalid]
@@ -243,11 +284,26 @@ func (n *Named) setUnderlying(typ Type) {
 // expand ensures that the underlying type of n is instantiated.
 // The underlying type will be Typ[Invalid] if there was an error.
 func (n *Named) expand(env *Environment) *Named {
+	if atomic.LoadUint32(&n.state) == namedExpanded {
+		return n
+	}
+
+	// n must be loaded before instantiation, in order to have accurate
+	// tparams. Loading acquires n.mu, so it must happen before we do.
+	n.load()
+
+	// Instances expanded lazily outside of a type-checking pass may be
+	// expanded concurrently, and need to be serialized. Within a pass, the
+	// Checker owns n and expansion may be reentrant, so we must not lock.
+	if n.check == nil {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if atomic.LoadUint32(&n.state) == namedExpanded {
+			return n // lost a race to another goroutine
+		}
+	}
+
 	if n.instPos != nil {
-		// n must be loaded before instantiation, in order to have accurate
-		// tparams. This is done implicitly by the call to n.TypeParams, but making
-		// it explicit is harmless: load is idempotent.
-		n.load()
 		var u Type
 		if n.check.validateTArgLen(*n.instPos, n.tparams.Len(), n.targs.Len()) {
 			// TODO(rfindley): handling an optional Checker and Environment here (and
@@ -268,7 +324,7 @@ func (n *Named) expand(env *Environment) *Named {
 				// shouldn't return that instance from expand.
 				env.typeForHash(h, n)
 			}
-			u = n.check.subst(*n.instPos, n.orig.underlying, makeSubstMap(n.TypeParams().list(), n.targs.list()), env)
+			u = n.check.subst(*n.instPos, n.orig.underlying, makeSubstMap(n.tparams.list(), n.targs.list()), env)
 		} else {
 			u = Typ[Invalid]
 		}
@@ -276,9 +332,26 @@ func (n *Named) expand(env *Environment) *Named {
 		n.fromRHS = u
 		n.instPos = nil
 	}
+
+	atomic.StoreUint32(&n.state, namedExpanded)
 	return n
 }
 
+// typeID returns a unique, non-zero ID for n, assigning one on first use.
+func (n *Named) typeID() uint64 {
+	if id := atomic.LoadUint64(&n.id); id != 0 {
+		return id
+	}
+	id := atomic.AddUint64(&lastNamedID, 1)
+	if !atomic.CompareAndSwapUint64(&n.id, 0, id) {
+		id = atomic.LoadUint64(&n.id) // another goroutine assigned an ID first
+	}
+	return id
+}
+
+// lastNamedID is the most recently assigned *Named type ID.
+var lastNamedID uint64
+
 // safeUnderlying returns the underlying of typ without expanding instances, to
 // avoid infinite recursion.
 //
@@ -0,0 +1,63 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+// The methods of an instance are instantiated on demand, and shared
+// with the selections of the method.
+func TestInstanceMethodsLazy(t *testing.T) {
+	const src = `
+package p
+
+type T[P any] struct{}
+
+func (T[P]) m(P) {}
+func (T[P]) n(P) {}
+
+type I interface{ m(int) }
+
+var x T[int]
+var _ I = x
+var _ = x.m
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := NewEnvironment()
+	conf := Config{Environment: env}
+	info := &Info{Selections: make(map[*ast.SelectorExpr]*Selection)}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	T := pkg.scope.Lookup("T").Type().(*Named)
+	hash := env.targsHash([]Type{Typ[Int]})
+	m, _, _ := LookupFieldOrMethod(T, false, pkg, "m")
+	n, _, _ := LookupFieldOrMethod(T, false, pkg, "n")
+	mInst := env.methods[methodKey{m.(*Func), hash}]
+	if mInst == nil {
+		t.Fatalf("method m of T[int] was not instantiated")
+	}
+	if nInst := env.methods[methodKey{n.(*Func), hash}]; nInst != nil {
+		t.Errorf("unused method n of T[int] was instantiated")
+	}
+	if len(info.Selections) != 1 {
+		t.Fatalf("got %d selections, want 1", len(info.Selections))
+	}
+	for _, sel := range info.Selections {
+		if sel.Obj() != mInst {
+			t.Errorf("selection x.m and the method set of T[int] use different objects for method m")
+		}
+	}
+}
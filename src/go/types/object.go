@@ -230,9 +230,21 @@ func NewTypeName(pos token.Pos, pkg *Package, name string, typ Type) *TypeName {
 	return &TypeName{object{nil, pos, pkg, name, typ, 0, colorFor(typ), token.NoPos}}
 }
 
-// _NewTypeNameLazy returns a new defined type like NewTypeName, but it
-// lazily calls resolve to finish constructing the Named object.
-func _NewTypeNameLazy(pos token.Pos, pkg *Package, name string, resolve func(named *Named) (tparams []*TypeParam, underlying Type, methods []*Func)) *TypeName {
+// NewTypeNameLazy returns a new type name denoting a defined type like
+// NewTypeName and NewNamed, but the type's type parameters, underlying
+// type, and methods are constructed lazily, by calling resolve with the
+// *Named type denoted by the returned TypeName. This permits importers to
+// defer the construction of types until they are used.
+//
+// The function resolve is called at most once, when any of these is first
+// needed, and may be called concurrently with other uses of the returned
+// TypeName or its type; its state is released after the call. The
+// underlying type it returns must not be nil or a *Named. The methods of
+// a defined interface type should have named as their receiver type.
+// Since named is locked during the call, resolve must not use any methods
+// of named other than Obj, and it must not (directly or indirectly) cause
+// named to be resolved.
+func NewTypeNameLazy(pos token.Pos, pkg *Package, name string, resolve func(named *Named) (tparams []*TypeParam, underlying Type, methods []*Func)) *TypeName {
 	obj := NewTypeName(pos, pkg, name, nil)
 	NewNamed(obj, nil, nil).resolve = resolve
 	return obj
@@ -348,11 +360,12 @@ func NewLabel(pos token.Pos, pkg *Package, name string) *Label {
 // Builtins don't have a valid type.
 type Builtin struct {
 	object
-	id builtinId
+	id     builtinId
+	custom *customBuiltin // for built-ins created with NewBuiltin, or nil
 }
 
 func newBuiltin(id builtinId) *Builtin {
-	return &Builtin{object{name: predeclaredFuncs[id].name, typ: Typ[Invalid], color_: black}, id}
+	return &Builtin{object{name: predeclaredFuncs[id].name, typ: Typ[Invalid], color_: black}, id, nil}
 }
 
 // Nil represents the predeclared value nil.
@@ -434,6 +447,9 @@ func writeObject(buf *bytes.Buffer, obj Object, qf Qualifier) {
 		}
 		if tname.IsAlias() {
 			buf.WriteString(" =")
+			if alias, _ := typ.(*Alias); alias != nil && alias.obj == tname {
+				typ = alias.fromRHS
+			}
 		} else {
 			typ = under(typ)
 		}
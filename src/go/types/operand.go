@@ -57,6 +57,8 @@ type operand struct {
 	typ  Type
 	val  constant.Value
 	id   builtinId
+
+	custom *customBuiltin // for custom built-ins, or nil
 }
 
 // Pos returns the position of the expression corresponding to x.
@@ -114,6 +116,9 @@ func operandString(x *operand, qf Qualifier) string {
 		switch x.mode {
 		case builtin:
 			expr = predeclaredFuncs[x.id].name
+			if x.custom != nil {
+				expr = x.custom.name
+			}
 		case typexpr:
 			expr = TypeString(x.typ, qf)
 		case constant_:
@@ -297,6 +302,7 @@ func (x *operand) assignableTo(check *Checker, T Type, reason *string) (bool, er
 			}
 			return false, _InvalidIfaceAssign
 		}
+		check.recordSatisfies(V, Ti)
 		return true, 0
 	}
 
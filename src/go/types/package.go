@@ -7,6 +7,7 @@ package types
 import (
 	"fmt"
 	"go/token"
+	"sort"
 )
 
 // A Package describes a Go package.
@@ -16,8 +17,10 @@ type Package struct {
 	scope    *Scope
 	complete bool
 	imports  []*Package
-	fake     bool // scope lookup errors are silently dropped if package is fake (internal use only)
-	cgo      bool // uses of this package will be rewritten into uses of declarations from _cgo_gotypes.go
+	fake     bool     // scope lookup errors are silently dropped if package is fake (internal use only)
+	cgo      bool     // uses of this package will be rewritten into uses of declarations from _cgo_gotypes.go
+	facts    factSet  // facts attached to the package and its objects
+	deps     *depInfo // for packages checked from source, or nil (see WalkDependencies)
 }
 
 // NewPackage returns a new Package for the given package path and name.
@@ -51,15 +54,132 @@ func (pkg *Package) MarkComplete() { pkg.complete = true }
 // Imports returns the list of packages directly imported by
 // pkg; the list is in source order.
 //
+// If pkg was type-checked, the order is the order in which the packages
+// are first imported by the files, in the order the files were given to
+// the type checker; each package appears once. It does not depend on the
+// order in which imports complete (see Config.ConcurrentImports).
+//
 // If pkg was loaded from export data, Imports includes packages that
 // provide package-level objects referenced by pkg. This may be more or
 // less than the set of packages directly imported by pkg's source code.
+// The importer for the gc compiler's export data (importer.For("gc", nil))
+// sorts this list by package path; packages imported from source (as with
+// importer.For("source", nil)) are type-checked, so their lists are in
+// source order.
 func (pkg *Package) Imports() []*Package { return pkg.imports }
 
 // SetImports sets the list of explicitly imported packages to list.
 // It is the caller's responsibility to make sure list elements are unique.
 func (pkg *Package) SetImports(list []*Package) { pkg.imports = list }
 
+// TransitiveImports returns the packages reachable from pkg through
+// Imports, excluding pkg itself, sorted by package path. If several
+// reachable packages have the same path, as may happen for packages
+// loaded from export data, the first one found in a depth-first
+// traversal of the import lists in their order is returned. The result
+// depends only on the import lists, so it is as stable as they are.
+func (pkg *Package) TransitiveImports() []*Package {
+	seen := map[string]bool{pkg.path: true}
+	var list []*Package
+	var visit func(p *Package)
+	visit = func(p *Package) {
+		for _, imp := range p.imports {
+			if !seen[imp.path] {
+				seen[imp.path] = true
+				list = append(list, imp)
+				visit(imp)
+			}
+		}
+	}
+	visit(pkg)
+	sort.Slice(list, func(i, j int) bool { return list[i].path < list[j].path })
+	return list
+}
+
+// An ObjectKind is a set of kinds of package-level objects.
+type ObjectKind uint
+
+const (
+	ConstObjects ObjectKind = 1 << iota // *Const
+	TypeObjects                         // *TypeName
+	VarObjects                          // *Var
+	FuncObjects                         // *Func
+
+	AllObjects = ConstObjects | TypeObjects | VarObjects | FuncObjects
+)
+
+// An ObjectFilter selects the objects returned by Package.Objects.
+type ObjectFilter struct {
+	// Kinds is the set of kinds of the selected objects;
+	// if it is 0, objects of all kinds are selected.
+	Kinds ObjectKind
+
+	// If ExportedOnly is set, only exported objects are selected.
+	ExportedOnly bool
+
+	// If Methods is set, the methods declared for the selected
+	// defined types are selected, too.
+	Methods bool
+}
+
+// Objects returns the package-level objects of pkg selected by filter,
+// ordered by position and, for equal positions, by name. For packages
+// checked from files added to a file set in order, as done by go/parser,
+// this is source order. Selecting exported objects only avoids loading the
+// unexported objects of imported packages; selecting objects of some kinds
+// only does not, as the kind of an object is only known once it is loaded.
+func (pkg *Package) Objects(filter ObjectFilter) []Object {
+	kinds := filter.Kinds
+	if kinds == 0 {
+		kinds = AllObjects
+	}
+
+	var list []Object
+	add := func(obj Object) {
+		if !filter.ExportedOnly || obj.Exported() {
+			list = append(list, obj)
+		}
+	}
+	var entries []scopeEntry
+	pkg.scope.forEach(func(name string, obj Object) {
+		if !filter.ExportedOnly || token.IsExported(name) { // don't resolve lazy objects needlessly
+			entries = append(entries, scopeEntry{name, obj})
+		}
+	})
+	for _, e := range entries {
+		obj := resolve(e.name, e.obj) // may import, so not within forEach
+		var kind ObjectKind
+		switch obj.(type) {
+		case *Const:
+			kind = ConstObjects
+		case *TypeName:
+			kind = TypeObjects
+		case *Var:
+			kind = VarObjects
+		case *Func:
+			kind = FuncObjects
+		}
+		if kinds&kind == 0 {
+			continue
+		}
+		add(obj)
+		if named, _ := obj.Type().(*Named); filter.Methods && named != nil && named.obj == obj {
+			for i := 0; i < named.NumMethods(); i++ {
+				add(named.Method(i))
+			}
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Pos() != b.Pos() {
+			return a.Pos() < b.Pos()
+		}
+		return a.Name() < b.Name()
+	})
+	return list
+}
+
 func (pkg *Package) String() string {
 	return fmt.Sprintf("package %s (%q)", pkg.name, pkg.path)
 }
@@ -0,0 +1,50 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the bounded parallelism of Config.Concurrency.
+
+package types
+
+import (
+	"runtime"
+	"sync"
+)
+
+// concurrency returns the maximum number of goroutines
+// to run at a time, as determined by conf.Concurrency.
+func (conf *Config) concurrency() int {
+	if conf.Concurrency > 0 {
+		return conf.Concurrency
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// parallel calls f(i) for each 0 <= i < n, using at most conf.concurrency()
+// goroutines at a time, and returns once all calls have returned. If only
+// one goroutine may be used, the calls are made in order by the calling
+// goroutine.
+func (conf *Config) parallel(n int, f func(i int)) {
+	limit := conf.concurrency()
+	if limit == 1 || n == 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -6,10 +6,12 @@
 
 package types
 
+import "go/token"
+
 // isNamed reports whether typ has a name.
 // isNamed may be called with types that are not fully set up.
 func isNamed(typ Type) bool {
-	switch typ.(type) {
+	switch Unalias(typ).(type) {
 	case *Basic, *Named, *TypeParam:
 		return true
 	}
@@ -20,7 +22,7 @@ func isNamed(typ Type) bool {
 // signatures are not included).
 func isGeneric(typ Type) bool {
 	// A parameterized type is only instantiated if it doesn't have an instantiation already.
-	named, _ := typ.(*Named)
+	named, _ := Unalias(typ).(*Named)
 	return named != nil && named.obj != nil && named.targs == nil && named.TypeParams() != nil
 }
 
@@ -115,6 +117,34 @@ func comparable(T Type, seen map[Type]bool) bool {
 	return false
 }
 
+// strictlyComparable reports whether values of type T are comparable
+// without the possibility of a run-time panic; that is, T is comparable
+// and neither T nor any type it contains is an interface.
+func strictlyComparable(T Type, seen map[Type]bool) bool {
+	if seen[T] {
+		return true
+	}
+	if seen == nil {
+		seen = make(map[Type]bool)
+	}
+	seen[T] = true
+
+	switch t := under(T).(type) {
+	case *Interface:
+		return false
+	case *Struct:
+		for _, f := range t.fields {
+			if !strictlyComparable(f.typ, seen) {
+				return false
+			}
+		}
+		return true
+	case *Array:
+		return strictlyComparable(t.elem, seen)
+	}
+	return comparable(T, nil)
+}
+
 // hasNil reports whether a type includes the nil value.
 func hasNil(typ Type) bool {
 	switch t := under(typ).(type) {
@@ -140,6 +170,7 @@ func (p *ifacePair) identical(q *ifacePair) bool {
 
 // For changes to this code the corresponding changes should be made to unifier.nify.
 func identical(x, y Type, cmpTags bool, p *ifacePair) bool {
+	x, y = Unalias(x), Unalias(y)
 	if x == y {
 		return true
 	}
@@ -174,6 +205,9 @@ func identical(x, y Type, cmpTags bool, p *ifacePair) bool {
 		// and identical tags. Two embedded fields are considered to have the same
 		// name. Lower-case field names from different packages are always different.
 		if y, ok := y.(*Struct); ok {
+			if x.canon != nil && x.canon == y.canon {
+				return true
+			}
 			if x.NumFields() == y.NumFields() {
 				for i, f := range x.fields {
 					g := y.fields[i]
@@ -219,10 +253,31 @@ func identical(x, y Type, cmpTags bool, p *ifacePair) bool {
 		// Generic functions must also have matching type parameter lists, but for the
 		// parameter names.
 		if y, ok := y.(*Signature); ok {
-			return x.variadic == y.variadic &&
-				identicalTParams(x.TypeParams().list(), y.TypeParams().list(), cmpTags, p) &&
-				identical(x.params, y.params, cmpTags, p) &&
-				identical(x.results, y.results, cmpTags, p)
+			if x.variadic != y.variadic || x.TypeParams().Len() != y.TypeParams().Len() {
+				return false
+			}
+			// In generic signatures, corresponding type parameters are the same
+			// (for instance, func[P any](P) and func[Q any](Q) are identical):
+			// compare y with its type parameters renamed to those of x.
+			yparams, yresults := y.params, y.results
+			if n := x.TypeParams().Len(); n > 0 {
+				targs := make([]Type, n)
+				for i, tpar := range x.TypeParams().list() {
+					targs[i] = tpar
+				}
+				smap := makeSubstMap(y.TypeParams().list(), targs)
+				var check *Checker // subst doesn't need a Checker here
+				for i, xtpar := range x.TypeParams().list() {
+					ybound := check.subst(token.NoPos, y.TypeParams().At(i).bound, smap, nil)
+					if !identical(xtpar.bound, ybound, cmpTags, p) {
+						return false
+					}
+				}
+				yparams = check.subst(token.NoPos, y.params, smap, nil).(*Tuple)
+				yresults = check.subst(token.NoPos, y.results, smap, nil).(*Tuple)
+			}
+			return identical(x.params, yparams, cmpTags, p) &&
+				identical(x.results, yresults, cmpTags, p)
 		}
 
 	case *Interface:
@@ -234,9 +289,17 @@ func identical(x, y Type, cmpTags bool, p *ifacePair) bool {
 		// are the same. Lower-case method names from different packages are always
 		// different. The order of the methods is irrelevant.
 		if y, ok := y.(*Interface); ok {
+			if x.canon != nil && x.canon == y.canon {
+				return true
+			}
 			xset := x.typeSet()
 			yset := y.typeSet()
-			if !xset.terms.equal(yset.terms) {
+			if len(xset.unions) > 0 || len(yset.unions) > 0 {
+				// Type sets of unions with method terms are not compared;
+				// such interfaces are only identical to themselves.
+				return false
+			}
+			if !xset.termsEqual(yset) {
 				return false
 			}
 			a := xset.methods
@@ -349,19 +412,6 @@ func identical(x, y Type, cmpTags bool, p *ifacePair) bool {
 	return false
 }
 
-func identicalTParams(x, y []*TypeParam, cmpTags bool, p *ifacePair) bool {
-	if len(x) != len(y) {
-		return false
-	}
-	for i, x := range x {
-		y := y[i]
-		if !identical(x.bound, y.bound, cmpTags, p) {
-			return false
-		}
-	}
-	return true
-}
-
 // Default returns the default "typed" type for an "untyped" type;
 // it returns the incoming type for all other types. The default type
 // for untyped nil is untyped nil.
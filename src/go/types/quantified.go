@@ -0,0 +1,216 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements AssignableToFor and AssertableToFor.
+
+package types
+
+import "go/token"
+
+// A Quantifier specifies how AssignableToFor and AssertableToFor interpret
+// the type parameters of the types they relate.
+type Quantifier int
+
+const (
+	// ForAll: the relation must hold for all instantiations, that is,
+	// for all combinations of types in the type sets of the type
+	// parameters.
+	ForAll Quantifier = iota
+
+	// ForSome: the relation must hold for some instantiation.
+	ForSome
+)
+
+// AssignableToFor reports whether a value of type V is assignable to a
+// variable of type T for all or for some instantiations of the type
+// parameters in V and T, as specified by q. An uninstantiated generic
+// type G (as found in the package scope) stands for its instantiation
+// with its own type parameters, so that, for example, a generic type
+// List is assignable to List[int] for some, but not for all,
+// instantiations.
+//
+// For ForAll, the result follows the assignability rules for type
+// parameters in the spec. For ForSome, a true result means that an
+// instantiation exists; it is found by unifying V and T and may be
+// missed (with a false result) if no instantiation makes V and T
+// identical, as in the assignment of a type parameter value with
+// constraint ~int to a variable of an unrelated interface type.
+func AssignableToFor(V, T Type, q Quantifier) bool {
+	V, T = ownInstance(V), ownInstance(T)
+	if assignableForAll(V, T) {
+		return true
+	}
+	return q == ForSome && forSome(V, T, func(u *unifier, V, T Type) bool {
+		return u.unify(V, T)
+	}, assignableForAll)
+}
+
+// AssertableToFor reports whether a value of interface type V can be
+// asserted to have type T, that is, whether the type assertion is
+// permitted because T is an interface or implements V, for all or for
+// some instantiations of the type parameters in V and T, as specified
+// by q. Uninstantiated generic types are interpreted as for
+// AssignableToFor.
+//
+// For ForAll, a type parameter T is assertable if its constraint has
+// the methods of V, or if its type set consists of specific types only,
+// each of which is assertable. For ForSome, a type parameter T is
+// assertable if a type in its type set may have the methods of V; for
+// other types T, an instantiation is found by unifying the method
+// signatures of V with those of T, as for AssignableToFor.
+func AssertableToFor(V *Interface, T Type, q Quantifier) bool {
+	T = ownInstance(T)
+	if assertableForAll(V, T) {
+		return true
+	}
+	if q == ForAll {
+		return false
+	}
+	if tpar, _ := T.(*TypeParam); tpar != nil {
+		return assertableTypeParam(V, tpar)
+	}
+	return forSome(V, T, func(u *unifier, V, T Type) bool {
+		for _, m := range V.(*Interface).typeSet().methods {
+			f := methodOf(T, m.pkg, m.name)
+			if f == nil || !u.unify(f.typ, m.typ) {
+				return false
+			}
+		}
+		return true
+	}, func(V, T Type) bool {
+		return assertableForAll(V.(*Interface), T)
+	})
+}
+
+func assignableForAll(V, T Type) bool {
+	x := operand{mode: value, typ: V}
+	ok, _ := x.assignableTo(nil, T, nil) // check not needed for non-constant x
+	return ok
+}
+
+func assertableForAll(V *Interface, T Type) bool {
+	if m, _ := (*Checker)(nil).assertableTo(V, T); m == nil {
+		return true
+	}
+	// The method set of a type parameter is that of its constraint, but
+	// each of the specific types of its type set may have more methods.
+	tpar, _ := T.(*TypeParam)
+	if tpar == nil {
+		return false
+	}
+	return tpar.iface().typeSet().is(func(t *term) bool {
+		return t.typ != theTop && !t.tilde && assertableForAll(V, t.typ)
+	})
+}
+
+// assertableTypeParam reports whether some type in the type set of tpar
+// may have the methods of V.
+func assertableTypeParam(V *Interface, tpar *TypeParam) bool {
+	tset := tpar.iface().typeSet()
+	for _, t := range tset.terms {
+		if t.typ == nil || t.tilde {
+			// A defined type may have the methods of V in addition
+			// to those required by the constraint, unless they have
+			// the same names but different signatures.
+			for _, m := range V.typeSet().methods {
+				if _, f := tset.LookupMethod(m.pkg, m.name); f != nil && !Identical(f.typ, m.typ) {
+					return false
+				}
+			}
+			return true
+		}
+		if assertableForAll(V, t.typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// methodOf returns the method of T with the given package and name, or nil.
+func methodOf(T Type, pkg *Package, name string) *Func {
+	obj, _, _ := lookupFieldOrMethod(T, false, pkg, name)
+	f, _ := obj.(*Func)
+	return f
+}
+
+// ownInstance returns the instantiation of T with its own type parameters
+// if T is an uninstantiated generic type, and T otherwise.
+func ownInstance(T Type) Type {
+	n, _ := T.(*Named)
+	if n == nil || n.TypeParams().Len() == 0 || n.TypeArgs().Len() > 0 {
+		return T
+	}
+	targs := make([]Type, n.TypeParams().Len())
+	for i := range targs {
+		targs[i] = n.TypeParams().At(i)
+	}
+	inst, err := Instantiate(nil, n, targs, false)
+	assert(err == nil)
+	return inst
+}
+
+// forSome reports whether there is an instantiation of the free type
+// parameters of V and T for which rel(V, T) holds. Candidate instantiations are
+// inferred by unify, and they must satisfy the constraints of the type
+// parameters; the type parameters not inferred remain and are interpreted
+// by rel.
+func forSome(V, T Type, unify func(u *unifier, V, T Type) bool, rel func(V, T Type) bool) bool {
+	// the free type parameters of V and T (see FreeTypeParams)
+	w := freeWalker{seen: make(map[Type]bool), bound: make(map[*TypeParam]bool)}
+	w.typ(V)
+	w.typ(T)
+	tparams := w.free
+	if len(tparams) == 0 {
+		return false
+	}
+
+	// The type parameters may come from different declarations. Unify
+	// (bidirectionally) in terms of fresh copies of them, in one list.
+	var check *Checker
+	fresh := make([]*TypeParam, len(tparams))
+	targs := make([]Type, len(tparams))
+	for i, tpar := range tparams {
+		obj := NewTypeName(tpar.obj.pos, tpar.obj.pkg, tpar.obj.name, nil)
+		fresh[i] = check.newTypeParam(obj, nil)
+		targs[i] = fresh[i]
+	}
+	bindTParams(fresh)
+	smap := makeSubstMap(tparams, targs)
+	for i, tpar := range tparams {
+		fresh[i].bound = check.subst(token.NoPos, tpar.bound, smap, nil)
+	}
+	V = check.subst(token.NoPos, V, smap, nil)
+	T = check.subst(token.NoPos, T, smap, nil)
+
+	u := newUnifier(false)
+	u.x.init(fresh)
+	u.y = u.x
+	if !unify(u, V, T) {
+		return false
+	}
+	// Complete the inferred types as in constraint type inference.
+	inferred, _ := u.x.types()
+	types, _ := check.inferB(fresh, inferred, false)
+	if types == nil {
+		return false
+	}
+	for i, typ := range types {
+		if typ == nil {
+			types[i] = fresh[i] // not inferred
+		}
+	}
+
+	smap = makeSubstMap(fresh, types)
+	for i, tpar := range fresh {
+		if types[i] == tpar {
+			continue
+		}
+		bound := check.subst(token.NoPos, tpar.bound, smap, nil)
+		iface := check.subst(token.NoPos, tpar.iface(), smap, nil).(*Interface)
+		if check.satisfiesIface(types[i], bound, iface, false) != nil {
+			return false
+		}
+	}
+	return rel(check.subst(token.NoPos, V, smap, nil), check.subst(token.NoPos, T, smap, nil))
+}
@@ -5,6 +5,8 @@
 package types
 
 import (
+	gocontext "context" // package types declares context
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/constant"
@@ -151,27 +153,14 @@ func (check *Checker) importPackage(at positioner, path, dir string) *Package {
 	} else {
 		// ordinary import
 		var err error
-		if importer := check.conf.Importer; importer == nil {
-			err = fmt.Errorf("Config.Importer not installed")
-		} else if importerFrom, ok := importer.(ImporterFrom); ok {
-			imp, err = importerFrom.ImportFrom(path, dir, 0)
-			if imp == nil && err == nil {
-				err = fmt.Errorf("Config.Importer.ImportFrom(%s, %s, 0) returned nil but no error", path, dir)
-			}
+		if res, ok := check.prefetched[key]; ok {
+			imp, err = res.pkg, res.err
+			delete(check.prefetched, key)
 		} else {
-			imp, err = importer.Import(path)
-			if imp == nil && err == nil {
-				err = fmt.Errorf("Config.Importer.Import(%s) returned nil but no error", path)
-			}
-		}
-		// make sure we have a valid package name
-		// (errors here can only happen through manipulation of packages after creation)
-		if err == nil && imp != nil && (imp.name == "_" || imp.name == "") {
-			err = fmt.Errorf("invalid package name: %q", imp.name)
-			imp = nil // create fake package below
+			imp, err = check.doImport(path, dir)
 		}
 		if err != nil {
-			check.errorf(at, _BrokenImport, "could not import %s (%s)", path, err)
+			check.importError(at, path, err)
 			if imp == nil {
 				// create a new fake package
 				// come up with a sensible package name (heuristic)
@@ -204,6 +193,140 @@ func (check *Checker) importPackage(at positioner, path, dir string) *Package {
 	return nil
 }
 
+// importError reports the failed import of path. An import cycle is
+// reported as seen from the checked package.
+func (check *Checker) importError(at positioner, path string, err error) {
+	cycle := importCycle(err)
+	if cycle == nil {
+		check.errorf(at, _BrokenImport, "could not import %s (%s)", path, err)
+		return
+	}
+	cycle = rotateCycle(cycle, check.pkg.path)
+	e := check.newErrorf(at, _BrokenImport, false, "could not import %s (%s)", path, &ImportCycleError{cycle}).(Error)
+	e.ImportCycle = cycle
+	check.err(e)
+}
+
+// importCycle returns the import cycle reported by the import error err,
+// or nil.
+func importCycle(err error) []string {
+	var cerr *ImportCycleError
+	if errors.As(err, &cerr) {
+		return cerr.Cycle
+	}
+	var e Error
+	if errors.As(err, &e) {
+		return e.ImportCycle
+	}
+	return nil
+}
+
+// rotateCycle returns the import cycle cycle beginning and ending with
+// path if path is part of the cycle; otherwise it returns cycle.
+func rotateCycle(cycle []string, path string) []string {
+	n := len(cycle) - 1 // the last path repeats the first
+	for i := 0; i < n; i++ {
+		if cycle[i] == path {
+			res := make([]string, 0, n+1)
+			res = append(res, cycle[i:n]...)
+			res = append(res, cycle[:i]...)
+			return append(res, path)
+		}
+	}
+	return cycle
+}
+
+// doImport calls the configured importer for the given (path, dir) pair,
+// passing it the context of the check if the importer accepts one.
+// It does not modify any Checker state and may be called concurrently
+// if the importer permits it.
+func (check *Checker) doImport(path, dir string) (imp *Package, err error) {
+	ctx := check.ctx
+	if ctx == nil {
+		ctx = gocontext.Background()
+	}
+	if importer := check.conf.Importer; importer == nil {
+		err = fmt.Errorf("Config.Importer not installed")
+	} else if err = ctx.Err(); err != nil {
+		// don't start an import if the check was cancelled
+	} else if importerFrom, ok := importer.(ImporterFromContext); ok {
+		imp, err = importerFrom.ImportFromContext(ctx, path, dir, 0)
+		if imp == nil && err == nil {
+			err = fmt.Errorf("Config.Importer.ImportFromContext(%s, %s, 0) returned nil but no error", path, dir)
+		}
+	} else if importerFrom, ok := importer.(ImporterFrom); ok {
+		imp, err = importerFrom.ImportFrom(path, dir, 0)
+		if imp == nil && err == nil {
+			err = fmt.Errorf("Config.Importer.ImportFrom(%s, %s, 0) returned nil but no error", path, dir)
+		}
+	} else {
+		imp, err = importer.Import(path)
+		if imp == nil && err == nil {
+			err = fmt.Errorf("Config.Importer.Import(%s) returned nil but no error", path)
+		}
+	}
+	// make sure we have a valid package name
+	// (errors here can only happen through manipulation of packages after creation)
+	if err == nil && imp != nil && (imp.name == "_" || imp.name == "") {
+		err = fmt.Errorf("invalid package name: %q", imp.name)
+		imp = nil // create fake package below
+	}
+	return
+}
+
+// An importResult holds the result of a concurrent import.
+type importResult struct {
+	pkg *Package
+	err error
+}
+
+// prefetchImports concurrently imports all packages imported by the
+// package files that are not already known to the checker, and records
+// the results in check.prefetched for use by importPackage. Package
+// unsafe, which importers don't load, and imports of the checked package
+// itself, which are import cycles, are left to importPackage.
+func (check *Checker) prefetchImports() {
+	var keys []importKey
+	seen := make(map[importKey]bool)
+	for _, file := range check.files {
+		fileDir := dir(check.fset.Position(file.Name.Pos()).Filename)
+		for _, d := range file.Decls {
+			d, _ := d.(*ast.GenDecl)
+			if d == nil || d.Tok != token.IMPORT {
+				continue
+			}
+			for _, spec := range d.Specs {
+				s, _ := spec.(*ast.ImportSpec)
+				if s == nil {
+					continue
+				}
+				path, err := validatedImportPath(s.Path.Value)
+				if err != nil || path == "C" || path == "unsafe" || path == check.pkg.path {
+					continue // handled by importPackage
+				}
+				key := importKey{path, fileDir}
+				if !seen[key] && check.impMap[key] == nil {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+			}
+		}
+	}
+	if len(keys) < 2 {
+		return // nothing to gain
+	}
+
+	results := make([]importResult, len(keys))
+	check.conf.parallel(len(keys), func(i int) {
+		results[i].pkg, results[i].err = check.doImport(keys[i].path, keys[i].dir)
+	})
+
+	check.prefetched = make(map[importKey]importResult, len(keys))
+	for i, key := range keys {
+		check.prefetched[key] = results[i]
+	}
+}
+
 // collectObjects collects all file and package objects and inserts them
 // into their respective scopes. It also performs imports and associates
 // methods with receiver base type names.
@@ -221,6 +344,11 @@ func (check *Checker) collectObjects() {
 		pkgImports[imp] = true
 	}
 
+	if check.conf.ConcurrentImports {
+		check.prefetchImports()
+		defer func() { check.prefetched = nil }()
+	}
+
 	type methodInfo struct {
 		obj  *Func      // method
 		ptr  bool       // true if pointer receiver
@@ -309,7 +437,7 @@ func (check *Checker) collectObjects() {
 						check.dotImportMap = make(map[dotImportKey]*PkgName)
 					}
 					// merge imported scope with file scope
-					for name, obj := range imp.scope.elems {
+					imp.scope.forEach(func(name string, obj Object) {
 						// Note: Avoid eager resolve(name, obj) here, so we only
 						// resolve dot-imported objects as needed.
 
@@ -329,7 +457,7 @@ func (check *Checker) collectObjects() {
 								check.dotImportMap[dotImportKey{fileScope, name}] = pkgName
 							}
 						}
-					}
+					})
 				} else {
 					// declare imported package object in file scope
 					// (no need to provide s.Name since we called check.recordDef earlier)
@@ -381,8 +509,8 @@ func (check *Checker) collectObjects() {
 					check.declarePkgObj(name, obj, di)
 				}
 			case typeDecl:
-				if d.spec.TypeParams.NumFields() != 0 && !check.allowVersion(pkg, 1, 18) {
-					check.softErrorf(d.spec.TypeParams.List[0], _Todo, "type parameters require go1.18 or later")
+				if d.spec.TypeParams.NumFields() != 0 && !check.allowVersion(pkg, 1, 18) && !check.forwardCompat(d.spec.TypeParams.List[0], 1, 18, "type parameters") {
+					check.warnf(d.spec.TypeParams.List[0], _Todo, "type parameters require go1.18 or later")
 				}
 				obj := NewTypeName(d.spec.Name.Pos(), pkg, d.spec.Name.Name, nil)
 				check.declarePkgObj(d.spec.Name, obj, &declInfo{file: fileScope, tdecl: d.spec})
@@ -439,8 +567,8 @@ func (check *Checker) collectObjects() {
 					}
 					check.recordDef(d.decl.Name, obj)
 				}
-				if d.decl.Type.TypeParams.NumFields() != 0 && !check.allowVersion(pkg, 1, 18) && !hasTParamError {
-					check.softErrorf(d.decl.Type.TypeParams.List[0], _Todo, "type parameters require go1.18 or later")
+				if d.decl.Type.TypeParams.NumFields() != 0 && !check.allowVersion(pkg, 1, 18) && !hasTParamError && !check.forwardCompat(d.decl.Type.TypeParams.List[0], 1, 18, "type parameters") {
+					check.warnf(d.decl.Type.TypeParams.List[0], _Todo, "type parameters require go1.18 or later")
 				}
 				info := &declInfo{file: fileScope, fdecl: d.decl}
 				// Methods are not package-level objects but we still track them in the
@@ -455,7 +583,7 @@ func (check *Checker) collectObjects() {
 
 	// verify that objects in package and file scopes have different names
 	for _, scope := range fileScopes {
-		for name, obj := range scope.elems {
+		scope.forEach(func(name string, obj Object) {
 			if alt := pkg.scope.Lookup(name); alt != nil {
 				obj = resolve(name, obj)
 				if pkg, ok := obj.(*PkgName); ok {
@@ -467,7 +595,7 @@ func (check *Checker) collectObjects() {
 					check.reportAltDecl(obj)
 				}
 			}
-		}
+		})
 	}
 
 	// Now that we have all package scope objects and all methods,
@@ -638,6 +766,12 @@ func (check *Checker) packageObjects() {
 	var aliasList []*TypeName
 	// phase 1
 	for _, obj := range objList {
+		// With Config.ConstantsOnly, other objects are only
+		// checked if a constant declaration depends on them.
+		if _, isConst := obj.(*Const); !isConst && check.conf.ConstantsOnly {
+			continue
+		}
+
 		// If we have a type alias, collect it for the 2nd phase.
 		if tname, _ := obj.(*TypeName); tname != nil && check.objMap[tname].tdecl.Assign.IsValid() {
 			aliasList = append(aliasList, tname)
@@ -668,7 +802,7 @@ func (a inSourceOrder) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 // unusedImports checks for unused imports.
 func (check *Checker) unusedImports() {
 	// if function bodies are not checked, packages' uses are likely missing - don't check
-	if check.conf.IgnoreFuncBodies {
+	if check.conf.IgnoreFuncBodies || check.conf.ConstantsOnly {
 		return
 	}
 
@@ -696,9 +830,9 @@ func (check *Checker) errorUnusedPkg(obj *PkgName) {
 		elem = elem[i+1:]
 	}
 	if obj.name == "" || obj.name == "." || obj.name == elem {
-		check.softErrorf(obj, _UnusedImport, "%q imported but not used", path)
+		check.reportUnused(check.conf.UnusedImports, obj, _UnusedImport, "%q imported but not used", path)
 	} else {
-		check.softErrorf(obj, _UnusedImport, "%q imported but not used as %s", path, obj.name)
+		check.reportUnused(check.conf.UnusedImports, obj, _UnusedImport, "%q imported but not used as %s", path, obj.name)
 	}
 }
 
@@ -5,6 +5,8 @@
 package types_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/importer"
@@ -12,6 +14,8 @@ import (
 	"go/token"
 	"internal/testenv"
 	"sort"
+	"strings"
+	"sync"
 	"testing"
 
 	. "go/types"
@@ -214,3 +218,235 @@ func TestResolveIdents(t *testing.T) {
 
 	// TODO(gri) add tests to check ImplicitObj callbacks
 }
+
+// concurrentTestImporter creates empty packages for all import paths
+// except "missing", and counts how often each path was imported.
+type concurrentTestImporter struct {
+	mu    sync.Mutex
+	calls map[string]int
+	order []string // import paths in the order of the calls
+}
+
+func (imp *concurrentTestImporter) Import(path string) (*Package, error) {
+	imp.mu.Lock()
+	defer imp.mu.Unlock()
+	imp.calls[path]++
+	imp.order = append(imp.order, path)
+	if path == "missing" {
+		return nil, fmt.Errorf("package %s not found", path)
+	}
+	pkg := NewPackage(path, path)
+	pkg.MarkComplete()
+	return pkg, nil
+}
+
+func TestConcurrentImports(t *testing.T) {
+	const src = `
+package p
+import (
+	"a"
+	"b"
+	"missing"
+	"c"
+)
+import _ "a"
+var _, _, _ = a.X, b.X, c.X
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imp := &concurrentTestImporter{calls: make(map[string]int)}
+	var errs []string
+	conf := Config{
+		Importer:          imp,
+		ConcurrentImports: true,
+		Error:             func(err error) { errs = append(errs, err.Error()) },
+	}
+	pkg, _ := conf.Check("p", fset, []*ast.File{f}, nil)
+
+	for _, path := range []string{"a", "b", "c", "missing"} {
+		if got := imp.calls[path]; got != 1 {
+			t.Errorf("%s imported %d times, want 1", path, got)
+		}
+	}
+	var paths []string
+	for _, imp := range pkg.Imports() {
+		paths = append(paths, imp.Path())
+	}
+	if got, want := fmt.Sprint(paths), "[a b missing c]"; got != want {
+		t.Errorf("got imports %s, want %s", got, want)
+	}
+	if len(errs) == 0 || !strings.Contains(errs[0], "could not import missing") {
+		t.Errorf("got errors %q, want an import error for package missing first", errs)
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	const src = `
+package p
+import (
+	"a"
+	"b"
+	"c"
+	"d"
+)
+var _, _, _, _ = a.X, b.X, c.X, d.X
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// With a concurrency of 1, concurrent imports are made in order.
+	for i := 0; i < 10; i++ {
+		imp := &concurrentTestImporter{calls: make(map[string]int)}
+		conf := Config{
+			Importer:          imp,
+			ConcurrentImports: true,
+			Concurrency:       1,
+			Error:             func(error) {},
+		}
+		conf.Check("p", fset, []*ast.File{f}, nil)
+		if got, want := fmt.Sprint(imp.order), "[a b c d]"; got != want {
+			t.Fatalf("got imports in order %s, want %s", got, want)
+		}
+	}
+}
+
+func TestConcurrentImportsSkipped(t *testing.T) {
+	const src = `
+package p
+import (
+	_ "unsafe"
+	_ "p"
+	"a"
+	"b"
+)
+var _, _ = a.X, b.X
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Package unsafe and the checked package itself are not prefetched.
+	imp := &concurrentTestImporter{calls: make(map[string]int)}
+	conf := Config{
+		Importer:          imp,
+		ConcurrentImports: true,
+		Concurrency:       1,
+		Error:             func(error) {},
+	}
+	conf.Check("p", fset, []*ast.File{f}, nil)
+	if got, want := fmt.Sprint(imp.order), "[a b unsafe p]"; got != want {
+		t.Errorf("got imports in order %s, want %s", got, want)
+	}
+}
+
+type ctxKey struct{}
+
+// contextTestImporter creates empty packages, records the context value
+// seen by each import, and cancels the context after importing "a".
+type contextTestImporter struct {
+	cancel func()
+	values []string
+}
+
+func (imp *contextTestImporter) Import(string) (*Package, error) {
+	panic("should not be called")
+}
+
+func (imp *contextTestImporter) ImportFrom(string, string, ImportMode) (*Package, error) {
+	panic("should not be called")
+}
+
+func (imp *contextTestImporter) ImportFromContext(ctx context.Context, path, dir string, mode ImportMode) (*Package, error) {
+	imp.values = append(imp.values, fmt.Sprintf("%s:%v", path, ctx.Value(ctxKey{})))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if path == "a" {
+		imp.cancel()
+	}
+	pkg := NewPackage(path, path)
+	pkg.MarkComplete()
+	return pkg, nil
+}
+
+func TestImportContext(t *testing.T) {
+	const src = `
+package p
+import "a"
+import "b"
+var _, _ = a.X, b.X
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "span"))
+	defer cancel()
+	imp := &contextTestImporter{cancel: func() {}}
+	conf := Config{Importer: imp, Error: func(error) {}}
+
+	// The context is passed to the importer.
+	if _, err := conf.CheckContext(ctx, "p", fset, []*ast.File{f}, nil); err == nil || !strings.Contains(err.Error(), "not declared by package a") {
+		t.Errorf("got error %v, want error for undeclared a.X", err)
+	}
+	if got, want := fmt.Sprint(imp.values), "[a:span b:span]"; got != want {
+		t.Errorf("got imports %s, want %s", got, want)
+	}
+
+	// Once the context is cancelled, no more imports are started
+	// and the check is abandoned.
+	imp = &contextTestImporter{cancel: cancel}
+	conf.Importer = imp
+	if _, err := conf.CheckContext(ctx, "p", fset, []*ast.File{f}, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+	if got, want := fmt.Sprint(imp.values), "[a:span]"; got != want {
+		t.Errorf("got imports %s, want %s", got, want)
+	}
+}
+
+// cycleTestImporter reports the import cycle it holds for all imports.
+type cycleTestImporter []string
+
+func (imp cycleTestImporter) Import(string) (*Package, error) {
+	return nil, fmt.Errorf("wrapped: %w", &ImportCycleError{imp})
+}
+
+func TestImportCycleError(t *testing.T) {
+	const src = `package p; import _ "b"`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cycle := range [][]string{
+		{"b", "c", "p", "b"},
+		{"c", "p", "b", "c"},
+	} {
+		conf := Config{Importer: cycleTestImporter(cycle)}
+		_, err := conf.Check("p", fset, []*ast.File{f}, nil)
+		var e Error
+		if !errors.As(err, &e) {
+			t.Fatalf("got error %v, want Error", err)
+		}
+		// The cycle begins with the checked package.
+		if got, want := fmt.Sprint(e.ImportCycle), "[p b c p]"; got != want {
+			t.Errorf("got import cycle %s, want %s", got, want)
+		}
+		if want := "could not import b (import cycle not allowed: p -> b -> c -> p)"; e.Msg != want {
+			t.Errorf("got message %q, want %q", e.Msg, want)
+		}
+	}
+}
@@ -11,6 +11,28 @@ import (
 	"go/token"
 )
 
+// IsTerminating reports whether s is a terminating statement
+// (https://golang.org/ref/spec#Terminating_statements), exactly as
+// determined by the type checker when it reports missing returns.
+// The statement must have been type-checked, with the resulting
+// identifier uses recorded in info.Uses (to identify calls of the
+// predeclared panic function).
+func IsTerminating(info *Info, s ast.Stmt) bool {
+	var check Checker
+	check.isPanic = make(map[*ast.CallExpr]bool)
+	ast.Inspect(s, func(n ast.Node) bool {
+		if call, _ := n.(*ast.CallExpr); call != nil {
+			if id, _ := unparen(call.Fun).(*ast.Ident); id != nil {
+				if bin, _ := info.Uses[id].(*Builtin); bin != nil && bin.id == _Panic {
+					check.isPanic[call] = true
+				}
+			}
+		}
+		return true
+	})
+	return check.isTerminating(s, "")
+}
+
 // isTerminating reports if s is a terminating statement.
 // If s is labeled, label is the label name; otherwise s
 // is "".
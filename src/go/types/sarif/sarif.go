@@ -0,0 +1,276 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sarif writes type checker errors as SARIF logs
+// (https://docs.oasis-open.org/sarif/sarif/v2.1.0/), for consumption by
+// CI systems and code scanning tools.
+package sarif
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"go/types"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// Write writes the errors errs, in the order they were reported, to w as
+// a SARIF 2.1.0 log of a single run of the named tool.
+//
+// Each error becomes a result, whose rule is the name of the error code, if
+// any (see types.Error.Code). Continuation errors, whose
+// messages start with a tab (such as "other declaration of x"), and the
+// instantiations of an error become related locations of the preceding
+// error, and suggestions become fixes.
+//
+// As in SARIF by default, columns count Unicode code points. They are
+// computed from the file contents obtained by calling readFile with the
+// file names recorded in the errors' file sets. If readFile is nil or fails,
+// columns count bytes, which is exact for ASCII source.
+func Write(w io.Writer, tool string, errs []types.Error, readFile func(filename string) ([]byte, error)) error {
+	s := sarifWriter{readFile: readFile, files: make(map[string][]byte)}
+	results := []sarifResult{}
+	for _, err := range errs {
+		if strings.HasPrefix(err.Msg, "\t") && len(results) > 0 {
+			r := &results[len(results)-1]
+			r.RelatedLocations = append(r.RelatedLocations, s.related(len(r.RelatedLocations), err.Fset, err.Pos, strings.TrimLeft(err.Msg, "\t")))
+			continue
+		}
+		results = append(results, s.result(err))
+	}
+
+	log := sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{{
+			Tool:       sarifTool{Driver: sarifDriver{Name: tool}},
+			ColumnKind: "unicodeCodePoints",
+			Results:    results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// The following types describe the subset of SARIF used by Write.
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name string `json:"name"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId,omitempty"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations,omitempty"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+	Fixes            []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	ID               *int                  `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion   `json:"deletedRegion"`
+	InsertedContent *sarifMessage `json:"insertedContent"`
+}
+
+type sarifWriter struct {
+	readFile func(filename string) ([]byte, error)
+	files    map[string][]byte // cache of file contents; nil entries for unreadable files
+}
+
+func (s *sarifWriter) result(err types.Error) sarifResult {
+	r := sarifResult{Level: "error", Message: sarifMessage{err.Msg}}
+	if _, name := err.Code(); name != "" {
+		r.RuleID = name
+	}
+	if err.Severity == types.SeverityWarning {
+		r.Level = "warning"
+	}
+	if err.Fset == nil || !err.Pos.IsValid() {
+		return r
+	}
+
+	// The span of the error, if known and not empty, is the region of
+	// the result; otherwise, it is the error position.
+	start, end := err.Span()
+	if !start.IsValid() || !end.IsValid() || start > err.Pos || end < err.Pos || start == end {
+		start, end = err.Pos, token.NoPos
+	}
+	loc := s.location(err.Fset, start, end)
+	r.Locations = []sarifLocation{loc}
+
+	for _, inst := range err.Instantiations {
+		r.RelatedLocations = append(r.RelatedLocations, s.related(len(r.RelatedLocations), err.Fset, inst.Pos, describeInstantiation(inst)))
+	}
+
+	if err.Suggestion != "" && end.IsValid() {
+		r.Fixes = []sarifFix{{
+			Description: sarifMessage{fmt.Sprintf("Replace with %s", err.Suggestion)},
+			ArtifactChanges: []sarifArtifactChange{{
+				ArtifactLocation: loc.PhysicalLocation.ArtifactLocation,
+				Replacements: []sarifReplacement{{
+					DeletedRegion:   loc.PhysicalLocation.Region,
+					InsertedContent: &sarifMessage{err.Suggestion},
+				}},
+			}},
+		}}
+	}
+	return r
+}
+
+// describeInstantiation returns a description of inst of the form
+// "in instantiation of G with [int]", as in types.FormatError.
+func describeInstantiation(inst types.Instantiation) string {
+	var name string
+	if t, _ := inst.Orig.(*types.Named); t != nil {
+		name = t.Obj().Name()
+	} else {
+		name = types.TypeString(inst.Orig, unqualified)
+	}
+	var targs []string
+	for i := 0; i < inst.TypeArgs.Len(); i++ {
+		targs = append(targs, types.TypeString(inst.TypeArgs.At(i), unqualified))
+	}
+	return stripAnnotations(fmt.Sprintf("in instantiation of %s with [%s]", name, strings.Join(targs, ", ")))
+}
+
+func unqualified(*types.Package) string { return "" }
+
+// stripAnnotations removes the annotations of types.TypeString used for
+// debugging, the subscripts of type parameters and the markers of
+// unexpanded instances, from s.
+func stripAnnotations(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '#' || '₀' <= r && r < '₀'+10 {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// related returns the related location with the given id and message at pos.
+func (s *sarifWriter) related(id int, fset *token.FileSet, pos token.Pos, msg string) sarifLocation {
+	loc := s.location(fset, pos, token.NoPos)
+	loc.ID = &id
+	loc.Message = &sarifMessage{msg}
+	return loc
+}
+
+// location returns the location of the region [start, end), or of the
+// position start if end is not valid.
+func (s *sarifWriter) location(fset *token.FileSet, start, end token.Pos) sarifLocation {
+	var loc sarifLocation
+	if fset == nil || !start.IsValid() {
+		return loc
+	}
+	p := fset.Position(start)
+	loc.PhysicalLocation.ArtifactLocation.URI = fileURI(p.Filename)
+	loc.PhysicalLocation.Region.StartLine = p.Line
+	loc.PhysicalLocation.Region.StartColumn = s.column(fset, start, p)
+	if end.IsValid() {
+		q := fset.Position(end)
+		loc.PhysicalLocation.Region.EndLine = q.Line
+		loc.PhysicalLocation.Region.EndColumn = s.column(fset, end, q)
+	}
+	return loc
+}
+
+// column returns the 1-based column of pos, at position p, in code points
+// if the file contents are available, and in bytes otherwise.
+func (s *sarifWriter) column(fset *token.FileSet, pos token.Pos, p token.Position) int {
+	// The column of a position on a line directive is not relative to
+	// the file contents.
+	file := fset.File(pos)
+	if file == nil || p.Filename != file.Name() || s.readFile == nil {
+		return p.Column
+	}
+	col, err := types.RuneColumn(fset, pos, s.source(file))
+	if err != nil {
+		return p.Column
+	}
+	return col
+}
+
+// source returns the contents of file, or nil if they are not available.
+func (s *sarifWriter) source(file *token.File) []byte {
+	name := file.Name()
+	src, ok := s.files[name]
+	if !ok {
+		var err error
+		src, err = s.readFile(name)
+		if err != nil || len(src) != file.Size() {
+			src = nil
+		}
+		s.files[name] = src
+	}
+	return src
+}
+
+// fileURI returns the URI reference of the file with the given name:
+// a file URI for absolute file names, and a relative reference otherwise.
+func fileURI(filename string) string {
+	u := url.URL{Path: filepath.ToSlash(filename)}
+	if filepath.IsAbs(filename) {
+		u.Scheme = "file"
+		if !strings.HasPrefix(u.Path, "/") {
+			u.Path = "/" + u.Path // Windows drive letter
+		}
+	}
+	return u.String()
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sarif_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"go/types/sarif"
+)
+
+func checkErrors(t *testing.T, src string) []types.Error {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []types.Error
+	conf := types.Config{Error: func(err error) { errs = append(errs, err.(types.Error)) }}
+	conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	return errs
+}
+
+func TestWrite(t *testing.T) {
+	const src = "package p\n\nvar value = 1\nvar _, _ = \"ä\", valeu\nvar value int\n"
+	errs := checkErrors(t, src)
+	if len(errs) != 3 {
+		t.Fatalf("got %d errors (%v), want 3", len(errs), errs)
+	}
+	readFile := func(filename string) ([]byte, error) { return []byte(src), nil }
+
+	var buf bytes.Buffer
+	if err := sarif.Write(&buf, "vet", errs, readFile); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string
+		Runs    []struct {
+			Tool    struct{ Driver struct{ Name string } }
+			Results []struct {
+				RuleID    string
+				Level     string
+				Message   struct{ Text string }
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string }
+						Region           struct{ StartLine, StartColumn, EndLine, EndColumn int }
+					}
+				}
+				RelatedLocations []struct {
+					Message          struct{ Text string }
+					PhysicalLocation struct {
+						Region struct{ StartLine, StartColumn int }
+					}
+				}
+				Fixes []struct {
+					ArtifactChanges []struct {
+						Replacements []struct {
+							DeletedRegion   struct{ StartLine, StartColumn, EndLine, EndColumn int }
+							InsertedContent struct{ Text string }
+						}
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.Bytes())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Name != "vet" {
+		t.Fatalf("unexpected log:\n%s", buf.Bytes())
+	}
+	results := log.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2:\n%s", len(results), buf.Bytes())
+	}
+
+	// The continuation error is a related location.
+	r := results[0]
+	if r.RuleID != "DuplicateDecl" || len(r.RelatedLocations) != 1 || r.RelatedLocations[0].Message.Text != "other declaration of value" || r.RelatedLocations[0].PhysicalLocation.Region.StartLine != 3 {
+		t.Errorf("unexpected related locations for redeclaration:\n%s", buf.Bytes())
+	}
+
+	// The misspelled identifier follows a multi-byte character.
+	r = results[1]
+	loc := r.Locations[0].PhysicalLocation
+	if r.RuleID != "UndeclaredName" || r.Level != "error" || loc.ArtifactLocation.URI != "p.go" || loc.Region.StartLine != 4 || loc.Region.StartColumn != 17 || loc.Region.EndColumn != 22 {
+		t.Errorf("unexpected result for undeclared name:\n%s", buf.Bytes())
+	}
+	if len(r.Fixes) != 1 || r.Fixes[0].ArtifactChanges[0].Replacements[0].InsertedContent.Text != "value" || r.Fixes[0].ArtifactChanges[0].Replacements[0].DeletedRegion != loc.Region {
+		t.Errorf("unexpected fix for undeclared name:\n%s", buf.Bytes())
+	}
+}
+
+func TestWriteInstantiations(t *testing.T) {
+	const src = "package p\n\nfunc f[P comparable]() {}\n\nvar _ = f[func()]\n"
+	errs := checkErrors(t, src)
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors (%v), want 1", len(errs), errs)
+	}
+
+	var buf bytes.Buffer
+	if err := sarif.Write(&buf, "vet", errs, nil); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Runs []struct {
+			Results []struct {
+				RelatedLocations []struct {
+					Message          struct{ Text string }
+					PhysicalLocation struct {
+						Region struct{ StartLine, StartColumn int }
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.Bytes())
+	}
+	related := log.Runs[0].Results[0].RelatedLocations
+	if len(related) != 1 || related[0].Message.Text != "in instantiation of func[P comparable]() with [func()]" || related[0].PhysicalLocation.Region.StartLine != 5 || related[0].PhysicalLocation.Region.StartColumn != 9 {
+		t.Errorf("unexpected related locations for instantiation:\n%s", buf.Bytes())
+	}
+}
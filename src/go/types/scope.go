@@ -24,16 +24,31 @@ type Scope struct {
 	parent   *Scope
 	children []*Scope
 	number   int               // parent.children[number-1] is this scope; 0 if there is no parent
-	elems    map[string]Object // lazily allocated
+	small    []scopeEntry      // elements sorted by name, while there are at most maxSmallScope of them
+	elems    map[string]Object // lazily allocated once the scope outgrows small; small is nil then
 	pos, end token.Pos         // scope extent; may be invalid
 	comment  string            // for debugging only
 	isFunc   bool              // set if this is a function scope (internal use only)
+	isUniv   bool              // set if this is the universe scope of Config.Predeclared (internal use only)
+	shadows  bool              // set if s declares a name of a universe object (see LookupParent)
+}
+
+// Most scopes (in particular block scopes in function bodies) contain only a
+// handful of objects. For those, a sorted slice is both smaller and faster to
+// search than a map. A scope switches to a map once it grows beyond
+// maxSmallScope elements.
+const maxSmallScope = 8
+
+// A scopeEntry is a single (unresolved) element of a small scope.
+type scopeEntry struct {
+	name string
+	obj  Object
 }
 
 // NewScope returns a new, empty scope contained in the given parent
 // scope, if any. The comment is for debugging only.
 func NewScope(parent *Scope, pos, end token.Pos, comment string) *Scope {
-	s := &Scope{parent, nil, 0, nil, pos, end, comment, false}
+	s := &Scope{parent, nil, 0, nil, nil, pos, end, comment, false, false, false}
 	// don't add children to Universe scope!
 	if parent != nil && parent != Universe {
 		parent.children = append(parent.children, s)
@@ -46,10 +61,22 @@ func NewScope(parent *Scope, pos, end token.Pos, comment string) *Scope {
 func (s *Scope) Parent() *Scope { return s.parent }
 
 // Len returns the number of scope elements.
-func (s *Scope) Len() int { return len(s.elems) }
+func (s *Scope) Len() int {
+	if s.elems != nil {
+		return len(s.elems)
+	}
+	return len(s.small)
+}
 
 // Names returns the scope's element names in sorted order.
 func (s *Scope) Names() []string {
+	if s.elems == nil {
+		names := make([]string, len(s.small))
+		for i, e := range s.small {
+			names[i] = e.name
+		}
+		return names // already sorted
+	}
 	names := make([]string, len(s.elems))
 	i := 0
 	for name := range s.elems {
@@ -69,7 +96,7 @@ func (s *Scope) Child(i int) *Scope { return s.children[i] }
 // Lookup returns the object in scope s with the given name if such an
 // object exists; otherwise the result is nil.
 func (s *Scope) Lookup(name string) Object {
-	return resolve(name, s.elems[name])
+	return resolve(name, s.lookup(name))
 }
 
 // LookupParent follows the parent chain of scopes starting with s until
@@ -83,6 +110,22 @@ func (s *Scope) Lookup(name string) Object {
 // time (see Insert). This can only happen for dot-imported objects
 // whose scope is the scope of the package that exported them.
 func (s *Scope) LookupParent(name string, pos token.Pos) (*Scope, Object) {
+	// Most identifiers denoting predeclared objects are not shadowed.
+	// Only scopes declaring such names need to be searched for them.
+	if obj := universeObject(name); obj != nil {
+		for ; s != nil; s = s.parent {
+			if s == Universe {
+				return s, obj
+			}
+			if s.shadows {
+				if obj := s.Lookup(name); obj != nil && (!pos.IsValid() || obj.scopePos() <= pos) {
+					return s, obj
+				}
+			}
+		}
+		return nil, nil
+	}
+
 	for ; s != nil; s = s.parent {
 		if obj := s.Lookup(name); obj != nil && (!pos.IsValid() || obj.scopePos() <= pos) {
 			return s, obj
@@ -95,8 +138,12 @@ func (s *Scope) LookupParent(name string, pos token.Pos) (*Scope, Object) {
 // If s already contains an alternative object alt with
 // the same name, Insert leaves s unchanged and returns alt.
 // Otherwise it inserts obj, sets the object's parent scope
-// if not already set, and returns nil.
+// if not already set, and returns nil. Insert panics if obj is a
+// built-in function created with NewBuiltin and s is not Universe.
 func (s *Scope) Insert(obj Object) Object {
+	if b, _ := obj.(*Builtin); b != nil && b.custom != nil && !s.isUniverse() {
+		panic(fmt.Sprintf("custom built-in %s inserted outside of the universe", b.name))
+	}
 	name := obj.Name()
 	if alt := s.Lookup(name); alt != nil {
 		return alt
@@ -116,18 +163,81 @@ func (s *Scope) Insert(obj Object) Object {
 // records the binding and returns true. The object's parent scope
 // will be set to s after resolve is called.
 func (s *Scope) _InsertLazy(name string, resolve func() Object) bool {
-	if s.elems[name] != nil {
+	if s.lookup(name) != nil {
 		return false
 	}
 	s.insert(name, &lazyObject{parent: s, resolve: resolve})
 	return true
 }
 
+// lookup returns the unresolved object with the given name in s, or nil.
+func (s *Scope) lookup(name string) Object {
+	if s.elems != nil {
+		return s.elems[name]
+	}
+	if i := s.search(name); i < len(s.small) && s.small[i].name == name {
+		return s.small[i].obj
+	}
+	return nil
+}
+
+// search returns the index of the first entry in s.small with a name >= name.
+func (s *Scope) search(name string) int {
+	i, j := 0, len(s.small)
+	for i < j {
+		h := int(uint(i+j) >> 1)
+		if s.small[h].name < name {
+			i = h + 1
+		} else {
+			j = h
+		}
+	}
+	return i
+}
+
 func (s *Scope) insert(name string, obj Object) {
-	if s.elems == nil {
-		s.elems = make(map[string]Object)
+	if s != Universe && universeObject(name) != nil {
+		s.shadows = true
+	}
+	if s.elems != nil {
+		s.elems[name] = obj
+		return
+	}
+
+	i := s.search(name)
+	if i < len(s.small) && s.small[i].name == name {
+		s.small[i].obj = obj
+		return
+	}
+
+	if len(s.small) < maxSmallScope {
+		s.small = append(s.small, scopeEntry{})
+		copy(s.small[i+1:], s.small[i:])
+		s.small[i] = scopeEntry{name, obj}
+		return
+	}
+
+	// s outgrew its small representation
+	s.elems = make(map[string]Object, 2*maxSmallScope)
+	for _, e := range s.small {
+		s.elems[e.name] = e.obj
 	}
 	s.elems[name] = obj
+	s.small = nil
+}
+
+// forEach calls f for each (unresolved) element of s, in unspecified order.
+// f must not modify s.
+func (s *Scope) forEach(f func(name string, obj Object)) {
+	if s.elems != nil {
+		for name, obj := range s.elems {
+			f(name, obj)
+		}
+		return
+	}
+	for _, e := range s.small {
+		f(e.name, e.obj)
+	}
 }
 
 // squash merges s with its parent scope p by adding all
@@ -139,13 +249,13 @@ func (s *Scope) insert(name string, obj Object) {
 func (s *Scope) squash(err func(obj, alt Object)) {
 	p := s.parent
 	assert(p != nil)
-	for name, obj := range s.elems {
+	s.forEach(func(name string, obj Object) {
 		obj = resolve(name, obj)
 		obj.setParent(nil)
 		if alt := p.Insert(obj); alt != nil {
 			err(obj, alt)
 		}
-	}
+	})
 
 	j := -1 // index of s in p.children
 	for i, ch := range p.children {
@@ -162,6 +272,7 @@ func (s *Scope) squash(err func(obj, alt Object)) {
 	p.children = append(p.children, s.children...)
 
 	s.children = nil
+	s.small = nil
 	s.elems = nil
 }
 
@@ -187,7 +298,7 @@ func (s *Scope) Contains(pos token.Pos) bool {
 func (s *Scope) Innermost(pos token.Pos) *Scope {
 	// Package scopes do not have extents since they may be
 	// discontiguous, so iterate over the package's files.
-	if s.parent == Universe {
+	if s.parent == Universe || s.parent != nil && s.parent.isUniv {
 		for _, s := range s.children {
 			if inner := s.Innermost(pos); inner != nil {
 				return inner
@@ -206,6 +317,151 @@ func (s *Scope) Innermost(pos token.Pos) *Scope {
 	return nil
 }
 
+// A ScopeKind describes the kind of a scope.
+type ScopeKind int
+
+const (
+	// UniverseScope is the kind of Universe and of the scope of the
+	// objects of Config.Predeclared.
+	UniverseScope ScopeKind = iota
+
+	// PackageScope is the kind of package scopes.
+	PackageScope
+
+	// FileScope is the kind of file scopes.
+	FileScope
+
+	// TypeParamScope is the kind of the scopes of the type parameters
+	// of generic type declarations.
+	TypeParamScope
+
+	// FuncScope is the kind of the scopes of function signatures and
+	// bodies, including those of function literals.
+	FuncScope
+
+	// BlockScope is the kind of the scopes of blocks and of statements
+	// with implicit blocks, such as if and for statements and case
+	// clauses.
+	BlockScope
+)
+
+// A ScopeLink describes an element of a scope chain (see InnermostChain).
+type ScopeLink struct {
+	Scope *Scope
+	Kind  ScopeKind
+
+	// Owner is the object whose declaration defines the scope, if any:
+	// the *Func of the function scope of a function, method, or
+	// interface method declared at package level, and the *TypeName of
+	// the type parameter scope of a generic type. It is nil for all
+	// other scopes, including those of function literals.
+	Owner Object
+}
+
+// InnermostChain returns the chain of scopes enclosing pos, from the
+// innermost scope containing pos (see Innermost) up to and including
+// Universe, together with their kinds and owning objects. If pos is not
+// within any scope, the result is nil.
+//
+// The owners of function and type parameter scopes are found among the
+// objects of the enclosing package scope, so the result is accurate
+// only once the package has been type-checked.
+func (s *Scope) InnermostChain(pos token.Pos) []ScopeLink {
+	inner := s.Innermost(pos)
+	if inner == nil {
+		return nil
+	}
+	var chain []ScopeLink
+	var pkgScope *Scope
+	for s := inner; s != nil; s = s.parent {
+		link := ScopeLink{Scope: s, Kind: s.kind()}
+		if link.Kind == PackageScope {
+			pkgScope = s
+		}
+		chain = append(chain, link)
+	}
+	if pkgScope != nil {
+		for i := range chain {
+			if k := chain[i].Kind; k == FuncScope || k == TypeParamScope {
+				chain[i].Owner = pkgScope.owner(chain[i].Scope)
+			}
+		}
+	}
+	return chain
+}
+
+// kind returns the kind of s, which must be a scope of the scope tree
+// of a package.
+func (s *Scope) kind() ScopeKind {
+	if s.isUniverse() {
+		return UniverseScope
+	}
+	n := 0 // number of scopes between s and the universe
+	for p := s.parent; p != nil && !p.isUniverse(); p = p.parent {
+		n++
+	}
+	switch {
+	case n == 0:
+		return PackageScope
+	case n == 1:
+		return FileScope
+	case s.isFunc:
+		return FuncScope
+	case n == 2:
+		// Apart from function scopes, type parameter scopes are the
+		// only children of file scopes.
+		return TypeParamScope
+	}
+	return BlockScope
+}
+
+// isUniverse reports whether s is Universe or the scope of the objects
+// of Config.Predeclared.
+func (s *Scope) isUniverse() bool {
+	return s == Universe || s != nil && s.isUniv
+}
+
+// owner returns the package-level object (or the method of a package-level
+// type) that owns the function or type parameter scope inner, or nil. The
+// receiver s must be a package scope.
+func (s *Scope) owner(inner *Scope) Object {
+	isOwner := func(f *Func) bool {
+		sig, _ := f.typ.(*Signature)
+		return sig != nil && sig.scope == inner
+	}
+	for _, name := range s.Names() {
+		switch obj := s.Lookup(name).(type) {
+		case *Func:
+			if isOwner(obj) {
+				return obj
+			}
+		case *TypeName:
+			named, _ := obj.typ.(*Named)
+			if named == nil || named.obj != obj {
+				continue
+			}
+			if named.tparams.Len() > 0 && named.tparams.At(0).obj.parent == inner {
+				return obj
+			}
+			for _, m := range named.methods {
+				if isOwner(m) {
+					return m
+				}
+			}
+			if iface, _ := named.underlying.(*Interface); iface != nil {
+				for _, m := range iface.methods {
+					if isOwner(m) {
+						return m
+					}
+				}
+			}
+		}
+	}
+	// Methods whose receiver base type is not a defined type are not
+	// recorded anywhere, and blank functions are not declared.
+	return nil
+}
+
 // WriteTo writes a string representation of the scope to w,
 // with the scope elements sorted by name.
 // The level of indentation is controlled by n >= 0, with
@@ -273,7 +529,7 @@ func resolve(name string, obj Object) Object {
 }
 
 // stub implementations so *lazyObject implements Object and we can
-// store them directly into Scope.elems.
+// store them directly into Scope.small and Scope.elems.
 func (*lazyObject) Parent() *Scope                        { panic("unreachable") }
 func (*lazyObject) Pos() token.Pos                        { panic("unreachable") }
 func (*lazyObject) Pkg() *Package                         { panic("unreachable") }
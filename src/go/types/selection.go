@@ -105,6 +105,107 @@ func (s *Selection) Index() []int { return s.index }
 // x to f in x.f.
 func (s *Selection) Indirect() bool { return s.indirect }
 
+// An Embedding describes an embedded field implicitly traversed in a
+// selector expression x.f.
+type Embedding struct {
+	Field    *Var // embedded field
+	Indirect bool // set if the field is of pointer type (*T)
+}
+
+// Path returns the embedded fields implicitly traversed to get from x to f
+// in x.f, starting at embedding depth 0; they correspond to the leading
+// entries of s.Index(). The result is empty if f is not promoted.
+// For a method in a method set, Path describes where the method was
+// promoted from.
+func (s *Selection) Path() []Embedding { return embeddingPath(s.recv, s.index) }
+
+// LookupPath looks up a field or method with given package and name in T
+// like LookupFieldOrMethod, and returns the embedded fields implicitly
+// traversed to get to it, as Selection.Path does for a selector expression
+// x.f with x of type T. It is useful for clients that build such access
+// paths without syntax, such as code generators.
+//
+// If no entry is found, obj and path are nil; indirect is as for
+// LookupFieldOrMethod.
+func LookupPath(T Type, addressable bool, pkg *Package, name string) (obj Object, path []Embedding, indirect bool) {
+	obj, index, indirect := LookupFieldOrMethod(T, addressable, pkg, name)
+	if obj == nil {
+		return nil, nil, indirect
+	}
+	return obj, embeddingPath(T, index), indirect
+}
+
+// embeddingPath returns the embedded fields denoted by the leading entries
+// of the index sequence starting at type recv.
+func embeddingPath(recv Type, index []int) []Embedding {
+	if len(index) <= 1 {
+		return nil
+	}
+	path := make([]Embedding, len(index)-1)
+	typ := recv
+	for i, j := range index[:len(index)-1] {
+		f := asStruct(derefStructPtr(typ)).fields[j]
+		_, ptr := deref(f.typ)
+		path[i] = Embedding{f, ptr}
+		typ = f.typ
+	}
+	return path
+}
+
+// An ImplicitOp describes an operation implicitly applied to the operand
+// of a step of a selector expression.
+type ImplicitOp int
+
+const (
+	ImplicitNone  ImplicitOp = iota // no implicit operation
+	ImplicitDeref                   // the operand p is implicitly dereferenced, as in (*p).f
+	ImplicitAddr                    // the address of the operand x is implicitly taken, as in (&x).m()
+)
+
+// Implicit returns the implicit operations applied in x.f, one for each
+// step: for 0 <= i < len(s.Path()), entry i describes the selection of the
+// embedded field s.Path()[i], and the last entry describes the selection
+// of f itself. The operand of the first step is x, and the operand of each
+// subsequent step is the field selected by the previous step.
+//
+// Selecting a field from (or a value method with) a pointer operand implies
+// a dereference; calling a pointer method with a non-pointer operand
+// implies taking its address. For method expressions, the operations are
+// those applied to the (first) argument of the method expression.
+func (s *Selection) Implicit() []ImplicitOp {
+	ops := make([]ImplicitOp, len(s.index))
+	typ := s.recv
+	for i, j := range s.index[:len(s.index)-1] {
+		if asPointer(typ) != nil {
+			ops[i] = ImplicitDeref
+		}
+		typ = asStruct(derefStructPtr(typ)).fields[j].typ
+	}
+
+	last := len(ops) - 1
+	f, _ := s.obj.(*Func)
+	if f == nil {
+		// field
+		if asPointer(typ) != nil {
+			ops[last] = ImplicitDeref
+		}
+		return ops
+	}
+
+	// Interface methods are called with the interface value as is.
+	base, isPtr := deref(typ)
+	if IsInterface(base) {
+		return ops
+	}
+	switch ptr := ptrRecv(f); {
+	case ptr && !isPtr:
+		ops[last] = ImplicitAddr
+	case !ptr && isPtr:
+		ops[last] = ImplicitDeref
+	}
+	return ops
+}
+
 func (s *Selection) String() string { return SelectionString(s, nil) }
 
 // SelectionString returns the string form of s.
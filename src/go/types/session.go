@@ -0,0 +1,289 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements incremental type checking of sets of packages.
+
+package types
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"sync"
+)
+
+// A Session type-checks a set of packages given as syntax trees and keeps
+// the results until the files of a package, or of a package it depends on,
+// change. Packages not in the session are imported with the importer of
+// the session's configuration, and cached by import path. All packages are
+// checked with the same Environment, so that identical instances are shared
+// among them.
+//
+// Packages are checked on demand: Check checks a package and the session
+// packages it imports if they have not been checked since they were last
+// invalidated, and does nothing otherwise. A Session is safe for concurrent
+// use; packages are checked one at a time.
+type Session struct {
+	// NewInfo, if set, is called each time the package with the given
+	// import path is checked, and returns the Info to record the results
+	// in, or nil.
+	NewInfo func(path string) *Info
+
+	// PrecomputeMethodSets, if set, causes the method sets of T and *T
+	// for each non-generic package-level defined type T of a checked
+	// package to be computed on background goroutines after the package
+	// is checked. They are recorded in the session's Environment, so that
+	// later queries through Environment.MethodSet are cheap.
+	PrecomputeMethodSets bool
+
+	conf Config
+	fset *token.FileSet
+
+	mu      sync.Mutex
+	pkgs    map[string]*sessionPackage // packages of the session, by import path
+	imports map[string]*Package        // cache of imported packages not in the session
+	stack   []string                   // import paths of the packages being checked
+	bg      sync.WaitGroup             // background computations; see Wait
+}
+
+// A sessionPackage holds the files of a package of a session
+// and the results of checking them.
+type sessionPackage struct {
+	files   []*ast.File
+	checked bool // pkg, info, err, and deps are current
+	pkg     *Package
+	info    *Info
+	err     error
+	deps    map[string]bool // import paths of the imported packages
+}
+
+// NewSession returns a new, empty session that checks packages with the
+// configuration conf and the file set fset of their syntax trees. If conf
+// has no Environment, the session creates one. The configuration is copied
+// and must not be used for checking packages of the session otherwise.
+func NewSession(conf *Config, fset *token.FileSet) *Session {
+	s := &Session{
+		conf:    *conf,
+		fset:    fset,
+		pkgs:    make(map[string]*sessionPackage),
+		imports: make(map[string]*Package),
+	}
+	if s.conf.Environment == nil {
+		s.conf.Environment = NewEnvironment()
+	}
+	return s
+}
+
+// Environment returns the environment shared by the packages of s.
+func (s *Session) Environment() *Environment {
+	return s.conf.Environment
+}
+
+// SetFiles adds the package with the given import path and files to s, or
+// replaces the files of the package if it is in s already, and invalidates
+// the package and its dependents. It returns the import paths of the
+// invalidated packages, in sorted order.
+func (s *Session) SetFiles(path string, files []*ast.File) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.pkgs[path]
+	if p == nil {
+		p = new(sessionPackage)
+		s.pkgs[path] = p
+	}
+	p.files = files
+	return s.invalidate(path)
+}
+
+// Remove removes the package with the given import path from s and
+// invalidates its dependents, which then import the package with the
+// importer of the session's configuration. It returns the import paths of
+// the invalidated packages, in sorted order.
+func (s *Session) Remove(path string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invalidated := s.invalidate(path)
+	delete(s.pkgs, path)
+	return invalidated
+}
+
+// Invalidate invalidates the package with the given import path and its
+// dependents, for instance because the package is not in s and its export
+// data changed. It returns the import paths of the invalidated packages
+// of s, in sorted order.
+func (s *Session) Invalidate(path string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.invalidate(path)
+}
+
+func (s *Session) invalidate(path string) []string {
+	var invalidated []string
+	seen := make(map[string]bool)
+	var visit func(path string)
+	visit = func(path string) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		delete(s.imports, path)
+		if p := s.pkgs[path]; p != nil {
+			if p.pkg != nil {
+				s.conf.Environment.forgetEvals(p.pkg)
+			}
+			p.checked = false
+			p.pkg, p.info, p.err, p.deps = nil, nil, nil, nil
+			invalidated = append(invalidated, path)
+		}
+		for dpath, p := range s.pkgs {
+			if p.deps[path] {
+				visit(dpath)
+			}
+		}
+	}
+	visit(path)
+	sort.Strings(invalidated)
+	return invalidated
+}
+
+// Check returns the type-checked package with the given import path in s,
+// and the Info recorded for it, if any. The package and the packages of s
+// it imports are checked if they were invalidated since they were last
+// checked. The error is the first error reported for the package as by
+// Config.Check, but checking always continues after errors, which are
+// reported to the Error function of the session's configuration, if any,
+// so that packages with errors can be imported by their dependents.
+func (s *Session) Check(path string) (*Package, *Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.pkgs[path]
+	if p == nil {
+		return nil, nil, fmt.Errorf("package %s is not in the session", path)
+	}
+	s.check(path, p)
+	return p.pkg, p.info, p.err
+}
+
+// check checks the package p with the given import path unless it is current.
+func (s *Session) check(path string, p *sessionPackage) {
+	if p.checked {
+		return
+	}
+	if s.NewInfo != nil {
+		p.info = s.NewInfo(path)
+	}
+	p.deps = make(map[string]bool)
+
+	s.stack = append(s.stack, path)
+	conf := s.conf
+	conf.Importer = sessionImporter{s, p}
+	conf.ConcurrentImports = false // the session is not safe for concurrent imports
+	// Keep checking after errors, so that the package is complete
+	// and can be imported by its dependents.
+	conf.Error = func(err error) {
+		if s.conf.Error != nil {
+			s.conf.Error(err)
+		}
+	}
+	p.pkg, p.err = conf.Check(path, s.fset, p.files, p.info)
+	s.stack = s.stack[:len(s.stack)-1]
+	p.checked = true
+	if p.pkg != nil && p.pkg.deps != nil {
+		// The session importer must not be used outside of Check.
+		p.pkg.deps.importer = s.conf.Importer
+	}
+
+	if s.PrecomputeMethodSets && p.pkg != nil {
+		s.precomputeMethodSets(p.pkg)
+	}
+}
+
+// precomputeMethodSets starts computing the method sets of the
+// package-level types of pkg in the background.
+func (s *Session) precomputeMethodSets(pkg *Package) {
+	var types []*Named
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if obj, _ := scope.Lookup(name).(*TypeName); obj != nil && !obj.IsAlias() {
+			if t, _ := obj.typ.(*Named); t != nil && t.TypeParams().Len() == 0 {
+				types = append(types, t)
+			}
+		}
+	}
+	if len(types) == 0 {
+		return
+	}
+
+	env := s.conf.Environment
+	s.bg.Add(1)
+	go func() {
+		defer s.bg.Done()
+		s.conf.parallel(len(types), func(i int) {
+			env.MethodSet(types[i])
+			env.MethodSet(NewPointer(types[i]))
+		})
+	}()
+}
+
+// Wait waits for the background computations started by Check, if any,
+// to finish.
+func (s *Session) Wait() {
+	s.bg.Wait()
+}
+
+// A sessionImporter imports the packages imported by the package p
+// of session s, and records them as dependencies of p.
+type sessionImporter struct {
+	s *Session
+	p *sessionPackage
+}
+
+func (imp sessionImporter) Import(path string) (*Package, error) {
+	return imp.ImportFrom(path, "", 0)
+}
+
+func (imp sessionImporter) ImportFrom(path, dir string, mode ImportMode) (*Package, error) {
+	s := imp.s
+	imp.p.deps[path] = true
+
+	if p := s.pkgs[path]; p != nil {
+		for i, spath := range s.stack {
+			if spath == path {
+				cycle := append(append([]string(nil), s.stack[i:]...), path)
+				return nil, &ImportCycleError{Cycle: cycle}
+			}
+		}
+		s.check(path, p)
+		if p.pkg == nil {
+			return nil, p.err
+		}
+		// Like an importer, return the package even if the
+		// package has errors, so that only the package is reported
+		// as erroneous, not every use of it.
+		return p.pkg, nil
+	}
+
+	if pkg := s.imports[path]; pkg != nil {
+		return pkg, nil
+	}
+	var pkg *Package
+	var err error
+	switch importer := s.conf.Importer.(type) {
+	case nil:
+		err = fmt.Errorf("package %s is not in the session and there is no importer", path)
+	case ImporterFrom:
+		pkg, err = importer.ImportFrom(path, dir, mode)
+	default:
+		pkg, err = importer.Import(path)
+	}
+	if err == nil && pkg != nil {
+		s.imports[path] = pkg
+	}
+	return pkg, err
+}
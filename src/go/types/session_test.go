@@ -0,0 +1,230 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"errors"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "go/types"
+)
+
+func TestSession(t *testing.T) {
+	fset := token.NewFileSet()
+	parse := func(src string) []*ast.File {
+		f, err := parser.ParseFile(fset, "", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []*ast.File{f}
+	}
+
+	var checked []string
+	s := NewSession(&Config{Importer: importer.Default()}, fset)
+	s.NewInfo = func(path string) *Info {
+		checked = append(checked, path)
+		return &Info{Defs: make(map[*ast.Ident]Object)}
+	}
+	s.SetFiles("a", parse(`package a; import "strings"; type T = strings.Builder`))
+	s.SetFiles("b", parse(`package b; import "a"; var V a.T`))
+	s.SetFiles("c", parse(`package c; import "b"; var W = b.V`))
+	s.SetFiles("d", parse(`package d; const C = 0`))
+
+	check := func(path string, want ...string) *Package {
+		t.Helper()
+		checked = nil
+		pkg, info, err := s.Check(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(checked, want) {
+			t.Errorf("checking %s checked %v, want %v", path, checked, want)
+		}
+		if info == nil || len(info.Defs) == 0 {
+			t.Errorf("no info recorded for %s", path)
+		}
+		return pkg
+	}
+
+	c := check("c", "c", "b", "a")
+	check("c")
+	check("d", "d")
+
+	// Changing a package invalidates its dependents only, and the
+	// results of the other packages are shared.
+	if got, want := s.SetFiles("b", parse(`package b; import "a"; var V *a.T`)), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SetFiles invalidated %v, want %v", got, want)
+	}
+	c2 := check("c", "c", "b")
+	if got := c2.Scope().Lookup("W").Type().String(); got != "*strings.Builder" {
+		t.Errorf("c.W has type %s after change, want *strings.Builder", got)
+	}
+	if c2 == c {
+		t.Errorf("c was not checked again")
+	}
+	a, _, _ := s.Check("a")
+	if c2.Imports()[0].Imports()[0] != a {
+		t.Errorf("b imports a different package a than the one in the session")
+	}
+	if got := s.Invalidate("d"); !reflect.DeepEqual(got, []string{"d"}) {
+		t.Errorf("Invalidate(d) invalidated %v, want [d]", got)
+	}
+
+	// Errors in dependencies are reported for the package with errors.
+	s.SetFiles("a", parse(`package a; type T = undefined`))
+	if _, _, err := s.Check("c"); err != nil {
+		t.Errorf("checking c with errors in a failed: %v", err)
+	}
+	if _, _, err := s.Check("a"); err == nil || !strings.Contains(err.Error(), "undeclared name: undefined") {
+		t.Errorf("got error %v for a, want undeclared name", err)
+	}
+
+	// Import cycles among session packages are reported
+	// for the package closing the cycle.
+	s.SetFiles("a", parse(`package a; import "c"; var _ = c.W`))
+	if _, _, err := s.Check("a"); err != nil {
+		t.Errorf("checking a failed: %v", err)
+	}
+	_, _, err := s.Check("b")
+	var terr Error
+	if !errors.As(err, &terr) || !reflect.DeepEqual(terr.ImportCycle, []string{"b", "a", "c", "b"}) {
+		t.Errorf("got error %v, want import cycle b -> a -> c -> b", err)
+	}
+
+	// Removed packages are imported with the configured importer.
+	if got, want := s.Remove("a"), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Remove invalidated %v, want %v", got, want)
+	}
+	if _, _, err := s.Check("b"); err == nil || !strings.Contains(err.Error(), `could not import a`) {
+		t.Errorf("got error %v after removing a, want import error", err)
+	}
+	if _, _, err := s.Check("a"); err == nil {
+		t.Errorf("checking removed package a succeeded")
+	}
+}
+
+func TestSessionPrecomputeMethodSets(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", `package p
+type T struct{ E }
+type E struct{}
+func (E) M() {}
+func (*T) N() {}
+type G[P any] struct{}
+func (G[P]) M() {}
+`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSession(&Config{}, fset)
+	s.PrecomputeMethodSets = true
+	s.SetFiles("p", []*ast.File{f})
+	pkg, _, err := s.Check("p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Wait()
+
+	env := s.Environment()
+	T := pkg.Scope().Lookup("T").Type()
+	if mset := env.MethodSet(T); mset.Len() != 1 || mset.At(0).Obj().Name() != "M" {
+		t.Errorf("got %s, want method M", mset)
+	}
+	mset := env.MethodSet(NewPointer(T))
+	if mset.Len() != 2 {
+		t.Errorf("got %s, want 2 methods", mset)
+	}
+	if env.MethodSet(NewPointer(T)) != mset {
+		t.Errorf("method set of *T is not memoized")
+	}
+}
+
+func TestSessionSnapshot(t *testing.T) {
+	fset := token.NewFileSet()
+	parse := func(src string) []*ast.File {
+		f, err := parser.ParseFile(fset, "", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []*ast.File{f}
+	}
+
+	var checked []string
+	s := NewSession(&Config{}, fset)
+	s.NewInfo = func(path string) *Info {
+		checked = append(checked, path)
+		return nil
+	}
+	s.SetFiles("a", parse(`package a; type G[P any] struct{ f P }; var X G[int]`))
+	s.SetFiles("b", parse(`package b; import "a"; var Y = a.X`))
+	b, _, err := s.Check("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := b.Imports()[0]
+	G := a.Scope().Lookup("G").Type()
+	env := s.Environment()
+	inst := func(targ Type) Type {
+		t.Helper()
+		res, err := Instantiate(env, G, []Type{targ}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	gint := inst(Typ[Int])
+
+	// Check a hypothetical edit of a.
+	snap := s.Snapshot()
+	s.SetFiles("a", parse(`package a; type G[P any] struct{ f P }; var X G[string]`))
+	b2, _, err := s.Check("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b2.Scope().Lookup("Y").Type().String(); got != "a.G[string]" {
+		t.Errorf("type of Y after edit = %s, want a.G[string]", got)
+	}
+	gstring := inst(Typ[String])
+	if inst(Typ[String]) != gstring {
+		t.Errorf("instances are not shared after the snapshot")
+	}
+	s.Restore(snap)
+
+	// The results of checking are those before the edit, and the
+	// instances recorded since the snapshot are discarded.
+	checked = nil
+	if got, _, _ := s.Check("b"); got != b || len(checked) != 0 {
+		t.Errorf("Check(b) after Restore returned %p and checked %v, want %p and nothing checked", got, checked, b)
+	}
+	if inst(Typ[Int]) != gint {
+		t.Errorf("instance G[int] from before the snapshot is no longer shared")
+	}
+	if inst(Typ[String]) == gstring {
+		t.Errorf("instance G[string] from after the snapshot is still shared")
+	}
+
+	// A released snapshot keeps the state, and can't be restored.
+	snap = s.Snapshot()
+	s.Invalidate("a")
+	s.Release(snap)
+	checked = nil
+	s.Check("b")
+	if !reflect.DeepEqual(checked, []string{"b", "a"}) {
+		t.Errorf("Check(b) after Release checked %v, want [b a]", checked)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Restore of a released snapshot did not panic")
+		}
+	}()
+	s.Restore(snap)
+}
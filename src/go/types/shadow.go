@@ -0,0 +1,52 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Config.ReportShadowing.
+
+package types
+
+import "go/ast"
+
+// reportShadowing reports a warning if the local object obj, which has just
+// been declared, shadows a local object of an enclosing block. The variable
+// init is the initialization expression of obj, if it is a variable that is
+// initialized by a single value.
+func (check *Checker) reportShadowing(obj Object, init ast.Expr) {
+	if !check.conf.ReportShadowing || obj.Name() == "_" || obj.Parent() == nil {
+		return // obj was not declared
+	}
+	name := obj.Name()
+	for _, exempt := range check.conf.ShadowingExempt {
+		if name == exempt {
+			return
+		}
+	}
+
+	s, alt := obj.Parent().parent.LookupParent(name, obj.Pos())
+	if alt == nil {
+		return
+	}
+	if k := s.kind(); k != FuncScope && k != BlockScope {
+		return // package-level objects and imports may be shadowed
+	}
+
+	// x := x deliberately copies x.
+	if id, _ := unparen(init).(*ast.Ident); id != nil && id.Name == name {
+		return
+	}
+
+	check.warnf(obj, _ShadowedDecl, "declaration of %s shadows declaration at %s", name, check.fset.Position(alt.Pos()))
+}
+
+// reportShadowedParams reports the parameters of the function literal with
+// signature sig that shadow local objects, as for reportShadowing. The
+// parameters of function types, including those of interface methods,
+// can't be referred to, so they are not reported.
+func (check *Checker) reportShadowedParams(sig *Signature) {
+	for _, list := range []*Tuple{sig.params, sig.results} {
+		for i := 0; i < list.Len(); i++ {
+			check.reportShadowing(list.At(i), nil)
+		}
+	}
+}
@@ -34,7 +34,19 @@ type Signature struct {
 // and results, either of which may be nil. If variadic is set, the function
 // is variadic, it must have at least one parameter, and the last parameter
 // must be of unnamed slice type.
+//
+// Deprecated: Use NewSignatureType instead which allows for type parameters.
 func NewSignature(recv *Var, params, results *Tuple, variadic bool) *Signature {
+	return NewSignatureType(recv, nil, nil, params, results, variadic)
+}
+
+// NewSignatureType creates a new function type for the given receiver,
+// receiver type parameters, type parameters, parameters, and results. If
+// variadic is set, params must hold at least one parameter and the last
+// parameter must be of unnamed slice type. If recv is non-nil, typeParams
+// must be empty. If recvTypeParams is non-empty, recv must be non-nil.
+// The type parameters must not be bound to another type or function yet.
+func NewSignatureType(recv *Var, recvTypeParams, typeParams []*TypeParam, params, results *Tuple, variadic bool) *Signature {
 	if variadic {
 		n := params.Len()
 		if n == 0 {
@@ -44,7 +56,20 @@ func NewSignature(recv *Var, params, results *Tuple, variadic bool) *Signature {
 			panic("variadic parameter must be of unnamed slice type")
 		}
 	}
-	return &Signature{recv: recv, params: params, results: results, variadic: variadic}
+	sig := &Signature{recv: recv, params: params, results: results, variadic: variadic}
+	if len(recvTypeParams) != 0 {
+		if recv == nil {
+			panic("function with receiver type parameters must have a receiver")
+		}
+		sig.rparams = bindTParams(recvTypeParams)
+	}
+	if len(typeParams) != 0 {
+		if recv != nil {
+			panic("function with type parameters cannot have a receiver")
+		}
+		sig.tparams = bindTParams(typeParams)
+	}
+	return sig
 }
 
 // Recv returns the receiver of signature s (if a method), or nil if a
@@ -152,12 +177,20 @@ func (check *Checker) funcType(sig *Signature, recvPar *ast.FieldList, ftyp *ast
 	}
 
 	if ftyp.TypeParams != nil {
-		check.collectTypeParams(&sig.tparams, ftyp.TypeParams)
+		check.collectTypeParams(&sig.tparams, ftyp.TypeParams, recvPar == nil)
 		// Always type-check method type parameters but complain that they are not allowed.
 		// (A separate check is needed when type-checking interface method signatures because
 		// they don't have a receiver specification.)
 		if recvPar != nil {
-			check.errorf(ftyp.TypeParams, _Todo, "methods cannot have type parameters")
+			if !check.conf.Experiments.GenericMethods {
+				check.errorf(ftyp.TypeParams, _Todo, "methods cannot have type parameters")
+			} else if rparams := sig.RecvTypeParams().list(); len(rparams) > 0 {
+				for _, tpar := range sig.TypeParams().list() {
+					if isParameterized(rparams, tpar.bound) {
+						check.errorf(atPos(tpar.obj.pos), _Todo, "constraint of method type parameter %s must not refer to receiver type parameters", tpar.obj.name)
+					}
+				}
+			}
 		}
 	}
 
@@ -198,7 +231,7 @@ func (check *Checker) funcType(sig *Signature, recvPar *ast.FieldList, ftyp *ast
 		// (ignore invalid types - error was reported before)
 		if rtyp != Typ[Invalid] {
 			var err string
-			switch T := rtyp.(type) {
+			switch T := Unalias(rtyp).(type) {
 			case *Named:
 				T.expand(nil)
 				// The receiver type may be an instantiated type referred to
@@ -270,7 +303,16 @@ func (check *Checker) collectParams(scope *Scope, list *ast.FieldList, type0 ast
 				// ignore ... and continue
 			}
 		}
-		typ := check.varType(ftype)
+		var typ Type
+		if variadic {
+			// ftype may be a variadic type parameter
+			old := check.variadicElt
+			check.variadicElt = ftype
+			typ = check.varType(ftype)
+			check.variadicElt = old
+		} else {
+			typ = check.varType(ftype)
+		}
 		// The parser ensures that f.Tag is nil and we don't
 		// care if a constructed AST contains a non-nil tag.
 		if len(field.Names) > 0 {
@@ -22,16 +22,17 @@ func TestSizeof(t *testing.T) {
 		{Basic{}, 16, 32},
 		{Array{}, 16, 24},
 		{Slice{}, 8, 16},
-		{Struct{}, 24, 48},
+		{Struct{}, 28, 56},
 		{Pointer{}, 8, 16},
 		{Tuple{}, 12, 24},
 		{Signature{}, 28, 56},
 		{Union{}, 16, 32},
-		{Interface{}, 44, 88},
+		{Interface{}, 48, 96},
 		{Map{}, 16, 32},
 		{Chan{}, 12, 24},
-		{Named{}, 72, 136},
-		{TypeParam{}, 28, 48},
+		{Alias{}, 20, 40},
+		{Named{}, 80, 144},
+		{TypeParam{}, 40, 64},
 		{term{}, 12, 24},
 		{top{}, 0, 0},
 
@@ -42,13 +43,13 @@ func TestSizeof(t *testing.T) {
 		{Var{}, 44, 80},
 		{Func{}, 44, 80},
 		{Label{}, 44, 80},
-		{Builtin{}, 44, 80},
+		{Builtin{}, 48, 88},
 		{Nil{}, 40, 72},
 
 		// Misc
-		{Scope{}, 44, 88},
-		{Package{}, 40, 80},
-		{_TypeSet{}, 28, 56},
+		{Scope{}, 56, 112},
+		{Package{}, 56, 104},
+		{_TypeSet{}, 56, 112},
 	}
 	for _, test := range tests {
 		got := reflect.TypeOf(test.val).Size()
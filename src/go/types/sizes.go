@@ -6,6 +6,11 @@
 
 package types
 
+import (
+	"sort"
+	"sync"
+)
+
 // Sizes defines the sizing functions for package unsafe.
 type Sizes interface {
 	// Alignof returns the alignment of a variable of type T.
@@ -38,6 +43,10 @@ type Sizes interface {
 //	- Arrays and structs are aligned per spec definition; all other
 //	  types are naturally aligned with a maximum alignment MaxAlign.
 //
+// Because of these simplifications, the sizes of some struct and array
+// types differ from those chosen by the gc compiler; GCSizes computes
+// the exact ones.
+//
 // *StdSizes implements Sizes.
 //
 type StdSizes struct {
@@ -185,6 +194,9 @@ var gcArchSizes = map[string]*StdSizes{
 // Supported architectures for compiler "gc":
 // "386", "arm", "arm64", "amd64", "amd64p32", "mips", "mipsle",
 // "mips64", "mips64le", "ppc64", "ppc64le", "riscv64", "s390x", "sparc64", "wasm".
+//
+// The Sizes for compiler "gc" are StdSizes, which differ from the layout
+// chosen by gc for some struct types; GCSizesFor returns exact Sizes.
 func SizesFor(compiler, arch string) Sizes {
 	var m map[string]*StdSizes
 	switch compiler {
@@ -202,6 +214,184 @@ func SizesFor(compiler, arch string) Sizes {
 	return s
 }
 
+// OptimalFieldOrder returns an order of the fields of struct s that
+// minimizes the size of s as computed by sizes (or GCSizesFor("amd64") if
+// sizes is nil), by avoiding padding between fields. The order is given
+// as a permutation of field indices: the i'th field of the reordered struct
+// is s.Field(order[i]). The results size and optimal are the sizes of s
+// with its fields in declaration order and in the returned order; they
+// include the padding at the end of s needed to make them a multiple of
+// the alignment of s, as in an array of s, even if sizes doesn't. If no
+// other order is smaller, order is the declaration order.
+// The fields of s must not be (or contain) type parameters.
+func OptimalFieldOrder(sizes Sizes, s *Struct) (order []int, size, optimal int64) {
+	if sizes == nil {
+		sizes = GCSizesFor("amd64")
+	}
+	order = make([]int, s.NumFields())
+	for i := range order {
+		order[i] = i
+	}
+	size = paddedSizeof(sizes, s)
+
+	// Zero-sized fields come first, followed by the other fields in order
+	// of decreasing alignment. Since the size of a type is a multiple of
+	// its alignment, this leaves no padding between fields for the usual
+	// (power of 2) alignments.
+	fields := s.fields
+	sorted := make([]int, len(order))
+	copy(sorted, order)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		fi, fj := fields[sorted[i]].typ, fields[sorted[j]].typ
+		zi, zj := sizes.Sizeof(fi) == 0, sizes.Sizeof(fj) == 0
+		if zi != zj {
+			return zi
+		}
+		return sizes.Alignof(fi) > sizes.Alignof(fj)
+	})
+
+	reordered := make([]*Var, len(sorted))
+	for i, j := range sorted {
+		reordered[i] = fields[j]
+	}
+	optimal = paddedSizeof(sizes, &Struct{fields: reordered})
+	if optimal >= size {
+		return order, size, size
+	}
+	return sorted, size, optimal
+}
+
+// paddedSizeof returns the size of s as computed by sizes, rounded up to
+// a multiple of the alignment of s.
+func paddedSizeof(sizes Sizes, s *Struct) int64 {
+	return align(sizes.Sizeof(s), sizes.Alignof(s))
+}
+
+// FieldOrderSavings returns, for each architecture supported by SizesFor
+// for the given compiler, the number of bytes by which the size of struct s
+// shrinks if its fields are ordered as reported by OptimalFieldOrder.
+// For the gc compiler, sizes are computed with GCSizes.
+// Architectures for which there are no savings are omitted; the result is
+// nil if the compiler is unknown. The fields of s must not be (or contain)
+// type parameters.
+func FieldOrderSavings(compiler string, s *Struct) map[string]int64 {
+	var m map[string]*StdSizes
+	switch compiler {
+	case "gc":
+		m = gcArchSizes
+	case "gccgo":
+		m = gccgoArchSizes
+	default:
+		return nil
+	}
+	savings := make(map[string]int64)
+	for arch, std := range m {
+		var sizes Sizes = std
+		if compiler == "gc" {
+			sizes = &GCSizes{std.WordSize, std.MaxAlign}
+		}
+		if _, size, optimal := OptimalFieldOrder(sizes, s); optimal < size {
+			savings[arch] = size - optimal
+		}
+	}
+	return savings
+}
+
+// A LayoutCache memoizes the sizes, alignments, and struct field offsets
+// computed by a Sizes, for tools that query the layout of many types.
+// If the Sizes is a *GCSizes, the layouts of component types (such as
+// the fields of a struct) are memoized as well. Types are memoized by
+// identity, not by type identity.
+//
+// *LayoutCache implements Sizes. It is safe for concurrent use.
+type LayoutCache struct {
+	sizes   Sizes
+	gc      *GCSizes // sizes, if it is a *GCSizes
+	aligns  sync.Map // Type -> int64
+	sizeofs sync.Map // Type -> int64
+	offsets sync.Map // *Struct -> []int64
+}
+
+// NewLayoutCache returns a new LayoutCache for sizes, or for
+// SizesFor("gc", "amd64") if sizes is nil.
+func NewLayoutCache(sizes Sizes) *LayoutCache {
+	if sizes == nil {
+		sizes = stdSizes
+	}
+	c := &LayoutCache{sizes: sizes}
+	c.gc, _ = sizes.(*GCSizes)
+	return c
+}
+
+func (c *LayoutCache) Alignof(T Type) int64 {
+	if a, ok := c.aligns.Load(T); ok {
+		return a.(int64)
+	}
+	var a int64
+	if c.gc != nil {
+		a = c.gc.alignof(c, T)
+	} else {
+		a = c.sizes.Alignof(T)
+	}
+	c.aligns.Store(T, a)
+	return a
+}
+
+// Offsetsof returns the offsets of the given struct fields. Unlike the
+// offsets of the fields of a struct type (see Layouts), they are not
+// memoized, but the layouts of the field types are.
+func (c *LayoutCache) Offsetsof(fields []*Var) []int64 {
+	if c.gc != nil {
+		return c.gc.offsetsof(c, fields)
+	}
+	return c.sizes.Offsetsof(fields)
+}
+
+func (c *LayoutCache) Sizeof(T Type) int64 {
+	if z, ok := c.sizeofs.Load(T); ok {
+		return z.(int64)
+	}
+	var z int64
+	if c.gc != nil {
+		z = c.gc.sizeof(c, T)
+	} else {
+		z = c.sizes.Sizeof(T)
+	}
+	c.sizeofs.Store(T, z)
+	return z
+}
+
+func (c *LayoutCache) structOffsets(t *Struct) []int64 {
+	if o, ok := c.offsets.Load(t); ok {
+		return o.([]int64)
+	}
+	o := c.Offsetsof(t.fields)
+	c.offsets.Store(t, o)
+	return o
+}
+
+// A Layout describes the memory layout of a type.
+type Layout struct {
+	Size    int64
+	Align   int64
+	Offsets []int64 // offsets of the fields, for struct types; must not be modified
+}
+
+// Layouts returns the layouts of the given types, in order. The types
+// must not be (or contain) type parameters.
+func (c *LayoutCache) Layouts(types []Type) []Layout {
+	layouts := make([]Layout, len(types))
+	for i, T := range types {
+		l := &layouts[i]
+		l.Size = c.Sizeof(T)
+		l.Align = c.Alignof(T)
+		if s, _ := under(T).(*Struct); s != nil {
+			l.Offsets = c.structOffsets(s)
+		}
+	}
+	return layouts
+}
+
 // stdSizes is used if Config.Sizes == nil.
 var stdSizes = SizesFor("gc", "amd64")
 
@@ -7,11 +7,19 @@
 package types_test
 
 import (
+	"fmt"
 	"go/ast"
 	"go/importer"
 	"go/parser"
 	"go/token"
 	"go/types"
+	"internal/testenv"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -110,3 +118,243 @@ const _ = unsafe.Offsetof(struct{ x int64 }{}.x)
 		_ = conf.Sizes.Alignof(tv.Type)
 	}
 }
+
+func TestOptimalFieldOrder(t *testing.T) {
+	const src = `
+package main
+
+type S struct {
+	a bool
+	b int64
+	c bool
+	d int32
+	e [0]int64
+	f string
+}
+`
+	ts := findStructType(t, src)
+
+	order, size, optimal := types.OptimalFieldOrder(types.GCSizesFor("amd64"), ts)
+	if want := []int{4, 1, 5, 3, 0, 2}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if size != 40 || optimal != 32 {
+		t.Errorf("size, optimal = %d, %d, want 40, 32", size, optimal)
+	}
+
+	// The sizes include trailing padding even if the Sizes don't.
+	order, size, optimal = types.OptimalFieldOrder(types.SizesFor("gc", "amd64"), ts)
+	if size != 40 || optimal != 32 {
+		t.Errorf("StdSizes: size, optimal = %d, %d, want 40, 32 (order %v)", size, optimal, order)
+	}
+
+	order, size, optimal = types.OptimalFieldOrder(types.GCSizesFor("386"), ts)
+	if size != 28 || optimal != 24 {
+		t.Errorf("386: size, optimal = %d, %d, want 28, 24 (order %v)", size, optimal, order)
+	}
+
+	savings := types.FieldOrderSavings("gc", ts)
+	if got := savings["amd64"]; got != 8 {
+		t.Errorf("amd64 savings = %d, want 8", got)
+	}
+	if got := savings["386"]; got != 4 {
+		t.Errorf("386 savings = %d, want 4", got)
+	}
+	if types.FieldOrderSavings("unknown", ts) != nil {
+		t.Errorf("savings for unknown compiler not nil")
+	}
+
+	// an optimally ordered struct is left alone
+	ts = findStructType(t, "package main; type T struct { s string; i int32; b bool }")
+	if order, size, optimal := types.OptimalFieldOrder(nil, ts); !reflect.DeepEqual(order, []int{0, 1, 2}) || size != optimal {
+		t.Errorf("got order %v, sizes %d, %d; want declaration order", order, size, optimal)
+	}
+	if savings := types.FieldOrderSavings("gc", ts); len(savings) != 0 {
+		t.Errorf("got savings %v, want none", savings)
+	}
+}
+
+func TestGCSizes(t *testing.T) {
+	sizes := types.GCSizesFor(runtime.GOARCH)
+	if sizes == nil {
+		t.Skipf("no GCSizes for %s", runtime.GOARCH)
+	}
+
+	const src = `
+package p
+
+type (
+	S1 struct { a int64; b byte }
+	S2 struct { a byte; b struct{} }
+	S3 struct { a struct{}; b [0]int64 }
+	S4 struct { a byte; b S1; c [3]S2 }
+	S5 [2]struct { a int32; b int16 }
+	S6 struct { a []S1; b complex128; c bool; d S3 }
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conf types.Config
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type (
+		S1 struct {
+			a int64
+			b byte
+		}
+		S2 struct {
+			a byte
+			b struct{}
+		}
+		S3 struct {
+			a struct{}
+			b [0]int64
+		}
+		S4 struct {
+			a byte
+			b S1
+			c [3]S2
+		}
+		S5 [2]struct {
+			a int32
+			b int16
+		}
+		S6 struct {
+			a []S1
+			b complex128
+			c bool
+			d S3
+		}
+	)
+	rtypes := []reflect.Type{
+		reflect.TypeOf(S1{}),
+		reflect.TypeOf(S2{}),
+		reflect.TypeOf(S3{}),
+		reflect.TypeOf(S4{}),
+		reflect.TypeOf(S5{}),
+		reflect.TypeOf(S6{}),
+	}
+
+	var typs []types.Type
+	for i := range rtypes {
+		typs = append(typs, pkg.Scope().Lookup(fmt.Sprintf("S%d", i+1)).Type())
+	}
+	cache := types.NewLayoutCache(sizes)
+	layouts := cache.Layouts(typs)
+	for i, rt := range rtypes {
+		T := typs[i]
+		l := layouts[i]
+		if got, want := sizes.Sizeof(T), int64(rt.Size()); got != want || l.Size != want {
+			t.Errorf("Sizeof(%s) = %d (cached %d), want %d", T, got, l.Size, want)
+		}
+		if got, want := sizes.Alignof(T), int64(rt.Align()); got != want || l.Align != want {
+			t.Errorf("Alignof(%s) = %d (cached %d), want %d", T, got, l.Align, want)
+		}
+		if rt.Kind() != reflect.Struct {
+			continue
+		}
+		var want []int64
+		for j := 0; j < rt.NumField(); j++ {
+			want = append(want, int64(rt.Field(j).Offset))
+		}
+		if !reflect.DeepEqual(l.Offsets, want) {
+			t.Errorf("offsets of %s = %v, want %v", T, l.Offsets, want)
+		}
+	}
+
+	// The layouts are memoized.
+	if l := cache.Layouts(typs[:1]); &l[0].Offsets[0] != &layouts[0].Offsets[0] {
+		t.Errorf("offsets of %s are not memoized", typs[0])
+	}
+
+	if types.GCSizesFor("unknown") != nil {
+		t.Errorf("GCSizes for unknown architecture not nil")
+	}
+}
+
+// TestGCSizesMatchCompiler verifies that GCSizes computes the same sizes,
+// alignments, and offsets as the compiler, for all architectures.
+func TestGCSizesMatchCompiler(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+
+	const src = `
+package p
+
+import "unsafe"
+
+type (
+	T1 struct { a int64; b byte }
+	T2 struct { a byte; b struct{} }
+	T3 struct { a struct{}; b [0]int64 }
+	T4 [3]struct { a int16; b byte }
+	T5 struct { a byte; b complex128; c [2]T2 }
+	T6 struct { a string; b []int; c interface{}; d map[int]int; e *int; f func(); g chan int; h uintptr }
+	T7 struct { a int32; b T3 }
+	T8 struct { a complex64; b float64; c bool }
+	T9 [0]T1
+	T10 struct { a T2; b int16; c T10x }
+	T10x struct { a [0]func(); b int8 }
+)
+
+var _ unsafe.Pointer
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for _, arch := range []string{"386", "arm", "arm64", "amd64", "mips", "mipsle", "mips64", "mips64le", "ppc64", "ppc64le", "riscv64", "s390x", "wasm"} {
+		sizes := types.GCSizesFor(arch)
+		if sizes == nil {
+			t.Errorf("no GCSizes for %s", arch)
+			continue
+		}
+
+		// Each assertion is an out-of-range constant index
+		// unless the compiler agrees.
+		var buf strings.Builder
+		buf.WriteString(src)
+		check := func(expr string, want int64) {
+			fmt.Fprintf(&buf, "var _ = [1]int{}[%s-%d]\n", expr, want)
+		}
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			T := scope.Lookup(name).Type()
+			fmt.Fprintf(&buf, "var x%s %s\n", name, name)
+			check("unsafe.Sizeof(x"+name+")", sizes.Sizeof(T))
+			check("unsafe.Alignof(x"+name+")", sizes.Alignof(T))
+			if s, ok := T.Underlying().(*types.Struct); ok {
+				var fields []*types.Var
+				for i := 0; i < s.NumFields(); i++ {
+					fields = append(fields, s.Field(i))
+				}
+				for i, o := range sizes.Offsetsof(fields) {
+					check(fmt.Sprintf("unsafe.Offsetof(x%s.%s)", name, fields[i].Name()), o)
+				}
+			}
+		}
+
+		file := filepath.Join(dir, arch+".go")
+		if err := os.WriteFile(file, []byte(buf.String()), 0666); err != nil {
+			t.Fatal(err)
+		}
+		cmd := exec.Command(testenv.GoToolPath(t), "tool", "compile", "-p", "p", "-o", os.DevNull, file)
+		cmd.Env = append(os.Environ(), "GOARCH="+arch)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("%s: layouts differ from the compiler's:\n%s", arch, out)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the recording of Info.Skipped.
+
+package types
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+)
+
+// A Skipped describes a part of a package that was not (fully) checked,
+// so that the information recorded for it in Info is incomplete.
+type Skipped struct {
+	Obj    Object    // package-level object whose declaration or body was skipped, or nil
+	Pos    token.Pos // position of the declaration, function body, or expression
+	Reason SkipReason
+}
+
+// A SkipReason describes why a part of a package was not checked.
+type SkipReason int
+
+const (
+	// SkipFuncBody: a function body, or the body of a function literal
+	// in a package-level declaration, was not checked because of
+	// Config.IgnoreFuncBodies.
+	SkipFuncBody SkipReason = iota + 1
+
+	// SkipNonConstant: a package-level declaration was not checked
+	// because of Config.ConstantsOnly.
+	SkipNonConstant
+
+	// SkipFakeImport: a qualified identifier of a fake package, imported
+	// as "C" with Config.FakeImportC or substituted for a package that
+	// could not be imported, was not resolved. The expressions in which
+	// it appears have no valid type.
+	SkipFakeImport
+
+	// SkipStopped: a package-level declaration or function body was not
+	// (fully) checked because checking stopped early, after the first
+	// error with no Config.Error function, after exceeding
+	// Config.MemoryBudget, or because the context was canceled.
+	SkipStopped
+)
+
+var skipReasons = [...]string{
+	SkipFuncBody:    "function body ignored",
+	SkipNonConstant: "not a constant",
+	SkipFakeImport:  "fake import",
+	SkipStopped:     "checking stopped",
+}
+
+func (r SkipReason) String() string {
+	if 0 < r && int(r) < len(skipReasons) {
+		return skipReasons[r]
+	}
+	return "invalid skip reason"
+}
+
+// recordSkipped records the part of the package at pos as skipped, if
+// Info.Skipped is recorded.
+func (check *Checker) recordSkipped(obj Object, pos token.Pos, reason SkipReason) {
+	if check.Skipped == nil {
+		return
+	}
+	check.Skipped = append(check.Skipped, Skipped{obj, pos, reason})
+}
+
+// pendBody records that the function body of obj (nil for function
+// literals) is to be checked, until the matching call of bodyDone, if
+// Info.Skipped is recorded.
+func (check *Checker) pendBody(obj Object, body *ast.BlockStmt) {
+	if check.Skipped == nil {
+		return
+	}
+	if check.bodies == nil {
+		check.bodies = make(map[*ast.BlockStmt]Object)
+	}
+	check.bodies[body] = obj
+}
+
+// bodyDone records that body is fully checked.
+func (check *Checker) bodyDone(body *ast.BlockStmt) {
+	if check.Skipped == nil {
+		return
+	}
+	delete(check.bodies, body)
+}
+
+// recordUnchecked records the package-level declarations and function
+// bodies that were not fully checked when checking ended, and sorts
+// Info.Skipped in source order, if Info.Skipped is recorded.
+func (check *Checker) recordUnchecked() {
+	if check.Skipped == nil {
+		return
+	}
+	for obj, d := range check.objMap {
+		if obj.color() == black && obj.Type() != nil {
+			if d.fdecl != nil && d.fdecl.Body != nil && check.conf.IgnoreFuncBodies {
+				check.recordSkipped(obj, d.fdecl.Body.Pos(), SkipFuncBody)
+			}
+			continue
+		}
+		reason := SkipStopped
+		if _, isConst := obj.(*Const); !isConst && check.conf.ConstantsOnly {
+			reason = SkipNonConstant
+		}
+		check.recordSkipped(obj, obj.Pos(), reason)
+	}
+	for body, obj := range check.bodies {
+		check.recordSkipped(obj, body.Pos(), SkipStopped)
+	}
+	check.bodies = nil
+
+	sort.SliceStable(check.Skipped, func(i, j int) bool {
+		return check.Skipped[i].Pos < check.Skipped[j].Pos
+	})
+}
@@ -0,0 +1,165 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements snapshots of Environments and Sessions.
+
+package types
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// An EnvSnapshot records the state of an Environment; see
+// Environment.Snapshot.
+type EnvSnapshot struct {
+	mark int // length of the journal when the snapshot was taken
+}
+
+// Snapshot records the current state of env, so that it can be restored
+// with Restore, discarding the types, instances, and memoized results
+// recorded in env after the snapshot, or released with Release.
+//
+// Snapshots are cheap: while a snapshot is live, env records its
+// insertions, and Restore undoes them. Types created after a snapshot
+// that is restored remain valid, but are no longer shared with the types
+// created later. Insertions concurrent with Snapshot or Restore may or
+// may not be discarded.
+func (env *Environment) Snapshot() *EnvSnapshot {
+	env.jmu.Lock()
+	defer env.jmu.Unlock()
+	snap := &EnvSnapshot{len(env.journal)}
+	env.snapshots = append(env.snapshots, snap)
+	atomic.StoreInt32(&env.journaling, 1)
+	return snap
+}
+
+// Restore restores the state of env recorded by snap. Restoring snap
+// releases it and the snapshots of env taken after it. Restore panics if
+// snap is not a live snapshot of env.
+func (env *Environment) Restore(snap *EnvSnapshot) {
+	env.mu.Lock() // for the undo functions of typeMap and methods
+	defer env.mu.Unlock()
+	env.jmu.Lock()
+	defer env.jmu.Unlock()
+	i := env.liveSnapshot(snap)
+	for j := len(env.journal) - 1; j >= snap.mark; j-- {
+		env.journal[j]()
+	}
+	env.journal = env.journal[:snap.mark]
+	env.snapshots = env.snapshots[:i]
+	env.stopJournal()
+}
+
+// Release releases snap, keeping the state of env. Release panics if snap
+// is not a live snapshot of env.
+func (env *Environment) Release(snap *EnvSnapshot) {
+	env.jmu.Lock()
+	defer env.jmu.Unlock()
+	i := env.liveSnapshot(snap)
+	env.snapshots = append(env.snapshots[:i], env.snapshots[i+1:]...)
+	env.stopJournal()
+}
+
+// liveSnapshot returns the index of snap in env.snapshots.
+func (env *Environment) liveSnapshot(snap *EnvSnapshot) int {
+	for i, s := range env.snapshots {
+		if s == snap {
+			return i
+		}
+	}
+	panic("snapshot is not live")
+}
+
+// stopJournal stops journaling if there are no live snapshots.
+func (env *Environment) stopJournal() {
+	if len(env.snapshots) == 0 {
+		env.journal = nil
+		atomic.StoreInt32(&env.journaling, 0)
+	}
+}
+
+// logInsert records the function undo that undoes an insertion into env,
+// if there is a live snapshot. Undo functions for typeMap and methods are
+// called with env.mu held.
+func (env *Environment) logInsert(undo func()) {
+	if atomic.LoadInt32(&env.journaling) == 0 {
+		return
+	}
+	env.jmu.Lock()
+	defer env.jmu.Unlock()
+	if len(env.snapshots) > 0 {
+		env.journal = append(env.journal, undo)
+	}
+}
+
+// loadOrStore is like m.LoadOrStore, for a map m of env, but records
+// the insertion, if any.
+func (env *Environment) loadOrStore(m *sync.Map, key, value interface{}) interface{} {
+	actual, loaded := m.LoadOrStore(key, value)
+	if !loaded {
+		env.logInsert(func() { m.Delete(key) })
+	}
+	return actual
+}
+
+// A SessionSnapshot records the state of a Session; see Session.Snapshot.
+type SessionSnapshot struct {
+	pkgs    map[string]sessionPackage // copies of the packages of the session
+	imports map[string]*Package
+	env     *EnvSnapshot
+}
+
+// Snapshot records the current state of s, including its Environment, so
+// that it can be restored with Restore after speculative operations on s,
+// such as checking a hypothetical edit of a package, or released with
+// Release. The checked packages are not copied, so that the cost of a
+// snapshot is proportional to the number of packages in s.
+func (s *Session) Snapshot() *SessionSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := &SessionSnapshot{
+		pkgs:    make(map[string]sessionPackage, len(s.pkgs)),
+		imports: make(map[string]*Package, len(s.imports)),
+		env:     s.conf.Environment.Snapshot(),
+	}
+	for path, p := range s.pkgs {
+		snap.pkgs[path] = *p
+	}
+	for path, pkg := range s.imports {
+		snap.imports[path] = pkg
+	}
+	return snap
+}
+
+// Restore restores the state of s recorded by snap: the packages of s,
+// their files, and the results of checking them are those at the time
+// of the snapshot, and the insertions into the session's Environment
+// since are discarded, as by Environment.Restore. The background
+// computations started since are waited for. Restore panics if snap is
+// not a live snapshot of s; restoring snap releases it and the snapshots
+// of s taken after it.
+func (s *Session) Restore(snap *SessionSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bg.Wait() // don't let background computations record into the environment
+	s.conf.Environment.Restore(snap.env)
+	s.pkgs = make(map[string]*sessionPackage, len(snap.pkgs))
+	for path, p := range snap.pkgs {
+		p := p
+		s.pkgs[path] = &p
+	}
+	s.imports = make(map[string]*Package, len(snap.imports))
+	for path, pkg := range snap.imports {
+		s.imports[path] = pkg
+	}
+}
+
+// Release releases snap, keeping the state of s. Release panics if snap
+// is not a live snapshot of s.
+func (s *Session) Release(snap *SessionSnapshot) {
+	s.conf.Environment.Release(snap.env)
+}
@@ -60,17 +60,17 @@ func (check *Checker) funcBody(decl *declInfo, name string, sig *Signature, body
 
 func (check *Checker) usage(scope *Scope) {
 	var unused []*Var
-	for name, elem := range scope.elems {
+	scope.forEach(func(name string, elem Object) {
 		elem = resolve(name, elem)
 		if v, _ := elem.(*Var); v != nil && !v.used {
 			unused = append(unused, v)
 		}
-	}
+	})
 	sort.Slice(unused, func(i, j int) bool {
 		return unused[i].pos < unused[j].pos
 	})
 	for _, v := range unused {
-		check.softErrorf(v, _UnusedVar, "%s declared but not used", v.name)
+		check.reportUnused(check.conf.UnusedVars, v, _UnusedVar, "%s declared but not used", v.name)
 	}
 
 	for _, scope := range scope.children {
@@ -746,7 +746,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				v.used = true // avoid usage error when checking entire function
 			}
 			if !used {
-				check.softErrorf(lhs, _UnusedVar, "%s declared but not used", lhs.Name)
+				check.reportUnused(check.conf.UnusedVars, lhs, _UnusedVar, "%s declared but not used", lhs.Name)
 			}
 		}
 
@@ -834,13 +834,33 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		if x.mode != invalid {
 			// Ranging over a type parameter is permitted if it has a structural type.
 			typ := optype(x.typ)
-			if _, ok := typ.(*Chan); ok && s.Value != nil {
+			switch {
+			case isInteger(typ):
+				if !check.allowVersion(check.pkg, 1, 22) && !check.forwardCompat(&x, 1, 22, "range over %s", &x) {
+					check.softErrorf(&x, _InvalidRangeExpr, "range over %s requires go1.22 or later", &x)
+				}
+				// spec: "If the range expression is an untyped constant n,
+				// it is given the default type of n."
+				if isUntyped(x.typ) {
+					check.assignment(&x, nil, "range clause")
+				}
+			case asSignature(typ) != nil:
+				if !check.allowVersion(check.pkg, 1, 23) && !check.forwardCompat(&x, 1, 23, "range over %s", &x) {
+					check.softErrorf(&x, _InvalidRangeExpr, "range over %s requires go1.23 or later", &x)
+				}
+			}
+			var max int
+			var msg string
+			key, val, max, msg = rangeKeyVal(x.typ, typ, isVarName(s.Key), isVarName(s.Value))
+			switch {
+			case max < 1 && s.Key != nil:
+				check.softErrorf(s.Key, _InvalidIterVar, "range over %s permits no iteration variables", &x)
+				// ok to continue
+			case max < 2 && s.Value != nil:
 				check.softErrorf(atPos(s.Value.Pos()), _InvalidIterVar, "range over %s permits only one iteration variable", &x)
 				// ok to continue
 			}
-			var msg string
-			key, val, msg = rangeKeyVal(typ, isVarName(s.Key), isVarName(s.Value))
-			if key == nil || msg != "" {
+			if key == nil && max > 0 || msg != "" {
 				if msg != "" {
 					// TODO(rFindley) should this be parenthesized, to be consistent with other qualifiers?
 					msg = ": " + msg
@@ -904,6 +924,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 					// for short variable declarations) and ends at the end of the innermost
 					// containing block."
 					check.declare(check.scope, nil /* recordDef already called */, obj, scopePos)
+					check.reportShadowing(obj, nil)
 				}
 			} else {
 				check.error(inNode(s, s.TokPos), _NoNewVar, "no new variables on left side of :=")
@@ -940,34 +961,78 @@ func isVarName(x ast.Expr) bool {
 }
 
 // rangeKeyVal returns the key and value type produced by a range clause
-// over an expression of type typ, and possibly an error message. If the
-// range clause is not permitted the returned key is nil or msg is not
-// empty (in that case we still may have a non-nil key type which can be
-// used to reduce the chance for follow-on errors).
+// over an expression of type orig with operational type typ, the maximum
+// number of iteration variables permitted, and possibly an error message.
+// If the range clause is not permitted the returned key is nil (and max
+// is > 0) or msg is not empty (in that case we still may have a non-nil
+// key type which can be used to reduce the chance for follow-on errors).
 // The wantKey, wantVal, and hasVal flags indicate which of the iteration
 // variables are used or present; this matters if we range over a generic
 // type where not all keys or values are of the same type.
-func rangeKeyVal(typ Type, wantKey, wantVal bool) (Type, Type, string) {
+func rangeKeyVal(orig, typ Type, wantKey, wantVal bool) (key, val Type, max int, msg string) {
 	switch typ := arrayPtrDeref(typ).(type) {
 	case *Basic:
 		if isString(typ) {
-			return Typ[Int], universeRune, "" // use 'rune' name
+			return Typ[Int], universeRune, 2, "" // use 'rune' name
+		}
+		if isInteger(typ) {
+			return orig, nil, 1, ""
 		}
 	case *Array:
-		return Typ[Int], typ.elem, ""
+		return Typ[Int], typ.elem, 2, ""
 	case *Slice:
-		return Typ[Int], typ.elem, ""
+		return Typ[Int], typ.elem, 2, ""
 	case *Map:
-		return typ.key, typ.elem, ""
+		return typ.key, typ.elem, 2, ""
 	case *Chan:
 		var msg string
 		if typ.dir == SendOnly {
 			msg = "receive from send-only channel"
 		}
-		return typ.elem, Typ[Invalid], msg
+		return typ.elem, nil, 1, msg
+	case *Signature:
+		return rangeFuncKeyVal(typ)
 	case *top:
 		// we have a type parameter with no structural type
-		return nil, nil, "no structural type"
+		return nil, nil, 2, "no structural type"
+	}
+	return nil, nil, 2, ""
+}
+
+// rangeFuncKeyVal is like rangeKeyVal for an iterator function of type sig,
+// which must be of the form func(yield func(K, V) bool) with at most two
+// yield parameters K and V.
+func rangeFuncKeyVal(sig *Signature) (key, val Type, max int, msg string) {
+	bad := func(msg string) (Type, Type, int, string) {
+		return nil, nil, 2, msg
+	}
+	if sig.TypeParams().Len() > 0 {
+		return bad("func must be instantiated")
+	}
+	if sig.params.Len() != 1 {
+		return bad("func must be func(yield func(...) bool): wrong argument count")
+	}
+	if sig.results.Len() != 0 {
+		return bad("func must be func(yield func(...) bool): func returns results")
+	}
+	cb := asSignature(sig.params.vars[0].typ)
+	if cb == nil {
+		return bad("func must be func(yield func(...) bool): argument is not func")
+	}
+	if cb.params.Len() > 2 {
+		return bad("func must be func(yield func(...) bool): yield func has too many parameters")
+	}
+	if cb.variadic {
+		return bad("func must be func(yield func(...) bool): yield func is variadic")
+	}
+	if cb.results.Len() != 1 || !isBoolean(cb.results.vars[0].typ) {
+		return bad("func must be func(yield func(...) bool): yield func does not return bool")
+	}
+	switch cb.params.Len() {
+	case 0:
+		return nil, nil, 0, ""
+	case 1:
+		return cb.params.vars[0].typ, nil, 1, ""
 	}
-	return nil, nil, ""
+	return cb.params.vars[0].typ, cb.params.vars[1].typ, 2, ""
 }
@@ -17,6 +17,7 @@ import (
 type Struct struct {
 	fields []*Var
 	tags   []string // field tags; nil if there are no tags
+	canon  *Struct  // canonical identical struct of the checked package, or nil (see Checker.internLiteral)
 }
 
 // NewStruct returns a new struct with the given fields and corresponding field tags.
@@ -102,6 +103,7 @@ func (check *Checker) structType(styp *Struct, e *ast.StructType) {
 	for _, f := range list.List {
 		typ = check.varType(f.Type)
 		tag = check.tag(f.Tag)
+		first := len(fields) // index of the first variable declared for f
 		if len(f.Names) > 0 {
 			// named fields
 			for _, name := range f.Names {
@@ -149,7 +151,12 @@ func (check *Checker) structType(styp *Struct, e *ast.StructType) {
 				case *Pointer:
 					check.error(embeddedPos, _InvalidPtrEmbed, "embedded field type cannot be a pointer")
 				case *TypeParam:
-					check.error(embeddedPos, _InvalidPtrEmbed, "embedded field type cannot be a (pointer to a) type parameter")
+					// Embedded type parameters are permitted with the
+					// EmbeddedTypeParams experiment; see its doc comment
+					// for the field name and promotion rules.
+					if !check.conf.Experiments.EmbeddedTypeParams {
+						check.error(embeddedPos, _InvalidPtrEmbed, "embedded field type cannot be a (pointer to a) type parameter")
+					}
 				case *Interface:
 					if isPtr {
 						check.error(embeddedPos, _InvalidPtrEmbed, "embedded field type cannot be a pointer to an interface")
@@ -157,6 +164,7 @@ func (check *Checker) structType(styp *Struct, e *ast.StructType) {
 				}
 			})
 		}
+		check.checkStructTag(f.Tag, tag, fields[first:])
 	}
 
 	styp.fields = fields
@@ -0,0 +1,185 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the parsing and checking of struct tags.
+
+package types
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// A StructTag is a struct tag parsed according to the conventions of
+// reflect.StructTag: a sequence of key:"value" pairs separated by spaces,
+// where each value is a quoted Go string literal.
+type StructTag struct {
+	Entries []StructTagEntry // in tag order
+}
+
+// A StructTagEntry is a key:"value" pair of a struct tag.
+type StructTagEntry struct {
+	Key    string
+	Value  string // unquoted value
+	Offset int    // byte offset of Key in the tag
+}
+
+// A StructTagError describes a malformed struct tag.
+type StructTagError struct {
+	Offset int // byte offset of the error in the tag
+	Msg    string
+}
+
+func (err *StructTagError) Error() string {
+	return fmt.Sprintf("struct tag: %s (at offset %d)", err.Msg, err.Offset)
+}
+
+// ParseStructTag parses tag. If tag is malformed, ParseStructTag returns
+// the entries preceding the error together with a *StructTagError; in
+// contrast, reflect.StructTag.Lookup silently ignores the rest of tag.
+// Duplicate keys are not an error (see Duplicates).
+func ParseStructTag(tag string) (StructTag, error) {
+	var t StructTag
+	offs := 0 // offset of tag[i] in the original tag
+	errorf := func(i int, format string, args ...interface{}) (StructTag, error) {
+		return t, &StructTagError{offs + i, fmt.Sprintf(format, args...)}
+	}
+	for {
+		// skip leading spaces
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		if i == len(tag) {
+			return t, nil
+		}
+		if i == 0 && len(t.Entries) > 0 {
+			return errorf(0, "key:\"value\" pairs not separated by spaces")
+		}
+		tag = tag[i:]
+		offs += i
+
+		// A key is a non-empty sequence of non-control characters
+		// other than space, quote, and colon.
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 {
+			return errorf(0, "invalid key")
+		}
+		if i == len(tag) || tag[i] != ':' {
+			return errorf(i, "missing ':' after key %s", tag[:i])
+		}
+		if i+1 == len(tag) || tag[i+1] != '"' {
+			return errorf(i+1, "value of key %s is not quoted", tag[:i])
+		}
+		key := tag[:i]
+
+		// scan the quoted value
+		j := i + 2
+		for j < len(tag) && tag[j] != '"' {
+			if tag[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		if j >= len(tag) {
+			return errorf(i+1, "unterminated value of key %s", key)
+		}
+		value, err := strconv.Unquote(tag[i+1 : j+1])
+		if err != nil {
+			return errorf(i+1, "invalid value of key %s", key)
+		}
+		t.Entries = append(t.Entries, StructTagEntry{key, value, offs})
+		tag = tag[j+1:]
+		offs += j + 1
+	}
+}
+
+// Lookup returns the value associated with key in t, and reports whether
+// there is such a value. As for reflect.StructTag.Lookup, the first entry
+// with the key determines the value.
+func (t StructTag) Lookup(key string) (value string, ok bool) {
+	for _, e := range t.Entries {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return "", false
+}
+
+// Get returns the value associated with key in t, or "".
+func (t StructTag) Get(key string) string {
+	value, _ := t.Lookup(key)
+	return value
+}
+
+// Duplicates returns the entries of t whose keys are also the keys of
+// preceding entries, and are hence ignored by Lookup.
+func (t StructTag) Duplicates() []StructTagEntry {
+	var dups []StructTagEntry
+	seen := make(map[string]bool)
+	for _, e := range t.Entries {
+		if seen[e.Key] {
+			dups = append(dups, e)
+		}
+		seen[e.Key] = true
+	}
+	return dups
+}
+
+// checkStructTag checks the tag lit of the struct fields vars, with value
+// tag, if Config.CheckStructTags is set.
+func (check *Checker) checkStructTag(lit *ast.BasicLit, tag string, vars []*Var) {
+	if !check.conf.CheckStructTags || lit == nil || tag == "" {
+		return
+	}
+	t, err := ParseStructTag(tag)
+	if err != nil {
+		check.reportStructTagError(lit, tag, err)
+		return
+	}
+	for _, e := range t.Duplicates() {
+		check.warnf(check.tagPos(lit, tag, e.Offset), _InvalidStructTag, "struct tag has duplicate key %s", e.Key)
+	}
+	if validate := check.conf.ValidateStructTag; validate != nil && len(vars) > 0 {
+		// Validation functions may look at the field types,
+		// which may not be set up completely yet.
+		check.later(func() {
+			for _, v := range vars {
+				if err := validate(v, t); err != nil {
+					check.reportStructTagError(lit, tag, err)
+				}
+			}
+		})
+	}
+}
+
+// reportStructTagError reports err for the tag lit with value tag, at the
+// offset of err if it is a *StructTagError.
+func (check *Checker) reportStructTagError(lit *ast.BasicLit, tag string, err error) {
+	offs := 0
+	msg := err.Error()
+	if err, _ := err.(*StructTagError); err != nil {
+		offs = err.Offset
+		msg = "invalid struct tag: " + err.Msg
+	}
+	check.warnf(check.tagPos(lit, tag, offs), _InvalidStructTag, "%s", msg)
+}
+
+// tagPos returns the position of the byte with offset offs in the tag
+// lit with value tag. The position is exact unless the literal contains
+// escape sequences or carriage returns, in which case it is that of the
+// start of the value.
+func (check *Checker) tagPos(lit *ast.BasicLit, tag string, offs int) posSpan {
+	pos := lit.Pos() + 1
+	if len(lit.Value) == len(tag)+2 && !strings.ContainsRune(lit.Value, '\\') {
+		pos += token.Pos(offs)
+	}
+	return inNode(lit, pos)
+}
@@ -93,6 +93,12 @@ func (subst *subster) typ(typ Type) Type {
 	case *Basic, *top:
 		// nothing to do
 
+	case *Alias:
+		// Keep the alias unless its actual type changes.
+		if actual := subst.typ(t.actual); actual != t.actual {
+			return actual
+		}
+
 	case *Array:
 		elem := subst.typOrNil(t.elem)
 		if elem != t.elem {
@@ -124,8 +130,13 @@ func (subst *subster) typ(typ Type) Type {
 		// recv := subst.var_(t.recv) // TODO(gri) this causes a stack overflow - explain
 		recv := t.recv
 		params := subst.tuple(t.params)
+		variadic := t.variadic
+		if expanded, ok := subst.expandParams(t); ok {
+			params = expanded
+			variadic = false
+		}
 		results := subst.tuple(t.results)
-		if recv != t.recv || params != t.params || results != t.results {
+		if recv != t.recv || params != t.params || results != t.results || variadic != t.variadic {
 			return &Signature{
 				rparams: t.rparams,
 				// TODO(rFindley) why can't we nil out tparams here, rather than in
@@ -135,7 +146,7 @@ func (subst *subster) typ(typ Type) Type {
 				recv:     recv,
 				params:   params,
 				results:  results,
-				variadic: t.variadic,
+				variadic: variadic,
 			}
 		}
 
@@ -263,6 +274,37 @@ func (subst *subster) typOrNil(typ Type) Type {
 	return subst.typ(typ)
 }
 
+// expandParams returns the parameters of signature t after substitution if
+// the final parameter of t is of the form args ...Ts, where Ts is a variadic
+// type parameter substituted by a *Tuple; args is replaced by one parameter
+// for each tuple element. If t has no such parameter, the result is nil, false.
+func (subst *subster) expandParams(t *Signature) (*Tuple, bool) {
+	if !t.variadic {
+		return nil, false
+	}
+	n := t.params.Len()
+	last := t.params.vars[n-1]
+	s, _ := last.typ.(*Slice)
+	if s == nil {
+		return nil, false
+	}
+	tpar, _ := s.elem.(*TypeParam)
+	if tpar == nil || !tpar.variadic {
+		return nil, false
+	}
+	pack, _ := subst.smap.lookup(tpar).(*Tuple)
+	if pack == nil {
+		return nil, false
+	}
+	prefix, _ := subst.varList(t.params.vars[:n-1])
+	vars := make([]*Var, len(prefix), len(prefix)+pack.Len())
+	copy(vars, prefix)
+	for _, v := range pack.vars {
+		vars = append(vars, NewParam(last.pos, last.pkg, last.name, v.typ))
+	}
+	return NewTuple(vars...), true
+}
+
 func (subst *subster) var_(v *Var) *Var {
 	if v != nil {
 		if typ := subst.typ(v.typ); typ != v.typ {
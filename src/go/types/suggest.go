@@ -0,0 +1,194 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements "did you mean" suggestions for undeclared
+// identifiers and unknown selectors.
+
+package types
+
+import (
+	"go/token"
+	"strings"
+)
+
+// A suggester finds the candidate name closest to a given (misspelled) name.
+// Candidates that differ only in case are preferred; otherwise the candidate
+// with the smallest edit distance wins, provided the distance is small
+// relative to the length of the name. Ties are broken by name so that the
+// result does not depend on the order in which candidates are added.
+type suggester struct {
+	name string // the name that was not found
+	best string // best candidate so far, or ""
+	dist int    // edit distance of best; -1 for case-only differences
+}
+
+func newSuggester(name string) *suggester {
+	return &suggester{name: name, dist: maxSuggestDist(name) + 1}
+}
+
+// maxSuggestDist returns the largest edit distance at which a candidate is
+// still considered a plausible misspelling of name.
+func maxSuggestDist(name string) int {
+	return len(name) / 3
+}
+
+// add considers candidate as a suggestion.
+func (s *suggester) add(candidate string) {
+	if candidate == s.name || candidate == "_" || candidate == "" {
+		return
+	}
+	var d int
+	if strings.EqualFold(candidate, s.name) {
+		d = -1
+	} else {
+		d = editDistance(s.name, candidate, s.dist)
+	}
+	if d < s.dist || d == s.dist && s.best != "" && candidate < s.best {
+		s.best = candidate
+		s.dist = d
+	}
+}
+
+// editDistance returns the edit distance between a and b, counting
+// insertions, deletions, substitutions, and transpositions of adjacent
+// bytes (optimal string alignment distance). If the distance exceeds max,
+// the result is some value > max.
+func editDistance(a, b string, max int) int {
+	if d := len(a) - len(b); d > max || -d > max {
+		return max + 1
+	}
+	prev2 := make([]int, len(b)+1) // row i-2
+	prev := make([]int, len(b)+1)  // row i-1
+	curr := make([]int, len(b)+1)  // row i
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && prev2[j-2]+1 < curr[j] {
+				curr[j] = prev2[j-2] + 1
+			}
+			if curr[j] < rowMin {
+				rowMin = curr[j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// suggestIdent returns the name of an object visible at the current position
+// that is a plausible misspelling of name, or "".
+func (check *Checker) suggestIdent(name string) string {
+	s := newSuggester(name)
+	for scope := check.scope; scope != nil; scope = scope.parent {
+		scope.forEach(func(n string, obj Object) {
+			// Lazily resolved objects are imported package-level objects,
+			// which are always visible; don't resolve them to find out.
+			if _, lazy := obj.(*lazyObject); lazy || !check.pos.IsValid() || obj.scopePos() <= check.pos {
+				s.add(n)
+			}
+		})
+	}
+	return s.best
+}
+
+// suggestImported returns the name of an exported object of pkg that is a
+// plausible misspelling of name, or "".
+func suggestImported(pkg *Package, name string) string {
+	s := newSuggester(name)
+	pkg.scope.forEach(func(n string, _ Object) {
+		if token.IsExported(n) {
+			s.add(n)
+		}
+	})
+	return s.best
+}
+
+// suggestSelector returns the name of a field or method of T accessible
+// from check.pkg that is a plausible misspelling of sel, or "".
+// If methodsOnly is set, fields are not considered.
+func (check *Checker) suggestSelector(T Type, sel string, methodsOnly bool) string {
+	s := newSuggester(sel)
+	add := func(obj Object) {
+		if obj.Exported() || obj.Pkg() == check.pkg {
+			s.add(obj.Name())
+		}
+	}
+
+	if tpar := asTypeParam(T); tpar != nil {
+		for _, m := range tpar.iface().typeSet().methods {
+			add(m)
+		}
+		return s.best
+	}
+
+	mset := NewMethodSet(T)
+	for i := 0; i < mset.Len(); i++ {
+		add(mset.At(i).Obj())
+	}
+	if !isPointer(T) && !IsInterface(T) {
+		mset := NewMethodSet(NewPointer(T))
+		for i := 0; i < mset.Len(); i++ {
+			add(mset.At(i).Obj())
+		}
+	}
+
+	if methodsOnly {
+		return s.best
+	}
+
+	// Collect fields, including promoted fields, up to a small depth.
+	const maxDepth = 3
+	seen := make(map[*Struct]bool)
+	var fields func(T Type, depth int)
+	fields = func(T Type, depth int) {
+		str := asStruct(derefStructPtr(T))
+		if str == nil || seen[str] || depth > maxDepth {
+			return
+		}
+		seen[str] = true
+		for _, f := range str.fields {
+			add(f)
+			if f.embedded {
+				fields(f.typ, depth+1)
+			}
+		}
+	}
+	fields(T, 0)
+
+	return s.best
+}
+
+// errorWithSuggestion reports an error like errorf. If suggestion is not
+// empty, the error records it as the suggested replacement for the
+// identifier at the error position.
+func (check *Checker) errorWithSuggestion(at positioner, code errorCode, suggestion string, format string, args ...interface{}) {
+	if check.isFollowOn(at, args) {
+		return
+	}
+	err := check.newErrorf(at, code, false, format, args...).(Error)
+	err.Suggestion = suggestion
+	check.err(err)
+}
@@ -0,0 +1,296 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the construction of an index from a type-checked package.
+
+package symbols
+
+import (
+	"go/ast"
+	"go/internal/typeparams"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// Build returns the index of the package pkg, which was type-checked with
+// the files positioned in fset and recorded the information info. The
+// definitions and references are taken from info.Defs and info.Uses, the
+// instantiations from info.Types, info.Inferred, and info.Callees; nil maps
+// are ignored. References to objects of other packages are indexed as well,
+// but their definitions are not. To index several packages, merge their
+// indices with Merge.
+func Build(fset *token.FileSet, pkg *types.Package, info *types.Info) *Index {
+	b := &builder{
+		fset:   fset,
+		syms:   make(map[string]*symbol),
+		fields: make(map[*types.Package]map[member]string),
+	}
+
+	// definitions
+	for id, obj := range info.Defs {
+		if obj == nil || obj.Pkg() != pkg {
+			continue
+		}
+		if s := b.symbol(obj); s != nil {
+			s.Def = b.location(id.Pos())
+		}
+	}
+
+	// method sets of package-level types
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		tname, _ := scope.Lookup(name).(*types.TypeName)
+		if tname == nil || tname.IsAlias() {
+			continue
+		}
+		if named, _ := tname.Type().(*types.Named); named != nil && named.TypeParams().Len() == 0 {
+			if s := b.symbol(tname); s != nil {
+				b.methodSets(s, named)
+			}
+		}
+	}
+
+	// references
+	for id, obj := range info.Uses {
+		if s := b.symbol(obj); s != nil {
+			s.refs = append(s.refs, b.location(id.Pos()))
+		}
+	}
+
+	// instantiations
+	for e, tv := range info.Types {
+		if !tv.IsType() {
+			if ix := typeparams.UnpackIndexExpr(e); ix != nil {
+				b.funcInstance(info, ix)
+			}
+			continue
+		}
+		if named, _ := tv.Type.(*types.Named); named != nil && named.TypeArgs().Len() > 0 {
+			if s := b.symbol(named.Obj()); s != nil {
+				b.addInstance(s, named.TypeArgs())
+			}
+		}
+	}
+	for _, c := range info.Callees {
+		if c.TArgs.Len() > 0 {
+			if s := b.symbol(c.Obj); s != nil {
+				b.addInstance(s, c.TArgs)
+			}
+		}
+	}
+	for e, inf := range info.Inferred {
+		if ix := typeparams.UnpackIndexExpr(e); ix != nil {
+			if s := b.symbol(usedObject(info, ix.X)); s != nil {
+				b.addInstance(s, inf.TArgs)
+			}
+		}
+	}
+
+	for _, s := range b.syms {
+		sort.Slice(s.refs, func(i, j int) bool { return s.refs[i].less(s.refs[j]) })
+		sort.Strings(s.instances)
+		s.instances = dedup(s.instances)
+	}
+
+	return newIndex(b.syms)
+}
+
+type builder struct {
+	fset   *token.FileSet
+	syms   map[string]*symbol
+	fields map[*types.Package]map[member]string // per package, struct fields of package-level types
+}
+
+// A member identifies a struct field. The position alone may not be unique
+// for fields of imported packages.
+type member struct {
+	pos  token.Pos
+	name string
+}
+
+func (b *builder) location(pos token.Pos) Location {
+	p := b.fset.Position(pos)
+	return Location{p.Filename, p.Line, p.Column}
+}
+
+// symbol returns the symbol for obj, creating it if necessary,
+// or nil if obj is not indexed.
+func (b *builder) symbol(obj types.Object) *symbol {
+	key, kind := b.key(obj)
+	if key == "" {
+		return nil
+	}
+	s := b.syms[key]
+	if s == nil {
+		s = &symbol{Symbol: Symbol{Key: key, Kind: kind}}
+		b.syms[key] = s
+	}
+	return s
+}
+
+// key returns the key and kind of obj, or "" if obj is not indexed.
+func (b *builder) key(obj types.Object) (string, Kind) {
+	if obj == nil || obj.Pkg() == nil || obj.Name() == "_" {
+		return "", Invalid
+	}
+	path := obj.Pkg().Path()
+
+	switch obj := obj.(type) {
+	case *types.Const:
+		if obj.Parent() == obj.Pkg().Scope() {
+			return path + "." + obj.Name(), Const
+		}
+	case *types.TypeName:
+		if obj.Parent() == obj.Pkg().Scope() {
+			kind := Type
+			if t, _ := obj.Type().Underlying().(*types.Interface); t != nil {
+				kind = Interface
+			}
+			return path + "." + obj.Name(), kind
+		}
+	case *types.Var:
+		if obj.IsField() {
+			if key := b.fieldsOf(obj.Pkg())[member{obj.Pos(), obj.Name()}]; key != "" {
+				return key, Field
+			}
+		} else if obj.Parent() == obj.Pkg().Scope() {
+			return path + "." + obj.Name(), Var
+		}
+	case *types.Func:
+		sig := obj.Type().(*types.Signature)
+		if sig.Recv() == nil {
+			if obj.Parent() == obj.Pkg().Scope() {
+				return path + "." + obj.Name(), Func
+			}
+			break
+		}
+		typ := sig.Recv().Type()
+		if p, _ := typ.(*types.Pointer); p != nil {
+			typ = p.Elem()
+		}
+		if named, _ := typ.(*types.Named); named != nil {
+			if tname := named.Obj(); tname.Pkg() != nil && tname.Parent() == tname.Pkg().Scope() {
+				return tname.Pkg().Path() + "." + tname.Name() + "." + obj.Name(), Method
+			}
+		}
+	}
+	return "", Invalid
+}
+
+// fieldsOf returns the keys of the (direct) struct fields of the
+// package-level types of pkg.
+func (b *builder) fieldsOf(pkg *types.Package) map[member]string {
+	m := b.fields[pkg]
+	if m == nil {
+		m = make(map[member]string)
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			tname, _ := scope.Lookup(name).(*types.TypeName)
+			if tname == nil || tname.IsAlias() {
+				continue
+			}
+			if s, _ := tname.Type().Underlying().(*types.Struct); s != nil {
+				for i := 0; i < s.NumFields(); i++ {
+					f := s.Field(i)
+					m[member{f.Pos(), f.Name()}] = pkg.Path() + "." + name + "." + f.Name()
+				}
+			}
+		}
+		b.fields[pkg] = m
+	}
+	return m
+}
+
+// methodSets records the method sets of the named type in s.
+func (b *builder) methodSets(s *symbol, named *types.Named) {
+	if t, _ := named.Underlying().(*types.Interface); t != nil {
+		if t.IsConstraint() {
+			return // cannot be implemented
+		}
+		s.methods = methodSet(types.NewMethodSet(named), nil)
+		return
+	}
+	s.methods = methodSet(types.NewMethodSet(named), nil)
+	s.ptrMethods = methodSet(types.NewMethodSet(types.NewPointer(named)), s.methods)
+}
+
+// methodSet returns the sorted method set entries of mset, excluding the
+// entries in the sorted list except. An entry consists of the method's Id
+// and signature. The result is never nil, to distinguish types with empty
+// method sets from types without method set information.
+func methodSet(mset *types.MethodSet, except []string) []string {
+	list := []string{}
+	for i := 0; i < mset.Len(); i++ {
+		m := mset.At(i).Obj()
+		entry := m.Id() + " " + types.TypeString(m.Type(), nil)
+		if j := sort.SearchStrings(except, entry); j < len(except) && except[j] == entry {
+			continue
+		}
+		list = append(list, entry)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// funcInstance records the explicit instantiation ix of a generic function
+// that is not called.
+func (b *builder) funcInstance(info *types.Info, ix *typeparams.IndexExpr) {
+	fn, _ := usedObject(info, ix.X).(*types.Func)
+	if fn == nil || fn.Type().(*types.Signature).TypeParams().Len() != len(ix.Indices) {
+		return // not a generic function, or a partial instantiation (recorded in info.Inferred)
+	}
+	if sig, _ := info.Types[ix.Orig].Type.(*types.Signature); sig == nil || sig.TypeParams().Len() > 0 {
+		return // not instantiated (for instance, because it is called)
+	}
+	targs := make([]types.Type, len(ix.Indices))
+	for i, x := range ix.Indices {
+		if targs[i] = info.Types[x].Type; targs[i] == nil {
+			return
+		}
+	}
+	if s := b.symbol(fn); s != nil {
+		b.addInstance(s, types.NewTypeList(targs))
+	}
+}
+
+func (b *builder) addInstance(s *symbol, targs *types.TypeList) {
+	str := "["
+	for i := 0; i < targs.Len(); i++ {
+		if i > 0 {
+			str += ", "
+		}
+		str += types.TypeString(targs.At(i), nil)
+	}
+	s.instances = append(s.instances, str+"]")
+}
+
+// usedObject returns the object denoted by the identifier or qualified
+// identifier or selector expression e, or nil.
+func usedObject(info *types.Info, e ast.Expr) types.Object {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return usedObject(info, e.X)
+	case *ast.Ident:
+		return info.Uses[e]
+	case *ast.SelectorExpr:
+		return info.Uses[e.Sel]
+	}
+	return nil
+}
+
+// dedup removes adjacent duplicates from the sorted list.
+func dedup(list []string) []string {
+	if len(list) < 2 {
+		return list
+	}
+	j := 1
+	for i := 1; i < len(list); i++ {
+		if list[i] != list[j-1] {
+			list[j] = list[i]
+			j++
+		}
+	}
+	return list[:j]
+}
@@ -0,0 +1,235 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the serialization of an index.
+
+package symbols
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The encoding of an index starts with the magic header, followed by a
+// string table and the symbols. All numbers are unsigned varints; strings
+// are referred to by their index in the string table.
+//
+//	index   = magic strings symbols .
+//	strings = n { len bytes } .
+//	symbols = n { key kind def refs list(methods) list(ptrMethods) list(instances) } .
+//	def     = location .
+//	refs    = n { location } .
+//	location= file line column .
+//	list    = 0 (nil) | n+1 { string } .
+const magic = "go/types/symbols\x00v1\n"
+
+// Encode writes the index to w. The encoding may be read back with Decode.
+func (x *Index) Encode(w io.Writer) error {
+	e := &encoder{w: bufio.NewWriter(w), index: make(map[string]uint64)}
+
+	// collect string table
+	for _, s := range x.syms {
+		e.intern(s.Key)
+		e.intern(s.Def.File)
+		for _, l := range s.refs {
+			e.intern(l.File)
+		}
+		for _, list := range [...][]string{s.methods, s.ptrMethods, s.instances} {
+			for _, str := range list {
+				e.intern(str)
+			}
+		}
+	}
+
+	e.w.WriteString(magic)
+	e.uint(uint64(len(e.strings)))
+	for _, str := range e.strings {
+		e.uint(uint64(len(str)))
+		e.w.WriteString(str)
+	}
+
+	e.uint(uint64(len(x.syms)))
+	for _, s := range x.syms {
+		e.uint(e.index[s.Key])
+		e.uint(uint64(s.Kind))
+		e.location(s.Def)
+		e.uint(uint64(len(s.refs)))
+		for _, l := range s.refs {
+			e.location(l)
+		}
+		e.list(s.methods)
+		e.list(s.ptrMethods)
+		e.list(s.instances)
+	}
+
+	return e.w.Flush()
+}
+
+type encoder struct {
+	w       *bufio.Writer
+	strings []string
+	index   map[string]uint64
+	buf     [binary.MaxVarintLen64]byte
+}
+
+func (e *encoder) intern(s string) {
+	if _, ok := e.index[s]; !ok {
+		e.index[s] = uint64(len(e.strings))
+		e.strings = append(e.strings, s)
+	}
+}
+
+func (e *encoder) uint(x uint64) {
+	n := binary.PutUvarint(e.buf[:], x)
+	e.w.Write(e.buf[:n])
+}
+
+func (e *encoder) location(l Location) {
+	e.uint(e.index[l.File])
+	e.uint(uint64(l.Line))
+	e.uint(uint64(l.Column))
+}
+
+func (e *encoder) list(list []string) {
+	if list == nil {
+		e.uint(0)
+		return
+	}
+	e.uint(uint64(len(list)) + 1)
+	for _, s := range list {
+		e.uint(e.index[s])
+	}
+}
+
+// errFormat is reported for malformed encodings.
+var errFormat = errors.New("symbols: invalid index encoding")
+
+// maxString is the maximum length of a string of an encoding.
+const maxString = 1 << 30
+
+// Decode reads an index encoded with Encode from r.
+func Decode(r io.Reader) (*Index, error) {
+	d := &decoder{r: bufio.NewReader(r)}
+
+	var hdr [len(magic)]byte
+	if _, err := io.ReadFull(d.r, hdr[:]); err != nil {
+		return nil, d.fail(err)
+	}
+	if string(hdr[:]) != magic {
+		return nil, errFormat
+	}
+
+	n := d.uint()
+	if d.err == nil && n > 1<<32 {
+		return nil, errFormat
+	}
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		size := d.uint()
+		if d.err != nil {
+			break
+		}
+		if size > maxString {
+			return nil, errFormat
+		}
+		// Don't trust size for the allocation: the string only grows
+		// as data is actually read.
+		var b strings.Builder
+		if _, err := io.CopyN(&b, d.r, int64(size)); err != nil {
+			return nil, d.fail(err)
+		}
+		d.strings = append(d.strings, b.String())
+	}
+
+	x := new(Index)
+	n = d.uint()
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		s := new(symbol)
+		s.Key = d.string()
+		s.Kind = Kind(d.uint())
+		s.Def = d.location()
+		if m := d.uint(); m > 0 && d.err == nil {
+			s.refs = make([]Location, 0, min(m, 1<<16))
+			for j := uint64(0); j < m && d.err == nil; j++ {
+				s.refs = append(s.refs, d.location())
+			}
+		}
+		s.methods = d.list()
+		s.ptrMethods = d.list()
+		s.instances = d.list()
+		if len(x.syms) > 0 && x.syms[len(x.syms)-1].Key >= s.Key {
+			d.err = errFormat // symbols must be sorted and unique
+		}
+		x.syms = append(x.syms, s)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return x, nil
+}
+
+type decoder struct {
+	r       *bufio.Reader
+	strings []string
+	err     error // first error encountered
+}
+
+func (d *decoder) fail(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = fmt.Errorf("%w: unexpected end of data", errFormat)
+	}
+	if d.err == nil {
+		d.err = err
+	}
+	return d.err
+}
+
+func (d *decoder) uint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	x, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		d.fail(err)
+	}
+	return x
+}
+
+func (d *decoder) string() string {
+	i := d.uint()
+	if d.err != nil {
+		return ""
+	}
+	if i >= uint64(len(d.strings)) {
+		d.err = errFormat
+		return ""
+	}
+	return d.strings[i]
+}
+
+func (d *decoder) location() Location {
+	return Location{d.string(), int(d.uint()), int(d.uint())}
+}
+
+func (d *decoder) list() []string {
+	n := d.uint()
+	if n == 0 || d.err != nil {
+		return nil
+	}
+	list := make([]string, 0, min(n-1, 1<<16))
+	for i := uint64(1); i < n && d.err == nil; i++ {
+		list = append(list, d.string())
+	}
+	return list
+}
+
+func min(x, y uint64) uint64 {
+	if x < y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,280 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package symbols implements a compact index of the symbols of type-checked
+// Go packages.
+//
+// An Index records the definitions of and references to package-level
+// objects, methods, and struct fields, the method sets of package-level
+// types (to find the types implementing an interface), and the instantiations
+// of generic types and functions. The index only holds strings and positions;
+// it does not retain the type-checked packages and may be encoded, decoded,
+// and merged with the indices of other packages.
+//
+// Symbols are identified by keys of the form
+//
+//	path.Name         package-level object Name of the package with import path path
+//	path.Type.Method  method Method of the package-level type Type
+//	path.Type.Field   field Field of the package-level struct type Type
+//
+// Objects that have no such key (for instance, local variables) are not
+// indexed.
+package symbols
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A Kind describes the kind of a symbol.
+type Kind uint8
+
+const (
+	Invalid Kind = iota
+	Const
+	Var
+	Func
+	Type
+	Interface
+	Method
+	Field
+)
+
+var kindNames = [...]string{
+	Invalid:   "invalid",
+	Const:     "const",
+	Var:       "var",
+	Func:      "func",
+	Type:      "type",
+	Interface: "interface",
+	Method:    "method",
+	Field:     "field",
+}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("Kind(%d)", k)
+}
+
+// A Location is a source position.
+type Location struct {
+	File         string
+	Line, Column int
+}
+
+// IsValid reports whether the location is valid.
+func (l Location) IsValid() bool { return l.Line > 0 }
+
+func (l Location) String() string {
+	if !l.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+}
+
+func (l Location) less(m Location) bool {
+	if l.File != m.File {
+		return l.File < m.File
+	}
+	if l.Line != m.Line {
+		return l.Line < m.Line
+	}
+	return l.Column < m.Column
+}
+
+// A Symbol describes an indexed symbol.
+type Symbol struct {
+	Key  string
+	Kind Kind
+	Def  Location // position of the definition; invalid if the symbol is only referenced
+}
+
+// An Implementation describes a type implementing an interface.
+type Implementation struct {
+	Key string // key of the implementing type
+	Ptr bool   // only a pointer to the type implements the interface
+}
+
+// An Index is a compact index of symbols. The zero value is an empty index.
+// An Index must not be modified during queries, but it may be queried
+// concurrently.
+type Index struct {
+	syms []*symbol // sorted by key
+}
+
+type symbol struct {
+	Symbol
+	refs       []Location // sorted
+	methods    []string   // sorted method set entries of the type (or interface)
+	ptrMethods []string   // sorted additional entries of the pointer method set
+	instances  []string   // sorted type argument lists of instantiations
+}
+
+// Len returns the number of symbols in the index.
+func (x *Index) Len() int { return len(x.syms) }
+
+// At returns the i'th symbol of the index, for 0 <= i < Len().
+// Symbols are sorted by key.
+func (x *Index) At(i int) Symbol { return x.syms[i].Symbol }
+
+// lookup returns the symbol with the given key, or nil.
+func (x *Index) lookup(key string) *symbol {
+	i := sort.Search(len(x.syms), func(i int) bool { return x.syms[i].Key >= key })
+	if i < len(x.syms) && x.syms[i].Key == key {
+		return x.syms[i]
+	}
+	return nil
+}
+
+// Lookup returns the symbol with the given key and reports whether it exists.
+func (x *Index) Lookup(key string) (Symbol, bool) {
+	if s := x.lookup(key); s != nil {
+		return s.Symbol, true
+	}
+	return Symbol{}, false
+}
+
+// References returns the locations of the references to the symbol with the
+// given key, sorted by position.
+func (x *Index) References(key string) []Location {
+	if s := x.lookup(key); s != nil {
+		return s.refs
+	}
+	return nil
+}
+
+// Instances returns the type argument lists, such as "[int, string]", of the
+// instantiations of the generic type or function with the given key, sorted.
+func (x *Index) Instances(key string) []string {
+	if s := x.lookup(key); s != nil {
+		return s.instances
+	}
+	return nil
+}
+
+// Implementations returns the indexed types implementing the interface with
+// the given key, sorted by key. Only types defined in the indexed packages
+// are considered; generic types and the interface itself are excluded.
+func (x *Index) Implementations(key string) []Implementation {
+	iface := x.lookup(key)
+	if iface == nil || iface.Kind != Interface {
+		return nil
+	}
+	var list []Implementation
+	for _, s := range x.syms {
+		if s == iface || s.Kind != Type && s.Kind != Interface || s.methods == nil {
+			continue
+		}
+		switch {
+		case subset(iface.methods, s.methods):
+			list = append(list, Implementation{s.Key, false})
+		case s.Kind == Type && subset(iface.methods, merge(s.methods, s.ptrMethods)):
+			list = append(list, Implementation{s.Key, true})
+		}
+	}
+	return list
+}
+
+// subset reports whether the sorted list a is a subset of the sorted list b.
+func subset(a, b []string) bool {
+	for _, m := range a {
+		i := sort.SearchStrings(b, m)
+		if i == len(b) || b[i] != m {
+			return false
+		}
+		b = b[i+1:]
+	}
+	return true
+}
+
+// merge returns the sorted union of the sorted lists a and b.
+func merge(a, b []string) []string {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	list := make([]string, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			list = append(list, a[0])
+			a = a[1:]
+		case a[0] > b[0]:
+			list = append(list, b[0])
+			b = b[1:]
+		default:
+			list = append(list, a[0])
+			a, b = a[1:], b[1:]
+		}
+	}
+	list = append(list, a...)
+	return append(list, b...)
+}
+
+// mergeLocations returns the sorted union of the sorted lists a and b.
+func mergeLocations(a, b []Location) []Location {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	list := make([]Location, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0].less(b[0]):
+			list = append(list, a[0])
+			a = a[1:]
+		case b[0].less(a[0]):
+			list = append(list, b[0])
+			b = b[1:]
+		default:
+			list = append(list, a[0])
+			a, b = a[1:], b[1:]
+		}
+	}
+	list = append(list, a...)
+	return append(list, b...)
+}
+
+// Merge returns the index combining the given indices, which may be shards
+// built from different packages or overlapping sets of packages. The
+// definition of a symbol is taken from the first index defining it;
+// references and instantiations are combined.
+func Merge(indices ...*Index) *Index {
+	m := make(map[string]*symbol)
+	for _, x := range indices {
+		for _, s := range x.syms {
+			t := m[s.Key]
+			if t == nil {
+				c := *s
+				m[s.Key] = &c
+				continue
+			}
+			if !t.Def.IsValid() && s.Def.IsValid() {
+				t.Kind = s.Kind
+				t.Def = s.Def
+				t.methods = s.methods
+				t.ptrMethods = s.ptrMethods
+			}
+			t.refs = mergeLocations(t.refs, s.refs)
+			t.instances = merge(t.instances, s.instances)
+		}
+	}
+	return newIndex(m)
+}
+
+// newIndex returns the index of the symbols in m.
+func newIndex(m map[string]*symbol) *Index {
+	x := &Index{syms: make([]*symbol, 0, len(m))}
+	for _, s := range m {
+		x.syms = append(x.syms, s)
+	}
+	sort.Slice(x.syms, func(i, j int) bool { return x.syms[i].Key < x.syms[j].Key })
+	return x
+}
@@ -0,0 +1,217 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package symbols_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+
+	. "go/types/symbols"
+)
+
+type importer map[string]*types.Package
+
+func (m importer) Import(path string) (*types.Package, error) {
+	if pkg := m[path]; pkg != nil {
+		return pkg, nil
+	}
+	return nil, fmt.Errorf("package %q not found", path)
+}
+
+const srcA = `package a
+
+type I interface{ M() }
+
+type T struct{ F int }
+
+func (*T) M() {}
+
+type G[P any] struct{ f P }
+
+func Id[P any](x P) P { return x }
+
+var _ = Id(T{F: 1})
+`
+
+const srcB = `package b
+
+import "a"
+
+type U struct{ a.T }
+
+type V int
+
+func (V) M() {}
+
+var _ a.I = V(0)
+var _ a.G[V]
+var _ = a.Id[string]
+var _ = new(a.T).F
+`
+
+// build type-checks the package src, using the packages in imp,
+// and returns its index.
+func build(t *testing.T, fset *token.FileSet, imp importer, filename, src string) *Index {
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types:    make(map[ast.Expr]types.TypeAndValue),
+		Defs:     make(map[*ast.Ident]types.Object),
+		Uses:     make(map[*ast.Ident]types.Object),
+		Inferred: make(map[ast.Expr]types.Inferred),
+		Callees:  make(map[*ast.CallExpr]types.Callee),
+	}
+	conf := types.Config{Importer: imp}
+	pkg, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp[pkg.Path()] = pkg
+	return Build(fset, pkg, info)
+}
+
+func TestIndex(t *testing.T) {
+	fset := token.NewFileSet()
+	imp := make(importer)
+	a := build(t, fset, imp, "a.go", srcA)
+	b := build(t, fset, imp, "b.go", srcB)
+
+	// The merged index must not depend on the order of shards,
+	// and must survive an encoding round trip.
+	x := Merge(b, a)
+	var buf bytes.Buffer
+	if err := x.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	y, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(x, y) {
+		t.Errorf("decoded index differs from encoded index")
+	}
+	if z := Merge(a, b); !reflect.DeepEqual(x, z) {
+		t.Errorf("merged index depends on shard order")
+	}
+
+	for _, test := range []struct {
+		key  string
+		kind Kind
+		def  string
+	}{
+		{"a.I", Interface, "a.go:3:6"},
+		{"a.I.M", Method, "a.go:3:19"},
+		{"a.T.F", Field, "a.go:5:16"},
+		{"a.T.M", Method, "a.go:7:11"},
+		{"a.Id", Func, "a.go:11:6"},
+		{"b.U.T", Field, "b.go:5:18"},
+		{"b.V", Type, "b.go:7:6"},
+	} {
+		s, ok := y.Lookup(test.key)
+		if !ok {
+			t.Errorf("%s not found", test.key)
+			continue
+		}
+		if s.Kind != test.kind || s.Def.String() != test.def {
+			t.Errorf("%s: got %s at %s, want %s at %s", test.key, s.Kind, s.Def, test.kind, test.def)
+		}
+	}
+	if _, ok := y.Lookup("a.G.f"); !ok {
+		t.Errorf("a.G.f not found")
+	}
+
+	refs := func(key string) (list []string) {
+		for _, l := range y.References(key) {
+			list = append(list, l.String())
+		}
+		return
+	}
+	if got, want := refs("a.T"), []string{"a.go:7:8", "a.go:13:12", "b.go:5:18", "b.go:14:15"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("references to a.T: got %v, want %v", got, want)
+	}
+	if got, want := refs("a.T.F"), []string{"a.go:13:14", "b.go:14:18"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("references to a.T.F: got %v, want %v", got, want)
+	}
+
+	impls := []Implementation{{"a.T", true}, {"b.U", true}, {"b.V", false}}
+	if got := y.Implementations("a.I"); !reflect.DeepEqual(got, impls) {
+		t.Errorf("implementations of a.I: got %v, want %v", got, impls)
+	}
+
+	if got, want := y.Instances("a.G"), []string{"[b.V]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("instances of a.G: got %v, want %v", got, want)
+	}
+	if got, want := y.Instances("a.Id"), []string{"[a.T]", "[string]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("instances of a.Id: got %v, want %v", got, want)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := new(Index).Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	for _, bad := range [][]byte{
+		nil,
+		data[:len(data)-1],
+		append([]byte("x"), data[1:]...),
+	} {
+		if _, err := Decode(bytes.NewReader(bad)); err == nil {
+			t.Errorf("Decode(%q) succeeded unexpectedly", bad)
+		}
+	}
+
+	// A huge string length must not be trusted.
+	for _, size := range []uint64{1 << 20, 1 << 40, 1<<64 - 1} {
+		var n [binary.MaxVarintLen64]byte
+		bad := append(data[:len(data)-2:len(data)-2], 1) // one string
+		bad = append(bad, n[:binary.PutUvarint(n[:], size)]...)
+		bad = append(bad, "abc"...)
+		if _, err := Decode(bytes.NewReader(bad)); !errors.Is(err, invalidEncoding()) {
+			t.Errorf("string of length %d: got error %v, want an invalid encoding", size, err)
+		}
+	}
+}
+
+// invalidEncoding returns the error reported for an invalid encoding.
+func invalidEncoding() error {
+	_, err := Decode(bytes.NewReader(nil))
+	return errors.Unwrap(err)
+}
+
+// Corrupted encodings are rejected or decoded, but never cause a panic.
+func TestDecodeCorrupt(t *testing.T) {
+	fset := token.NewFileSet()
+	imp := make(importer)
+	x := Merge(build(t, fset, imp, "a.go", srcA), build(t, fset, imp, "b.go", srcB))
+	var buf bytes.Buffer
+	if err := x.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	for i := range data {
+		for _, b := range []byte{0, 1, 0x7f, 0x80, 0xff, data[i] ^ 0x10} {
+			bad := append([]byte(nil), data...)
+			bad[i] = b
+			if _, err := Decode(bytes.NewReader(bad)); err != nil && !errors.Is(err, invalidEncoding()) {
+				t.Fatalf("byte %d set to %#x: got error %v, want an invalid encoding", i, b, err)
+			}
+		}
+		if _, err := Decode(bytes.NewReader(data[:i])); err == nil {
+			t.Errorf("encoding truncated to %d bytes: Decode succeeded unexpectedly", i)
+		}
+	}
+}
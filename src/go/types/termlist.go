@@ -4,7 +4,10 @@
 
 package types
 
-import "bytes"
+import (
+	"bytes"
+	"sort"
+)
 
 // A termlist represents the type set represented by the union
 // t1 ∪ y2 ∪ ... tn of the type sets of the terms t1 to tn.
@@ -58,10 +61,54 @@ func (xl termlist) isAll() bool {
 	return false
 }
 
+// Termlists with more than smallTermlist terms are normalized and
+// intersected with a termIndex, which only compares terms that may
+// overlap. This makes these operations roughly linear for the large
+// unions of distinct types found in generated constraints.
+const smallTermlist = 16
+
 // norm returns the normal form of xl.
 func (xl termlist) norm() termlist {
-	// Quadratic algorithm, but good enough for now.
-	// TODO(gri) fix asymptotic performance
+	if len(xl) <= smallTermlist {
+		return xl.normQuadratic()
+	}
+	// If we encounter a 𝓤 term, the entire list is 𝓤.
+	if xl.isAll() {
+		return allTermlist
+	}
+
+	// Merge each term with the later terms it may overlap, in order,
+	// as normQuadratic does. If a merged term becomes a tilde term, it
+	// may overlap more of the later terms.
+	ix := newTermIndex(xl)
+	var used termBits = make([]uint64, len(xl)/64+1)
+	var rl termlist
+	for i, xi := range xl {
+		if xi == nil || used.has(i) {
+			continue
+		}
+		cands := ix.candidates(xi)
+		for n := search(cands, i); n < len(cands); n++ {
+			j := cands[n]
+			if used.has(j) {
+				continue
+			}
+			if u1, u2 := xi.union(xl[j]); u2 == nil {
+				if exactNamed(u1) == nil && exactNamed(xi) != nil {
+					cands = ix.candidates(u1)
+					n = search(cands, j) - 1
+				}
+				xi = u1
+				used.set(j) // xj is now unioned into xi - ignore it in future iterations
+			}
+		}
+		rl = append(rl, xi)
+	}
+	return rl
+}
+
+// normQuadratic is like norm but compares all pairs of terms.
+func (xl termlist) normQuadratic() termlist {
 	used := make([]bool, len(xl))
 	var rl termlist
 	for i, xi := range xl {
@@ -112,13 +159,32 @@ func (xl termlist) intersect(yl termlist) termlist {
 		return nil
 	}
 
-	// Quadratic algorithm, but good enough for now.
-	// TODO(gri) fix asymptotic performance
+	if len(xl)*len(yl) <= smallTermlist*smallTermlist || yl.isAll() {
+		var rl termlist
+		for _, x := range xl {
+			for _, y := range yl {
+				if r := x.intersect(y); r != nil {
+					rl = append(rl, r)
+				}
+			}
+		}
+		return rl.norm()
+	}
+
+	// Only intersect terms that may overlap. yl contains no 𝓤 term.
+	ix := newTermIndex(yl)
 	var rl termlist
 	for _, x := range xl {
-		for _, y := range yl {
-			if r := x.intersect(y); r != nil {
-				rl = append(rl, r)
+		switch {
+		case x == nil:
+			// nothing to do
+		case x.typ == nil:
+			rl = append(rl, yl...) // 𝓤 ∩ yl == yl
+		default:
+			for _, i := range ix.candidates(x) {
+				if r := x.intersect(yl[i]); r != nil {
+					rl = append(rl, r)
+				}
 			}
 		}
 	}
@@ -165,3 +231,235 @@ func (xl termlist) subsetOf(yl termlist) bool {
 	}
 	return true
 }
+
+// A termKey partitions non-empty, non-𝓤 terms such that terms with
+// different keys are guaranteed to be disjoint. Terms with the same key
+// may or may not be disjoint.
+type termKey struct {
+	kind int         // kind of under(typ): basic kind or one of the termKey* values
+	n    int         // additional kind-specific discriminator (such as array length)
+	elem interface{} // kind-specific elementKey, or nil
+}
+
+// termKey kinds for non-basic types; basic kinds are non-negative.
+const (
+	termKeyOther = -1 - iota
+	termKeyPointer
+	termKeySlice
+	termKeyArray
+	termKeyMap
+	termKeyChan
+	termKeyStruct
+	termKeySignature
+)
+
+// keyOf returns the partition key of the non-empty, non-𝓤 term x.
+// Two terms can only overlap if their types have identical underlying
+// types, so the key is computed from the underlying type alone, such
+// that identical types always produce the same key.
+func keyOf(x *term) termKey {
+	switch u := under(x.typ).(type) {
+	case *Basic:
+		return termKey{int(u.kind), 0, nil}
+	case *Pointer:
+		return termKey{termKeyPointer, 0, elementKey(u.base)}
+	case *Slice:
+		return termKey{termKeySlice, 0, elementKey(u.elem)}
+	case *Array:
+		return termKey{termKeyArray, int(u.len), elementKey(u.elem)}
+	case *Map:
+		return termKey{termKeyMap, 0, elementKey(u.elem)}
+	case *Chan:
+		return termKey{termKeyChan, int(u.dir), elementKey(u.elem)}
+	case *Struct:
+		return termKey{termKeyStruct, len(u.fields), nil}
+	case *Signature:
+		return termKey{termKeySignature, u.params.Len()<<16 | u.results.Len(), nil}
+	}
+	return termKey{termKeyOther, 0, nil}
+}
+
+// elementKey returns a coarse key for an element type typ which is the same
+// for identical types: the basic kind for basic types, the type name for
+// (possibly instantiated) defined types, and nil otherwise. Aliases have
+// the key of the type they denote.
+func elementKey(typ Type) interface{} {
+	switch t := Unalias(typ).(type) {
+	case *Basic:
+		return t.kind
+	case *Named:
+		return t.Obj()
+	}
+	return nil
+}
+
+// A termIndex supports fast operations on a large termlist (such as a
+// constraint listing hundreds of enumerated types). Its terms form a table
+// in which each term is identified by its index, so that sets of terms are
+// represented by bitsets. The terms are indexed by partition (see keyOf),
+// and the exact terms of defined types, which make up enumerations, by
+// their type, since they only overlap identical types and tilde terms.
+type termIndex struct {
+	terms termlist              // table of the terms; nil terms are not indexed
+	parts map[termKey]*termPart // term indices by partition
+}
+
+// A termPart holds the indices of the terms of a partition, in increasing
+// order.
+type termPart struct {
+	all   []int            // all terms
+	other []int            // all terms but the exact terms of defined types
+	named map[*Named][]int // exact terms of defined types, by origin type
+}
+
+// newTermIndex returns an index for the termlist xl, which must not
+// contain a 𝓤 term.
+func newTermIndex(xl termlist) *termIndex {
+	ix := &termIndex{xl, make(map[termKey]*termPart)}
+	for i, x := range xl {
+		if x == nil {
+			continue
+		}
+		k := keyOf(x)
+		p := ix.parts[k]
+		if p == nil {
+			p = &termPart{named: make(map[*Named][]int)}
+			ix.parts[k] = p
+		}
+		p.all = append(p.all, i)
+		if n := exactNamed(x); n != nil {
+			p.named[n] = append(p.named[n], i)
+		} else {
+			p.other = append(p.other, i)
+		}
+	}
+	return ix
+}
+
+// newTypeSetIndex returns an index for the normalized termlist xl of a
+// type set, or nil if xl is too short to benefit from one or is 𝓤.
+func newTypeSetIndex(xl termlist) *termIndex {
+	if len(xl) <= smallTermlist || xl.isAll() {
+		return nil
+	}
+	return newTermIndex(xl)
+}
+
+// exactNamed returns the origin type of x's type if x is an exact term of
+// a defined type, and nil otherwise.
+func exactNamed(x *term) *Named {
+	if n, _ := Unalias(x.typ).(*Named); n != nil && !x.tilde {
+		return n.orig
+	}
+	return nil
+}
+
+// candidates returns the indices of the terms of ix that may overlap the
+// non-empty, non-𝓤 term x, in increasing order.
+func (ix *termIndex) candidates(x *term) []int {
+	p := ix.parts[keyOf(x)]
+	if p == nil {
+		return nil
+	}
+	n := exactNamed(x)
+	if n == nil {
+		return p.all
+	}
+	return mergeIndices(p.other, p.named[n])
+}
+
+// mergeIndices returns the union of the sorted index lists a and b.
+func mergeIndices(a, b []int) []int {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	res := make([]int, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if a[0] < b[0] {
+			res, a = append(res, a[0]), a[1:]
+		} else {
+			res, b = append(res, b[0]), b[1:]
+		}
+	}
+	res = append(res, a...)
+	return append(res, b...)
+}
+
+// search returns the position of the first index greater than j in the
+// sorted index list list.
+func search(list []int, j int) int {
+	return sort.Search(len(list), func(n int) bool { return list[n] > j })
+}
+
+// includes reports whether t ∈ ix.terms.
+func (ix *termIndex) includes(t Type) bool {
+	x := &term{false, t}
+	for _, i := range ix.candidates(x) {
+		if ix.terms[i].includes(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// supersetOf reports whether y ⊆ ix.terms.
+func (ix *termIndex) supersetOf(y *term) bool {
+	switch {
+	case y == nil:
+		return true // ∅ ⊆ ix.terms
+	case y.typ == nil:
+		return false // ix.terms is not 𝓤
+	}
+	for _, i := range ix.candidates(y) {
+		if y.subsetOf(ix.terms[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// subsetOfIndexed reports whether xl ⊆ yl, where yl is the indexed termlist.
+func (xl termlist) subsetOfIndexed(ix *termIndex) bool {
+	for _, x := range xl {
+		if !ix.supersetOf(x) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameTerms reports whether xl consists of the same terms as ix.terms, in
+// any order, where both lists are in normal form. If so, they represent the
+// same type set.
+func (ix *termIndex) sameTerms(xl termlist) bool {
+	if len(xl) != len(ix.terms) {
+		return false
+	}
+	var seen termBits = make([]uint64, len(ix.terms)/64+1)
+	for _, x := range xl {
+		if x == nil || x.typ == nil {
+			return false
+		}
+		found := false
+		for _, i := range ix.candidates(x) {
+			if !seen.has(i) && x.equal(ix.terms[i]) {
+				seen.set(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// A termBits is a set of term indices.
+type termBits []uint64
+
+func (b termBits) has(i int) bool { return b[i/64]&(1<<(i%64)) != 0 }
+func (b termBits) set(i int)      { b[i/64] |= 1 << (i % 64) }
@@ -5,6 +5,9 @@
 package types
 
 import (
+	"fmt"
+	"go/token"
+	"sort"
 	"strings"
 	"testing"
 )
@@ -311,3 +314,142 @@ func TestTermlistSubsetOf(t *testing.T) {
 		}
 	}
 }
+
+// largeTermlist returns a term list of n terms drawn (deterministically)
+// from a mix of basic, tilde, defined, and composite types.
+func largeTermlist(n, seed int) termlist {
+	var terms []*term
+	for _, x := range testTerms {
+		if x != nil && x.typ != nil {
+			terms = append(terms, x)
+		}
+	}
+	for _, typ := range []Type{NewSlice(Typ[Int]), NewSlice(myInt), NewPointer(Typ[String]), NewArray(Typ[Int], 3)} {
+		terms = append(terms, &term{false, typ}, &term{true, typ})
+	}
+	for i := 0; i < 20; i++ {
+		tname := NewTypeName(token.NoPos, nil, fmt.Sprintf("E%d", i), nil)
+		terms = append(terms, &term{false, NewNamed(tname, Typ[Int], nil)})
+	}
+	sortTerms(terms)
+
+	var xl termlist
+	for i := 0; i < n; i++ {
+		xl = append(xl, terms[(i*13+seed)%len(terms)]) // len(terms) and 13 are coprime
+	}
+	return xl
+}
+
+func sortTerms(terms []*term) {
+	sort.Slice(terms, func(i, j int) bool { return terms[i].String() < terms[j].String() })
+}
+
+func TestTermlistLarge(t *testing.T) {
+	for n := smallTermlist; n < 4*smallTermlist; n += 5 {
+		for seed := 0; seed < 3; seed++ {
+			xl := largeTermlist(n, seed)
+			want := xl.normQuadratic()
+			if got := xl.norm(); got.String() != want.String() {
+				t.Errorf("(%v).norm() = %v; want %v", xl, got, want)
+			}
+
+			yl := largeTermlist(n, seed+4)
+			var rl termlist
+			for _, x := range xl {
+				for _, y := range yl {
+					if r := x.intersect(y); r != nil {
+						rl = append(rl, r)
+					}
+				}
+			}
+			if got, want := xl.intersect(yl), rl.normQuadratic(); !got.equal(want) {
+				t.Errorf("(%v).intersect(%v) = %v; want %v", xl, yl, got, want)
+			}
+		}
+	}
+}
+
+func TestTermIndex(t *testing.T) {
+	// Use exact terms only, so that normalization doesn't collapse them.
+	var xl termlist
+	for _, x := range largeTermlist(3*smallTermlist, 1) {
+		if !x.tilde {
+			xl = append(xl, x)
+		}
+	}
+	xl = xl.norm()
+	ix := newTermIndex(xl)
+	if ix == nil {
+		t.Fatalf("no index for %d terms", len(xl))
+	}
+	for _, y := range largeTermlist(3*smallTermlist, 2) {
+		if got, want := ix.supersetOf(y), xl.supersetOf(y); got != want {
+			t.Errorf("index.supersetOf(%v) = %v; want %v", y, got, want)
+		}
+		if got, want := ix.includes(y.typ), xl.includes(y.typ); got != want {
+			t.Errorf("index.includes(%v) = %v; want %v", y.typ, got, want)
+		}
+	}
+	if !xl.subsetOfIndexed(ix) {
+		t.Errorf("%v is not a subset of itself", xl)
+	}
+}
+
+// enumTermlist returns a term list of the exact terms of n distinct
+// defined types with underlying type int.
+func enumTermlist(n int) termlist {
+	var xl termlist
+	for i := 0; i < n; i++ {
+		tname := NewTypeName(token.NoPos, nil, fmt.Sprintf("E%d", i), nil)
+		xl = append(xl, &term{false, NewNamed(tname, Typ[Int], nil)})
+	}
+	return xl
+}
+
+func TestTermlistEnum(t *testing.T) {
+	xl := enumTermlist(500)
+	// The terms are all in the same partition, but don't overlap.
+	if got := xl.norm(); len(got) != len(xl) {
+		t.Fatalf("norm of %d distinct terms has %d terms", len(xl), len(got))
+	}
+	yl := append(xl[250:len(xl):len(xl)], xl[:100]...)
+	if got := xl.intersect(yl); !got.equal(yl) || len(got) != len(yl) {
+		t.Errorf("intersection has %d terms, want %d", len(got), len(yl))
+	}
+
+	// A tilde term overlaps all terms.
+	zl := append(append(xl[:300:300], &term{true, Typ[Int]}), xl[300:]...)
+	if got, want := zl.norm(), zl.normQuadratic(); got.String() != want.String() {
+		t.Errorf("norm = %v; want %v", got, want)
+	}
+
+	// sameTerms ignores the order of the terms.
+	ix := newTypeSetIndex(xl)
+	rev := make(termlist, len(xl))
+	for i, x := range xl {
+		rev[len(xl)-1-i] = x
+	}
+	if !ix.sameTerms(rev) {
+		t.Errorf("reversed termlist does not have the same terms")
+	}
+	if ix.sameTerms(append(rev[1:len(rev):len(rev)], rev[1])) {
+		t.Errorf("termlist with a duplicate term has the same terms")
+	}
+}
+
+func BenchmarkTermlistEnum(b *testing.B) {
+	for _, n := range []int{100, 1000} {
+		xl := enumTermlist(n)
+		yl := append(xl[n/2:n:n], xl[:n/4]...)
+		b.Run(fmt.Sprintf("norm/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				xl.norm()
+			}
+		})
+		b.Run(fmt.Sprintf("intersect/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				xl.intersect(yl)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements checking packages together with their tests.
+
+package types
+
+import (
+	gocontext "context"
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// CheckTest type-checks the package with the given path together with its
+// tests, as done by "go test": files are the files of the package including
+// its in-package test files, and xtestFiles are the files of the external
+// test package, if any, whose path is path+"_test". Imports of path by the
+// external test package resolve to the test-augmented package; all other
+// imports are resolved by conf.Importer. A package imported by the external
+// test package that imports path itself, directly or indirectly, such as
+// net/http/httptest imported by net/http_test, must be checked again against
+// the test-augmented package, as "go test" does: CheckTest leaves this to
+// conf.Importer, which must implement TestImporter. Otherwise, such an
+// import would see a different package for path, and is reported as an
+// error.
+//
+// The results of checking the package and the external test package are
+// recorded in info and xinfo, respectively, which may be nil. The returned
+// error is the first error reported by either check; as for Check, the
+// external test package is not checked if the package has errors and
+// conf.Error is not set. If there are no xtestFiles, the external test
+// package is nil.
+func (conf *Config) CheckTest(path string, fset *token.FileSet, files, xtestFiles []*ast.File, info, xinfo *Info) (pkg, xpkg *Package, err error) {
+	pkg, err = conf.Check(path, fset, files, info)
+	if len(xtestFiles) == 0 || err != nil && conf.Error == nil {
+		return pkg, nil, err
+	}
+
+	xconf := *conf
+	xconf.Importer = testImporter{path, pkg, conf.Importer}
+	xpkg, xerr := xconf.Check(path+"_test", fset, xtestFiles, xinfo)
+	if err == nil {
+		err = xerr
+	}
+	return pkg, xpkg, err
+}
+
+// A TestImporter is an Importer that can import the dependencies of an
+// external test package checked with Config.CheckTest.
+type TestImporter interface {
+	Importer
+
+	// ImportForTest is like ImportFrom, but imports the package with the
+	// given import path for the external test package of the package
+	// under test, whose test-augmented package is test. If the imported
+	// package imports the package under test, directly or indirectly, it
+	// and the packages it imports on the way must be (re-)checked with
+	// imports of test.Path() resolving to test.
+	ImportForTest(path, dir string, mode ImportMode, test *Package) (*Package, error)
+}
+
+// A testImporter imports the test-augmented package pkg for its path,
+// and delegates all other imports to imp.
+type testImporter struct {
+	path string
+	pkg  *Package
+	imp  Importer
+}
+
+func (t testImporter) Import(path string) (*Package, error) {
+	return t.ImportFromContext(gocontext.Background(), path, "", 0)
+}
+
+func (t testImporter) ImportFrom(path, dir string, mode ImportMode) (*Package, error) {
+	return t.ImportFromContext(gocontext.Background(), path, dir, mode)
+}
+
+func (t testImporter) ImportFromContext(ctx gocontext.Context, path, dir string, mode ImportMode) (*Package, error) {
+	if path == t.path {
+		return t.pkg, nil
+	}
+	var pkg *Package
+	var err error
+	switch imp := t.imp.(type) {
+	case nil:
+		return nil, fmt.Errorf("Config.Importer not installed")
+	case TestImporter:
+		pkg, err = imp.ImportForTest(path, dir, mode, t.pkg)
+	case ImporterFromContext:
+		pkg, err = imp.ImportFromContext(ctx, path, dir, mode)
+	case ImporterFrom:
+		pkg, err = imp.ImportFrom(path, dir, mode)
+	default:
+		pkg, err = t.imp.Import(path)
+	}
+	if err == nil && pkg != nil && t.importsPath(pkg, make(map[*Package]bool)) {
+		return nil, fmt.Errorf("package %s imports %s, which is not checked with its test files", path, t.path)
+	}
+	return pkg, err
+}
+
+// importsPath reports whether pkg imports a package with path t.path other
+// than t.pkg, directly or indirectly.
+func (t testImporter) importsPath(pkg *Package, seen map[*Package]bool) bool {
+	for _, imp := range pkg.imports {
+		if imp.path == t.path {
+			if imp != t.pkg {
+				return true
+			}
+			continue
+		}
+		if !seen[imp] {
+			seen[imp] = true
+			if t.importsPath(imp, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the recording of Info.DeclTimes.
+
+package types
+
+import (
+	"sort"
+	"time"
+)
+
+// A declTimer attributes the time spent checking to the package-level
+// declarations being checked.
+type declTimer struct {
+	stack []Object  // declarations being checked; the innermost one is charged
+	last  time.Time // start of the interval not yet charged
+}
+
+// beginDecl starts charging the checking time to obj, until the matching
+// call of endDecl. The time spent in nested declarations is charged to
+// those only.
+func (check *Checker) beginDecl(obj Object) {
+	t := &check.timer
+	t.charge(check.DeclTimes)
+	t.stack = append(t.stack, obj)
+}
+
+// endDecl ends the innermost call of beginDecl.
+func (check *Checker) endDecl() {
+	t := &check.timer
+	t.charge(check.DeclTimes)
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// timedDecl returns f, or if declaration times are recorded and a
+// declaration is being checked, a function that calls f and charges
+// the time spent to that declaration.
+func (check *Checker) timedDecl(f func()) func() {
+	n := len(check.timer.stack)
+	if check.DeclTimes == nil || n == 0 {
+		return f
+	}
+	obj := check.timer.stack[n-1]
+	return func() {
+		check.beginDecl(obj)
+		defer check.endDecl()
+		f()
+	}
+}
+
+// charge charges the time since the last call to the innermost declaration.
+func (t *declTimer) charge(times map[Object]time.Duration) {
+	now := time.Now()
+	if n := len(t.stack); n > 0 {
+		times[t.stack[n-1]] += now.Sub(t.last)
+	}
+	t.last = now
+}
+
+// A DeclTime is the time spent checking a declaration; see Info.DeclTimes.
+type DeclTime struct {
+	Obj  Object
+	Time time.Duration
+}
+
+// SlowestDecls returns the n declarations recorded in info.DeclTimes that
+// took the most time to check, slowest first, or all of them if there
+// are fewer than n.
+func (info *Info) SlowestDecls(n int) []DeclTime {
+	list := make([]DeclTime, 0, len(info.DeclTimes))
+	for obj, d := range info.DeclTimes {
+		list = append(list, DeclTime{obj, d})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Time != list[j].Time {
+			return list[i].Time > list[j].Time
+		}
+		return list[i].Obj.Pos() < list[j].Obj.Pos()
+	})
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
@@ -34,6 +34,7 @@ func (t *top) String() string   { return TypeString(t, nil) }
 // under must only be called when a type is known
 // to be fully set up.
 func under(t Type) Type {
+	t = Unalias(t)
 	if n := asNamed(t); n != nil {
 		return n.under()
 	}
@@ -64,6 +65,30 @@ func optype(typ Type) Type {
 	return under(typ)
 }
 
+// If t is not a type parameter, coreType returns the underlying type.
+// If t is a type parameter, coreType returns the single underlying
+// type of all types in its type set if it exists, or nil otherwise.
+// Unlike the structural type of a type parameter, the core type of a
+// type parameter constrained by []int | MySlice, where MySlice is
+// defined as []int, is []int.
+func coreType(t Type) Type {
+	tpar := asTypeParam(t)
+	if tpar == nil {
+		return under(t)
+	}
+	var su Type
+	if tpar.underIs(func(u Type) bool {
+		if u == theTop || su != nil && !Identical(su, u) {
+			return false
+		}
+		su = u
+		return true
+	}) {
+		return su
+	}
+	return nil
+}
+
 // Converters
 //
 // A converter must only be called when a type is
@@ -112,7 +137,7 @@ func asInterface(t Type) *Interface {
 }
 
 func asNamed(t Type) *Named {
-	e, _ := t.(*Named)
+	e, _ := Unalias(t).(*Named)
 	if e != nil {
 		e.expand(nil)
 	}
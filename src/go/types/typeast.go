@@ -0,0 +1,239 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements TypeExpr.
+
+package types
+
+import (
+	"fmt"
+	"go/ast"
+	"go/internal/typeparams"
+	"go/token"
+	"strconv"
+)
+
+// TypeExpr returns a type expression denoting typ, for use in generated
+// code. The Qualifier controls the qualification of package-level type
+// names: it returns the name by which a package is referred to, or "" if
+// its names are not qualified; if it is nil, packages are referred to by
+// their names. The nodes of the expression have no positions.
+//
+// Signatures are returned as *ast.FuncType, without their receivers.
+// TypeExpr returns an error for types that have no Go syntax, such as
+// untyped and invalid types, tuples, and generic signatures, which are
+// only part of function declarations.
+func TypeExpr(typ Type, qf Qualifier) (ast.Expr, error) {
+	c := astTypeConverter{qf: qf}
+	x := c.expr(typ)
+	return x, c.err
+}
+
+type astTypeConverter struct {
+	qf  Qualifier
+	err error // first error
+}
+
+func (c *astTypeConverter) errorf(format string, args ...interface{}) *ast.BadExpr {
+	if c.err == nil {
+		c.err = fmt.Errorf(format, args...)
+	}
+	return &ast.BadExpr{}
+}
+
+func (c *astTypeConverter) expr(typ Type) ast.Expr {
+	switch t := typ.(type) {
+	case nil:
+		return c.errorf("nil type")
+
+	case *Basic:
+		if t.info&IsUntyped != 0 || t.kind == Invalid {
+			return c.errorf("%s has no type expression", t)
+		}
+		// exported basic types go into package unsafe
+		// (currently this is just unsafe.Pointer)
+		if token.IsExported(t.name) {
+			if obj, _ := Unsafe.scope.Lookup(t.name).(*TypeName); obj != nil {
+				return c.typeName(obj)
+			}
+		}
+		return ast.NewIdent(t.name)
+
+	case *Array:
+		if t.len < 0 {
+			return c.errorf("array %s has unknown length", t)
+		}
+		return &ast.ArrayType{Len: &ast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(t.len, 10)}, Elt: c.expr(t.elem)}
+
+	case *Slice:
+		return &ast.ArrayType{Elt: c.expr(t.elem)}
+
+	case *Struct:
+		fields := new(ast.FieldList)
+		for i, f := range t.fields {
+			field := &ast.Field{Type: c.expr(f.typ)}
+			if !f.embedded {
+				field.Names = []*ast.Ident{ast.NewIdent(f.name)}
+			}
+			if tag := t.Tag(i); tag != "" {
+				lit := strconv.Quote(tag)
+				if strconv.CanBackquote(tag) {
+					lit = "`" + tag + "`"
+				}
+				field.Tag = &ast.BasicLit{Kind: token.STRING, Value: lit}
+			}
+			fields.List = append(fields.List, field)
+		}
+		return &ast.StructType{Fields: fields}
+
+	case *Pointer:
+		return &ast.StarExpr{X: c.expr(t.base)}
+
+	case *Tuple:
+		return c.errorf("tuple %s has no type expression", t)
+
+	case *Signature:
+		if t.tparams.Len() > 0 {
+			return c.errorf("generic signature %s has no type expression", t)
+		}
+		return c.funcType(t)
+
+	case *Union:
+		return c.union(t)
+
+	case *Interface:
+		methods := new(ast.FieldList)
+		for _, m := range t.methods {
+			methods.List = append(methods.List, &ast.Field{
+				Names: []*ast.Ident{ast.NewIdent(m.name)},
+				Type:  c.funcType(m.typ.(*Signature)),
+			})
+		}
+		for _, e := range t.embeddeds {
+			methods.List = append(methods.List, &ast.Field{Type: c.expr(e)})
+		}
+		return &ast.InterfaceType{Methods: methods}
+
+	case *Map:
+		return &ast.MapType{Key: c.expr(t.key), Value: c.expr(t.elem)}
+
+	case *Chan:
+		elem := c.expr(t.elem)
+		var dir ast.ChanDir
+		switch t.dir {
+		case SendRecv:
+			dir = ast.SEND | ast.RECV
+			// chan (<-chan T) requires parentheses
+			if e, _ := t.elem.(*Chan); e != nil && e.dir == RecvOnly {
+				elem = &ast.ParenExpr{X: elem}
+			}
+		case SendOnly:
+			dir = ast.SEND
+		case RecvOnly:
+			dir = ast.RECV
+		default:
+			return c.errorf("unknown channel direction")
+		}
+		return &ast.ChanType{Dir: dir, Value: elem}
+
+	case *Named:
+		x := c.typeName(t.obj)
+		if targs := t.TypeArgs(); targs.Len() > 0 {
+			list := make([]ast.Expr, targs.Len())
+			for i := range list {
+				list[i] = c.expr(targs.At(i))
+			}
+			x = typeparams.PackIndexExpr(x, token.NoPos, list, token.NoPos)
+		}
+		return x
+
+	case *Alias:
+		return c.typeName(t.obj)
+
+	case *TypeParam:
+		return ast.NewIdent(t.obj.name)
+	}
+	return c.errorf("unexpected type %T", typ)
+}
+
+// typeName returns the qualified name of obj.
+func (c *astTypeConverter) typeName(obj *TypeName) ast.Expr {
+	name := ast.NewIdent(obj.name)
+	if obj.pkg == nil {
+		return name
+	}
+	qual := obj.pkg.name
+	if c.qf != nil {
+		qual = c.qf(obj.pkg)
+	}
+	if qual == "" {
+		return name
+	}
+	return &ast.SelectorExpr{X: ast.NewIdent(qual), Sel: name}
+}
+
+func (c *astTypeConverter) funcType(sig *Signature) *ast.FuncType {
+	f := &ast.FuncType{Params: c.params(sig.params, sig.variadic), Results: c.params(sig.results, false)}
+	if len(f.Results.List) == 0 {
+		f.Results = nil
+	}
+	return f
+}
+
+// params returns the parameter list for the variables of tup. If a
+// variable is named, unnamed variables in the same list are named "_".
+func (c *astTypeConverter) params(tup *Tuple, variadic bool) *ast.FieldList {
+	list := new(ast.FieldList)
+	if tup == nil {
+		return list
+	}
+	named := false
+	for _, v := range tup.vars {
+		if v.name != "" {
+			named = true
+		}
+	}
+	for i, v := range tup.vars {
+		var typ ast.Expr
+		if variadic && i == tup.Len()-1 {
+			if s, _ := v.typ.(*Slice); s != nil {
+				typ = &ast.Ellipsis{Elt: c.expr(s.elem)}
+			} else {
+				typ = c.errorf("variadic parameter of type %s", v.typ) // string, for append only
+			}
+		} else {
+			typ = c.expr(v.typ)
+		}
+		field := &ast.Field{Type: typ}
+		if named {
+			name := v.name
+			if name == "" {
+				name = "_"
+			}
+			field.Names = []*ast.Ident{ast.NewIdent(name)}
+		}
+		list.List = append(list.List, field)
+	}
+	return list
+}
+
+// union returns the union of the terms of u as a binary expression.
+func (c *astTypeConverter) union(u *Union) ast.Expr {
+	var x ast.Expr
+	for _, t := range u.terms {
+		y := c.expr(t.typ)
+		if t.tilde {
+			y = &ast.UnaryExpr{Op: token.TILDE, X: y}
+		}
+		if x == nil {
+			x = y
+		} else {
+			x = &ast.BinaryExpr{X: x, Op: token.OR, Y: y}
+		}
+	}
+	if x == nil {
+		return c.errorf("empty union")
+	}
+	return x
+}
@@ -0,0 +1,165 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the generator of nodes.go.
+
+package typedast_test
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+var write = flag.Bool("write", false, "write nodes.go")
+
+// scopeNodes lists the node kinds that may appear in Info.Scopes.
+var scopeNodes = []string{
+	"File",
+	"FuncType",
+	"BlockStmt",
+	"IfStmt",
+	"SwitchStmt",
+	"TypeSwitchStmt",
+	"CaseClause",
+	"CommClause",
+	"ForStmt",
+	"RangeStmt",
+}
+
+// otherNodes lists the node kinds that are neither expressions nor scope
+// nodes but have views, for the accessors declared in typedast.go.
+var otherNodes = []string{
+	"ImportSpec",
+	"Field",
+}
+
+// TestGenerate verifies that nodes.go matches the declarations of go/ast.
+// If -write is set, it writes the generated content to nodes.go instead.
+func TestGenerate(t *testing.T) {
+	exprs := exprNodes(t)
+	generatedContent := generate(exprs)
+	out, err := format.Source(generatedContent)
+	if err != nil {
+		t.Fatalf("formatting generated source: %v\n%s", err, generatedContent)
+	}
+
+	const filename = "nodes.go"
+	onDiskContent, err := os.ReadFile(filename)
+	if err != nil && !*write {
+		t.Fatalf("reading %q: %v", filename, err)
+	}
+	if bytes.Equal(onDiskContent, out) {
+		return // nothing to do
+	}
+	if *write {
+		if err := os.WriteFile(filename, out, 0o644); err != nil {
+			t.Fatalf("writing %q: %v", filename, err)
+		}
+		return
+	}
+	t.Errorf("generated file content does not match %q (run go generate in go/types/typedast)", filename)
+}
+
+// exprNodes returns the names of the expression node kinds of go/ast,
+// that is, of the types with an exprNode method, in source order.
+func exprNodes(t *testing.T) []string {
+	filename := filepath.Join(runtime.GOROOT(), "src", "go", "ast", "ast.go")
+	file, err := parser.ParseFile(token.NewFileSet(), filename, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, decl := range file.Decls {
+		fdecl, _ := decl.(*ast.FuncDecl)
+		if fdecl == nil || fdecl.Recv == nil || fdecl.Name.Name != "exprNode" {
+			continue
+		}
+		if star, _ := fdecl.Recv.List[0].Type.(*ast.StarExpr); star != nil {
+			if id, _ := star.X.(*ast.Ident); id != nil {
+				names = append(names, id.Name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		t.Fatalf("no expression nodes found in %s", filename)
+	}
+	return names
+}
+
+// generate returns the unformatted content of nodes.go.
+func generate(exprs []string) []byte {
+	var buf bytes.Buffer
+	p := func(format string, args ...interface{}) {
+		fmt.Fprintf(&buf, format, args...)
+	}
+
+	p("// Code generated by \"go test -run=Generate -write\"; DO NOT EDIT.\n\n")
+	p("package typedast\n\n")
+	p("import (\n\t\"go/ast\"\n\t\"go/constant\"\n\t\"go/types\"\n)\n")
+
+	isExpr := make(map[string]bool)
+	for _, name := range exprs {
+		isExpr[name] = true
+	}
+	isScope := make(map[string]bool)
+	for _, name := range scopeNodes {
+		isScope[name] = true
+	}
+
+	// view declares the view of the node kind name.
+	view := func(name string) {
+		p("\n// %s %s is the typed view of an *ast.%s.\n", article(name), name, name)
+		p("type %s struct {\n\t*ast.%s\n\tInfo Info\n}\n", name, name)
+		p("\n// %s returns the typed view of x.\n", name)
+		p("func (info Info) %s(x *ast.%s) %s { return %s{x, info} }\n", name, name, name, name)
+		if isExpr[name] {
+			p("\nfunc (x %s) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.%s) }\n", name, name)
+			p("\nfunc (x %s) TypeOf() types.Type { return x.Info.TypeOf(x.%s) }\n", name, name)
+			p("\nfunc (x %s) ConstValue() constant.Value { return x.Info.Types[x.%s].Value }\n", name, name)
+		}
+		if isScope[name] {
+			p("\n// Scope returns the scope x defines, or nil.\n")
+			p("func (x %s) Scope() *types.Scope { return x.Info.Scopes[x.%s] }\n", name, name)
+		}
+	}
+
+	for _, name := range exprs {
+		view(name)
+	}
+
+	p("\n// Expr returns the typed view of x, or nil if x is nil.\n")
+	p("func (info Info) Expr(x ast.Expr) Expr {\n\tswitch x := x.(type) {\n\tcase nil:\n\t\treturn nil\n")
+	for _, name := range exprs {
+		p("\tcase *ast.%s:\n\t\treturn info.%s(x)\n", name, name)
+	}
+	p("\t}\n\tpanic(\"unreachable\")\n}\n")
+
+	for _, name := range scopeNodes {
+		if !isExpr[name] {
+			view(name)
+		}
+	}
+	for _, name := range otherNodes {
+		view(name)
+	}
+	return buf.Bytes()
+}
+
+// article returns the indefinite article for name.
+func article(name string) string {
+	if strings.ContainsRune("AEIOU", rune(name[0])) {
+		return "An"
+	}
+	return "A"
+}
@@ -0,0 +1,554 @@
+// Code generated by "go test -run=Generate -write"; DO NOT EDIT.
+
+package typedast
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+)
+
+// A BadExpr is the typed view of an *ast.BadExpr.
+type BadExpr struct {
+	*ast.BadExpr
+	Info Info
+}
+
+// BadExpr returns the typed view of x.
+func (info Info) BadExpr(x *ast.BadExpr) BadExpr { return BadExpr{x, info} }
+
+func (x BadExpr) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.BadExpr) }
+
+func (x BadExpr) TypeOf() types.Type { return x.Info.TypeOf(x.BadExpr) }
+
+func (x BadExpr) ConstValue() constant.Value { return x.Info.Types[x.BadExpr].Value }
+
+// An Ident is the typed view of an *ast.Ident.
+type Ident struct {
+	*ast.Ident
+	Info Info
+}
+
+// Ident returns the typed view of x.
+func (info Info) Ident(x *ast.Ident) Ident { return Ident{x, info} }
+
+func (x Ident) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.Ident) }
+
+func (x Ident) TypeOf() types.Type { return x.Info.TypeOf(x.Ident) }
+
+func (x Ident) ConstValue() constant.Value { return x.Info.Types[x.Ident].Value }
+
+// An Ellipsis is the typed view of an *ast.Ellipsis.
+type Ellipsis struct {
+	*ast.Ellipsis
+	Info Info
+}
+
+// Ellipsis returns the typed view of x.
+func (info Info) Ellipsis(x *ast.Ellipsis) Ellipsis { return Ellipsis{x, info} }
+
+func (x Ellipsis) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.Ellipsis) }
+
+func (x Ellipsis) TypeOf() types.Type { return x.Info.TypeOf(x.Ellipsis) }
+
+func (x Ellipsis) ConstValue() constant.Value { return x.Info.Types[x.Ellipsis].Value }
+
+// A BasicLit is the typed view of an *ast.BasicLit.
+type BasicLit struct {
+	*ast.BasicLit
+	Info Info
+}
+
+// BasicLit returns the typed view of x.
+func (info Info) BasicLit(x *ast.BasicLit) BasicLit { return BasicLit{x, info} }
+
+func (x BasicLit) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.BasicLit) }
+
+func (x BasicLit) TypeOf() types.Type { return x.Info.TypeOf(x.BasicLit) }
+
+func (x BasicLit) ConstValue() constant.Value { return x.Info.Types[x.BasicLit].Value }
+
+// A FuncLit is the typed view of an *ast.FuncLit.
+type FuncLit struct {
+	*ast.FuncLit
+	Info Info
+}
+
+// FuncLit returns the typed view of x.
+func (info Info) FuncLit(x *ast.FuncLit) FuncLit { return FuncLit{x, info} }
+
+func (x FuncLit) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.FuncLit) }
+
+func (x FuncLit) TypeOf() types.Type { return x.Info.TypeOf(x.FuncLit) }
+
+func (x FuncLit) ConstValue() constant.Value { return x.Info.Types[x.FuncLit].Value }
+
+// A CompositeLit is the typed view of an *ast.CompositeLit.
+type CompositeLit struct {
+	*ast.CompositeLit
+	Info Info
+}
+
+// CompositeLit returns the typed view of x.
+func (info Info) CompositeLit(x *ast.CompositeLit) CompositeLit { return CompositeLit{x, info} }
+
+func (x CompositeLit) TypeAndValue() (types.TypeAndValue, bool) {
+	return x.Info.typeAndValue(x.CompositeLit)
+}
+
+func (x CompositeLit) TypeOf() types.Type { return x.Info.TypeOf(x.CompositeLit) }
+
+func (x CompositeLit) ConstValue() constant.Value { return x.Info.Types[x.CompositeLit].Value }
+
+// A ParenExpr is the typed view of an *ast.ParenExpr.
+type ParenExpr struct {
+	*ast.ParenExpr
+	Info Info
+}
+
+// ParenExpr returns the typed view of x.
+func (info Info) ParenExpr(x *ast.ParenExpr) ParenExpr { return ParenExpr{x, info} }
+
+func (x ParenExpr) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.ParenExpr) }
+
+func (x ParenExpr) TypeOf() types.Type { return x.Info.TypeOf(x.ParenExpr) }
+
+func (x ParenExpr) ConstValue() constant.Value { return x.Info.Types[x.ParenExpr].Value }
+
+// A SelectorExpr is the typed view of an *ast.SelectorExpr.
+type SelectorExpr struct {
+	*ast.SelectorExpr
+	Info Info
+}
+
+// SelectorExpr returns the typed view of x.
+func (info Info) SelectorExpr(x *ast.SelectorExpr) SelectorExpr { return SelectorExpr{x, info} }
+
+func (x SelectorExpr) TypeAndValue() (types.TypeAndValue, bool) {
+	return x.Info.typeAndValue(x.SelectorExpr)
+}
+
+func (x SelectorExpr) TypeOf() types.Type { return x.Info.TypeOf(x.SelectorExpr) }
+
+func (x SelectorExpr) ConstValue() constant.Value { return x.Info.Types[x.SelectorExpr].Value }
+
+// An IndexExpr is the typed view of an *ast.IndexExpr.
+type IndexExpr struct {
+	*ast.IndexExpr
+	Info Info
+}
+
+// IndexExpr returns the typed view of x.
+func (info Info) IndexExpr(x *ast.IndexExpr) IndexExpr { return IndexExpr{x, info} }
+
+func (x IndexExpr) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.IndexExpr) }
+
+func (x IndexExpr) TypeOf() types.Type { return x.Info.TypeOf(x.IndexExpr) }
+
+func (x IndexExpr) ConstValue() constant.Value { return x.Info.Types[x.IndexExpr].Value }
+
+// An IndexListExpr is the typed view of an *ast.IndexListExpr.
+type IndexListExpr struct {
+	*ast.IndexListExpr
+	Info Info
+}
+
+// IndexListExpr returns the typed view of x.
+func (info Info) IndexListExpr(x *ast.IndexListExpr) IndexListExpr { return IndexListExpr{x, info} }
+
+func (x IndexListExpr) TypeAndValue() (types.TypeAndValue, bool) {
+	return x.Info.typeAndValue(x.IndexListExpr)
+}
+
+func (x IndexListExpr) TypeOf() types.Type { return x.Info.TypeOf(x.IndexListExpr) }
+
+func (x IndexListExpr) ConstValue() constant.Value { return x.Info.Types[x.IndexListExpr].Value }
+
+// A SliceExpr is the typed view of an *ast.SliceExpr.
+type SliceExpr struct {
+	*ast.SliceExpr
+	Info Info
+}
+
+// SliceExpr returns the typed view of x.
+func (info Info) SliceExpr(x *ast.SliceExpr) SliceExpr { return SliceExpr{x, info} }
+
+func (x SliceExpr) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.SliceExpr) }
+
+func (x SliceExpr) TypeOf() types.Type { return x.Info.TypeOf(x.SliceExpr) }
+
+func (x SliceExpr) ConstValue() constant.Value { return x.Info.Types[x.SliceExpr].Value }
+
+// A TypeAssertExpr is the typed view of an *ast.TypeAssertExpr.
+type TypeAssertExpr struct {
+	*ast.TypeAssertExpr
+	Info Info
+}
+
+// TypeAssertExpr returns the typed view of x.
+func (info Info) TypeAssertExpr(x *ast.TypeAssertExpr) TypeAssertExpr { return TypeAssertExpr{x, info} }
+
+func (x TypeAssertExpr) TypeAndValue() (types.TypeAndValue, bool) {
+	return x.Info.typeAndValue(x.TypeAssertExpr)
+}
+
+func (x TypeAssertExpr) TypeOf() types.Type { return x.Info.TypeOf(x.TypeAssertExpr) }
+
+func (x TypeAssertExpr) ConstValue() constant.Value { return x.Info.Types[x.TypeAssertExpr].Value }
+
+// A CallExpr is the typed view of an *ast.CallExpr.
+type CallExpr struct {
+	*ast.CallExpr
+	Info Info
+}
+
+// CallExpr returns the typed view of x.
+func (info Info) CallExpr(x *ast.CallExpr) CallExpr { return CallExpr{x, info} }
+
+func (x CallExpr) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.CallExpr) }
+
+func (x CallExpr) TypeOf() types.Type { return x.Info.TypeOf(x.CallExpr) }
+
+func (x CallExpr) ConstValue() constant.Value { return x.Info.Types[x.CallExpr].Value }
+
+// A StarExpr is the typed view of an *ast.StarExpr.
+type StarExpr struct {
+	*ast.StarExpr
+	Info Info
+}
+
+// StarExpr returns the typed view of x.
+func (info Info) StarExpr(x *ast.StarExpr) StarExpr { return StarExpr{x, info} }
+
+func (x StarExpr) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.StarExpr) }
+
+func (x StarExpr) TypeOf() types.Type { return x.Info.TypeOf(x.StarExpr) }
+
+func (x StarExpr) ConstValue() constant.Value { return x.Info.Types[x.StarExpr].Value }
+
+// An UnaryExpr is the typed view of an *ast.UnaryExpr.
+type UnaryExpr struct {
+	*ast.UnaryExpr
+	Info Info
+}
+
+// UnaryExpr returns the typed view of x.
+func (info Info) UnaryExpr(x *ast.UnaryExpr) UnaryExpr { return UnaryExpr{x, info} }
+
+func (x UnaryExpr) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.UnaryExpr) }
+
+func (x UnaryExpr) TypeOf() types.Type { return x.Info.TypeOf(x.UnaryExpr) }
+
+func (x UnaryExpr) ConstValue() constant.Value { return x.Info.Types[x.UnaryExpr].Value }
+
+// A BinaryExpr is the typed view of an *ast.BinaryExpr.
+type BinaryExpr struct {
+	*ast.BinaryExpr
+	Info Info
+}
+
+// BinaryExpr returns the typed view of x.
+func (info Info) BinaryExpr(x *ast.BinaryExpr) BinaryExpr { return BinaryExpr{x, info} }
+
+func (x BinaryExpr) TypeAndValue() (types.TypeAndValue, bool) {
+	return x.Info.typeAndValue(x.BinaryExpr)
+}
+
+func (x BinaryExpr) TypeOf() types.Type { return x.Info.TypeOf(x.BinaryExpr) }
+
+func (x BinaryExpr) ConstValue() constant.Value { return x.Info.Types[x.BinaryExpr].Value }
+
+// A KeyValueExpr is the typed view of an *ast.KeyValueExpr.
+type KeyValueExpr struct {
+	*ast.KeyValueExpr
+	Info Info
+}
+
+// KeyValueExpr returns the typed view of x.
+func (info Info) KeyValueExpr(x *ast.KeyValueExpr) KeyValueExpr { return KeyValueExpr{x, info} }
+
+func (x KeyValueExpr) TypeAndValue() (types.TypeAndValue, bool) {
+	return x.Info.typeAndValue(x.KeyValueExpr)
+}
+
+func (x KeyValueExpr) TypeOf() types.Type { return x.Info.TypeOf(x.KeyValueExpr) }
+
+func (x KeyValueExpr) ConstValue() constant.Value { return x.Info.Types[x.KeyValueExpr].Value }
+
+// An ArrayType is the typed view of an *ast.ArrayType.
+type ArrayType struct {
+	*ast.ArrayType
+	Info Info
+}
+
+// ArrayType returns the typed view of x.
+func (info Info) ArrayType(x *ast.ArrayType) ArrayType { return ArrayType{x, info} }
+
+func (x ArrayType) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.ArrayType) }
+
+func (x ArrayType) TypeOf() types.Type { return x.Info.TypeOf(x.ArrayType) }
+
+func (x ArrayType) ConstValue() constant.Value { return x.Info.Types[x.ArrayType].Value }
+
+// A StructType is the typed view of an *ast.StructType.
+type StructType struct {
+	*ast.StructType
+	Info Info
+}
+
+// StructType returns the typed view of x.
+func (info Info) StructType(x *ast.StructType) StructType { return StructType{x, info} }
+
+func (x StructType) TypeAndValue() (types.TypeAndValue, bool) {
+	return x.Info.typeAndValue(x.StructType)
+}
+
+func (x StructType) TypeOf() types.Type { return x.Info.TypeOf(x.StructType) }
+
+func (x StructType) ConstValue() constant.Value { return x.Info.Types[x.StructType].Value }
+
+// A FuncType is the typed view of an *ast.FuncType.
+type FuncType struct {
+	*ast.FuncType
+	Info Info
+}
+
+// FuncType returns the typed view of x.
+func (info Info) FuncType(x *ast.FuncType) FuncType { return FuncType{x, info} }
+
+func (x FuncType) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.FuncType) }
+
+func (x FuncType) TypeOf() types.Type { return x.Info.TypeOf(x.FuncType) }
+
+func (x FuncType) ConstValue() constant.Value { return x.Info.Types[x.FuncType].Value }
+
+// Scope returns the scope x defines, or nil.
+func (x FuncType) Scope() *types.Scope { return x.Info.Scopes[x.FuncType] }
+
+// An InterfaceType is the typed view of an *ast.InterfaceType.
+type InterfaceType struct {
+	*ast.InterfaceType
+	Info Info
+}
+
+// InterfaceType returns the typed view of x.
+func (info Info) InterfaceType(x *ast.InterfaceType) InterfaceType { return InterfaceType{x, info} }
+
+func (x InterfaceType) TypeAndValue() (types.TypeAndValue, bool) {
+	return x.Info.typeAndValue(x.InterfaceType)
+}
+
+func (x InterfaceType) TypeOf() types.Type { return x.Info.TypeOf(x.InterfaceType) }
+
+func (x InterfaceType) ConstValue() constant.Value { return x.Info.Types[x.InterfaceType].Value }
+
+// A MapType is the typed view of an *ast.MapType.
+type MapType struct {
+	*ast.MapType
+	Info Info
+}
+
+// MapType returns the typed view of x.
+func (info Info) MapType(x *ast.MapType) MapType { return MapType{x, info} }
+
+func (x MapType) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.MapType) }
+
+func (x MapType) TypeOf() types.Type { return x.Info.TypeOf(x.MapType) }
+
+func (x MapType) ConstValue() constant.Value { return x.Info.Types[x.MapType].Value }
+
+// A ChanType is the typed view of an *ast.ChanType.
+type ChanType struct {
+	*ast.ChanType
+	Info Info
+}
+
+// ChanType returns the typed view of x.
+func (info Info) ChanType(x *ast.ChanType) ChanType { return ChanType{x, info} }
+
+func (x ChanType) TypeAndValue() (types.TypeAndValue, bool) { return x.Info.typeAndValue(x.ChanType) }
+
+func (x ChanType) TypeOf() types.Type { return x.Info.TypeOf(x.ChanType) }
+
+func (x ChanType) ConstValue() constant.Value { return x.Info.Types[x.ChanType].Value }
+
+// Expr returns the typed view of x, or nil if x is nil.
+func (info Info) Expr(x ast.Expr) Expr {
+	switch x := x.(type) {
+	case nil:
+		return nil
+	case *ast.BadExpr:
+		return info.BadExpr(x)
+	case *ast.Ident:
+		return info.Ident(x)
+	case *ast.Ellipsis:
+		return info.Ellipsis(x)
+	case *ast.BasicLit:
+		return info.BasicLit(x)
+	case *ast.FuncLit:
+		return info.FuncLit(x)
+	case *ast.CompositeLit:
+		return info.CompositeLit(x)
+	case *ast.ParenExpr:
+		return info.ParenExpr(x)
+	case *ast.SelectorExpr:
+		return info.SelectorExpr(x)
+	case *ast.IndexExpr:
+		return info.IndexExpr(x)
+	case *ast.IndexListExpr:
+		return info.IndexListExpr(x)
+	case *ast.SliceExpr:
+		return info.SliceExpr(x)
+	case *ast.TypeAssertExpr:
+		return info.TypeAssertExpr(x)
+	case *ast.CallExpr:
+		return info.CallExpr(x)
+	case *ast.StarExpr:
+		return info.StarExpr(x)
+	case *ast.UnaryExpr:
+		return info.UnaryExpr(x)
+	case *ast.BinaryExpr:
+		return info.BinaryExpr(x)
+	case *ast.KeyValueExpr:
+		return info.KeyValueExpr(x)
+	case *ast.ArrayType:
+		return info.ArrayType(x)
+	case *ast.StructType:
+		return info.StructType(x)
+	case *ast.FuncType:
+		return info.FuncType(x)
+	case *ast.InterfaceType:
+		return info.InterfaceType(x)
+	case *ast.MapType:
+		return info.MapType(x)
+	case *ast.ChanType:
+		return info.ChanType(x)
+	}
+	panic("unreachable")
+}
+
+// A File is the typed view of an *ast.File.
+type File struct {
+	*ast.File
+	Info Info
+}
+
+// File returns the typed view of x.
+func (info Info) File(x *ast.File) File { return File{x, info} }
+
+// Scope returns the scope x defines, or nil.
+func (x File) Scope() *types.Scope { return x.Info.Scopes[x.File] }
+
+// A BlockStmt is the typed view of an *ast.BlockStmt.
+type BlockStmt struct {
+	*ast.BlockStmt
+	Info Info
+}
+
+// BlockStmt returns the typed view of x.
+func (info Info) BlockStmt(x *ast.BlockStmt) BlockStmt { return BlockStmt{x, info} }
+
+// Scope returns the scope x defines, or nil.
+func (x BlockStmt) Scope() *types.Scope { return x.Info.Scopes[x.BlockStmt] }
+
+// An IfStmt is the typed view of an *ast.IfStmt.
+type IfStmt struct {
+	*ast.IfStmt
+	Info Info
+}
+
+// IfStmt returns the typed view of x.
+func (info Info) IfStmt(x *ast.IfStmt) IfStmt { return IfStmt{x, info} }
+
+// Scope returns the scope x defines, or nil.
+func (x IfStmt) Scope() *types.Scope { return x.Info.Scopes[x.IfStmt] }
+
+// A SwitchStmt is the typed view of an *ast.SwitchStmt.
+type SwitchStmt struct {
+	*ast.SwitchStmt
+	Info Info
+}
+
+// SwitchStmt returns the typed view of x.
+func (info Info) SwitchStmt(x *ast.SwitchStmt) SwitchStmt { return SwitchStmt{x, info} }
+
+// Scope returns the scope x defines, or nil.
+func (x SwitchStmt) Scope() *types.Scope { return x.Info.Scopes[x.SwitchStmt] }
+
+// A TypeSwitchStmt is the typed view of an *ast.TypeSwitchStmt.
+type TypeSwitchStmt struct {
+	*ast.TypeSwitchStmt
+	Info Info
+}
+
+// TypeSwitchStmt returns the typed view of x.
+func (info Info) TypeSwitchStmt(x *ast.TypeSwitchStmt) TypeSwitchStmt { return TypeSwitchStmt{x, info} }
+
+// Scope returns the scope x defines, or nil.
+func (x TypeSwitchStmt) Scope() *types.Scope { return x.Info.Scopes[x.TypeSwitchStmt] }
+
+// A CaseClause is the typed view of an *ast.CaseClause.
+type CaseClause struct {
+	*ast.CaseClause
+	Info Info
+}
+
+// CaseClause returns the typed view of x.
+func (info Info) CaseClause(x *ast.CaseClause) CaseClause { return CaseClause{x, info} }
+
+// Scope returns the scope x defines, or nil.
+func (x CaseClause) Scope() *types.Scope { return x.Info.Scopes[x.CaseClause] }
+
+// A CommClause is the typed view of an *ast.CommClause.
+type CommClause struct {
+	*ast.CommClause
+	Info Info
+}
+
+// CommClause returns the typed view of x.
+func (info Info) CommClause(x *ast.CommClause) CommClause { return CommClause{x, info} }
+
+// Scope returns the scope x defines, or nil.
+func (x CommClause) Scope() *types.Scope { return x.Info.Scopes[x.CommClause] }
+
+// A ForStmt is the typed view of an *ast.ForStmt.
+type ForStmt struct {
+	*ast.ForStmt
+	Info Info
+}
+
+// ForStmt returns the typed view of x.
+func (info Info) ForStmt(x *ast.ForStmt) ForStmt { return ForStmt{x, info} }
+
+// Scope returns the scope x defines, or nil.
+func (x ForStmt) Scope() *types.Scope { return x.Info.Scopes[x.ForStmt] }
+
+// A RangeStmt is the typed view of an *ast.RangeStmt.
+type RangeStmt struct {
+	*ast.RangeStmt
+	Info Info
+}
+
+// RangeStmt returns the typed view of x.
+func (info Info) RangeStmt(x *ast.RangeStmt) RangeStmt { return RangeStmt{x, info} }
+
+// Scope returns the scope x defines, or nil.
+func (x RangeStmt) Scope() *types.Scope { return x.Info.Scopes[x.RangeStmt] }
+
+// An ImportSpec is the typed view of an *ast.ImportSpec.
+type ImportSpec struct {
+	*ast.ImportSpec
+	Info Info
+}
+
+// ImportSpec returns the typed view of x.
+func (info Info) ImportSpec(x *ast.ImportSpec) ImportSpec { return ImportSpec{x, info} }
+
+// A Field is the typed view of an *ast.Field.
+type Field struct {
+	*ast.Field
+	Info Info
+}
+
+// Field returns the typed view of x.
+func (info Info) Field(x *ast.Field) Field { return Field{x, info} }
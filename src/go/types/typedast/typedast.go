@@ -0,0 +1,122 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package typedast provides typed views of the nodes of type-checked syntax
+// trees: each view pairs a node with the information the type checker
+// recorded for it in a types.Info and provides accessors for it, such as
+// CallExpr.Callee and Ident.Object, in place of lookups in the maps of the
+// Info.
+//
+// The views of expressions, of nodes defining scopes, and of some other
+// nodes are generated from the declarations of package go/ast (see
+// nodes.go); the accessors specific to a node kind are declared in this
+// file. Views embed their node, so that its fields can be accessed
+// directly; the field Scope of ast.File, which is shadowed by the method
+// File.Scope, is accessible as x.File.Scope. The views of the children of
+// a node are obtained with the methods of Info, as in info.Expr(call.Fun).
+package typedast
+
+//go:generate go test -run=Generate -write
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+)
+
+// An Info provides the typed views of the nodes of syntax trees checked
+// with the information recorded in the embedded types.Info. Maps of the
+// Info that are nil are treated as empty.
+type Info struct{ *types.Info }
+
+// An Expr is the typed view of an expression.
+type Expr interface {
+	ast.Expr
+
+	// TypeAndValue returns the type and value recorded for the
+	// expression, and reports whether they were recorded.
+	TypeAndValue() (types.TypeAndValue, bool)
+
+	// TypeOf returns the type of the expression, or nil if none was
+	// recorded.
+	TypeOf() types.Type
+
+	// ConstValue returns the value of a constant expression, or nil.
+	ConstValue() constant.Value
+}
+
+// typeAndValue returns the type and value recorded for x, if any.
+func (info Info) typeAndValue(x ast.Expr) (types.TypeAndValue, bool) {
+	tv, ok := info.Types[x]
+	return tv, ok
+}
+
+// Object returns the object x defines, or else the object it denotes,
+// or nil if there is none, as recorded in info.Defs and info.Uses.
+func (x Ident) Object() types.Object {
+	if obj := x.Info.Defs[x.Ident]; obj != nil {
+		return obj
+	}
+	return x.Info.Uses[x.Ident]
+}
+
+// IsDef reports whether x is the name of a declared object.
+func (x Ident) IsDef() bool {
+	_, ok := x.Info.Defs[x.Ident]
+	return ok
+}
+
+// Selection returns the selection x denotes, or nil for qualified
+// identifiers.
+func (x SelectorExpr) Selection() *types.Selection {
+	return x.Info.Selections[x.SelectorExpr]
+}
+
+// Object returns the object x denotes: the selected field or method, or
+// the package-level object of a qualified identifier.
+func (x SelectorExpr) Object() types.Object {
+	if sel := x.Selection(); sel != nil {
+		return sel.Obj()
+	}
+	return x.Info.Uses[x.Sel]
+}
+
+// Callee returns the statically known function, method, or built-in x
+// calls, or nil.
+func (x CallExpr) Callee() types.Object {
+	return x.Info.Callees[x.CallExpr].Obj
+}
+
+// Inferred returns the type arguments and signature inferred for the call
+// of a generic function, and reports whether they were inferred.
+func (x CallExpr) Inferred() (types.Inferred, bool) {
+	inf, ok := x.Info.Inferred[x.CallExpr]
+	return inf, ok
+}
+
+// PkgName returns the package name object declared by the import x.
+func (x ImportSpec) PkgName() *types.PkgName {
+	var obj types.Object
+	if x.Name != nil {
+		obj = x.Info.Defs[x.Name]
+	} else {
+		obj = x.Info.Implicits[x.ImportSpec]
+	}
+	pkgName, _ := obj.(*types.PkgName)
+	return pkgName
+}
+
+// Implicit returns the variable implicitly declared for an unnamed
+// parameter or result x, or nil.
+func (x Field) Implicit() *types.Var {
+	v, _ := x.Info.Implicits[x.Field].(*types.Var)
+	return v
+}
+
+// Implicit returns the variable implicitly declared for the clause x of
+// a type switch with a short variable declaration, or nil.
+func (x CaseClause) Implicit() *types.Var {
+	v, _ := x.Info.Implicits[x.CaseClause].(*types.Var)
+	return v
+}
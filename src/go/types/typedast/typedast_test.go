@@ -0,0 +1,125 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typedast_test
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"go/types/typedast"
+)
+
+func TestViews(t *testing.T) {
+	const src = `
+package p
+
+import "strings"
+
+type T struct{ s string }
+
+func (T) m(int) {}
+
+const c = 1 << 2
+
+func _(x interface{}) {
+	strings.ToUpper("x")
+	T{}.m(c)
+	switch y := x.(type) {
+	case int:
+		_ = y
+	}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tinfo := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Callees:    make(map[*ast.CallExpr]types.Callee),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("p", fset, []*ast.File{f}, tinfo); err != nil {
+		t.Fatal(err)
+	}
+	info := typedast.Info{Info: tinfo}
+
+	if got := info.ImportSpec(f.Imports[0]).PkgName(); got == nil || got.Imported().Path() != "strings" {
+		t.Errorf("PkgName = %v, want package strings", got)
+	}
+	if info.File(f).Scope() == nil {
+		t.Error("no file scope")
+	}
+
+	var callees []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			call := info.CallExpr(n)
+			if obj := call.Callee(); obj != nil {
+				callees = append(callees, obj.Name())
+			}
+			if tv, ok := call.TypeAndValue(); !ok || !tv.IsVoid() && tv.Type == nil {
+				t.Errorf("%s: no type recorded", fset.Position(n.Pos()))
+			}
+		case *ast.SelectorExpr:
+			sel := info.SelectorExpr(n)
+			qualified := sel.Selection() == nil
+			if want := sel.Sel.Name == "ToUpper"; qualified != want {
+				t.Errorf("%s: qualified identifier = %v, want %v", fset.Position(n.Pos()), qualified, want)
+			}
+			if sel.Object() == nil {
+				t.Errorf("%s: no object for selector", fset.Position(n.Pos()))
+			}
+		case *ast.CaseClause:
+			if v := info.CaseClause(n).Implicit(); v == nil || v.Type() != types.Typ[types.Int] {
+				t.Errorf("Implicit = %v, want y of type int", v)
+			}
+		case *ast.Field:
+			if len(n.Names) == 0 && n.Type.(*ast.Ident).Name == "int" {
+				if v := info.Field(n).Implicit(); v == nil {
+					t.Error("no implicit parameter")
+				}
+			}
+		case *ast.Ident:
+			if n.Name == "c" {
+				x := info.Ident(n)
+				if obj, _ := x.Object().(*types.Const); obj == nil || x.IsDef() != (obj.Pos() == n.Pos()) {
+					t.Errorf("%s: unexpected object %v", fset.Position(n.Pos()), x.Object())
+				}
+			}
+		}
+		return true
+	})
+	if got, want := callees, []string{"ToUpper", "m"}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("callees = %v, want %v", got, want)
+	}
+
+	// Expr returns the view of the expression's kind.
+	decl := f.Decls[3].(*ast.GenDecl).Specs[0].(*ast.ValueSpec)
+	x := info.Expr(decl.Values[0])
+	if _, ok := x.(typedast.BinaryExpr); !ok {
+		t.Errorf("Expr returned %T, want typedast.BinaryExpr", x)
+	}
+	if got := x.ConstValue(); got == nil || got.String() != "4" {
+		t.Errorf("ConstValue = %v, want 4", got)
+	}
+	if got := x.TypeOf(); got != types.Typ[types.UntypedInt] {
+		t.Errorf("TypeOf = %v, want untyped int", got)
+	}
+	if info.Expr(nil) != nil {
+		t.Error("Expr(nil) != nil")
+	}
+}
@@ -21,11 +21,13 @@ func nextID() uint64 { return uint64(atomic.AddUint32(&lastID, 1)) }
 // A TypeParam represents a type parameter type.
 type TypeParam struct {
 	check *Checker  // for lazy type bound completion
-	id    uint64    // unique id, for debugging only
+	id    uint64    // id shown by TypeString, unique per checker; for debugging only
+	uid   uint64    // unique id among all type parameters, for type hashing
 	obj   *TypeName // corresponding type name
 	index int       // type parameter index in source order, starting at 0
 	// TODO(rfindley): this could also be Typ[Invalid]. Verify that this is handled correctly.
-	bound Type // *Named or *Interface; underlying type is always *Interface
+	bound    Type // *Named or *Interface; underlying type is always *Interface
+	variadic bool // see Experiments.VariadicTypeParams
 }
 
 // NewTypeParam returns a new TypeParam. Type parameters may be set on a Named
@@ -38,13 +40,13 @@ func NewTypeParam(obj *TypeName, constraint Type) *TypeParam {
 }
 
 func (check *Checker) newTypeParam(obj *TypeName, constraint Type) *TypeParam {
-	// Always increment lastID, even if it is not used.
-	id := nextID()
+	uid := nextID()
+	id := uid
 	if check != nil {
 		check.nextID++
 		id = check.nextID
 	}
-	typ := &TypeParam{check: check, id: id, obj: obj, index: -1, bound: constraint}
+	typ := &TypeParam{check: check, id: id, uid: uid, obj: obj, index: -1, bound: constraint}
 	if obj.typ == nil {
 		obj.typ = typ
 	}
@@ -67,6 +69,10 @@ func (t *TypeParam) _SetId(id uint64) {
 // Obj returns the type name for t.
 func (t *TypeParam) Obj() *TypeName { return t.obj }
 
+// Variadic reports whether t is a variadic type parameter
+// (see Experiments.VariadicTypeParams).
+func (t *TypeParam) Variadic() bool { return t.variadic }
+
 // Constraint returns the type constraint specified for t.
 func (t *TypeParam) Constraint() Type {
 	// compute the type set if possible (we may not have an interface)
@@ -81,6 +87,10 @@ func (t *TypeParam) Constraint() Type {
 	return t.bound
 }
 
+// MethodInterface returns the interface with the methods that may be
+// called on values of type t (see Interface.MethodInterface).
+func (t *TypeParam) MethodInterface() *Interface { return t.iface().MethodInterface() }
+
 // SetConstraint sets the type constraint for t.
 func (t *TypeParam) SetConstraint(bound Type) {
 	if bound == nil {
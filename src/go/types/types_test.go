@@ -6,3 +6,7 @@ package types
 
 // Debug is set if go/types is built with debug mode enabled.
 const Debug = debug
+
+// SetUsesCgo sets conf up for checking cgo-processed files, as the source
+// importer does for packages using cgo.
+func SetUsesCgo(conf *Config) { conf.go115UsesCgo = true }
@@ -18,18 +18,28 @@ import (
 type _TypeSet struct {
 	comparable bool // if set, the interface is or embeds comparable
 	// TODO(gri) consider using a set for the methods for faster lookup
-	methods []*Func  // all methods of the interface; sorted by unique ID
-	terms   termlist // type terms of the type set
+	methods []*Func    // all methods of the interface; sorted by unique ID
+	terms   termlist   // type terms of the type set
+	index   *termIndex // index for large term lists, or nil
+	unions  []*Union   // unions with interface terms with methods; see inUnion
+
+	// For type sets of interfaces and unions, the structural type is
+	// computed once their terms are final (see setTerms), since type
+	// parameters query it for almost every operation on their values.
+	structural    Type // structural type of terms, if known
+	hasStructural bool // set if structural is known
 }
 
 // IsEmpty reports whether type set s is the empty set.
 func (s *_TypeSet) IsEmpty() bool { return s.terms.isEmpty() }
 
 // IsAll reports whether type set s is the set of all types (corresponding to the empty interface).
-func (s *_TypeSet) IsAll() bool { return !s.comparable && len(s.methods) == 0 && s.terms.isAll() }
+func (s *_TypeSet) IsAll() bool {
+	return !s.comparable && len(s.methods) == 0 && s.terms.isAll() && len(s.unions) == 0
+}
 
 // IsConstraint reports whether type set s is not just a set of methods.
-func (s *_TypeSet) IsConstraint() bool { return s.comparable || !s.terms.isAll() }
+func (s *_TypeSet) IsConstraint() bool { return s.comparable || !s.terms.isAll() || len(s.unions) > 0 }
 
 // IsComparable reports whether each type in the set is comparable.
 func (s *_TypeSet) IsComparable() bool {
@@ -45,7 +55,32 @@ func (s *_TypeSet) IsComparable() bool {
 
 // IsTypeSet reports whether the type set s is represented by a finite set of underlying types.
 func (s *_TypeSet) IsTypeSet() bool {
-	return !s.comparable && len(s.methods) == 0
+	return !s.comparable && len(s.methods) == 0 && len(s.unions) == 0
+}
+
+// satisfiable reports whether some type may be in type set s. Terms ~T are
+// assumed to include types with arbitrary methods. Type sets with unions
+// with methods (see inUnion) are assumed to be satisfiable.
+func (s *_TypeSet) satisfiable() bool {
+	if s.terms.isAll() || len(s.unions) > 0 {
+		return true
+	}
+	var iface *Interface // methods of s, if any
+	if len(s.methods) > 0 {
+		iface = &Interface{complete: true, tset: &_TypeSet{methods: s.methods, terms: allTermlist}}
+	}
+	for _, t := range s.terms {
+		if s.comparable && !Comparable(t.typ) {
+			continue
+		}
+		if iface != nil && !t.tilde {
+			if m, _ := MissingMethod(t.typ, iface, true); m != nil {
+				continue
+			}
+		}
+		return true
+	}
+	return false
 }
 
 // NumMethods returns the number of methods available.
@@ -92,6 +127,12 @@ func (s *_TypeSet) String() string {
 	if hasTerms {
 		buf.WriteString(s.terms.String())
 	}
+	for i, u := range s.unions {
+		if i > 0 || s.comparable || hasMethods || hasTerms {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(u.String())
+	}
 	buf.WriteString("}")
 	return buf.String()
 }
@@ -99,10 +140,48 @@ func (s *_TypeSet) String() string {
 // ----------------------------------------------------------------------------
 // Implementation
 
-func (s *_TypeSet) hasTerms() bool              { return !s.terms.isAll() }
-func (s *_TypeSet) structuralType() Type        { return s.terms.structuralType() }
-func (s *_TypeSet) includes(t Type) bool        { return s.terms.includes(t) }
-func (s1 *_TypeSet) subsetOf(s2 *_TypeSet) bool { return s1.terms.subsetOf(s2.terms) }
+func (s *_TypeSet) hasTerms() bool { return !s.terms.isAll() }
+func (s *_TypeSet) structuralType() Type {
+	if s.hasStructural {
+		return s.structural
+	}
+	return s.terms.structuralType()
+}
+
+// setTerms sets the final terms of s, and the information derived from them.
+func (s *_TypeSet) setTerms(terms termlist) {
+	s.terms = terms
+	s.index = newTypeSetIndex(terms)
+	s.structural = terms.structuralType()
+	s.hasStructural = true
+}
+
+// includes reports whether t ∈ s.
+func (s *_TypeSet) includes(t Type) bool {
+	if s.index != nil {
+		return s.index.includes(t)
+	}
+	return s.terms.includes(t)
+}
+
+// subsetOf reports whether the terms of s1 are a subset of the terms of s2.
+func (s1 *_TypeSet) subsetOf(s2 *_TypeSet) bool {
+	if s2.index != nil {
+		return s1.terms.subsetOfIndexed(s2.index)
+	}
+	return s1.terms.subsetOf(s2.terms)
+}
+
+// termsEqual reports whether s1 and s2 have the same terms.
+func (s1 *_TypeSet) termsEqual(s2 *_TypeSet) bool {
+	switch {
+	case s1.index == nil && s2.index == nil:
+		return s1.terms.equal(s2.terms)
+	case s2.index != nil && s2.index.sameTerms(s1.terms):
+		return true
+	}
+	return s1.subsetOf(s2) && s2.subsetOf(s1)
+}
 
 // TODO(gri) TypeSet.is and TypeSet.underIs should probably also go into termlist.go
 
@@ -239,7 +318,7 @@ func computeInterfaceTypeSet(check *Checker, pos token.Pos, ityp *Interface) *_T
 			}
 			// check != nil
 			check.later(func() {
-				if !check.allowVersion(m.pkg, 1, 14) || !Identical(m.typ, other.Type()) {
+				if !Identical(m.typ, other.Type()) || !check.allowVersion(m.pkg, 1, 14) && !check.forwardCompat(atPos(pos), 1, 14, "duplicate method %s", m.name) {
 					check.errorf(atPos(pos), _DuplicateDecl, "duplicate method %s", m.name)
 					check.errorf(atPos(mpos[other.(*Func)]), _DuplicateDecl, "\tother declaration of %s", m.name) // secondary error, \t indented
 				}
@@ -253,6 +332,7 @@ func computeInterfaceTypeSet(check *Checker, pos token.Pos, ityp *Interface) *_T
 
 	// collect embedded elements
 	var allTerms = allTermlist
+	var unions []*Union
 	for i, typ := range ityp.embeddeds {
 		// The embedding position is nil for imported interfaces
 		// and also for interface copies after substitution (but
@@ -266,7 +346,7 @@ func computeInterfaceTypeSet(check *Checker, pos token.Pos, ityp *Interface) *_T
 		case *Interface:
 			tset := computeInterfaceTypeSet(check, pos, u)
 			// If typ is local, an error was already reported where typ is specified/defined.
-			if check != nil && check.isImportedConstraint(typ) && !check.allowVersion(check.pkg, 1, 18) {
+			if check != nil && check.isImportedConstraint(typ) && !check.allowVersion(check.pkg, 1, 18) && !check.forwardCompat(atPos(pos), 1, 18, "embedding constraint interface %s", typ) {
 				check.errorf(atPos(pos), _Todo, "embedding constraint interface %s requires go1.18 or later", typ)
 				continue
 			}
@@ -277,8 +357,9 @@ func computeInterfaceTypeSet(check *Checker, pos token.Pos, ityp *Interface) *_T
 				addMethod(pos, m, false) // use embedding position pos rather than m.pos
 			}
 			terms = tset.terms
+			unions = append(unions, tset.unions...)
 		case *Union:
-			if check != nil && !check.allowVersion(check.pkg, 1, 18) {
+			if check != nil && !check.allowVersion(check.pkg, 1, 18) && !check.forwardCompat(atPos(pos), 1, 18, "embedding interface element %s", u) {
 				check.errorf(atPos(pos), _Todo, "embedding interface element %s requires go1.18 or later", u)
 				continue
 			}
@@ -286,7 +367,11 @@ func computeInterfaceTypeSet(check *Checker, pos token.Pos, ityp *Interface) *_T
 			if tset == &invalidTypeSet {
 				continue // ignore invalid unions
 			}
+			for _, m := range tset.methods {
+				addMethod(pos, m, false)
+			}
 			terms = tset.terms
+			unions = append(unions, tset.unions...)
 		case *TypeParam:
 			// Embedding stand-alone type parameters is not permitted.
 			// This case is handled during union parsing.
@@ -295,7 +380,7 @@ func computeInterfaceTypeSet(check *Checker, pos token.Pos, ityp *Interface) *_T
 			if typ == Typ[Invalid] {
 				continue
 			}
-			if check != nil && !check.allowVersion(check.pkg, 1, 18) {
+			if check != nil && !check.allowVersion(check.pkg, 1, 18) && !check.forwardCompat(atPos(pos), 1, 18, "embedding non-interface type %s", typ) {
 				check.errorf(atPos(pos), _InvalidIfaceEmbed, "embedding non-interface type %s requires go1.18 or later", typ)
 				continue
 			}
@@ -322,7 +407,8 @@ func computeInterfaceTypeSet(check *Checker, pos token.Pos, ityp *Interface) *_T
 		sort.Sort(byUniqueMethodName(methods))
 		ityp.tset.methods = methods
 	}
-	ityp.tset.terms = allTerms
+	ityp.tset.setTerms(allTerms)
+	ityp.tset.unions = unions
 
 	return ityp.tset
 }
@@ -362,12 +448,21 @@ func computeUnionTypeSet(check *Checker, pos token.Pos, utyp *Union) *_TypeSet {
 	// avoid infinite recursion (see also computeInterfaceTypeSet)
 	utyp.tset = new(_TypeSet)
 
+	// The type set of a union expression is the union of the type sets
+	// of each term. Collect all terms first and normalize them once,
+	// rather than normalizing the partial union after each term.
 	var allTerms termlist
+	var tsets []*_TypeSet // type sets of the terms, for unions with methods
+	hasMethods := false
 	for _, t := range utyp.terms {
-		var terms termlist
 		switch u := under(t.typ).(type) {
 		case *Interface:
-			terms = computeInterfaceTypeSet(check, pos, u).terms
+			tset := computeInterfaceTypeSet(check, pos, u)
+			allTerms = append(allTerms, tset.terms...)
+			tsets = append(tsets, tset)
+			if len(tset.methods) > 0 || len(tset.unions) > 0 {
+				hasMethods = true
+			}
 		case *TypeParam:
 			// A stand-alone type parameters is not permitted as union term.
 			// This case is handled during union parsing.
@@ -376,20 +471,44 @@ func computeUnionTypeSet(check *Checker, pos token.Pos, utyp *Union) *_TypeSet {
 			if t.typ == Typ[Invalid] {
 				continue
 			}
-			terms = termlist{(*term)(t)}
+			allTerms = append(allTerms, (*term)(t))
+			tsets = append(tsets, &_TypeSet{terms: termlist{(*term)(t)}})
 		}
-		// The type set of a union expression is the union
-		// of the type sets of each term.
-		allTerms = allTerms.union(terms)
-		if len(allTerms) > maxTermCount {
-			if check != nil {
-				check.errorf(atPos(pos), _Todo, "cannot handle more than %d union terms (implementation limitation)", maxTermCount)
-			}
-			utyp.tset = &invalidTypeSet
-			return utyp.tset
+	}
+	allTerms = allTerms.norm()
+	if len(allTerms) > maxTermCount {
+		if check != nil {
+			check.errorf(atPos(pos), _Todo, "cannot handle more than %d union terms (implementation limitation)", maxTermCount)
 		}
+		utyp.tset = &invalidTypeSet
+		return utyp.tset
+	}
+	utyp.tset.setTerms(allTerms)
+	if hasMethods {
+		// The terms and methods of the union describe a superset of its
+		// type set; see inUnion.
+		utyp.tset.methods = commonMethods(tsets)
+		utyp.tset.unions = []*Union{utyp}
 	}
-	utyp.tset.terms = allTerms
 
 	return utyp.tset
 }
+
+// commonMethods returns the methods (of the first type set) that are present,
+// with identical signatures, in all type sets in list.
+func commonMethods(list []*_TypeSet) []*Func {
+	var res []*Func
+	for _, m := range list[0].methods {
+		common := true
+		for _, s := range list[1:] {
+			if _, f := s.LookupMethod(m.pkg, m.name); f == nil || !Identical(f.typ, m.typ) {
+				common = false
+				break
+			}
+		}
+		if common {
+			res = append(res, m)
+		}
+	}
+	return res
+}
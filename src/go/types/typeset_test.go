@@ -5,6 +5,7 @@
 package types
 
 import (
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
@@ -79,3 +80,36 @@ func TestTypeSetString(t *testing.T) {
 }
 
 // TODO(gri) add more tests
+
+func TestTypeSetStructuralType(t *testing.T) {
+	for body, want := range map[string]string{
+		"{}":                          "<nil>",
+		"{int}":                       "int",
+		"{~int}":                      "int",
+		"{int|string}":                "<nil>",
+		"{~[]byte; []byte|string}":    "[]byte",
+		"{E}; type E interface{~int}": "int",
+		"{m(); ~string}":              "string",
+	} {
+		src := "package p; type T interface" + body
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "p.go", src, parser.AllErrors)
+		if file == nil {
+			t.Fatalf("%s: %v (invalid test case)", body, err)
+		}
+		var conf Config
+		pkg, err := conf.Check(file.Name.Name, fset, []*ast.File{file}, nil)
+		if err != nil {
+			t.Fatalf("%s: %v (invalid test case)", body, err)
+		}
+		tset := under(pkg.scope.Lookup("T").Type()).(*Interface).typeSet()
+
+		if got := fmt.Sprint(tset.structuralType()); got != want {
+			t.Errorf("%s: got %s; want %s", body, got, want)
+		}
+		// the structural type is computed once the type set is complete
+		if tset != &topTypeSet && !tset.hasStructural {
+			t.Errorf("%s: structural type not recorded", body)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the intersection and union of interfaces.
+
+package types
+
+import "fmt"
+
+// IntersectInterfaces returns a complete interface whose type set is the
+// intersection of the type sets of the interfaces in list: its methods
+// are the methods of all interfaces, it is comparable if any of the
+// interfaces is, and its type terms are the normalized intersection of
+// their type terms. An interface with the empty type set is represented
+// as interface{int; string}. The intersection of no interfaces is the
+// empty interface.
+//
+// IntersectInterfaces returns an error if methods with the same name have
+// different signatures, or if an interface has a union with interface
+// terms with methods (see Experiments.MethodUnions).
+func IntersectInterfaces(list ...*Interface) (*Interface, error) {
+	var methods []*Func
+	var seen objset
+	terms := allTermlist
+	comparable := false
+	for _, t := range list {
+		tset := t.typeSet()
+		if len(tset.unions) > 0 {
+			return nil, fmt.Errorf("cannot intersect %s: union with methods", t)
+		}
+		for _, m := range tset.methods {
+			if other := seen.insert(m); other != nil {
+				if !Identical(m.typ, other.Type()) {
+					return nil, fmt.Errorf("cannot intersect %s: method %s has signatures %s and %s", t, m.name, other.Type(), m.typ)
+				}
+				continue
+			}
+			methods = append(methods, m)
+		}
+		if tset.comparable {
+			comparable = true
+		}
+		terms = terms.intersect(tset.terms)
+	}
+	return newTermsInterface(methods, comparable, terms), nil
+}
+
+// UnionInterfaces returns a complete interface whose type set is the union
+// of the type sets of the interfaces in list, with the normalized union of
+// their type terms as its only element. The result is represented like
+// that of IntersectInterfaces; the union of no interfaces has the empty
+// type set.
+//
+// As for union elements in source, UnionInterfaces returns an error if an
+// interface has methods or is comparable.
+func UnionInterfaces(list ...*Interface) (*Interface, error) {
+	var terms termlist // empty type set
+	for _, t := range list {
+		tset := t.typeSet()
+		if len(tset.methods) > 0 || len(tset.unions) > 0 {
+			return nil, fmt.Errorf("cannot form union with %s: interface has methods", t)
+		}
+		if tset.comparable {
+			return nil, fmt.Errorf("cannot form union with %s: interface is comparable", t)
+		}
+		terms = terms.union(tset.terms)
+	}
+	return newTermsInterface(nil, false, terms), nil
+}
+
+// newTermsInterface returns a complete interface with the given methods,
+// embedding comparable if set, and with the normalized type terms.
+func newTermsInterface(methods []*Func, comparable bool, terms termlist) *Interface {
+	var embeddeds []Type
+	if comparable {
+		embeddeds = append(embeddeds, universeComparable.Type())
+	}
+	switch {
+	case terms.isEmpty():
+		// An empty union can't be expressed; use disjoint types instead.
+		embeddeds = append(embeddeds, Typ[Int], Typ[String])
+	case terms.isAll():
+		// nothing to do
+	case len(terms) == 1 && !terms[0].tilde:
+		embeddeds = append(embeddeds, terms[0].typ)
+	default:
+		list := make([]*Term, len(terms))
+		for i, t := range terms {
+			list[i] = NewTerm(t.tilde, t.typ)
+		}
+		embeddeds = append(embeddeds, NewUnion(list))
+	}
+	return NewInterfaceType(methods, embeddeds).Complete()
+}
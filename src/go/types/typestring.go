@@ -9,6 +9,7 @@ package types
 import (
 	"bytes"
 	"fmt"
+	"go/ast"
 	"go/token"
 	"strconv"
 	"unicode/utf8"
@@ -41,6 +42,44 @@ func RelativeTo(pkg *Package) Qualifier {
 	}
 }
 
+// FileQualifier returns a Qualifier that qualifies members of packages
+// imported by the file f of pkg, as recorded in info, by the names the file
+// imports them with. Members of dot-imported packages and of pkg itself are
+// not qualified. Members of packages that are not imported by f, or only
+// with a blank import, are qualified by the package path, as with
+// RelativeTo. If f imports a package repeatedly, its first import is used.
+func FileQualifier(f *ast.File, pkg *Package, info *Info) Qualifier {
+	names := make(map[*Package]string)
+	for _, spec := range f.Imports {
+		var obj Object
+		if spec.Name != nil {
+			obj = info.Defs[spec.Name]
+		} else {
+			obj = info.Implicits[spec]
+		}
+		pname, _ := obj.(*PkgName)
+		if pname == nil || pname.name == "_" {
+			continue
+		}
+		if _, ok := names[pname.imported]; !ok {
+			name := pname.name
+			if name == "." {
+				name = ""
+			}
+			names[pname.imported] = name
+		}
+	}
+	return func(other *Package) string {
+		if other == pkg {
+			return ""
+		}
+		if name, ok := names[other]; ok {
+			return name
+		}
+		return other.Path()
+	}
+}
+
 // TypeString returns the string representation of typ.
 // The Qualifier controls the printing of
 // package-level objects, and may be nil.
@@ -243,6 +282,15 @@ func (w *typeWriter) typ(typ Type) {
 			w.tParamList(t.TypeParams().list())
 		}
 
+	case *Alias:
+		// Aliases are identical to their actual types;
+		// only the actual type matters for type hashing.
+		if w.env != nil {
+			w.typ(t.actual)
+			break
+		}
+		w.typeName(t.obj)
+
 	case *TypeParam:
 		if t.obj == nil {
 			w.error("unnamed type parameter")
@@ -255,6 +303,12 @@ func (w *typeWriter) typ(typ Type) {
 		if t.obj.pkg != nil {
 			writePackage(w.buf, t.obj.pkg, w.qf)
 		}
+		if w.env != nil {
+			// Type parameters are identical only to themselves, and their
+			// ids may be shared (see CanonicalPackage).
+			w.string(t.obj.name + subscript(t.uid))
+			break
+		}
 		w.string(t.obj.name + subscript(t.id))
 
 	case *top:
@@ -268,11 +322,10 @@ func (w *typeWriter) typ(typ Type) {
 }
 
 // If w.env is non-nil, typePrefix writes a unique prefix for the named type t
-// based on the types already observed by w.env. If w.env is nil, it does
-// nothing.
+// based on its type ID. If w.env is nil, it does nothing.
 func (w *typeWriter) typePrefix(t *Named) {
 	if w.env != nil {
-		w.string(strconv.Itoa(w.env.idForType(t)))
+		w.string(strconv.FormatUint(t.typeID(), 10))
 	}
 }
 
@@ -299,7 +352,7 @@ func (w *typeWriter) tParamList(list []*TypeParam) {
 			continue
 		}
 		if i > 0 {
-			if tpar.bound != prev {
+			if tpar.bound != prev || tpar.variadic {
 				// bound changed - write previous one before advancing
 				w.byte(' ')
 				w.typ(prev)
@@ -311,6 +364,9 @@ func (w *typeWriter) tParamList(list []*TypeParam) {
 	}
 	if prev != nil {
 		w.byte(' ')
+		if list[len(list)-1].variadic {
+			w.string("...")
+		}
 		w.typ(prev)
 	}
 	w.byte(']')
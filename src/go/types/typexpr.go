@@ -11,7 +11,6 @@ import (
 	"go/ast"
 	"go/constant"
 	"go/internal/typeparams"
-	"go/token"
 	"strings"
 )
 
@@ -32,7 +31,11 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, wantType bool)
 		if e.Name == "_" {
 			check.error(e, _InvalidBlank, "cannot use _ as value or type")
 		} else {
-			check.errorf(e, _UndeclaredName, "undeclared name: %s", e.Name)
+			if alt := check.suggestIdent(e.Name); alt != "" {
+				check.errorWithSuggestion(e, _UndeclaredName, alt, "undeclared name: %s (did you mean %s?)", e.Name, alt)
+			} else {
+				check.errorf(e, _UndeclaredName, "undeclared name: %s", e.Name)
+			}
 		}
 		return
 	case universeAny, universeComparable:
@@ -70,6 +73,7 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, wantType bool)
 	// we only have to mark variables, see *Var case below).
 	if pkgName := check.dotImportMap[dotImportKey{scope, obj.Name()}]; pkgName != nil {
 		pkgName.used = true
+		check.useImported(obj)
 	}
 
 	switch obj := obj.(type) {
@@ -95,6 +99,10 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, wantType bool)
 		x.mode = constant_
 
 	case *TypeName:
+		if tpar, _ := typ.(*TypeParam); tpar != nil && tpar.variadic && e != check.variadicElt {
+			check.errorf(e, _Todo, "variadic type parameter %s can only be used as type of a final ... parameter", obj.name)
+			return
+		}
 		x.mode = typexpr
 
 	case *Var:
@@ -116,6 +124,7 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, wantType bool)
 
 	case *Builtin:
 		x.id = obj.id
+		x.custom = obj.custom
 		x.mode = builtin
 
 	case *Nil:
@@ -220,6 +229,7 @@ func (check *Checker) typInternal(e0 ast.Expr, def *Named) (T Type) {
 		}()
 	}
 
+	check.checkMemory()
 	switch e := e0.(type) {
 	case *ast.BadExpr:
 		// ignore - error reported before
@@ -260,8 +270,8 @@ func (check *Checker) typInternal(e0 ast.Expr, def *Named) (T Type) {
 
 	case *ast.IndexExpr, *ast.IndexListExpr:
 		ix := typeparams.UnpackIndexExpr(e)
-		if !check.allowVersion(check.pkg, 1, 18) {
-			check.softErrorf(inNode(e, ix.Lbrack), _Todo, "type instantiation requires go1.18 or later")
+		if !check.allowVersion(check.pkg, 1, 18) && !check.forwardCompat(inNode(e, ix.Lbrack), 1, 18, "type instantiation") {
+			check.warnf(inNode(e, ix.Lbrack), _Todo, "type instantiation requires go1.18 or later")
 		}
 		// TODO(rfindley): type instantiation should require go1.18
 		return check.instantiatedType(ix.X, ix.Indices, def)
@@ -283,7 +293,7 @@ func (check *Checker) typInternal(e0 ast.Expr, def *Named) (T Type) {
 		typ := new(Slice)
 		def.setUnderlying(typ)
 		typ.elem = check.varType(e.Elt)
-		return typ
+		return check.intern(typ, def)
 
 	case *ast.Ellipsis:
 		// dots are handled explicitly where they are legal
@@ -295,13 +305,13 @@ func (check *Checker) typInternal(e0 ast.Expr, def *Named) (T Type) {
 		typ := new(Struct)
 		def.setUnderlying(typ)
 		check.structType(typ, e)
-		return typ
+		return check.intern(typ, def)
 
 	case *ast.StarExpr:
 		typ := new(Pointer)
 		def.setUnderlying(typ)
 		typ.base = check.varType(e.X)
-		return typ
+		return check.intern(typ, def)
 
 	case *ast.FuncType:
 		typ := new(Signature)
@@ -316,7 +326,7 @@ func (check *Checker) typInternal(e0 ast.Expr, def *Named) (T Type) {
 			typ.obj = def.obj
 		}
 		check.interfaceType(typ, e, def)
-		return typ
+		return check.intern(typ, def)
 
 	case *ast.MapType:
 		typ := new(Map)
@@ -341,7 +351,7 @@ func (check *Checker) typInternal(e0 ast.Expr, def *Named) (T Type) {
 			}
 		})
 
-		return typ
+		return check.intern(typ, def)
 
 	case *ast.ChanType:
 		typ := new(Chan)
@@ -390,8 +400,19 @@ func (check *Checker) instantiatedType(x ast.Expr, targsx []ast.Expr, def *Named
 		return Typ[Invalid]
 	}
 
+	// If the number of type arguments is wrong, report the error now and
+	// recover with an instance using adjusted type arguments (see
+	// recoveryTArgs), so that uses of the type can still be checked.
+	// Constraints are not verified in this case.
+	if tparams := base.TypeParams().list(); len(targs) != len(tparams) {
+		check.validateTArgLen(x.Pos(), len(tparams), len(targs))
+		typ := check.instance(x.Pos(), base, recoveryTArgs(tparams, targs), check.conf.Environment)
+		def.setUnderlying(typ)
+		return typ
+	}
+
 	// determine argument positions
-	posList := make([]token.Pos, len(targs))
+	posList := check.newPosList(len(targs))
 	for i, arg := range targsx {
 		posList[i] = arg.Pos()
 	}
@@ -105,8 +105,12 @@ func parseUnion(check *Checker, tlist []ast.Expr) Type {
 			// in the beginning. Embedded interfaces with tilde are excluded above. If we reach
 			// here, we must have at least two terms in the union.
 			if f != nil && !f.typeSet().IsTypeSet() {
-				check.errorf(atPos(pos), _Todo, "cannot use %s in union (interface contains methods)", t)
-				continue // don't report another error for t
+				// Interfaces with methods are permitted with the MethodUnions
+				// experiment; comparable is not.
+				if !check.conf.Experiments.MethodUnions || f.typeSet().comparable {
+					check.errorf(atPos(pos), _Todo, "cannot use %s in union (interface contains methods)", t)
+					continue // don't report another error for t
+				}
 			}
 
 			// Report overlapping (non-disjoint) terms such as
@@ -0,0 +1,50 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types
+
+import (
+	"go/token"
+	"testing"
+)
+
+func TestUniverseTable(t *testing.T) {
+	for _, name := range Universe.Names() {
+		if obj := universeObject(name); obj != Universe.Lookup(name) {
+			t.Errorf("%s: got %v, want %v (universeSlot collision?)", name, obj, Universe.Lookup(name))
+		}
+	}
+	for _, name := range []string{"", "x", "Int", "int0", "unsafe"} {
+		if obj := universeObject(name); obj != nil {
+			t.Errorf("%s: got %v, want nil", name, obj)
+		}
+	}
+}
+
+func TestLookupPredeclared(t *testing.T) {
+	outer := NewScope(Universe, 1, 100, "outer")
+	inner := NewScope(outer, 10, 90, "inner")
+	obj := NewVar(20, nil, "int", Typ[String])
+	outer.Insert(obj)
+	obj.setScopePos(20)
+
+	for _, test := range []struct {
+		pos  token.Pos
+		want Object
+	}{
+		{token.NoPos, obj},
+		{15, Universe.Lookup("int")},
+		{25, obj},
+	} {
+		if _, got := inner.LookupParent("int", test.pos); got != test.want {
+			t.Errorf("LookupParent(int, %d) = %v, want %v", test.pos, got, test.want)
+		}
+	}
+	if _, got := inner.LookupParent("string", 25); got != Universe.Lookup("string") {
+		t.Errorf("LookupParent(string) = %v, want %v", got, Universe.Lookup("string"))
+	}
+	if _, got := NewScope(nil, 1, 100, "detached").LookupParent("int", token.NoPos); got != nil {
+		t.Errorf("LookupParent(int) in detached scope = %v, want nil", got)
+	}
+}
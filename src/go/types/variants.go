@@ -0,0 +1,167 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements checking packages under several build configurations.
+
+package types
+
+import (
+	gocontext "context"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"sync"
+)
+
+// A BuildVariant describes one of the build configurations, such as a
+// GOOS/GOARCH pair, under which Config.CheckVariants checks a package.
+// Unset fields default to the corresponding fields of the Config.
+type BuildVariant struct {
+	Name      string   // name of the variant, such as "linux/386"; for use by clients
+	Sizes     Sizes    // if set, overrides Config.Sizes, as by SizesFor("gc", goarch)
+	GoVersion string   // if set, overrides Config.GoVersion
+	Importer  Importer // if set, overrides Config.Importer
+
+	// If Files is set, only the files for which it reports true, such as
+	// the files satisfying the build constraints of the variant, are
+	// checked.
+	Files func(*ast.File) bool
+}
+
+// A VariantResult holds the results of checking a package under
+// a BuildVariant.
+type VariantResult struct {
+	Variant *BuildVariant
+	Package *Package
+	Errors  []error // errors reported for the variant, in the order they were reported
+}
+
+// CheckVariants type-checks the package with the given path and files,
+// whose positions are recorded in fset, once for each of the given build
+// variants, and returns the results in the order of the variants.
+//
+// The variants are checked concurrently, as permitted by conf.Concurrency.
+// They share the syntax trees, the Environment of conf, in which one is
+// created if there is none, and the imported packages: each package is
+// imported only once per importer and Sizes, so that variants using the
+// same importer and Sizes share its result, and the calls of each importer
+// are serialized. Variants with different Sizes import packages anew, as
+// packages checked from source depend on the Sizes. Checking a variant
+// continues after errors, which are collected in the results instead of
+// being reported to conf.Error.
+func (conf *Config) CheckVariants(path string, fset *token.FileSet, files []*ast.File, variants []BuildVariant) []VariantResult {
+	env := conf.Environment
+	if env == nil {
+		env = NewEnvironment()
+	}
+	var shared []*variantImporter // for conf.Importer, by Sizes
+
+	results := make([]VariantResult, len(variants))
+	confs := make([]Config, len(variants))
+	vfiles := make([][]*ast.File, len(variants))
+	for i := range variants {
+		v := &variants[i]
+		res := &results[i]
+		res.Variant = v
+
+		c := &confs[i]
+		*c = *conf
+		c.Environment = env
+		c.ConcurrentImports = false // imports are cached and serialized by the variant importer
+		c.Error = func(err error) { res.Errors = append(res.Errors, err) }
+		if v.Sizes != nil {
+			c.Sizes = v.Sizes
+		}
+		if v.GoVersion != "" {
+			c.GoVersion = v.GoVersion
+		}
+		var imp *variantImporter
+		if v.Importer != nil {
+			imp = newVariantImporter(v.Importer, c.Sizes)
+		} else if conf.Importer != nil {
+			for _, s := range shared {
+				if sameSizes(s.sizes, c.Sizes) {
+					imp = s
+					break
+				}
+			}
+			if imp == nil {
+				imp = newVariantImporter(conf.Importer, c.Sizes)
+				shared = append(shared, imp)
+			}
+		}
+		if imp != nil {
+			c.Importer = imp
+		}
+
+		vfiles[i] = files
+		if v.Files != nil {
+			vfiles[i] = nil
+			for _, f := range files {
+				if v.Files(f) {
+					vfiles[i] = append(vfiles[i], f)
+				}
+			}
+		}
+	}
+
+	conf.parallel(len(variants), func(i int) {
+		results[i].Package, _ = confs[i].Check(path, fset, vfiles[i], nil)
+	})
+	return results
+}
+
+// sameSizes reports whether x and y are the same Sizes.
+func sameSizes(x, y Sizes) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	t := reflect.TypeOf(x)
+	return t == reflect.TypeOf(y) && t.Comparable() && x == y
+}
+
+// A variantImporter caches the packages imported by an importer for the
+// variants with the given Sizes and serializes the calls of the importer.
+type variantImporter struct {
+	imp   Importer
+	sizes Sizes
+	mu    sync.Mutex
+	cache map[importKey]importResult
+}
+
+func newVariantImporter(imp Importer, sizes Sizes) *variantImporter {
+	return &variantImporter{imp: imp, sizes: sizes, cache: make(map[importKey]importResult)}
+}
+
+func (v *variantImporter) Import(path string) (*Package, error) {
+	return v.ImportFromContext(gocontext.Background(), path, "", 0)
+}
+
+func (v *variantImporter) ImportFrom(path, dir string, mode ImportMode) (*Package, error) {
+	return v.ImportFromContext(gocontext.Background(), path, dir, mode)
+}
+
+func (v *variantImporter) ImportFromContext(ctx gocontext.Context, path, dir string, mode ImportMode) (*Package, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key := importKey{path, dir}
+	if res, ok := v.cache[key]; ok {
+		return res.pkg, res.err
+	}
+	var res importResult
+	switch imp := v.imp.(type) {
+	case ImporterFromContext:
+		res.pkg, res.err = imp.ImportFromContext(ctx, path, dir, mode)
+	case ImporterFrom:
+		res.pkg, res.err = imp.ImportFrom(path, dir, mode)
+	default:
+		res.pkg, res.err = v.imp.Import(path)
+	}
+	if ctx.Err() == nil {
+		// don't cache the results of imports cancelled by ctx
+		v.cache[key] = res
+	}
+	return res.pkg, res.err
+}
@@ -21,25 +21,50 @@ func (check *Checker) langCompat(lit *ast.BasicLit) {
 		return
 	}
 	// len(s) > 2
-	if strings.Contains(s, "_") {
-		check.errorf(lit, _InvalidLit, "underscores in numeric literals requires go1.13 or later")
+	var feature string
+	switch radix := s[1]; {
+	case strings.Contains(s, "_"):
+		feature = "underscores in numeric literals"
+	case s[0] != '0':
 		return
-	}
-	if s[0] != '0' {
+	case radix == 'b' || radix == 'B':
+		feature = "binary literals"
+	case radix == 'o' || radix == 'O':
+		feature = "0o/0O-style octal literals"
+	case lit.Kind != token.INT && (radix == 'x' || radix == 'X'):
+		feature = "hexadecimal floating-point literals"
+	default:
 		return
 	}
-	radix := s[1]
-	if radix == 'b' || radix == 'B' {
-		check.errorf(lit, _InvalidLit, "binary literals requires go1.13 or later")
-		return
+	if !check.forwardCompat(lit, 1, 13, feature) {
+		check.errorf(lit, _InvalidLit, "%s requires go1.13 or later", feature)
 	}
-	if radix == 'o' || radix == 'O' {
-		check.errorf(lit, _InvalidLit, "0o/0O-style octal literals requires go1.13 or later")
-		return
+}
+
+// forwardCompat reports whether Config.ForwardCompatible is set. If so,
+// it reports that the language feature described by format and args,
+// used at at, requires go<major>.<minor> or later, as a warning with
+// Error.RequiresVersion set. The caller then checks the feature as if the
+// language version permitted it. Each position is reported at most once.
+func (check *Checker) forwardCompat(at positioner, major, minor int, format string, args ...interface{}) bool {
+	if !check.conf.ForwardCompatible {
+		return false
+	}
+	pos := at.Pos()
+	if check.tooNew[pos] {
+		return true
 	}
-	if lit.Kind != token.INT && (radix == 'x' || radix == 'X') {
-		check.errorf(lit, _InvalidLit, "hexadecimal floating-point literals requires go1.13 or later")
+	if check.tooNew == nil {
+		check.tooNew = make(map[token.Pos]bool)
 	}
+	check.tooNew[pos] = true
+
+	v := fmt.Sprintf("go%d.%d", major, minor)
+	err := check.newErrorf(at, _UnsupportedFeature, true, format+" requires %s or later", append(args, v)...).(Error)
+	err.Severity = SeverityWarning
+	err.RequiresVersion = v
+	check.err(err)
+	return true
 }
 
 // allowVersion reports whether the given package